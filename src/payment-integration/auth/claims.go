@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultClockSkew       = 30 * time.Second
+	defaultReplayCacheSize = 10000
+)
+
+// ClaimTemplate describes the claims minted for tokens sent to a target bank service.
+// Empty fields fall back to the authenticator's default template.
+type ClaimTemplate struct {
+	Issuer   string   `json:"iss,omitempty"`
+	Audience []string `json:"aud,omitempty"`
+	User     string   `json:"user,omitempty"`
+	Name     string   `json:"name,omitempty"`
+}
+
+// DefaultClaimTemplate returns the claims used when no template is configured
+func DefaultClaimTemplate() ClaimTemplate {
+	return ClaimTemplate{
+		User: "payment-service",
+		Name: "Payment Integration Service",
+	}
+}
+
+// merge fills empty fields of t from fallback
+func (t ClaimTemplate) merge(fallback ClaimTemplate) ClaimTemplate {
+	if t.Issuer == "" {
+		t.Issuer = fallback.Issuer
+	}
+	if len(t.Audience) == 0 {
+		t.Audience = fallback.Audience
+	}
+	if t.User == "" {
+		t.User = fallback.User
+	}
+	if t.Name == "" {
+		t.Name = fallback.Name
+	}
+	return t
+}
+
+// LoadClaimTemplates reads per-service claim templates from a JSON file keyed by service name
+func LoadClaimTemplates(path string) (map[string]ClaimTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claim templates: %w", err)
+	}
+
+	var templates map[string]ClaimTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse claim templates: %w", err)
+	}
+	return templates, nil
+}
+
+// Errors returned by replayCache.observe
+var (
+	errTokenReplayed   = errors.New("token has already been used")
+	errReplayCacheFull = errors.New("too many unexpired tokens to track")
+)
+
+// replayCache remembers seen token ids until they expire, holding at most
+// capacity entries. Entries are kept in a min-heap on expiry so every expired
+// one can be dropped, whatever order the tokens arrived in.
+type replayCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*replayEntry
+	expiries replayHeap
+}
+
+type replayEntry struct {
+	jti       string
+	expiresAt time.Time
+}
+
+func newReplayCache(capacity int) *replayCache {
+	if capacity <= 0 {
+		capacity = defaultReplayCacheSize
+	}
+	return &replayCache{
+		capacity: capacity,
+		entries:  make(map[string]*replayEntry),
+	}
+}
+
+// observe records jti. It returns errTokenReplayed if jti was seen before and
+// has not expired, and errReplayCacheFull if the cache is full of unexpired
+// ids; forgetting one of them would let its token be replayed.
+func (c *replayCache) observe(jti string, expiresAt time.Time, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.expiries) > 0 && !now.Before(c.expiries[0].expiresAt) {
+		delete(c.entries, heap.Pop(&c.expiries).(*replayEntry).jti)
+	}
+
+	if _, exists := c.entries[jti]; exists {
+		return errTokenReplayed
+	}
+	if len(c.entries) >= c.capacity {
+		return errReplayCacheFull
+	}
+
+	entry := &replayEntry{jti: jti, expiresAt: expiresAt}
+	c.entries[jti] = entry
+	heap.Push(&c.expiries, entry)
+	return nil
+}
+
+// resized returns a cache of the given capacity holding c's unexpired ids.
+// If there are more than fit, the ones expiring soonest are dropped.
+func (c *replayCache) resized(capacity int, now time.Time) *replayCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	next := newReplayCache(capacity)
+	for _, entry := range c.expiries {
+		if now.Before(entry.expiresAt) {
+			next.entries[entry.jti] = entry
+			next.expiries = append(next.expiries, entry)
+		}
+	}
+	heap.Init(&next.expiries)
+	for len(next.entries) > next.capacity {
+		delete(next.entries, heap.Pop(&next.expiries).(*replayEntry).jti)
+	}
+	return next
+}
+
+// len returns the number of tracked token ids
+func (c *replayCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// replayHeap orders entries by expiry, soonest first. It implements heap.Interface.
+type replayHeap []*replayEntry
+
+func (h replayHeap) Len() int           { return len(h) }
+func (h replayHeap) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h replayHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *replayHeap) Push(x interface{}) { *h = append(*h, x.(*replayEntry)) }
+
+func (h *replayHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestAuthenticator(t *testing.T) *ServiceAuthenticator {
+	privateKeyPath, publicKeyPath := setupTestKeys(t)
+	t.Cleanup(func() {
+		os.Remove(privateKeyPath)
+		os.Remove(publicKeyPath)
+	})
+
+	authenticator, err := NewServiceAuthenticator(privateKeyPath, publicKeyPath, 3600)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	return authenticator
+}
+
+func parseClaims(t *testing.T, tokenString string) *ServiceClaims {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &ServiceClaims{})
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	return token.Claims.(*ServiceClaims)
+}
+
+func TestTokenCarriesRegisteredClaims(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	authenticator.SetDefaultClaims(ClaimTemplate{
+		Issuer:   "https://payments.example",
+		Audience: []string{"bank-of-anthos"},
+	})
+	authenticator.SetClockSkew(45 * time.Second)
+
+	token, err := authenticator.GenerateServiceToken("1234567890")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	claims := parseClaims(t, token)
+
+	if claims.Issuer != "https://payments.example" {
+		t.Errorf("Expected issuer https://payments.example, got %s", claims.Issuer)
+	}
+	if len(claims.Audience) != 1 || claims.Audience[0] != "bank-of-anthos" {
+		t.Errorf("Unexpected audience: %v", claims.Audience)
+	}
+	if claims.ID == "" {
+		t.Error("jti claim is missing")
+	}
+	if claims.NotBefore == nil || !claims.NotBefore.Equal(claims.IssuedAt.Time) {
+		t.Errorf("nbf should not be backdated, got nbf=%v iat=%v", claims.NotBefore, claims.IssuedAt)
+	}
+	if claims.User != "payment-service" {
+		t.Errorf("Default user claim should be kept, got %s", claims.User)
+	}
+
+	other, err := authenticator.GenerateServiceToken("1234567890")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if parseClaims(t, other).ID == claims.ID {
+		t.Error("Each token should have a unique jti")
+	}
+}
+
+func TestValidateTokenToleratesClockSkew(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	authenticator.SetClockSkew(30 * time.Second)
+
+	sign := func(jti string, notBefore time.Time) string {
+		t.Helper()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, &ServiceClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        jti,
+				NotBefore: jwt.NewNumericDate(notBefore),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		})
+		token.Header["kid"] = authenticator.KeyID()
+		signed, err := token.SignedString(authenticator.privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	// A token from an issuer whose clock runs ahead of ours
+	if _, err := authenticator.ValidateToken(sign("ahead", time.Now().Add(20*time.Second))); err != nil {
+		t.Errorf("Expected nbf within the skew to be accepted, got %v", err)
+	}
+	if _, err := authenticator.ValidateToken(sign("future", time.Now().Add(time.Minute))); !errors.Is(err, jwt.ErrTokenNotValidYet) {
+		t.Errorf("Expected nbf beyond the skew to be rejected, got %v", err)
+	}
+}
+
+func TestClaimTemplatePerService(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	authenticator.SetDefaultClaims(ClaimTemplate{Issuer: "payment-integration", Audience: []string{"default"}})
+	authenticator.SetClaimTemplate("ledgerwriter", ClaimTemplate{Audience: []string{"ledgerwriter"}, Name: "Ledger Client"})
+
+	token, err := authenticator.GenerateServiceTokenFor("ledgerwriter", "1234567890")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	claims := parseClaims(t, token)
+
+	if claims.Audience[0] != "ledgerwriter" {
+		t.Errorf("Expected template audience ledgerwriter, got %v", claims.Audience)
+	}
+	if claims.Name != "Ledger Client" {
+		t.Errorf("Expected template name, got %s", claims.Name)
+	}
+	if claims.Issuer != "payment-integration" {
+		t.Errorf("Issuer should fall back to the default, got %s", claims.Issuer)
+	}
+
+	token, err = authenticator.GenerateServiceTokenFor("balancereader", "1234567890")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if aud := parseClaims(t, token).Audience; aud[0] != "default" {
+		t.Errorf("Unknown service should use default audience, got %v", aud)
+	}
+}
+
+func TestValidateTokenChecksIssuerAndAudience(t *testing.T) {
+	issuer := newTestAuthenticator(t)
+	issuer.SetDefaultClaims(ClaimTemplate{Issuer: "someone-else", Audience: []string{"bank"}})
+
+	token, err := issuer.GenerateServiceToken("1234567890")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	issuer.SetDefaultClaims(ClaimTemplate{Issuer: "payment-integration", Audience: []string{"bank"}})
+	if _, err := issuer.ValidateToken(token); err == nil {
+		t.Error("Token with wrong issuer should not validate")
+	}
+
+	issuer.SetDefaultClaims(ClaimTemplate{Issuer: "someone-else", Audience: []string{"other-bank"}})
+	_, err = issuer.ValidateToken(token)
+	if err == nil || !strings.Contains(err.Error(), "audience") {
+		t.Errorf("Expected audience mismatch error, got %v", err)
+	}
+
+	issuer.SetDefaultClaims(ClaimTemplate{Issuer: "someone-else", Audience: []string{"bank"}})
+	if _, err := issuer.ValidateToken(token); err != nil {
+		t.Errorf("Token should validate with matching issuer and audience: %v", err)
+	}
+}
+
+func TestValidateTokenRejectsReplay(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	token, err := authenticator.GenerateServiceToken("1234567890")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	if _, err := authenticator.ValidateToken(token); err != nil {
+		t.Fatalf("First presentation should validate: %v", err)
+	}
+	_, err = authenticator.ValidateToken(token)
+	if err == nil || !strings.Contains(err.Error(), "already been used") {
+		t.Errorf("Expected replay error, got %v", err)
+	}
+}
+
+func TestReplayCacheIsBounded(t *testing.T) {
+	cache := newReplayCache(3)
+	now := time.Now()
+	expiry := now.Add(time.Hour)
+
+	for _, jti := range []string{"a", "b", "c"} {
+		if err := cache.observe(jti, expiry, now); err != nil {
+			t.Errorf("jti %s should be accepted, got %v", jti, err)
+		}
+	}
+
+	if err := cache.observe("d", expiry, now); !errors.Is(err, errReplayCacheFull) {
+		t.Errorf("Expected a full cache to reject a new jti, got %v", err)
+	}
+	if cache.len() != 3 {
+		t.Errorf("Expected cache to hold 3 entries, got %d", cache.len())
+	}
+	if err := cache.observe("a", expiry, now); !errors.Is(err, errTokenReplayed) {
+		t.Errorf("Oldest jti should still be remembered, got %v", err)
+	}
+}
+
+func TestReplayCacheForgetsExpiredEntries(t *testing.T) {
+	cache := newReplayCache(2)
+	now := time.Now()
+
+	// The short-lived token arrives last but expires first
+	cache.observe("long", now.Add(time.Hour), now)
+	cache.observe("short", now.Add(time.Second), now)
+
+	later := now.Add(time.Minute)
+	if err := cache.observe("new", later.Add(time.Hour), later); err != nil {
+		t.Errorf("Expected the expired jti to make room, got %v", err)
+	}
+	if err := cache.observe("short", later.Add(time.Hour), later); !errors.Is(err, errReplayCacheFull) {
+		t.Errorf("Expected the expired jti to be forgotten and the cache full, got %v", err)
+	}
+	if err := cache.observe("long", later.Add(time.Hour), later); !errors.Is(err, errTokenReplayed) {
+		t.Errorf("Unexpired jti should be reported as a replay, got %v", err)
+	}
+}
+
+func TestSetReplayCacheSizeKeepsSeenTokens(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	token, err := authenticator.GenerateServiceToken("1234567890")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := authenticator.ValidateToken(token); err != nil {
+		t.Fatalf("First presentation should validate: %v", err)
+	}
+
+	authenticator.SetReplayCacheSize(10)
+	if _, err := authenticator.ValidateToken(token); !errors.Is(err, errTokenReplayed) {
+		t.Errorf("Expected the token to be remembered after resizing, got %v", err)
+	}
+}
+
+func TestReplayCacheResizeDropsSoonestExpiring(t *testing.T) {
+	cache := newReplayCache(3)
+	now := time.Now()
+	cache.observe("expired", now.Add(time.Second), now)
+	cache.observe("soon", now.Add(time.Minute), now)
+	cache.observe("late", now.Add(time.Hour), now)
+
+	later := now.Add(2 * time.Second)
+	resized := cache.resized(1, later)
+	if resized.len() != 1 {
+		t.Fatalf("Expected 1 entry after resizing, got %d", resized.len())
+	}
+	if err := resized.observe("late", later.Add(time.Hour), later); !errors.Is(err, errTokenReplayed) {
+		t.Errorf("Expected the latest expiring jti to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// JWKSPath is where the JSON Web Key Set is served on the HTTP server
+	JWKSPath = "/.well-known/jwks.json"
+	// DiscoveryPath is where the OpenID-style discovery document is served
+	DiscoveryPath = "/.well-known/openid-configuration"
+)
+
+// JSONWebKey is the RFC 7517 representation of an RSA public key
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JSONWebKeySet is a set of public keys that verifiers can use to check our tokens
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// DiscoveryDocument is a minimal OpenID Provider metadata document
+type DiscoveryDocument struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	ClaimsSupported                  []string `json:"claims_supported"`
+}
+
+// KeyID returns the RFC 7638 thumbprint of an RSA public key, used as its kid
+func KeyID(pub *rsa.PublicKey) string {
+	// Members must be in lexicographic order with no whitespace
+	thumbprintInput := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, encodeExponent(pub.E), encodeModulus(pub.N))
+	sum := sha256.Sum256([]byte(thumbprintInput))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// NewJSONWebKey converts an RSA public key into its JWK form
+func NewJSONWebKey(pub *rsa.PublicKey) JSONWebKey {
+	return JSONWebKey{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: KeyID(pub),
+		N:   encodeModulus(pub.N),
+		E:   encodeExponent(pub.E),
+	}
+}
+
+func encodeModulus(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func encodeExponent(e int) string {
+	return base64.RawURLEncoding.EncodeToString(big.NewInt(int64(e)).Bytes())
+}
+
+// JWKSHandler serves the authenticator's verification keys as a JWKS document
+func JWKSHandler(sa *ServiceAuthenticator, maxAge time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := json.Marshal(sa.JWKS())
+		if err != nil {
+			http.Error(w, "failed to encode key set", http.StatusInternalServerError)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:12]) + `"`
+
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+		w.Header().Set("ETag", etag)
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	})
+}
+
+// DiscoveryHandler serves an OpenID-style discovery document pointing at the JWKS endpoint.
+// The JWKS URI is derived from the request's scheme and host, as is the issuer when empty.
+func DiscoveryHandler(issuer string, maxAge time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		base := requestBaseURL(r)
+		docIssuer := strings.TrimSuffix(issuer, "/")
+		if docIssuer == "" {
+			docIssuer = base
+		}
+
+		doc := DiscoveryDocument{
+			Issuer:                           docIssuer,
+			JWKSURI:                          base + JWKSPath,
+			IDTokenSigningAlgValuesSupported: []string{"RS256"},
+			ResponseTypesSupported:           []string{"id_token"},
+			SubjectTypesSupported:            []string{"public"},
+			ClaimsSupported:                  []string{"user", "acct", "name", "iss", "aud", "iat", "nbf", "exp", "jti"},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(doc)
+		}
+	})
+}
+
+// requestBaseURL reconstructs the externally visible base URL of a request
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := r.Header.Get("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	return scheme + "://" + r.Host
+}
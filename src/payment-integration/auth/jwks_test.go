@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWKSContainsActiveAndPreviousKeys(t *testing.T) {
+	privateKeyPath, publicKeyPath := setupTestKeys(t)
+	defer os.Remove(privateKeyPath)
+	defer os.Remove(publicKeyPath)
+
+	oldPrivateKeyPath, oldPublicKeyPath := setupTestKeys(t)
+	defer os.Remove(oldPrivateKeyPath)
+	defer os.Remove(oldPublicKeyPath)
+
+	authenticator, err := NewServiceAuthenticator(privateKeyPath, publicKeyPath, 3600)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if err := authenticator.AddPreviousPublicKey(oldPublicKeyPath); err != nil {
+		t.Fatalf("Failed to add previous key: %v", err)
+	}
+
+	set := authenticator.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(set.Keys))
+	}
+	if set.Keys[0].Kid != authenticator.KeyID() {
+		t.Errorf("Expected active key first, got kid %s", set.Keys[0].Kid)
+	}
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || key.Alg != "RS256" || key.Use != "sig" {
+			t.Errorf("Unexpected key parameters: %+v", key)
+		}
+		if key.N == "" || key.E != "AQAB" {
+			t.Errorf("Unexpected key material: n=%q e=%q", key.N, key.E)
+		}
+	}
+}
+
+func TestTokenSignedWithPreviousKeyValidates(t *testing.T) {
+	privateKeyPath, publicKeyPath := setupTestKeys(t)
+	defer os.Remove(privateKeyPath)
+	defer os.Remove(publicKeyPath)
+
+	oldPrivateKeyPath, oldPublicKeyPath := setupTestKeys(t)
+	defer os.Remove(oldPrivateKeyPath)
+	defer os.Remove(oldPublicKeyPath)
+
+	oldAuthenticator, err := NewServiceAuthenticator(oldPrivateKeyPath, oldPublicKeyPath, 3600)
+	if err != nil {
+		t.Fatalf("Failed to create old authenticator: %v", err)
+	}
+	token, err := oldAuthenticator.GenerateServiceToken("1234567890")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	authenticator, err := NewServiceAuthenticator(privateKeyPath, publicKeyPath, 3600)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	if _, err := authenticator.ValidateToken(token); err == nil {
+		t.Fatal("Token signed with an unknown key should not validate")
+	}
+
+	if err := authenticator.AddPreviousPublicKey(oldPublicKeyPath); err != nil {
+		t.Fatalf("Failed to add previous key: %v", err)
+	}
+	if _, err := authenticator.ValidateToken(token); err != nil {
+		t.Errorf("Token signed with previous key should validate: %v", err)
+	}
+}
+
+func TestTokenHeaderCarriesKeyID(t *testing.T) {
+	privateKeyPath, publicKeyPath := setupTestKeys(t)
+	defer os.Remove(privateKeyPath)
+	defer os.Remove(publicKeyPath)
+
+	authenticator, err := NewServiceAuthenticator(privateKeyPath, publicKeyPath, 3600)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	tokenString, err := authenticator.GenerateServiceToken("1234567890")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &ServiceClaims{})
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	if token.Header["kid"] != authenticator.KeyID() {
+		t.Errorf("Expected kid %s, got %v", authenticator.KeyID(), token.Header["kid"])
+	}
+}
+
+func TestJWKSHandler(t *testing.T) {
+	privateKeyPath, publicKeyPath := setupTestKeys(t)
+	defer os.Remove(privateKeyPath)
+	defer os.Remove(publicKeyPath)
+
+	authenticator, err := NewServiceAuthenticator(privateKeyPath, publicKeyPath, 3600)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	handler := JWKSHandler(authenticator, 5*time.Minute)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=300" {
+		t.Errorf("Unexpected Cache-Control header: %s", got)
+	}
+
+	var set JSONWebKeySet
+	if err := json.NewDecoder(rec.Body).Decode(&set); err != nil {
+		t.Fatalf("Failed to decode JWKS: %v", err)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].Kid != authenticator.KeyID() {
+		t.Errorf("Unexpected key set: %+v", set)
+	}
+
+	// A matching ETag should short-circuit with 304
+	req := httptest.NewRequest(http.MethodGet, JWKSPath, nil)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("Expected status 304, got %d", rec.Code)
+	}
+}
+
+func TestDiscoveryHandler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, DiscoveryPath, nil)
+	req.Host = "payment-integration:8080"
+
+	rec := httptest.NewRecorder()
+	DiscoveryHandler("", time.Minute).ServeHTTP(rec, req)
+
+	var doc DiscoveryDocument
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode discovery document: %v", err)
+	}
+	if doc.Issuer != "http://payment-integration:8080" {
+		t.Errorf("Unexpected issuer: %s", doc.Issuer)
+	}
+	if !strings.HasSuffix(doc.JWKSURI, JWKSPath) {
+		t.Errorf("Unexpected jwks_uri: %s", doc.JWKSURI)
+	}
+}
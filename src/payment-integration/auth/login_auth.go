@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const defaultRefreshMargin = 30 * time.Second
+
+// Credentials are a Bank of Anthos userservice login
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// CredentialStore looks up the userservice login to use for a bank account
+type CredentialStore interface {
+	Credentials(accountNumber string) (Credentials, error)
+}
+
+// ReloadableCredentialStore is implemented by stores whose credentials can
+// change while the service runs, such as a mounted secret that is rotated
+type ReloadableCredentialStore interface {
+	CredentialStore
+	Reload() error
+}
+
+// credentialFile is the on-disk layout of a FileCredentialStore. Accounts either
+// hold their owner's login directly or name an entry in Delegates.
+type credentialFile struct {
+	Accounts  map[string]accountCredential `json:"accounts"`
+	Delegates map[string]Credentials       `json:"delegates"`
+}
+
+type accountCredential struct {
+	Credentials
+	Delegate string `json:"delegate,omitempty"`
+}
+
+// FileCredentialStore serves credentials from a JSON file, typically a mounted Kubernetes secret
+type FileCredentialStore struct {
+	path string
+
+	mu      sync.RWMutex
+	data    credentialFile
+	modTime time.Time
+}
+
+// NewFileCredentialStore loads credentials from path
+func NewFileCredentialStore(path string) (*FileCredentialStore, error) {
+	store := &FileCredentialStore{path: path}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload re-reads the credentials file if it changed since the last load
+func (s *FileCredentialStore) Reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat credentials file: %w", err)
+	}
+
+	s.mu.RLock()
+	unchanged := info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var data credentialFile
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+
+	s.mu.Lock()
+	s.data = data
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+	return nil
+}
+
+// Credentials returns the login for an account, resolving delegated credentials
+func (s *FileCredentialStore) Credentials(accountNumber string) (Credentials, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.data.Accounts[accountNumber]
+	if !ok {
+		return Credentials{}, fmt.Errorf("no credentials for account %s", accountNumber)
+	}
+
+	if entry.Delegate != "" {
+		delegate, ok := s.data.Delegates[entry.Delegate]
+		if !ok {
+			return Credentials{}, fmt.Errorf("account %s references unknown delegate %s", accountNumber, entry.Delegate)
+		}
+		return delegate, nil
+	}
+
+	if entry.Username == "" || entry.Password == "" {
+		return Credentials{}, fmt.Errorf("incomplete credentials for account %s", accountNumber)
+	}
+	return entry.Credentials, nil
+}
+
+// LoginAuthenticator obtains bank JWTs by logging in to the Bank of Anthos userservice
+// instead of signing them with the bank's private key. Tokens are cached until shortly
+// before they expire. A reloadable credential store is reloaded before each login,
+// so rotated credentials are picked up without a restart.
+type LoginAuthenticator struct {
+	userserviceURL string
+	httpClient     *http.Client
+	credentials    CredentialStore
+	refreshMargin  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedToken
+}
+
+type cachedToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+type loginResponse struct {
+	Token string `json:"token"`
+}
+
+// NewLoginAuthenticator creates an authenticator that logs in to userserviceURL
+func NewLoginAuthenticator(userserviceURL string, credentials CredentialStore) *LoginAuthenticator {
+	return &LoginAuthenticator{
+		userserviceURL: userserviceURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		credentials:   credentials,
+		refreshMargin: defaultRefreshMargin,
+		cache:         make(map[string]cachedToken),
+	}
+}
+
+// GetAuthHeader returns a bearer header for the account, logging in if no cached token is usable
+func (a *LoginAuthenticator) GetAuthHeader(accountNumber string) (string, error) {
+	token, err := a.Token(accountNumber)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
+// Token returns a userservice-issued JWT for the account
+func (a *LoginAuthenticator) Token(accountNumber string) (string, error) {
+	a.mu.Lock()
+	cached, ok := a.cache[accountNumber]
+	a.mu.Unlock()
+	if ok && time.Now().Add(a.refreshMargin).Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+
+	if reloadable, ok := a.credentials.(ReloadableCredentialStore); ok {
+		if err := reloadable.Reload(); err != nil {
+			return "", err
+		}
+	}
+	creds, err := a.credentials.Credentials(accountNumber)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := a.login(creds)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := parseUnverifiedClaims(token)
+	if err != nil {
+		return "", err
+	}
+	if claims.Acct != accountNumber {
+		return "", fmt.Errorf("userservice token is for account %s, not %s", claims.Acct, accountNumber)
+	}
+	if claims.ExpiresAt == nil {
+		return "", fmt.Errorf("userservice token has no expiry")
+	}
+
+	a.mu.Lock()
+	a.cache[accountNumber] = cachedToken{token: token, expiresAt: claims.ExpiresAt.Time}
+	a.mu.Unlock()
+
+	return token, nil
+}
+
+// Invalidate drops the cached token for an account, e.g. after the bank rejected it
+func (a *LoginAuthenticator) Invalidate(accountNumber string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.cache, accountNumber)
+}
+
+// login calls GET /login on the userservice and returns the issued token
+func (a *LoginAuthenticator) login(creds Credentials) (string, error) {
+	query := url.Values{}
+	query.Set("username", creds.Username)
+	query.Set("password", creds.Password)
+
+	resp, err := a.httpClient.Get(fmt.Sprintf("%s/login?%s", a.userserviceURL, query.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("userservice login failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read login response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("userservice login for %s rejected with status %d", creds.Username, resp.StatusCode)
+	}
+
+	var login loginResponse
+	if err := json.Unmarshal(body, &login); err != nil || login.Token == "" {
+		return "", fmt.Errorf("userservice login response did not contain a token")
+	}
+	return login.Token, nil
+}
+
+// parseUnverifiedClaims reads a userservice token's claims. The bank verifies the
+// signature; we only need acct and exp for caching.
+func parseUnverifiedClaims(token string) (*ServiceClaims, error) {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &ServiceClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse userservice token: %w", err)
+	}
+	claims, ok := parsed.Claims.(*ServiceClaims)
+	if !ok {
+		return nil, fmt.Errorf("unexpected userservice token claims")
+	}
+	return claims, nil
+}
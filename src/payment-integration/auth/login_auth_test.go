@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testCredentials = `{
+  "accounts": {
+    "1011226111": {"username": "testuser", "password": "bankofanthos"},
+    "1033623433": {"delegate": "alice-delegate"},
+    "1055757655": {"username": "incomplete"}
+  },
+  "delegates": {
+    "alice-delegate": {"username": "alice", "password": "delegated-secret"}
+  }
+}`
+
+func writeCredentials(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// fakeUserservice mimics Bank of Anthos /login, issuing tokens from a test key
+func fakeUserservice(t *testing.T, accounts map[string]string, expiry time.Duration, logins *int32) *httptest.Server {
+	issuer := newTestAuthenticator(t)
+	issuer.expiryTime = expiry
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/login" {
+			t.Errorf("Expected path /login, got %s", r.URL.Path)
+		}
+		atomic.AddInt32(logins, 1)
+
+		username := r.URL.Query().Get("username")
+		acct, ok := accounts[username+":"+r.URL.Query().Get("password")]
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		token, err := issuer.GenerateServiceToken(acct)
+		if err != nil {
+			t.Errorf("Failed to issue token: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(loginResponse{Token: token})
+	}))
+}
+
+func TestFileCredentialStore(t *testing.T) {
+	store, err := NewFileCredentialStore(writeCredentials(t, testCredentials))
+	if err != nil {
+		t.Fatalf("Failed to load credentials: %v", err)
+	}
+
+	creds, err := store.Credentials("1011226111")
+	if err != nil || creds.Username != "testuser" {
+		t.Errorf("Expected testuser credentials, got %+v (%v)", creds, err)
+	}
+
+	creds, err = store.Credentials("1033623433")
+	if err != nil || creds.Username != "alice" {
+		t.Errorf("Expected delegated alice credentials, got %+v (%v)", creds, err)
+	}
+
+	if _, err := store.Credentials("1055757655"); err == nil {
+		t.Error("Expected error for incomplete credentials")
+	}
+	if _, err := store.Credentials("9999999999"); err == nil {
+		t.Error("Expected error for unknown account")
+	}
+}
+
+func TestLoginAuthenticatorCachesToken(t *testing.T) {
+	var logins int32
+	server := fakeUserservice(t, map[string]string{"testuser:bankofanthos": "1011226111"}, time.Hour, &logins)
+	defer server.Close()
+
+	store, err := NewFileCredentialStore(writeCredentials(t, testCredentials))
+	if err != nil {
+		t.Fatalf("Failed to load credentials: %v", err)
+	}
+	authenticator := NewLoginAuthenticator(server.URL, store)
+
+	first, err := authenticator.GetAuthHeader("1011226111")
+	if err != nil {
+		t.Fatalf("GetAuthHeader failed: %v", err)
+	}
+	if !strings.HasPrefix(first, "Bearer ") {
+		t.Errorf("Expected bearer header, got %s", first)
+	}
+
+	second, err := authenticator.GetAuthHeader("1011226111")
+	if err != nil {
+		t.Fatalf("GetAuthHeader failed: %v", err)
+	}
+	if first != second {
+		t.Error("Expected cached token to be reused")
+	}
+	if logins != 1 {
+		t.Errorf("Expected 1 login, got %d", logins)
+	}
+
+	authenticator.Invalidate("1011226111")
+	if _, err := authenticator.GetAuthHeader("1011226111"); err != nil {
+		t.Fatalf("GetAuthHeader failed: %v", err)
+	}
+	if logins != 2 {
+		t.Errorf("Expected a fresh login after invalidation, got %d logins", logins)
+	}
+}
+
+func TestLoginAuthenticatorRefreshesNearExpiry(t *testing.T) {
+	var logins int32
+	// Tokens expire inside the refresh margin, so every call must log in again
+	server := fakeUserservice(t, map[string]string{"testuser:bankofanthos": "1011226111"}, 10*time.Second, &logins)
+	defer server.Close()
+
+	store, err := NewFileCredentialStore(writeCredentials(t, testCredentials))
+	if err != nil {
+		t.Fatalf("Failed to load credentials: %v", err)
+	}
+	authenticator := NewLoginAuthenticator(server.URL, store)
+
+	for i := 0; i < 2; i++ {
+		if _, err := authenticator.GetAuthHeader("1011226111"); err != nil {
+			t.Fatalf("GetAuthHeader failed: %v", err)
+		}
+	}
+	if logins != 2 {
+		t.Errorf("Expected 2 logins, got %d", logins)
+	}
+}
+
+func TestLoginAuthenticatorRejectsWrongAccount(t *testing.T) {
+	var logins int32
+	// The delegate logs in as a different account than the one requested
+	server := fakeUserservice(t, map[string]string{"alice:delegated-secret": "2222222222"}, time.Hour, &logins)
+	defer server.Close()
+
+	store, err := NewFileCredentialStore(writeCredentials(t, testCredentials))
+	if err != nil {
+		t.Fatalf("Failed to load credentials: %v", err)
+	}
+	authenticator := NewLoginAuthenticator(server.URL, store)
+
+	_, err = authenticator.GetAuthHeader("1033623433")
+	if err == nil || !strings.Contains(err.Error(), "not 1033623433") {
+		t.Errorf("Expected account mismatch error, got %v", err)
+	}
+}
+
+func TestLoginAuthenticatorLoginFailure(t *testing.T) {
+	var logins int32
+	server := fakeUserservice(t, map[string]string{}, time.Hour, &logins)
+	defer server.Close()
+
+	store, err := NewFileCredentialStore(writeCredentials(t, testCredentials))
+	if err != nil {
+		t.Fatalf("Failed to load credentials: %v", err)
+	}
+	authenticator := NewLoginAuthenticator(server.URL, store)
+
+	if _, err := authenticator.GetAuthHeader("1011226111"); err == nil {
+		t.Error("Expected error when userservice rejects the login")
+	}
+}
+
+func TestLoginAuthenticatorReloadsCredentials(t *testing.T) {
+	var logins int32
+	server := fakeUserservice(t, map[string]string{
+		"testuser:bankofanthos": "1011226111",
+		"testuser:rotated":      "1011226111",
+	}, time.Hour, &logins)
+	defer server.Close()
+
+	path := writeCredentials(t, testCredentials)
+	store, err := NewFileCredentialStore(path)
+	if err != nil {
+		t.Fatalf("Failed to load credentials: %v", err)
+	}
+	authenticator := NewLoginAuthenticator(server.URL, store)
+	if _, err := authenticator.GetAuthHeader("1011226111"); err != nil {
+		t.Fatalf("GetAuthHeader failed: %v", err)
+	}
+
+	// Rotate the password; the old one no longer logs in
+	rotated := strings.Replace(testCredentials, `"password": "bankofanthos"`, `"password": "rotated"`, 1)
+	if err := os.WriteFile(path, []byte(rotated), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator.Invalidate("1011226111")
+	if _, err := authenticator.GetAuthHeader("1011226111"); err != nil {
+		t.Fatalf("GetAuthHeader failed: %v", err)
+	}
+	if creds, _ := store.Credentials("1011226111"); creds.Password != "rotated" {
+		t.Errorf("Expected the rotated password to be loaded, got %q", creds.Password)
+	}
+}
@@ -6,15 +6,25 @@ import (
 	"encoding/pem"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/gke-hackathon/payment-integration/utils"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type ServiceAuthenticator struct {
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
-	expiryTime time.Duration
+	privateKey   *rsa.PrivateKey
+	publicKey    *rsa.PublicKey
+	keyID        string
+	previousKeys []*rsa.PublicKey
+	expiryTime   time.Duration
+	clockSkew    time.Duration
+
+	mu              sync.RWMutex
+	defaultTemplate ClaimTemplate
+	templates       map[string]ClaimTemplate
+	replay          *replayCache
 }
 
 type ServiceClaims struct {
@@ -48,10 +58,114 @@ func NewServiceAuthenticator(privateKeyPath, publicKeyPath string, expirySeconds
 	return &ServiceAuthenticator{
 		privateKey: privateKey,
 		publicKey:  publicKey,
+		keyID:      KeyID(publicKey),
 		expiryTime: time.Duration(expirySeconds) * time.Second,
+		clockSkew:  defaultClockSkew,
+
+		defaultTemplate: DefaultClaimTemplate(),
+		templates:       make(map[string]ClaimTemplate),
+		replay:          newReplayCache(defaultReplayCacheSize),
 	}, nil
 }
 
+// SetDefaultClaims sets the issuer, audience and identity claims used for every token.
+// ValidateToken requires tokens to carry this issuer and one of these audiences.
+func (sa *ServiceAuthenticator) SetDefaultClaims(template ClaimTemplate) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.defaultTemplate = template.merge(DefaultClaimTemplate())
+}
+
+// SetClaimTemplate overrides the claims minted for tokens sent to a target bank service
+func (sa *ServiceAuthenticator) SetClaimTemplate(service string, template ClaimTemplate) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.templates[service] = template
+}
+
+// SetClockSkew sets how far ahead of our clock a token's nbf may be when
+// ValidateToken checks it. Issued tokens are never backdated.
+func (sa *ServiceAuthenticator) SetClockSkew(skew time.Duration) {
+	if skew < 0 {
+		skew = 0
+	}
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.clockSkew = skew
+}
+
+// SetReplayCacheSize bounds how many token ids ValidateToken remembers. Ids
+// that have not expired yet are kept, so their tokens still can't be replayed.
+func (sa *ServiceAuthenticator) SetReplayCacheSize(size int) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.replay = sa.replay.resized(size, time.Now())
+}
+
+// Issuer returns the configured iss claim, or an empty string if none is set
+func (sa *ServiceAuthenticator) Issuer() string {
+	sa.mu.RLock()
+	defer sa.mu.RUnlock()
+	return sa.defaultTemplate.Issuer
+}
+
+// claimTemplate resolves the template for a target service, falling back to the default
+func (sa *ServiceAuthenticator) claimTemplate(service string) ClaimTemplate {
+	sa.mu.RLock()
+	defer sa.mu.RUnlock()
+	if template, ok := sa.templates[service]; ok {
+		return template.merge(sa.defaultTemplate)
+	}
+	return sa.defaultTemplate
+}
+
+// AddPreviousPublicKey loads a retired public key so tokens signed before a key
+// rotation still validate and the key keeps being published in the JWKS
+func (sa *ServiceAuthenticator) AddPreviousPublicKey(publicKeyPath string) error {
+	publicKeyData, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read previous public key: %w", err)
+	}
+
+	publicKey, err := parsePublicKey(publicKeyData)
+	if err != nil {
+		return fmt.Errorf("failed to parse previous public key: %w", err)
+	}
+
+	if KeyID(publicKey) == sa.keyID {
+		return nil
+	}
+	sa.previousKeys = append(sa.previousKeys, publicKey)
+	return nil
+}
+
+// KeyID returns the kid of the active signing key
+func (sa *ServiceAuthenticator) KeyID() string {
+	return sa.keyID
+}
+
+// JWKS returns the active and previous public keys as a JSON Web Key Set
+func (sa *ServiceAuthenticator) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{NewJSONWebKey(sa.publicKey)}}
+	for _, key := range sa.previousKeys {
+		set.Keys = append(set.Keys, NewJSONWebKey(key))
+	}
+	return set
+}
+
+// verificationKey returns the public key matching a token's kid header
+func (sa *ServiceAuthenticator) verificationKey(kid string) (*rsa.PublicKey, error) {
+	if kid == "" || kid == sa.keyID {
+		return sa.publicKey, nil
+	}
+	for _, key := range sa.previousKeys {
+		if KeyID(key) == kid {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key: %s", kid)
+}
+
 func parsePrivateKey(keyData []byte) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode(keyData)
 	if block == nil {
@@ -99,18 +213,29 @@ func parsePublicKey(keyData []byte) (*rsa.PublicKey, error) {
 }
 
 func (sa *ServiceAuthenticator) GenerateServiceToken(accountNumber string) (string, error) {
+	return sa.GenerateServiceTokenFor("", accountNumber)
+}
+
+// GenerateServiceTokenFor mints a token using the claim template of the target bank service
+func (sa *ServiceAuthenticator) GenerateServiceTokenFor(service, accountNumber string) (string, error) {
+	template := sa.claimTemplate(service)
 	now := time.Now()
 	claims := ServiceClaims{
-		User: "payment-service",
+		User: template.User,
 		Acct: accountNumber, // Use the actual sender's account number
-		Name: "Payment Integration Service",
+		Name: template.Name,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        utils.GenerateUUID(),
+			Issuer:    template.Issuer,
+			Audience:  template.Audience,
 			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(sa.expiryTime)),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = sa.keyID
 	tokenString, err := token.SignedString(sa.privateKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
@@ -119,27 +244,75 @@ func (sa *ServiceAuthenticator) GenerateServiceToken(accountNumber string) (stri
 	return tokenString, nil
 }
 
+// ValidateToken verifies a token's signature, time claims, issuer and audience,
+// and rejects any token whose jti has already been presented. The clock skew
+// tolerance only applies to nbf; expiry is enforced strictly.
 func (sa *ServiceAuthenticator) ValidateToken(tokenString string) (*ServiceClaims, error) {
+	expected := sa.claimTemplate("")
+	sa.mu.RLock()
+	clockSkew, replay := sa.clockSkew, sa.replay
+	sa.mu.RUnlock()
+
 	token, err := jwt.ParseWithClaims(tokenString, &ServiceClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return sa.publicKey, nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		return sa.verificationKey(kid)
+	}, jwt.WithoutClaimsValidation())
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(*ServiceClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*ServiceClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	now := time.Now()
+	if claims.ExpiresAt == nil || !now.Before(claims.ExpiresAt.Time) {
+		return nil, fmt.Errorf("failed to parse token: %w", jwt.ErrTokenExpired)
+	}
+	if claims.NotBefore != nil && now.Add(clockSkew).Before(claims.NotBefore.Time) {
+		return nil, fmt.Errorf("failed to parse token: %w", jwt.ErrTokenNotValidYet)
+	}
+
+	if expected.Issuer != "" && claims.Issuer != expected.Issuer {
+		return nil, fmt.Errorf("token issuer %q does not match %q", claims.Issuer, expected.Issuer)
+	}
+	if len(expected.Audience) > 0 && !hasAnyAudience(claims.Audience, expected.Audience) {
+		return nil, fmt.Errorf("token audience %v does not match %v", []string(claims.Audience), expected.Audience)
+	}
+
+	if claims.ID == "" {
+		return nil, fmt.Errorf("token is missing jti")
+	}
+	if err := replay.observe(claims.ID, claims.ExpiresAt.Time, now); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, claims.ID)
+	}
+
+	return claims, nil
+}
+
+func hasAnyAudience(actual jwt.ClaimStrings, expected []string) bool {
+	for _, want := range expected {
+		for _, got := range actual {
+			if got == want {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (sa *ServiceAuthenticator) GetAuthHeader(accountNumber string) (string, error) {
-	token, err := sa.GenerateServiceToken(accountNumber)
+	return sa.GetAuthHeaderForService("", accountNumber)
+}
+
+// GetAuthHeaderForService returns a bearer header minted for the target bank service
+func (sa *ServiceAuthenticator) GetAuthHeaderForService(service, accountNumber string) (string, error) {
+	token, err := sa.GenerateServiceTokenFor(service, accountNumber)
 	if err != nil {
 		return "", err
 	}
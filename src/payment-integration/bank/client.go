@@ -10,11 +10,30 @@ import (
 	"time"
 )
 
+// Bank of Anthos services the client talks to, used to pick per-service token claims
+const (
+	ServiceLedgerWriter  = "ledgerwriter"
+	ServiceBalanceReader = "balancereader"
+)
+
 // Authenticator interface for JWT token generation
 type Authenticator interface {
 	GetAuthHeader(accountNumber string) (string, error)
 }
 
+// ServiceAuthenticator is implemented by authenticators that mint different
+// claims depending on which bank service a request targets
+type ServiceAuthenticator interface {
+	GetAuthHeaderForService(service, accountNumber string) (string, error)
+}
+
+// TokenInvalidator is implemented by authenticators that cache tokens. The
+// client drops an account's token when the bank rejects it, so the next
+// request authenticates afresh.
+type TokenInvalidator interface {
+	Invalidate(accountNumber string)
+}
+
 // Client represents a Bank of Anthos API client
 type Client struct {
 	baseURL       string
@@ -33,8 +52,44 @@ func NewClient(baseURL string, authenticator Authenticator) *Client {
 	}
 }
 
-// CreateTransaction creates a new bank transaction
+// authHeader builds the Authorization header for a request to the given bank service
+func (c *Client) authHeader(service, accountNumber string) (string, error) {
+	if serviceAuth, ok := c.authenticator.(ServiceAuthenticator); ok {
+		return serviceAuth.GetAuthHeaderForService(service, accountNumber)
+	}
+	return c.authenticator.GetAuthHeader(accountNumber)
+}
+
+// invalidate drops the cached token of an account whose request the bank
+// rejected as unauthorized
+func (c *Client) invalidate(statusCode int, accountNumber string) {
+	if statusCode != http.StatusUnauthorized {
+		return
+	}
+	if invalidator, ok := c.authenticator.(TokenInvalidator); ok {
+		invalidator.Invalidate(accountNumber)
+	}
+}
+
+// BaseURL returns the bank backend the client talks to
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// CreateTransaction creates a new bank transaction, authenticated as the sender
 func (c *Client) CreateTransaction(req *TransactionRequest) (*TransactionResponse, error) {
+	return c.postTransaction(req, req.FromAccountNum)
+}
+
+// CreateDeposit records the credit leg of a cross-bank transfer at the
+// recipient's bank, where the sender is an external account. It is
+// authenticated as the recipient.
+func (c *Client) CreateDeposit(req *TransactionRequest) (*TransactionResponse, error) {
+	return c.postTransaction(req, req.ToAccountNum)
+}
+
+// postTransaction sends a transaction to the ledger, authenticated as authAccount
+func (c *Client) postTransaction(req *TransactionRequest, authAccount string) (*TransactionResponse, error) {
 	url := fmt.Sprintf("%s/transactions", c.baseURL)
 
 	// Marshal request to JSON
@@ -57,13 +112,12 @@ func (c *Client) CreateTransaction(req *TransactionRequest) (*TransactionRespons
 
 	// Add JWT authentication if available
 	if c.authenticator != nil {
-		// Use the sender's account number for authentication
-		authHeader, err := c.authenticator.GetAuthHeader(req.FromAccountNum)
+		authHeader, err := c.authHeader(ServiceLedgerWriter, authAccount)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate auth header: %w", err)
 		}
 		httpReq.Header.Set("Authorization", authHeader)
-		log.Printf("Added JWT auth header for account %s", req.FromAccountNum)
+		log.Printf("Added JWT auth header for account %s", authAccount)
 	} else {
 		log.Printf("WARNING: No authenticator available, request will likely fail")
 	}
@@ -83,6 +137,7 @@ func (c *Client) CreateTransaction(req *TransactionRequest) (*TransactionRespons
 
 	// Check for errors
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		c.invalidate(resp.StatusCode, authAccount)
 		var errResp ErrorResponse
 		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error != "" {
 			return nil, NewBankError(resp.StatusCode, errResp.Error, errResp.Message)
@@ -124,7 +179,7 @@ func (c *Client) CheckBalance(accountNum, routingNum string) (*BalanceResponse,
 
 	// Add JWT authentication if available
 	if c.authenticator != nil {
-		authHeader, err := c.authenticator.GetAuthHeader(accountNum)
+		authHeader, err := c.authHeader(ServiceBalanceReader, accountNum)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate auth header: %w", err)
 		}
@@ -146,6 +201,7 @@ func (c *Client) CheckBalance(accountNum, routingNum string) (*BalanceResponse,
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
+		c.invalidate(resp.StatusCode, accountNum)
 		var errResp ErrorResponse
 		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error != "" {
 			return nil, NewBankError(resp.StatusCode, errResp.Error, errResp.Message)
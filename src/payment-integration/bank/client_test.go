@@ -152,6 +152,25 @@ func TestCreateTransactionDuplicate(t *testing.T) {
 	}
 }
 
+func TestCreateTransactionUnauthorizedInvalidatesToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "unauthorized", Message: "token expired"})
+	}))
+	defer server.Close()
+
+	authenticator := &invalidatingAuthenticator{}
+	client := NewClient(server.URL, authenticator)
+
+	_, err := client.CreateTransaction(&TransactionRequest{FromAccountNum: "1234567890", ToAccountNum: "9999999999", Amount: 100})
+	if bankErr, ok := err.(*BankError); !ok || !bankErr.IsUnauthorized() {
+		t.Fatalf("Expected unauthorized error, got %v", err)
+	}
+	if len(authenticator.invalidated) != 1 || authenticator.invalidated[0] != "1234567890" {
+		t.Errorf("Expected the sender's token to be invalidated, got %v", authenticator.invalidated)
+	}
+}
+
 func TestHealthCheck(t *testing.T) {
 	// Create mock server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -191,3 +210,13 @@ type mockAuthenticator struct{}
 func (m *mockAuthenticator) GetAuthHeader(accountNumber string) (string, error) {
 	return "Bearer mock-jwt-token", nil
 }
+
+// invalidatingAuthenticator records the accounts whose tokens were invalidated
+type invalidatingAuthenticator struct {
+	mockAuthenticator
+	invalidated []string
+}
+
+func (a *invalidatingAuthenticator) Invalidate(accountNumber string) {
+	a.invalidated = append(a.invalidated, accountNumber)
+}
@@ -0,0 +1,191 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+var (
+	// ErrInvalidRoutingNumber is returned for routing numbers that are malformed or fail the ABA checksum
+	ErrInvalidRoutingNumber = errors.New("invalid routing number")
+	// ErrNoRoute is returned when no bank backend serves a routing number
+	ErrNoRoute = errors.New("no bank route for routing number")
+)
+
+// DemoRoutingNumbers are the routing numbers used by Bank of Anthos and this
+// service's defaults. Neither passes the ABA checksum.
+var DemoRoutingNumbers = []string{"883745000", "123456789"}
+
+// ABAChecksum reports whether a 9-digit routing number passes the ABA checksum:
+// 3(d1+d4+d7) + 7(d2+d5+d8) + (d3+d6+d9) must be a multiple of 10
+func ABAChecksum(routingNum string) bool {
+	if len(routingNum) != 9 {
+		return false
+	}
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i, ch := range routingNum {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+		sum += int(ch-'0') * weights[i%3]
+	}
+	return sum%10 == 0
+}
+
+// RoutingValidator enforces the ABA checksum, except for explicitly exempted
+// routing numbers such as demo banks
+type RoutingValidator struct {
+	mu     sync.RWMutex
+	exempt map[string]bool
+}
+
+// NewRoutingValidator creates a validator that skips the checksum for the given routing numbers
+func NewRoutingValidator(exempt ...string) *RoutingValidator {
+	v := &RoutingValidator{exempt: make(map[string]bool)}
+	for _, routingNum := range exempt {
+		v.Exempt(routingNum)
+	}
+	return v
+}
+
+// Exempt skips the checksum for a routing number; it must still be 9 digits
+func (v *RoutingValidator) Exempt(routingNum string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.exempt[routingNum] = true
+}
+
+// ValidateRoutingNumber checks that a routing number is 9 digits and passes the
+// ABA checksum unless exempt
+func (v *RoutingValidator) ValidateRoutingNumber(routingNum string) error {
+	if len(routingNum) != 9 {
+		return fmt.Errorf("%w: %q must be 9 digits", ErrInvalidRoutingNumber, routingNum)
+	}
+	for _, ch := range routingNum {
+		if ch < '0' || ch > '9' {
+			return fmt.Errorf("%w: %q must be 9 digits", ErrInvalidRoutingNumber, routingNum)
+		}
+	}
+
+	v.mu.RLock()
+	exempt := v.exempt[routingNum]
+	v.mu.RUnlock()
+
+	if !exempt && !ABAChecksum(routingNum) {
+		return fmt.Errorf("%w: %s fails the ABA checksum", ErrInvalidRoutingNumber, routingNum)
+	}
+	return nil
+}
+
+// Route configures the bank backend serving one routing number
+type Route struct {
+	RoutingNumber   string    `json:"routing_number"`
+	Name            string    `json:"name"`
+	BaseURL         string    `json:"base_url"`
+	MerchantAccount string    `json:"merchant_account"`
+	ChecksumExempt  bool      `json:"checksum_exempt"`
+	Auth            RouteAuth `json:"auth"`
+}
+
+// RouteAuth holds a route's credentials. An empty mode reuses the service's default authenticator.
+type RouteAuth struct {
+	Mode            string `json:"mode" yaml:"mode"`
+	PrivateKeyPath  string `json:"private_key_path" yaml:"private_key_path"`
+	PublicKeyPath   string `json:"public_key_path" yaml:"public_key_path"`
+	UserserviceURL  string `json:"userservice_url" yaml:"userservice_url"`
+	CredentialsPath string `json:"credentials_path" yaml:"credentials_path"`
+}
+
+// routingTableFile is the on-disk layout of a routing table
+type routingTableFile struct {
+	Routes []Route `json:"routes"`
+}
+
+// LoadRoutes reads a JSON routing table of the form {"routes": [...]}
+func LoadRoutes(path string) ([]Route, error) {
+	var file routingTableFile
+	found, err := storage.ReadJSON(path, &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing table: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("routing table %s does not exist", path)
+	}
+
+	seen := make(map[string]bool, len(file.Routes))
+	for _, route := range file.Routes {
+		if route.BaseURL == "" {
+			return nil, fmt.Errorf("route for %s has no base_url", route.RoutingNumber)
+		}
+		if seen[route.RoutingNumber] {
+			return nil, fmt.Errorf("routing number %s is listed more than once", route.RoutingNumber)
+		}
+		seen[route.RoutingNumber] = true
+	}
+	return file.Routes, nil
+}
+
+// Router sends each routing number's transactions to its own bank backend
+type Router struct {
+	mu        sync.RWMutex
+	validator *RoutingValidator
+	clients   map[string]*Client
+	fallback  *Client
+}
+
+// NewRouter creates an empty router that validates routing numbers with validator
+func NewRouter(validator *RoutingValidator) *Router {
+	return &Router{validator: validator, clients: make(map[string]*Client)}
+}
+
+// AddRoute sends transactions for routingNum to client
+func (r *Router) AddRoute(routingNum string, client *Client) error {
+	if err := r.validator.ValidateRoutingNumber(routingNum); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[routingNum] = client
+	return nil
+}
+
+// SetFallback serves routing numbers without their own route. Without a
+// fallback, unrouted routing numbers are rejected with ErrNoRoute.
+func (r *Router) SetFallback(client *Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = client
+}
+
+// ClientFor returns the bank backend serving routingNum
+func (r *Router) ClientFor(routingNum string) (*Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if client, ok := r.clients[routingNum]; ok {
+		return client, nil
+	}
+	if r.fallback != nil {
+		return r.fallback, nil
+	}
+	return nil, fmt.Errorf("%w %s", ErrNoRoute, routingNum)
+}
+
+// RoutingNumbers returns the routing numbers with their own route, sorted
+func (r *Router) RoutingNumbers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	routingNums := make([]string, 0, len(r.clients))
+	for routingNum := range r.clients {
+		routingNums = append(routingNums, routingNum)
+	}
+	sort.Strings(routingNums)
+	return routingNums
+}
@@ -0,0 +1,145 @@
+package bank
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestABAChecksum(t *testing.T) {
+	tests := []struct {
+		routingNum string
+		valid      bool
+	}{
+		{"011000015", true},
+		{"021000021", true},
+		{"121000358", true},
+		{"021000022", false},
+		{"883745000", false},
+		{"123456789", false},
+		{"12345678", false},
+		{"0110000A5", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.routingNum, func(t *testing.T) {
+			if got := ABAChecksum(tt.routingNum); got != tt.valid {
+				t.Errorf("Expected %v, got %v", tt.valid, got)
+			}
+		})
+	}
+}
+
+func TestRoutingValidatorExemptions(t *testing.T) {
+	v := NewRoutingValidator("883745000")
+
+	if err := v.ValidateRoutingNumber("883745000"); err != nil {
+		t.Errorf("Expected exempt demo routing number to pass, got %v", err)
+	}
+	if err := v.ValidateRoutingNumber("021000021"); err != nil {
+		t.Errorf("Expected valid routing number to pass, got %v", err)
+	}
+	if err := v.ValidateRoutingNumber("123456789"); !errors.Is(err, ErrInvalidRoutingNumber) {
+		t.Errorf("Expected ErrInvalidRoutingNumber, got %v", err)
+	}
+
+	// Exemptions skip only the checksum, not the format
+	v.Exempt("12345")
+	if err := v.ValidateRoutingNumber("12345"); !errors.Is(err, ErrInvalidRoutingNumber) {
+		t.Errorf("Expected ErrInvalidRoutingNumber for short exempt number, got %v", err)
+	}
+}
+
+func TestRouterClientFor(t *testing.T) {
+	router := NewRouter(NewRoutingValidator("883745000"))
+	anthos := NewClient("http://anthos", &mockAuthenticator{})
+	other := NewClient("http://other", &mockAuthenticator{})
+
+	if err := router.AddRoute("883745000", anthos); err != nil {
+		t.Fatalf("AddRoute failed: %v", err)
+	}
+	if err := router.AddRoute("021000021", other); err != nil {
+		t.Fatalf("AddRoute failed: %v", err)
+	}
+	if err := router.AddRoute("123456789", other); !errors.Is(err, ErrInvalidRoutingNumber) {
+		t.Errorf("Expected invalid routing number to be rejected, got %v", err)
+	}
+
+	if client, _ := router.ClientFor("021000021"); client != other {
+		t.Errorf("Expected 021000021 to route to %s, got %v", other.BaseURL(), client)
+	}
+	if _, err := router.ClientFor("011000015"); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("Expected ErrNoRoute, got %v", err)
+	}
+
+	router.SetFallback(anthos)
+	if client, _ := router.ClientFor("011000015"); client != anthos {
+		t.Errorf("Expected unrouted number to use the fallback, got %v", client)
+	}
+}
+
+func TestLoadRoutes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.json")
+	table := `{"routes": [
+		{"routing_number": "883745000", "base_url": "http://anthos", "checksum_exempt": true},
+		{"routing_number": "021000021", "base_url": "http://other", "merchant_account": "2222222222",
+		 "auth": {"mode": "login", "userservice_url": "http://users", "credentials_path": "/creds.json"}}
+	]}`
+	os.WriteFile(path, []byte(table), 0600)
+
+	routes, err := LoadRoutes(path)
+	if err != nil {
+		t.Fatalf("LoadRoutes failed: %v", err)
+	}
+	if len(routes) != 2 || !routes[0].ChecksumExempt || routes[1].Auth.Mode != "login" || routes[1].MerchantAccount != "2222222222" {
+		t.Errorf("Unexpected routes: %+v", routes)
+	}
+
+	os.WriteFile(path, []byte(`{"routes": [{"routing_number": "021000021"}]}`), 0600)
+	if _, err := LoadRoutes(path); err == nil {
+		t.Error("Expected error for route without base_url")
+	}
+
+	if _, err := LoadRoutes(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing routing table")
+	}
+}
+
+type recordingAuthenticator struct {
+	accounts []string
+}
+
+func (a *recordingAuthenticator) GetAuthHeader(accountNumber string) (string, error) {
+	a.accounts = append(a.accounts, accountNumber)
+	return "Bearer mock-jwt-token", nil
+}
+
+func TestCreateDepositAuthenticatesAsRecipient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(TransactionResponse{TransactionID: 1})
+	}))
+	defer server.Close()
+
+	authenticator := &recordingAuthenticator{}
+	client := NewClient(server.URL, authenticator)
+
+	req := &TransactionRequest{
+		FromAccountNum: "1234567890",
+		FromRoutingNum: "883745000",
+		ToAccountNum:   "2222222222",
+		ToRoutingNum:   "021000021",
+		Amount:         100,
+		UUID:           "test-uuid",
+	}
+	if _, err := client.CreateDeposit(req); err != nil {
+		t.Fatalf("CreateDeposit failed: %v", err)
+	}
+	if len(authenticator.accounts) != 1 || authenticator.accounts[0] != "2222222222" {
+		t.Errorf("Expected deposit authenticated as 2222222222, got %v", authenticator.accounts)
+	}
+}
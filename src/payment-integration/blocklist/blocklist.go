@@ -0,0 +1,314 @@
+package blocklist
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrFileEntry is returned when removing an entry that comes from the blocklist
+// file, which only the file can change
+var ErrFileEntry = errors.New("blocklist entry is managed in the blocklist file")
+
+// Charge is a charge as seen by the blocklist. Empty fields are not checked.
+type Charge struct {
+	Payer string
+	Payee string
+	// Card is the card fingerprint
+	Card string
+	BIN  string
+}
+
+// file is the layout of the blocklist file
+type file struct {
+	Entries []Entry `yaml:"entries"`
+}
+
+// index is an immutable lookup over the entries. An account or card can have
+// both an admin and a file entry, admin first, so the file entry still applies
+// once the admin entry expires.
+type index struct {
+	accounts map[string][]Entry
+	cards    map[string][]Entry
+	bins     []Entry
+}
+
+// Blocklist combines entries from the admin API, kept in a Store, with entries
+// from an optional file that is reloaded when it changes
+type Blocklist struct {
+	store  Store
+	path   string
+	logger *logging.Logger
+	// clock is swapped by SetClock while the reload goroutine reads it
+	clock atomic.Pointer[func() time.Time]
+
+	// mu serializes changes; lookups only read the index
+	mu      sync.Mutex
+	admin   []Entry
+	file    []Entry
+	modTime time.Time
+	index   atomic.Pointer[index]
+
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+// New loads the admin entries from store and, if path is set, the blocklist file
+func New(store Store, path string, logger *logging.Logger) (*Blocklist, error) {
+	admin, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	b := &Blocklist{store: store, path: path, logger: logger, admin: admin}
+	b.SetClock(time.Now)
+	b.rebuild()
+
+	if path != "" {
+		if _, err := b.Reload(); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// SetClock overrides the time source, for tests
+func (b *Blocklist) SetClock(now func() time.Time) {
+	b.clock.Store(&now)
+}
+
+func (b *Blocklist) now() time.Time {
+	return (*b.clock.Load())()
+}
+
+// Reload re-reads the blocklist file if it changed since the last load and
+// reports whether it did. An invalid file leaves the previous entries in place.
+func (b *Blocklist) Reload() (bool, error) {
+	if b.path == "" {
+		return false, nil
+	}
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat blocklist file: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if info.ModTime().Equal(b.modTime) {
+		return false, nil
+	}
+
+	raw, err := os.ReadFile(b.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read blocklist file: %w", err)
+	}
+	var f file
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return false, fmt.Errorf("failed to parse blocklist file: %w", err)
+	}
+	for i := range f.Entries {
+		if err := f.Entries[i].Validate(); err != nil {
+			return false, fmt.Errorf("blocklist file entry %d: %w", i+1, err)
+		}
+		f.Entries[i].Source = SourceFile
+	}
+
+	b.file = f.Entries
+	b.modTime = info.ModTime()
+	b.rebuild()
+	return true, nil
+}
+
+// rebuild swaps in a new index. It is called with mu held, or before the
+// blocklist is shared. Admin entries win over file entries for the same value.
+func (b *Blocklist) rebuild() {
+	idx := &index{accounts: make(map[string][]Entry), cards: make(map[string][]Entry)}
+	for _, entries := range [][]Entry{b.admin, b.file} {
+		for _, entry := range entries {
+			switch entry.Kind {
+			case KindAccount:
+				idx.accounts[entry.Value] = append(idx.accounts[entry.Value], entry)
+			case KindCard:
+				idx.cards[entry.Value] = append(idx.cards[entry.Value], entry)
+			case KindBIN:
+				idx.bins = append(idx.bins, entry)
+			}
+		}
+	}
+	b.index.Store(idx)
+}
+
+// firstActive returns the first of entries that has not expired
+func firstActive(entries []Entry, now time.Time) (Entry, bool) {
+	for _, entry := range entries {
+		if !entry.Expired(now) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Check returns a *Blocked error if any part of the charge is blocklisted
+func (b *Blocklist) Check(c Charge) error {
+	idx := b.index.Load()
+	now := b.now()
+
+	for _, party := range []struct{ name, account string }{{"payer", c.Payer}, {"payee", c.Payee}} {
+		if entry, ok := firstActive(idx.accounts[party.account], now); ok && party.account != "" {
+			return &Blocked{Entry: entry, Party: party.name}
+		}
+	}
+	if entry, ok := firstActive(idx.cards[c.Card], now); ok && c.Card != "" {
+		return &Blocked{Entry: entry}
+	}
+	if c.BIN != "" {
+		for _, entry := range idx.bins {
+			if entry.matchesBIN(c.BIN) && !entry.Expired(now) {
+				return &Blocked{Entry: entry}
+			}
+		}
+	}
+	return nil
+}
+
+// Add validates and stores an admin entry, replacing any with the same kind
+// and value. It applies to charges immediately.
+func (b *Blocklist) Add(entry Entry) (Entry, error) {
+	if err := entry.Validate(); err != nil {
+		return Entry{}, err
+	}
+	entry.Source = SourceAdmin
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = b.now().UTC()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.store.Put(entry); err != nil {
+		return Entry{}, err
+	}
+	return entry, b.reloadAdmin()
+}
+
+// Remove deletes an admin entry. Entries from the blocklist file can only be
+// removed from the file.
+func (b *Blocklist) Remove(kind Kind, value string) (Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := Key(kind, value)
+	var removed *Entry
+	for i := range b.admin {
+		if Key(b.admin[i].Kind, b.admin[i].Value) == key {
+			removed = &b.admin[i]
+		}
+	}
+	if removed == nil {
+		for _, entry := range b.file {
+			if Key(entry.Kind, entry.Value) == key {
+				return Entry{}, ErrFileEntry
+			}
+		}
+		return Entry{}, ErrNotFound
+	}
+
+	entry := *removed
+	if err := b.store.Delete(kind, value); err != nil {
+		return Entry{}, err
+	}
+	return entry, b.reloadAdmin()
+}
+
+// reloadAdmin re-reads the admin entries from the store. It is called with mu held.
+func (b *Blocklist) reloadAdmin() error {
+	admin, err := b.store.List()
+	if err != nil {
+		return err
+	}
+	b.admin = admin
+	b.rebuild()
+	return nil
+}
+
+// List returns the unexpired entries, ordered by kind and value
+func (b *Blocklist) List() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	var entries []Entry
+	for _, set := range [][]Entry{b.admin, b.file} {
+		for _, entry := range set {
+			if !entry.Expired(now) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return Key(entries[i].Kind, entries[i].Value) < Key(entries[j].Kind, entries[j].Value)
+	})
+	return entries
+}
+
+// PurgeExpired deletes expired admin entries from the store and returns how
+// many were deleted
+func (b *Blocklist) PurgeExpired() (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	purged := 0
+	for _, entry := range b.admin {
+		if entry.Expired(now) {
+			if err := b.store.Delete(entry.Kind, entry.Value); err != nil && !errors.Is(err, ErrNotFound) {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	if purged == 0 {
+		return 0, nil
+	}
+	return purged, b.reloadAdmin()
+}
+
+// Start reloads the blocklist file and purges expired entries every interval
+// until Stop is called
+func (b *Blocklist) Start(interval time.Duration) {
+	b.ticker = time.NewTicker(interval)
+	b.done = make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-b.ticker.C:
+				if reloaded, err := b.Reload(); err != nil {
+					b.logger.Error("Failed to reload blocklist file, keeping previous entries", err, map[string]interface{}{"path": b.path})
+				} else if reloaded {
+					b.logger.Info("Blocklist file reloaded", map[string]interface{}{"path": b.path})
+				}
+				if _, err := b.PurgeExpired(); err != nil {
+					b.logger.Error("Failed to purge expired blocklist entries", err, nil)
+				}
+			case <-b.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the reload loop
+func (b *Blocklist) Stop() {
+	if b.ticker != nil {
+		b.ticker.Stop()
+		close(b.done)
+		b.ticker = nil
+	}
+}
@@ -0,0 +1,197 @@
+package blocklist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/logging"
+)
+
+var noon = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func newTestBlocklist(t *testing.T, store Store, path string) (*Blocklist, *time.Time) {
+	t.Helper()
+	b, err := New(store, path, logging.NewLogger("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := noon
+	b.SetClock(func() time.Time { return now })
+	return b, &now
+}
+
+// writeFile writes the blocklist file with a distinct modification time
+func writeFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEntryValidation(t *testing.T) {
+	tests := []struct {
+		entry Entry
+		valid bool
+	}{
+		{Entry{Kind: KindAccount, Value: "1011226111"}, true},
+		{Entry{Kind: KindAccount, Value: "acct-1"}, false},
+		{Entry{Kind: KindCard, Value: "fp-1"}, true},
+		{Entry{Kind: KindCard}, false},
+		{Entry{Kind: KindBIN, Value: "4111"}, true},
+		{Entry{Kind: KindBIN, Value: "400000-400999"}, true},
+		{Entry{Kind: KindBIN, Value: "400999-400000"}, false},
+		{Entry{Kind: KindBIN, Value: "4000-400999"}, false},
+		{Entry{Kind: KindBIN, Value: "123456789"}, false},
+		// Charges only carry 6 BIN digits, so longer entries could never match
+		{Entry{Kind: KindBIN, Value: "41111111"}, false},
+		{Entry{Kind: KindBIN, Value: "40000000-40009999"}, false},
+		{Entry{Kind: "iban", Value: "DE89"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.entry.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v: expected valid=%v, got %v", tt.entry, tt.valid, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	b, now := newTestBlocklist(t, NewMemoryStore(), "")
+	for _, entry := range []Entry{
+		{Kind: KindAccount, Value: "1011226111", Reason: "compromised demo account"},
+		{Kind: KindCard, Value: "fp-stolen", Reason: "reported stolen", ExpiresAt: noon.Add(time.Hour)},
+		{Kind: KindBIN, Value: "400000-400999", Reason: "test range"},
+		{Kind: KindBIN, Value: "5105", Reason: "issuer incident"},
+	} {
+		if _, err := b.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		charge Charge
+		reason string
+		party  string
+	}{
+		{Charge{Payer: "1011226111"}, "ACCOUNT_FROZEN", "payer"},
+		{Charge{Payer: "1033623433", Payee: "1011226111"}, "ACCOUNT_FROZEN", "payee"},
+		{Charge{Payer: "1033623433", Card: "fp-stolen"}, "CARD_FROZEN", ""},
+		{Charge{Payer: "1033623433", BIN: "400512"}, "BIN_FROZEN", ""},
+		{Charge{Payer: "1033623433", BIN: "401000"}, "", ""},
+		{Charge{Payer: "1033623433", BIN: "510510"}, "BIN_FROZEN", ""},
+		{Charge{Payer: "1033623433", Card: "fp-ok", BIN: "453201"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		err := b.Check(tt.charge)
+		var blocked *Blocked
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("%+v: expected no match, got %v", tt.charge, err)
+			}
+			continue
+		}
+		if !errors.As(err, &blocked) || blocked.Reason() != tt.reason || blocked.Party != tt.party {
+			t.Errorf("%+v: expected %s for %q, got %v", tt.charge, tt.reason, tt.party, err)
+		}
+	}
+
+	// Expired entries stop applying and are purged
+	*now = now.Add(time.Hour)
+	if err := b.Check(Charge{Card: "fp-stolen"}); err != nil {
+		t.Errorf("Expected the card entry to have expired, got %v", err)
+	}
+	if purged, err := b.PurgeExpired(); err != nil || purged != 1 || len(b.List()) != 3 {
+		t.Errorf("Expected 1 entry purged and 3 left, got %d (%v) and %d", purged, err, len(b.List()))
+	}
+}
+
+func TestFileReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "blocklist.yaml")
+	writeFile(t, path, `
+entries:
+  - kind: account
+    value: "1011226111"
+    reason: incident 42
+`, noon)
+
+	store, err := NewFileStore(filepath.Join(dir, "admin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, now := newTestBlocklist(t, store, path)
+	if err := b.Check(Charge{Payer: "1011226111"}); err == nil {
+		t.Fatal("Expected the file entry to apply")
+	}
+	if _, err := b.Remove(KindAccount, "1011226111"); !errors.Is(err, ErrFileEntry) {
+		t.Errorf("Expected ErrFileEntry, got %v", err)
+	}
+
+	// An unchanged file isn't re-read
+	if reloaded, err := b.Reload(); reloaded || err != nil {
+		t.Errorf("Expected no reload, got %v (%v)", reloaded, err)
+	}
+
+	// An invalid file keeps the previous entries
+	writeFile(t, path, "entries:\n  - kind: account\n    value: acct\n", noon.Add(time.Minute))
+	if _, err := b.Reload(); err == nil {
+		t.Error("Expected an invalid file to be rejected")
+	}
+	if err := b.Check(Charge{Payer: "1011226111"}); err == nil {
+		t.Error("Expected the previous entries to stay in place")
+	}
+
+	writeFile(t, path, "entries:\n  - kind: bin\n    value: \"4111\"\n", noon.Add(2*time.Minute))
+	if reloaded, err := b.Reload(); !reloaded || err != nil {
+		t.Fatalf("Expected a reload, got %v (%v)", reloaded, err)
+	}
+	if b.Check(Charge{Payer: "1011226111"}) != nil || b.Check(Charge{BIN: "411111"}) == nil {
+		t.Error("Expected the reloaded entries to replace the previous ones")
+	}
+
+	// An expired admin entry for the same account doesn't hide the file entry
+	writeFile(t, path, "entries:\n  - kind: account\n    value: \"1022334455\"\n    reason: incident 43\n", noon.Add(3*time.Minute))
+	if _, err := b.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Add(Entry{Kind: KindAccount, Value: "1022334455", Reason: "watch", CreatedBy: "alice", ExpiresAt: noon.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	*now = noon.Add(2 * time.Hour)
+	var blocked *Blocked
+	if err := b.Check(Charge{Payer: "1022334455"}); !errors.As(err, &blocked) || blocked.Entry.Source != SourceFile {
+		t.Errorf("Expected the file entry to apply once the admin entry expired, got %v", err)
+	}
+	if _, err := b.Remove(KindAccount, "1022334455"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Admin entries persist across restarts
+	if _, err := b.Add(Entry{Kind: KindCard, Value: "fp-1", Reason: "chargeback", CreatedBy: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewFileStore(filepath.Join(dir, "admin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = newTestBlocklist(t, reopened, path)
+	if err := b.Check(Charge{Card: "fp-1"}); err == nil {
+		t.Error("Expected the admin entry to survive a restart")
+	}
+	if _, err := b.Remove(KindCard, "fp-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Check(Charge{Card: "fp-1"}); err != nil {
+		t.Errorf("Expected the removed entry to stop applying, got %v", err)
+	}
+	if _, err := b.Remove(KindCard, "fp-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
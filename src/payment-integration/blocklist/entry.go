@@ -0,0 +1,154 @@
+// Package blocklist freezes bank accounts, cards and BIN ranges so that charges
+// against them are refused before any money moves.
+package blocklist
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "payment-integration"
+
+// Kind is what an entry blocks
+type Kind string
+
+const (
+	// KindAccount blocks a bank account, as the payer or the payee
+	KindAccount Kind = "account"
+	// KindCard blocks a card by its fingerprint
+	KindCard Kind = "card"
+	// KindBIN blocks a BIN prefix such as "4111" or range such as "400000-400999"
+	KindBIN Kind = "bin"
+)
+
+// ParseKind parses "account", "card" or "bin"
+func ParseKind(value string) (Kind, error) {
+	switch kind := Kind(strings.ToLower(strings.TrimSpace(value))); kind {
+	case KindAccount, KindCard, KindBIN:
+		return kind, nil
+	}
+	return "", fmt.Errorf("blocklist kind %q must be account, card or bin", value)
+}
+
+// Sources of entries
+const (
+	SourceAdmin = "admin"
+	SourceFile  = "file"
+)
+
+// Entry is one blocked account, card or BIN range
+type Entry struct {
+	Kind  Kind   `json:"kind" yaml:"kind"`
+	Value string `json:"value" yaml:"value"`
+	// Reason is for operators; it is logged but never returned to callers
+	Reason string `json:"reason" yaml:"reason"`
+	// ExpiresAt is when the entry stops applying; zero never expires
+	ExpiresAt time.Time `json:"expires_at,omitempty" yaml:"expires_at"`
+	CreatedBy string    `json:"created_by,omitempty" yaml:"created_by"`
+	CreatedAt time.Time `json:"created_at,omitempty" yaml:"created_at"`
+	// Source is SourceAdmin or SourceFile
+	Source string `json:"source" yaml:"-"`
+}
+
+// binLength is the number of card digits charges carry as their BIN, so the
+// longest BIN entry that can match
+const binLength = 6
+
+// Key identifies an entry by kind and value
+func Key(kind Kind, value string) string {
+	return string(kind) + ":" + value
+}
+
+// Validate checks the entry's kind and value
+func (e *Entry) Validate() error {
+	if _, err := ParseKind(string(e.Kind)); err != nil {
+		return err
+	}
+	if e.Value == "" {
+		return fmt.Errorf("blocklist %s entry needs a value", e.Kind)
+	}
+
+	switch e.Kind {
+	case KindAccount:
+		if !isDigits(e.Value) {
+			return fmt.Errorf("blocklisted account %q must be digits", e.Value)
+		}
+	case KindBIN:
+		start, end, isRange := strings.Cut(e.Value, "-")
+		if !isDigits(start) || len(start) > binLength {
+			return fmt.Errorf("blocklisted BIN %q must be up to %d digits or a range of them", e.Value, binLength)
+		}
+		if isRange && (!isDigits(end) || len(end) != len(start) || end < start) {
+			return fmt.Errorf("blocklisted BIN range %q needs an end of the same length after its start", e.Value)
+		}
+	}
+	return nil
+}
+
+// Expired reports whether the entry no longer applies at now
+func (e *Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// matchesBIN reports whether a BIN entry covers bin
+func (e *Entry) matchesBIN(bin string) bool {
+	start, end, isRange := strings.Cut(e.Value, "-")
+	if !isRange {
+		return strings.HasPrefix(bin, start)
+	}
+	if len(bin) < len(start) {
+		return false
+	}
+	prefix := bin[:len(start)]
+	return prefix >= start && prefix <= end
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// Blocked reports the entry a charge matched
+type Blocked struct {
+	Entry Entry
+	// Party is "payer" or "payee" for account entries
+	Party string
+}
+
+// Error implements the error interface
+func (b *Blocked) Error() string {
+	return fmt.Sprintf("blocklisted %s %s: %s", b.Entry.Kind, b.Entry.Value, b.Entry.Reason)
+}
+
+// Reason returns the ErrorInfo reason for the entry's kind
+func (b *Blocked) Reason() string {
+	switch b.Entry.Kind {
+	case KindAccount:
+		return "ACCOUNT_FROZEN"
+	case KindCard:
+		return "CARD_FROZEN"
+	default:
+		return "BIN_FROZEN"
+	}
+}
+
+// ToGRPCError converts a Blocked to a PermissionDenied status. The entry's
+// value and reason are left out so they don't leak to the caller.
+func (b *Blocked) ToGRPCError() error {
+	st := status.New(codes.PermissionDenied, "the payment was declined")
+	info := &errdetails.ErrorInfo{
+		Reason: b.Reason(),
+		Domain: errorDomain,
+	}
+	if b.Party != "" {
+		info.Metadata = map[string]string{"party": b.Party}
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
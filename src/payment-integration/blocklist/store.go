@@ -0,0 +1,117 @@
+package blocklist
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+// ErrNotFound is returned when removing an entry that doesn't exist
+var ErrNotFound = errors.New("blocklist entry not found")
+
+// Store persists the entries added through the admin API
+type Store interface {
+	Put(entry Entry) error
+	Delete(kind Kind, value string) error
+	List() ([]Entry, error)
+}
+
+// MemoryStore keeps entries in memory
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry)}
+}
+
+// Put inserts or replaces an entry
+func (s *MemoryStore) Put(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[Key(entry.Kind, entry.Value)] = entry
+	return nil
+}
+
+// Delete removes an entry or returns ErrNotFound
+func (s *MemoryStore) Delete(kind Kind, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := Key(kind, value)
+	if _, ok := s.entries[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, key)
+	return nil
+}
+
+// List returns all entries ordered by kind and value
+func (s *MemoryStore) List() ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return Key(entries[i].Kind, entries[i].Value) < Key(entries[j].Kind, entries[j].Value)
+	})
+	return entries, nil
+}
+
+// FileStore is a MemoryStore that writes through to a JSON file
+type FileStore struct {
+	*MemoryStore
+	path    string
+	writeMu sync.Mutex
+}
+
+// NewFileStore loads entries from path, starting empty if the file does not exist
+func NewFileStore(path string) (*FileStore, error) {
+	var entries []Entry
+	if _, err := storage.ReadJSON(path, &entries); err != nil {
+		return nil, fmt.Errorf("failed to load blocklist: %w", err)
+	}
+
+	store := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	for _, entry := range entries {
+		store.MemoryStore.Put(entry)
+	}
+	return store, nil
+}
+
+// Put inserts or replaces an entry and persists the store
+func (s *FileStore) Put(entry Entry) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.MemoryStore.Put(entry)
+	return s.persist()
+}
+
+// Delete removes an entry and persists the store
+func (s *FileStore) Delete(kind Kind, value string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.MemoryStore.Delete(kind, value); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+func (s *FileStore) persist() error {
+	entries, _ := s.MemoryStore.List()
+	if err := storage.WriteJSON(s.path, entries); err != nil {
+		return fmt.Errorf("failed to persist blocklist: %w", err)
+	}
+	return nil
+}
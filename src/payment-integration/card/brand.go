@@ -0,0 +1,135 @@
+package card
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Brand is a card network
+type Brand string
+
+const (
+	BrandUnknown    Brand = ""
+	BrandVisa       Brand = "visa"
+	BrandMastercard Brand = "mastercard"
+	BrandAmex       Brand = "amex"
+	BrandDiscover   Brand = "discover"
+	BrandDiners     Brand = "diners"
+	BrandJCB        Brand = "jcb"
+	BrandUnionPay   Brand = "unionpay"
+)
+
+// binRange is an inclusive range of issuer identification number prefixes
+// that all have the same number of digits
+type binRange struct {
+	low, high int
+	digits    int
+}
+
+// brandRule describes how a brand's cards are numbered
+type brandRule struct {
+	brand     Brand
+	ranges    []binRange
+	lengths   []int
+	cvvLength int
+}
+
+// brandRules lists the BIN ranges of each supported network. When ranges
+// overlap, the rule with the longest matching prefix wins.
+var brandRules = []brandRule{
+	{
+		brand:     BrandVisa,
+		ranges:    []binRange{{4, 4, 1}},
+		lengths:   []int{13, 16, 19},
+		cvvLength: 3,
+	},
+	{
+		brand:     BrandMastercard,
+		ranges:    []binRange{{51, 55, 2}, {2221, 2720, 4}},
+		lengths:   []int{16},
+		cvvLength: 3,
+	},
+	{
+		brand:     BrandAmex,
+		ranges:    []binRange{{34, 34, 2}, {37, 37, 2}},
+		lengths:   []int{15},
+		cvvLength: 4,
+	},
+	{
+		brand:     BrandDiscover,
+		ranges:    []binRange{{6011, 6011, 4}, {644, 649, 3}, {65, 65, 2}, {622126, 622925, 6}},
+		lengths:   []int{16, 17, 18, 19},
+		cvvLength: 3,
+	},
+	{
+		brand:     BrandDiners,
+		ranges:    []binRange{{300, 305, 3}, {36, 36, 2}, {38, 39, 2}},
+		lengths:   []int{14, 15, 16, 17, 18, 19},
+		cvvLength: 3,
+	},
+	{
+		brand:     BrandJCB,
+		ranges:    []binRange{{3528, 3589, 4}},
+		lengths:   []int{16, 17, 18, 19},
+		cvvLength: 3,
+	},
+	{
+		brand:     BrandUnionPay,
+		ranges:    []binRange{{62, 62, 2}},
+		lengths:   []int{16, 17, 18, 19},
+		cvvLength: 3,
+	},
+}
+
+// DetectBrand returns the brand whose BIN ranges match the card number's prefix
+func DetectBrand(number string) Brand {
+	rule := ruleFor(number)
+	if rule == nil {
+		return BrandUnknown
+	}
+	return rule.brand
+}
+
+// ParseBrand parses a brand name as used in configuration
+func ParseBrand(name string) (Brand, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, rule := range brandRules {
+		if string(rule.brand) == name {
+			return rule.brand, nil
+		}
+	}
+	return BrandUnknown, fmt.Errorf("unknown card brand %q", name)
+}
+
+// ruleFor returns the most specific brand rule matching number, or nil
+func ruleFor(number string) *brandRule {
+	var best *brandRule
+	bestDigits := 0
+
+	for i := range brandRules {
+		for _, r := range brandRules[i].ranges {
+			if len(number) < r.digits || r.digits <= bestDigits {
+				continue
+			}
+			prefix, err := strconv.Atoi(number[:r.digits])
+			if err != nil {
+				continue
+			}
+			if prefix >= r.low && prefix <= r.high {
+				best = &brandRules[i]
+				bestDigits = r.digits
+			}
+		}
+	}
+	return best
+}
+
+func (r *brandRule) acceptsLength(n int) bool {
+	for _, length := range r.lengths {
+		if length == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package card
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func reasonOf(err error) string {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Reason
+	}
+	return ""
+}
+
+func TestValidateNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		brand  Brand
+		reason string
+	}{
+		{"Visa", "4532015112830366", BrandVisa, ""},
+		{"Visa with dashes", "4532-0151-1283-0366", BrandVisa, ""},
+		{"Mastercard", "5555555555554444", BrandMastercard, ""},
+		{"Mastercard 2-series", "2223003122003222", BrandMastercard, ""},
+		{"Amex", "378282246310005", BrandAmex, ""},
+		{"Discover", "6011111111111117", BrandDiscover, ""},
+		{"Diners", "30569309025904", BrandDiners, ""},
+		{"JCB", "3530111333300000", BrandJCB, ""},
+		{"UnionPay", "6200000000000005", BrandUnionPay, ""},
+		{"Empty", "", BrandUnknown, ReasonNumberFormat},
+		{"Letters", "4532ABC112830366", BrandUnknown, ReasonNumberFormat},
+		{"Too short", "123456789012", BrandUnknown, ReasonNumberLength},
+		{"Bad checksum", "4532015112830367", BrandUnknown, ReasonLuhn},
+		{"Unknown brand", "9999999999999995", BrandUnknown, ReasonUnknownBrand},
+		{"Wrong length for Amex", "3782822463100003", BrandAmex, ReasonNumberLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brand, err := ValidateNumber(tt.number)
+			if reasonOf(err) != tt.reason {
+				t.Errorf("Expected reason %q, got %q (%v)", tt.reason, reasonOf(err), err)
+			}
+			if brand != tt.brand {
+				t.Errorf("Expected brand %q, got %q", tt.brand, brand)
+			}
+		})
+	}
+}
+
+func TestValidatorExpiry(t *testing.T) {
+	v := NewValidator(nil)
+	v.SetClock(func() time.Time { return time.Date(2026, time.March, 31, 23, 0, 0, 0, time.UTC) })
+
+	tests := []struct {
+		name   string
+		year   int32
+		month  int32
+		reason string
+	}{
+		{"Expires this month", 2026, 3, ""},
+		{"Two-digit year", 27, 1, ""},
+		{"Expired last month", 2026, 2, ReasonExpired},
+		{"Expired last year", 2025, 12, ReasonExpired},
+		{"Month zero", 2027, 0, ReasonInvalidExpiry},
+		{"Month 13", 2027, 13, ReasonInvalidExpiry},
+		{"Missing year", 0, 5, ReasonExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reason := reasonOf(v.ValidateExpiry(tt.year, tt.month)); reason != tt.reason {
+				t.Errorf("Expected reason %q, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestValidateCVV(t *testing.T) {
+	tests := []struct {
+		name   string
+		brand  Brand
+		cvv    int32
+		reason string
+	}{
+		{"Visa 3 digits", BrandVisa, 123, ""},
+		{"Visa 4 digits", BrandVisa, 1234, ReasonInvalidCVV},
+		{"Amex 4 digits", BrandAmex, 1234, ""},
+		{"Amex 5 digits", BrandAmex, 12345, ReasonInvalidCVV},
+		{"Missing", BrandMastercard, 0, ReasonInvalidCVV},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reason := reasonOf(ValidateCVV(tt.brand, tt.cvv)); reason != tt.reason {
+				t.Errorf("Expected reason %q, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestValidatorBrandAllowlist(t *testing.T) {
+	v := NewValidator([]Brand{BrandVisa, BrandMastercard})
+	v.SetClock(func() time.Time { return time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC) })
+
+	if _, err := v.Validate(Card{Number: "4532015112830366", CVV: 123, ExpirationYear: 2030, ExpirationMonth: 1}); err != nil {
+		t.Errorf("Expected Visa to be accepted, got %v", err)
+	}
+
+	brand, err := v.Validate(Card{Number: "378282246310005", CVV: 1234, ExpirationYear: 2030, ExpirationMonth: 1})
+	if reasonOf(err) != ReasonBrandNotAccepted || brand != BrandAmex {
+		t.Errorf("Expected Amex to be rejected, got %s (%v)", brand, err)
+	}
+
+	if _, err := ParseBrand(" Visa "); err != nil {
+		t.Errorf("Expected ParseBrand to accept Visa, got %v", err)
+	}
+	if _, err := ParseBrand("maestro"); err == nil {
+		t.Error("Expected ParseBrand to reject an unknown brand")
+	}
+}
@@ -0,0 +1,213 @@
+// Package card validates payment card data: Luhn checksums, brand detection
+// from BIN ranges, per-brand number and CVV lengths, and expiry dates.
+package card
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the ErrorInfo domain attached to validation failures
+const errorDomain = "payment-integration"
+
+// Reasons reported in the ErrorInfo of a validation failure
+const (
+	ReasonNumberFormat     = "CARD_NUMBER_FORMAT"
+	ReasonNumberLength     = "CARD_NUMBER_LENGTH"
+	ReasonLuhn             = "CARD_NUMBER_CHECKSUM"
+	ReasonUnknownBrand     = "CARD_BRAND_UNKNOWN"
+	ReasonBrandNotAccepted = "CARD_BRAND_NOT_ACCEPTED"
+	ReasonInvalidExpiry    = "CARD_EXPIRY_INVALID"
+	ReasonExpired          = "CARD_EXPIRED"
+	ReasonInvalidCVV       = "CARD_CVV_INVALID"
+)
+
+// ValidationError describes why card data was rejected
+type ValidationError struct {
+	Reason  string
+	Message string
+	Brand   Brand
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ToGRPCError converts a ValidationError to an InvalidArgument status carrying its reason
+func (e *ValidationError) ToGRPCError() error {
+	st := status.New(codes.InvalidArgument, e.Message)
+	info := &errdetails.ErrorInfo{Reason: e.Reason, Domain: errorDomain}
+	if e.Brand != BrandUnknown {
+		info.Metadata = map[string]string{"brand": string(e.Brand)}
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func invalid(reason string, brand Brand, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Reason: reason, Message: fmt.Sprintf(format, args...), Brand: brand}
+}
+
+// Card is the card data checked by a Validator
+type Card struct {
+	Number          string
+	CVV             int32
+	ExpirationYear  int32
+	ExpirationMonth int32
+}
+
+// Luhn reports whether a string of digits passes the Luhn checksum
+func Luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return len(number) > 0 && sum%10 == 0
+}
+
+// ValidateNumber checks a card number's format, checksum, brand and brand-specific
+// length, ignoring spaces and dashes. It returns the detected brand.
+func ValidateNumber(number string) (Brand, error) {
+	cleaned := strings.NewReplacer(" ", "", "-", "").Replace(number)
+
+	if cleaned == "" {
+		return BrandUnknown, invalid(ReasonNumberFormat, BrandUnknown, "card number is required")
+	}
+	for _, ch := range cleaned {
+		if ch < '0' || ch > '9' {
+			return BrandUnknown, invalid(ReasonNumberFormat, BrandUnknown, "card number contains non-digit characters")
+		}
+	}
+	if len(cleaned) < 13 || len(cleaned) > 19 {
+		return BrandUnknown, invalid(ReasonNumberLength, BrandUnknown, "invalid card number length: %d", len(cleaned))
+	}
+	if !Luhn(cleaned) {
+		return BrandUnknown, invalid(ReasonLuhn, BrandUnknown, "card number fails the Luhn check")
+	}
+
+	rule := ruleFor(cleaned)
+	if rule == nil {
+		return BrandUnknown, invalid(ReasonUnknownBrand, BrandUnknown, "card brand not recognized")
+	}
+	if !rule.acceptsLength(len(cleaned)) {
+		return rule.brand, invalid(ReasonNumberLength, rule.brand, "invalid %s card number length: %d", rule.brand, len(cleaned))
+	}
+	return rule.brand, nil
+}
+
+// Validator checks cards against the accepted brands and the current date
+type Validator struct {
+	accepted map[Brand]bool
+	now      func() time.Time
+}
+
+// NewValidator creates a validator accepting the given brands. No brands accepts all.
+func NewValidator(accepted []Brand) *Validator {
+	v := &Validator{now: time.Now}
+	if len(accepted) > 0 {
+		v.accepted = make(map[Brand]bool, len(accepted))
+		for _, brand := range accepted {
+			v.accepted[brand] = true
+		}
+	}
+	return v
+}
+
+// SetClock replaces the time source used for expiry checks
+func (v *Validator) SetClock(now func() time.Time) {
+	v.now = now
+}
+
+// Validate checks a card's number, brand, expiry and CVV and returns its brand
+func (v *Validator) Validate(c Card) (Brand, error) {
+	brand, err := v.ValidateNumber(c.Number)
+	if err != nil {
+		return brand, err
+	}
+	if err := v.ValidateExpiry(c.ExpirationYear, c.ExpirationMonth); err != nil {
+		return brand, err
+	}
+	if err := ValidateCVV(brand, c.CVV); err != nil {
+		return brand, err
+	}
+	return brand, nil
+}
+
+// ValidateNumber checks a card number and that its brand is accepted
+func (v *Validator) ValidateNumber(number string) (Brand, error) {
+	brand, err := ValidateNumber(number)
+	if err != nil {
+		return brand, err
+	}
+	return brand, v.CheckBrand(brand)
+}
+
+// CheckBrand rejects brands outside the allowlist
+func (v *Validator) CheckBrand(brand Brand) error {
+	if v.accepted != nil && !v.accepted[brand] {
+		return invalid(ReasonBrandNotAccepted, brand, "%s cards are not accepted", brand)
+	}
+	return nil
+}
+
+// ValidateExpiry rejects malformed expiry dates and cards past the end of their expiry month.
+// Two-digit years are taken to be in the 2000s.
+func (v *Validator) ValidateExpiry(year, month int32) error {
+	if month < 1 || month > 12 {
+		return invalid(ReasonInvalidExpiry, BrandUnknown, "invalid expiration month: %d", month)
+	}
+	if year >= 0 && year < 100 {
+		year += 2000
+	}
+	if year < 2000 || year > 9999 {
+		return invalid(ReasonInvalidExpiry, BrandUnknown, "invalid expiration year: %d", year)
+	}
+
+	// A card is valid through the last day of its expiry month
+	now := v.now().UTC()
+	expiresAt := time.Date(int(year), time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC)
+	if !now.Before(expiresAt) {
+		return invalid(ReasonExpired, BrandUnknown, "card expired %02d/%d", month, year)
+	}
+	return nil
+}
+
+// ValidateCVV checks a CVV against the brand's code length. CVVs are carried as
+// integers, so leading zeros are lost and only the upper bound can be enforced.
+func ValidateCVV(brand Brand, cvv int32) error {
+	length := 3
+	for _, rule := range brandRules {
+		if rule.brand == brand {
+			length = rule.cvvLength
+		}
+	}
+
+	limit := int32(1)
+	for i := 0; i < length; i++ {
+		limit *= 10
+	}
+	if cvv <= 0 || cvv >= limit {
+		return invalid(ReasonInvalidCVV, brand, "CVV must be %d digits", length)
+	}
+	return nil
+}
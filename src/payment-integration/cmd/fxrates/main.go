@@ -0,0 +1,44 @@
+// Command fxrates is a local stand-in for an FX rate feed. It serves a rate
+// table file over HTTP in the format read by FX_RATES_URL, stamped with the
+// time of each request as a live feed would be.
+//
+//	fxrates [-addr :8090] [-rates rates.json]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/fx"
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	ratesPath := flag.String("rates", "rates.json", "rate table file")
+	flag.Parse()
+
+	// Validate the table up front so a bad file fails at startup
+	if _, err := fx.NewStaticProvider(*ratesPath); err != nil {
+		log.Fatalf("fxrates: %v", err)
+	}
+
+	http.HandleFunc("/rates", func(w http.ResponseWriter, r *http.Request) {
+		// Re-read the file on every request so rates can be edited while running
+		var table fx.RateTable
+		if _, err := storage.ReadJSON(*ratesPath, &table); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		table.Timestamp = time.Now().UTC()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(table)
+	})
+
+	log.Printf("fxrates: serving %s on %s/rates", *ratesPath, *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,132 @@
+// Command mapcard shows which mapping strategy would resolve each card, without
+// charging anything. It reads the same environment variables as the service;
+// flags override them.
+//
+//	mapcard [-strategies path] [-links path] CARD_NUMBER...
+//
+// Card numbers are read from stdin, one per line, when none are given.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gke-hackathon/payment-integration/bank"
+	"github.com/gke-hackathon/payment-integration/mapper"
+)
+
+func main() {
+	strategiesPath := flag.String("strategies", os.Getenv("MAPPER_STRATEGIES_PATH"), "mapping strategies file (YAML or JSON)")
+	linksPath := flag.String("links", os.Getenv("CARD_LINK_STORE_PATH"), "card link table file, used with CARD_HASH_KEY")
+	legacy := flag.Bool("legacy-last10", os.Getenv("MAPPER_LEGACY_LAST10") == "true", "append the legacy last-10 strategy to the default chain")
+	merchant := flag.String("merchant", envDefault("MERCHANT_ACCOUNT", "1111111111"), "default merchant account")
+	routing := flag.String("routing", envDefault("ROUTING_NUMBER", "123456789"), "default routing number")
+	flag.Parse()
+
+	accountMapper, err := buildMapper(*strategiesPath, *linksPath, *legacy, *merchant, *routing)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mapcard: %v\n", err)
+		os.Exit(2)
+	}
+
+	cards := flag.Args()
+	if len(cards) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				cards = append(cards, line)
+			}
+		}
+	}
+
+	routingValidator := bank.NewRoutingValidator(strings.Split(envDefault("ABA_CHECKSUM_EXEMPT", strings.Join(bank.DemoRoutingNumbers, ",")), ",")...)
+
+	fmt.Printf("Strategy chain: %s\n\n", strings.Join(accountMapper.StrategyNames(), " -> "))
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CARD\tSTRATEGY\tOUTCOME\tACCOUNT\tROUTING\tNOTE")
+
+	unresolved := 0
+	for _, cardNumber := range cards {
+		res, err := accountMapper.Resolve(cardNumber)
+		label := maskCard(cardNumber)
+
+		for _, result := range res.Trace {
+			account, routingNum, note := "", "", ""
+			switch {
+			case result.Outcome == mapper.OutcomeMatched:
+				account, routingNum = res.AccountNum, res.RoutingNum
+				if err := routingValidator.ValidateRoutingNumber(routingNum); err != nil {
+					note = err.Error()
+				}
+			case result.Err != nil:
+				note = result.Err.Error()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", label, result.Strategy, result.Outcome, account, routingNum, note)
+			label = ""
+		}
+
+		if err != nil {
+			unresolved++
+			if len(res.Trace) == 0 || res.Trace[len(res.Trace)-1].Outcome == mapper.OutcomeNoMatch {
+				fmt.Fprintf(w, "%s\t-\tunresolved\t\t\t%s\n", label, err)
+			}
+		}
+	}
+	w.Flush()
+
+	if unresolved > 0 {
+		os.Exit(1)
+	}
+}
+
+// buildMapper configures a mapper the way the service does, but never writes to the link table
+func buildMapper(strategiesPath, linksPath string, legacy bool, merchant, routing string) (*mapper.AccountMapper, error) {
+	accountMapper := mapper.NewAccountMapper(merchant, routing)
+
+	if hashKey := os.Getenv("CARD_HASH_KEY"); hashKey != "" && linksPath != "" {
+		hasher, err := mapper.NewCardHasher([]byte(hashKey))
+		if err != nil {
+			return nil, err
+		}
+		links, err := mapper.NewFileLinkStore(linksPath)
+		if err != nil {
+			return nil, err
+		}
+		accountMapper.UseLinkStore(links, hasher)
+	}
+
+	if legacy {
+		accountMapper.EnableLegacyLastTen()
+	}
+
+	if strategiesPath != "" {
+		cfg, err := mapper.LoadStrategyConfig(strategiesPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := accountMapper.UseStrategies(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return accountMapper, nil
+}
+
+// maskCard shows only the last four digits of a card number
+func maskCard(cardNumber string) string {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(cardNumber)
+	if len(digits) < 4 {
+		return "****"
+	}
+	return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+}
+
+func envDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -1,52 +1,397 @@
+// Package config holds the settings of every subsystem. Values start from the
+// defaults, then come from an optional YAML file, environment variables and
+// command line flags, each overriding the one before.
 package config
 
 import (
-	"log"
-	"os"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/gke-hackathon/payment-integration/bank"
+	"github.com/gke-hackathon/payment-integration/card"
+	"github.com/gke-hackathon/payment-integration/duplicate"
+	"github.com/gke-hackathon/payment-integration/logging"
+	"github.com/gke-hackathon/payment-integration/middleware"
+	"github.com/gke-hackathon/payment-integration/money"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/yaml.v3"
 )
 
-// Config holds the configuration for the payment integration service
+// redacted replaces secrets when a config is printed
+const redacted = "REDACTED"
+
+// Config is the configuration of the payment integration service. Each field
+// is tagged with its YAML key, its environment variable and, for secrets, how
+// it is redacted: "true" hides the whole value, "url" only the password.
+// Fields tagged reload:"true" can change while the service runs; the others
+// take effect on restart.
 type Config struct {
-	Port            string
-	MerchantAccount string
-	RoutingNumber   string
-	BankAPIURL      string
-	LogLevel        string
-	PrivateKeyPath  string
-	PublicKeyPath   string
-	TokenExpiry     string
-}
-
-// LoadConfig loads configuration from environment variables
-func LoadConfig() *Config {
-	cfg := &Config{
-		Port:            getEnv("PORT", "50051"),
-		MerchantAccount: getEnv("MERCHANT_ACCOUNT", "1111111111"),
-		RoutingNumber:   getEnv("ROUTING_NUMBER", "123456789"),
-		BankAPIURL:      getEnv("BANK_API_URL", "http://ledgerwriter.bank-of-anthos.svc.cluster.local:8080"),
-		LogLevel:        getEnv("LOG_LEVEL", "INFO"),
-		PrivateKeyPath:  getEnv("PRIV_KEY_PATH", "/tmp/.ssh/privatekey"),
-		PublicKeyPath:   getEnv("PUB_KEY_PATH", "/tmp/.ssh/publickey"),
-		TokenExpiry:     getEnv("TOKEN_EXPIRY_SECONDS", "3600"),
-	}
-
-	log.Printf("Configuration loaded:")
-	log.Printf("  Port: %s", cfg.Port)
-	log.Printf("  Merchant Account: %s", cfg.MerchantAccount)
-	log.Printf("  Routing Number: %s", cfg.RoutingNumber)
-	log.Printf("  Bank API URL: %s", cfg.BankAPIURL)
-	log.Printf("  Log Level: %s", cfg.LogLevel)
-	log.Printf("  JWT Private Key Path: %s", cfg.PrivateKeyPath)
-	log.Printf("  JWT Public Key Path: %s", cfg.PublicKeyPath)
-	log.Printf("  Token Expiry Seconds: %s", cfg.TokenExpiry)
-
-	return cfg
-}
-
-// getEnv gets an environment variable with a fallback default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
+	Server       ServerConfig       `yaml:"server"`
+	Merchant     MerchantConfig     `yaml:"merchant"`
+	Bank         BankConfig         `yaml:"bank"`
+	Auth         AuthConfig         `yaml:"auth"`
+	Mapper       MapperConfig       `yaml:"mapper"`
+	Card         CardConfig         `yaml:"card"`
+	Vault        VaultConfig        `yaml:"vault"`
+	Money        MoneyConfig        `yaml:"money"`
+	FX           FXConfig           `yaml:"fx"`
+	Transactions TransactionsConfig `yaml:"transactions"`
+	RateLimit    RateLimitConfig    `yaml:"rate_limit"`
+	Velocity     VelocityConfig     `yaml:"velocity"`
+	Risk         RiskConfig         `yaml:"risk"`
+	Review       ReviewConfig       `yaml:"review"`
+	Blocklist    BlocklistConfig    `yaml:"blocklist"`
+	Duplicates   DuplicatesConfig   `yaml:"duplicates"`
+	Profiles     ProfilesConfig     `yaml:"profiles"`
+	SpendCaps    SpendCapsConfig    `yaml:"spend_caps"`
+	Tenants      TenantsConfig      `yaml:"tenants"`
+}
+
+// ServerConfig holds the listeners, logging and admin access
+type ServerConfig struct {
+	Port     int    `yaml:"port" env:"PORT"`
+	HTTPPort int    `yaml:"http_port" env:"HTTP_PORT"`
+	LogLevel string `yaml:"log_level" env:"LOG_LEVEL" reload:"true"`
+	// AdminToken enables the admin APIs when set
+	AdminToken string `yaml:"admin_token" env:"ADMIN_TOKEN" secret:"true"`
+}
+
+// MerchantConfig is the default merchant account charges are paid into
+type MerchantConfig struct {
+	Account       string `yaml:"account" env:"MERCHANT_ACCOUNT" reload:"true"`
+	RoutingNumber string `yaml:"routing_number" env:"ROUTING_NUMBER" reload:"true"`
+}
+
+// BankConfig locates the bank backends
+type BankConfig struct {
+	APIURL         string   `yaml:"api_url" env:"BANK_API_URL" reload:"true"`
+	RoutesPath     string   `yaml:"routes_path" env:"BANK_ROUTES_PATH" reload:"true"`
+	ChecksumExempt []string `yaml:"checksum_exempt" env:"ABA_CHECKSUM_EXEMPT" reload:"true"`
+}
+
+// AuthConfig holds the bank authentication and service token settings
+type AuthConfig struct {
+	// Mode is "forge" or "login"
+	Mode                  string   `yaml:"mode" env:"AUTH_MODE"`
+	PrivateKeyPath        string   `yaml:"private_key_path" env:"PRIV_KEY_PATH"`
+	PublicKeyPath         string   `yaml:"public_key_path" env:"PUB_KEY_PATH"`
+	PreviousPublicKeyPath string   `yaml:"previous_public_key_path" env:"PREV_PUB_KEY_PATH"`
+	UserserviceURL        string   `yaml:"userservice_url" env:"USERSERVICE_URL"`
+	CredentialsPath       string   `yaml:"credentials_path" env:"BANK_CREDENTIALS_PATH"`
+	TokenExpirySeconds    int      `yaml:"token_expiry_seconds" env:"TOKEN_EXPIRY_SECONDS"`
+	Issuer                string   `yaml:"issuer" env:"JWT_ISSUER"`
+	Audience              []string `yaml:"audience" env:"JWT_AUDIENCE"`
+	ClockSkewSeconds      int      `yaml:"clock_skew_seconds" env:"JWT_CLOCK_SKEW_SECONDS"`
+	ReplayCacheSize       int      `yaml:"replay_cache_size" env:"JWT_REPLAY_CACHE_SIZE"`
+	ClaimTemplatesPath    string   `yaml:"claim_templates_path" env:"JWT_CLAIM_TEMPLATES_PATH"`
+}
+
+// MapperConfig holds the card-to-account mapping settings
+type MapperConfig struct {
+	// CardHashKey enables the card link table when set
+	CardHashKey    string `yaml:"card_hash_key" env:"CARD_HASH_KEY" secret:"true"`
+	LinkStorePath  string `yaml:"link_store_path" env:"CARD_LINK_STORE_PATH"`
+	LegacyLastTen  bool   `yaml:"legacy_last10" env:"MAPPER_LEGACY_LAST10" reload:"true"`
+	StrategiesPath string `yaml:"strategies_path" env:"MAPPER_STRATEGIES_PATH" reload:"true"`
+}
+
+// CardConfig holds card validation settings
+type CardConfig struct {
+	// AcceptedBrands is the brand allowlist; empty accepts every brand
+	AcceptedBrands []string `yaml:"accepted_brands" env:"CARD_ACCEPTED_BRANDS"`
+}
+
+// VaultConfig holds the card tokenization vault settings
+type VaultConfig struct {
+	// MasterKey is base64; tokenization is disabled when it is empty
+	MasterKey        string `yaml:"master_key" env:"VAULT_MASTER_KEY" secret:"true"`
+	StorePath        string `yaml:"store_path" env:"VAULT_STORE_PATH"`
+	KeyRotationHours int    `yaml:"key_rotation_hours" env:"VAULT_KEY_ROTATION_HOURS"`
+}
+
+// MoneyConfig holds amount handling settings
+type MoneyConfig struct {
+	RoundingPolicy string `yaml:"rounding_policy" env:"MONEY_ROUNDING_POLICY"`
+}
+
+// FXConfig holds currency conversion settings
+type FXConfig struct {
+	LedgerCurrency    string `yaml:"ledger_currency" env:"LEDGER_CURRENCY"`
+	RatesPath         string `yaml:"rates_path" env:"FX_RATES_PATH"`
+	RatesURL          string `yaml:"rates_url" env:"FX_RATES_URL"`
+	RefreshSeconds    int    `yaml:"refresh_seconds" env:"FX_REFRESH_SECONDS"`
+	MaxRateAgeSeconds int    `yaml:"max_rate_age_seconds" env:"FX_MAX_RATE_AGE_SECONDS"`
+	SpreadBPS         int    `yaml:"spread_bps" env:"FX_SPREAD_BPS"`
+}
+
+// TransactionsConfig holds the transaction record store settings
+type TransactionsConfig struct {
+	StorePath string `yaml:"store_path" env:"TRANSACTION_STORE_PATH"`
+}
+
+// RateLimitConfig holds the per-account rate limiter settings
+type RateLimitConfig struct {
+	PerMinute int    `yaml:"per_minute" env:"RATE_LIMIT_PER_MINUTE" reload:"true"`
+	Algorithm string `yaml:"algorithm" env:"RATE_LIMIT_ALGORITHM"`
+	// Burst is the token bucket capacity; 0 uses PerMinute
+	Burst     int    `yaml:"burst" env:"RATE_LIMIT_BURST" reload:"true"`
+	Overrides string `yaml:"overrides" env:"RATE_LIMIT_OVERRIDES" reload:"true"`
+	// Key is what charges are limited by: account, card or caller
+	Key              string `yaml:"key" env:"RATE_LIMIT_KEY"`
+	RedisURL         string `yaml:"redis_url" env:"RATE_LIMIT_REDIS_URL" secret:"url"`
+	RedisFailureMode string `yaml:"redis_failure_mode" env:"RATE_LIMIT_REDIS_FAILURE_MODE"`
+	RedisTimeoutMS   int    `yaml:"redis_timeout_ms" env:"RATE_LIMIT_REDIS_TIMEOUT_MS"`
+}
+
+// VelocityConfig locates the velocity policy
+type VelocityConfig struct {
+	PolicyPath string `yaml:"policy_path" env:"VELOCITY_POLICY_PATH"`
+}
+
+// RiskConfig locates the risk policy
+type RiskConfig struct {
+	PolicyPath string `yaml:"policy_path" env:"RISK_POLICY_PATH"`
+}
+
+// ReviewConfig holds the manual review queue settings
+type ReviewConfig struct {
+	SLASeconds int `yaml:"sla_seconds" env:"REVIEW_SLA_SECONDS"`
+}
+
+// BlocklistConfig holds the blocklist settings
+type BlocklistConfig struct {
+	Path          string `yaml:"path" env:"BLOCKLIST_PATH"`
+	StorePath     string `yaml:"store_path" env:"BLOCKLIST_STORE_PATH"`
+	ReloadSeconds int    `yaml:"reload_seconds" env:"BLOCKLIST_RELOAD_SECONDS"`
+}
+
+// DuplicatesConfig holds the duplicate charge guard settings
+type DuplicatesConfig struct {
+	// WindowSeconds of 0 disables the guard
+	WindowSeconds int    `yaml:"window_seconds" env:"DUPLICATE_WINDOW_SECONDS"`
+	Action        string `yaml:"action" env:"DUPLICATE_ACTION"`
+}
+
+// ProfilesConfig holds the behavioural profile settings
+type ProfilesConfig struct {
+	StorePath  string `yaml:"store_path" env:"PROFILE_STORE_PATH"`
+	MinHistory int    `yaml:"min_history" env:"PROFILE_MIN_HISTORY"`
+}
+
+// SpendCapsConfig locates the spend cap policy and its counters
+type SpendCapsConfig struct {
+	PolicyPath string `yaml:"policy_path" env:"SPEND_CAP_POLICY_PATH"`
+	StorePath  string `yaml:"store_path" env:"SPEND_CAP_STORE_PATH"`
+}
+
+// TenantsConfig locates the storefronts sharing the service. Without it the
+// service serves a single storefront.
+type TenantsConfig struct {
+	Path string `yaml:"path" env:"TENANTS_PATH"`
+}
+
+// Default returns the configuration used when nothing is set
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{Port: 50051, HTTPPort: 8080, LogLevel: string(logging.INFO)},
+		Merchant: MerchantConfig{
+			Account:       "1111111111",
+			RoutingNumber: "123456789",
+		},
+		Bank: BankConfig{
+			APIURL:         "http://ledgerwriter.bank-of-anthos.svc.cluster.local:8080",
+			ChecksumExempt: append([]string(nil), bank.DemoRoutingNumbers...),
+		},
+		Auth: AuthConfig{
+			Mode:               "forge",
+			PrivateKeyPath:     "/tmp/.ssh/privatekey",
+			PublicKeyPath:      "/tmp/.ssh/publickey",
+			UserserviceURL:     "http://userservice.bank-of-anthos.svc.cluster.local:8080",
+			CredentialsPath:    "/var/secrets/bank-credentials/credentials.json",
+			TokenExpirySeconds: 3600,
+			ClockSkewSeconds:   30,
+			ReplayCacheSize:    10000,
+		},
+		Vault: VaultConfig{KeyRotationHours: 720},
+		Money: MoneyConfig{RoundingPolicy: "half_even"},
+		FX:    FXConfig{LedgerCurrency: "USD", RefreshSeconds: 60, MaxRateAgeSeconds: 86400},
+		RateLimit: RateLimitConfig{
+			PerMinute:        10,
+			Algorithm:        middleware.AlgorithmTokenBucket,
+			Key:              "account",
+			RedisFailureMode: string(middleware.FailLocal),
+			RedisTimeoutMS:   50,
+		},
+		Review:     ReviewConfig{SLASeconds: 3600},
+		Blocklist:  BlocklistConfig{ReloadSeconds: 10},
+		Duplicates: DuplicatesConfig{Action: string(duplicate.ActionReturnFirst)},
+		Profiles:   ProfilesConfig{MinHistory: 5},
+	}
+}
+
+// Errors lists every problem found while loading a configuration
+type Errors []string
+
+// Error implements the error interface, one problem per line
+func (e Errors) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e, "\n  ")
+}
+
+// validator collects problems, naming each setting by its YAML key and
+// environment variable
+type validator struct {
+	envs map[string]string
+	errs Errors
+}
+
+func (v *validator) check(ok bool, path, format string, args ...interface{}) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Sprintf("%s: %s", label(v.envs, path), fmt.Sprintf(format, args...)))
+	}
+}
+
+func (v *validator) checkErr(err error, path string) {
+	if err != nil {
+		v.errs = append(v.errs, fmt.Sprintf("%s: %v", label(v.envs, path), err))
+	}
+}
+
+// label names a setting for error messages, such as
+// "auth.token_expiry_seconds (TOKEN_EXPIRY_SECONDS)"
+func label(envs map[string]string, path string) string {
+	if env := envs[path]; env != "" {
+		return fmt.Sprintf("%s (%s)", path, env)
+	}
+	return path
+}
+
+// Validate checks every setting and returns an Errors listing all problems
+func (c *Config) Validate() error {
+	v := &validator{envs: envNames()}
+
+	v.check(c.Server.Port > 0 && c.Server.Port < 65536, "server.port", "must be a port number, got %d", c.Server.Port)
+	v.check(c.Server.HTTPPort > 0 && c.Server.HTTPPort < 65536, "server.http_port", "must be a port number, got %d", c.Server.HTTPPort)
+	v.check(c.Server.Port != c.Server.HTTPPort, "server.http_port", "must differ from server.port")
+	_, err := logging.ParseLevel(c.Server.LogLevel)
+	v.checkErr(err, "server.log_level")
+
+	v.check(isDigits(c.Merchant.Account, 10), "merchant.account", "must be 10 digits, got %q", c.Merchant.Account)
+	v.check(isDigits(c.Merchant.RoutingNumber, 9), "merchant.routing_number", "must be 9 digits, got %q", c.Merchant.RoutingNumber)
+
+	v.checkErr(checkURL(c.Bank.APIURL), "bank.api_url")
+	for _, routingNumber := range c.Bank.ChecksumExempt {
+		v.check(isDigits(routingNumber, 9), "bank.checksum_exempt", "must be 9-digit routing numbers, got %q", routingNumber)
+	}
+
+	v.check(c.Auth.Mode == "forge" || c.Auth.Mode == "login", "auth.mode", "must be forge or login, got %q", c.Auth.Mode)
+	if c.Auth.Mode == "login" {
+		v.checkErr(checkURL(c.Auth.UserserviceURL), "auth.userservice_url")
+	}
+	v.check(c.Auth.TokenExpirySeconds > 0, "auth.token_expiry_seconds", "must be positive, got %d", c.Auth.TokenExpirySeconds)
+	v.check(c.Auth.ClockSkewSeconds >= 0, "auth.clock_skew_seconds", "must not be negative, got %d", c.Auth.ClockSkewSeconds)
+	v.check(c.Auth.ReplayCacheSize > 0, "auth.replay_cache_size", "must be positive, got %d", c.Auth.ReplayCacheSize)
+
+	if c.Mapper.CardHashKey != "" {
+		v.check(len(c.Mapper.CardHashKey) >= 16, "mapper.card_hash_key", "must be at least 16 bytes, got %d", len(c.Mapper.CardHashKey))
+	}
+	for _, name := range c.Card.AcceptedBrands {
+		_, err := card.ParseBrand(name)
+		v.checkErr(err, "card.accepted_brands")
+	}
+
+	if c.Vault.MasterKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.Vault.MasterKey)
+		v.check(err == nil && len(key) == 32, "vault.master_key", "must be 32 bytes of base64")
+	}
+	v.check(c.Vault.KeyRotationHours >= 0, "vault.key_rotation_hours", "must not be negative, got %d", c.Vault.KeyRotationHours)
+
+	_, err = money.ParseRoundingPolicy(c.Money.RoundingPolicy)
+	v.checkErr(err, "money.rounding_policy")
+
+	_, err = money.LookupCurrency(c.FX.LedgerCurrency)
+	v.checkErr(err, "fx.ledger_currency")
+	if c.FX.RatesURL != "" {
+		v.checkErr(checkURL(c.FX.RatesURL), "fx.rates_url")
+	}
+	v.check(c.FX.RefreshSeconds > 0, "fx.refresh_seconds", "must be positive, got %d", c.FX.RefreshSeconds)
+	v.check(c.FX.MaxRateAgeSeconds >= 0, "fx.max_rate_age_seconds", "must not be negative, got %d", c.FX.MaxRateAgeSeconds)
+	v.check(c.FX.SpreadBPS >= 0 && c.FX.SpreadBPS < 10000, "fx.spread_bps", "must be between 0 and 9999, got %d", c.FX.SpreadBPS)
+
+	v.check(c.RateLimit.PerMinute > 0, "rate_limit.per_minute", "must be positive, got %d", c.RateLimit.PerMinute)
+	v.check(c.RateLimit.Algorithm == middleware.AlgorithmTokenBucket || c.RateLimit.Algorithm == middleware.AlgorithmFixedWindow,
+		"rate_limit.algorithm", "must be %s or %s, got %q", middleware.AlgorithmTokenBucket, middleware.AlgorithmFixedWindow, c.RateLimit.Algorithm)
+	v.check(c.RateLimit.Burst >= 0, "rate_limit.burst", "must not be negative, got %d", c.RateLimit.Burst)
+	_, err = middleware.ParseBucketOverrides(c.RateLimit.Overrides)
+	v.checkErr(err, "rate_limit.overrides")
+	v.check(c.RateLimit.Key == "account" || c.RateLimit.Key == "card" || c.RateLimit.Key == "caller",
+		"rate_limit.key", "must be account, card or caller, got %q", c.RateLimit.Key)
+	if c.RateLimit.RedisURL != "" {
+		_, err = redis.ParseURL(c.RateLimit.RedisURL)
+		v.checkErr(err, "rate_limit.redis_url")
+		v.check(c.RateLimit.Algorithm == middleware.AlgorithmTokenBucket, "rate_limit.redis_url", "needs the %s algorithm", middleware.AlgorithmTokenBucket)
+	}
+	_, err = middleware.ParseFailureMode(c.RateLimit.RedisFailureMode)
+	v.checkErr(err, "rate_limit.redis_failure_mode")
+	v.check(c.RateLimit.RedisTimeoutMS > 0, "rate_limit.redis_timeout_ms", "must be positive, got %d", c.RateLimit.RedisTimeoutMS)
+
+	v.check(c.Review.SLASeconds > 0, "review.sla_seconds", "must be positive, got %d", c.Review.SLASeconds)
+	v.check(c.Blocklist.ReloadSeconds > 0, "blocklist.reload_seconds", "must be positive, got %d", c.Blocklist.ReloadSeconds)
+	v.check(c.Duplicates.WindowSeconds >= 0, "duplicates.window_seconds", "must not be negative, got %d", c.Duplicates.WindowSeconds)
+	_, err = duplicate.ParseAction(c.Duplicates.Action)
+	v.checkErr(err, "duplicates.action")
+	v.check(c.Profiles.MinHistory > 0, "profiles.min_history", "must be positive, got %d", c.Profiles.MinHistory)
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// isDigits reports whether s is exactly n digits
+func isDigits(s string, n int) bool {
+	return len(s) == n && strings.Trim(s, "0123456789") == ""
+}
+
+// checkURL requires an absolute http or https URL
+func checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL, got %q", raw)
+	}
+	return nil
+}
+
+// Redacted returns a copy of the config with secrets hidden, safe to print or log
+func (c *Config) Redacted() *Config {
+	copied := *c
+	for _, f := range fields(&copied) {
+		if f.value.Kind() != reflect.String || f.value.String() == "" {
+			continue
+		}
+		switch f.secret {
+		case "true":
+			f.value.SetString(redacted)
+		case "url":
+			if u, err := url.Parse(f.value.String()); err == nil {
+				f.value.SetString(u.Redacted())
+			} else {
+				f.value.SetString(redacted)
+			}
+		}
+	}
+	return &copied
+}
+
+// Print writes the config as YAML with secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
 }
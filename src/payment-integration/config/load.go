@@ -0,0 +1,199 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// field is one setting, found by walking the Config struct
+type field struct {
+	// path is the dotted YAML key, such as "auth.token_expiry_seconds"
+	path   string
+	env    string
+	secret string
+	reload bool
+	value  reflect.Value
+}
+
+// fields returns every setting of cfg, in declaration order
+func fields(cfg *Config) []field {
+	var out []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			path := prefix + strings.Split(sf.Tag.Get("yaml"), ",")[0]
+			if sf.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), path+".")
+				continue
+			}
+			out = append(out, field{
+				path:   path,
+				env:    sf.Tag.Get("env"),
+				secret: sf.Tag.Get("secret"),
+				reload: sf.Tag.Get("reload") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return out
+}
+
+// envNames maps each setting's path to its environment variable
+func envNames() map[string]string {
+	names := make(map[string]string)
+	for _, f := range fields(&Config{}) {
+		names[f.path] = f.env
+	}
+	return names
+}
+
+// set parses raw into a setting. Lists are comma-separated.
+func (f field) set(raw string) error {
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", raw)
+		}
+		f.value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", raw)
+		}
+		f.value.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", f.value.Type())
+	}
+	return nil
+}
+
+// format renders a setting the way set parses it
+func (f field) format() string {
+	if f.value.Kind() == reflect.Slice {
+		return strings.Join(f.value.Interface().([]string), ",")
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+// flagValue records a flag for Loader to apply after the environment
+type flagValue struct {
+	name    string
+	isBool  bool
+	flags   map[string]string
+	current string
+}
+
+func (v *flagValue) String() string { return v.current }
+
+func (v *flagValue) Set(raw string) error {
+	v.current = raw
+	v.flags[v.name] = raw
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool { return v.isBool }
+
+// Loader builds a Config from its sources. Flags are parsed once; the file and
+// the environment are read again on every Load.
+type Loader struct {
+	path      string
+	lookupEnv func(string) (string, bool)
+	flags     map[string]string
+}
+
+// NewLoader registers -config and one flag per setting on fs, named by its YAML
+// path such as -rate_limit.per_minute, then parses args. The config file is
+// the -config flag, or CONFIG_PATH when the flag is not given; without either
+// only the environment and flags apply.
+func NewLoader(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*Loader, error) {
+	l := &Loader{lookupEnv: lookupEnv, flags: make(map[string]string)}
+
+	path, _ := lookupEnv("CONFIG_PATH")
+	fs.StringVar(&l.path, "config", path, "YAML config file (CONFIG_PATH)")
+	for _, f := range fields(Default()) {
+		fs.Var(&flagValue{name: f.path, isBool: f.value.Kind() == reflect.Bool, flags: l.flags, current: f.format()},
+			f.path, fmt.Sprintf("overrides %s", f.env))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path returns the config file, or "" if there is none
+func (l *Loader) Path() string {
+	return l.path
+}
+
+// Load reads the defaults, the config file, the environment and the flags, in
+// that order, and validates the result. Problems are returned together as Errors.
+func (l *Loader) Load() (*Config, error) {
+	cfg := Default()
+	if l.path != "" {
+		if err := readFile(l.path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs Errors
+	for _, f := range fields(cfg) {
+		if raw, ok := l.lookupEnv(f.env); ok && raw != "" {
+			if err := f.set(raw); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", f.env, err))
+			}
+		}
+		if raw, ok := l.flags[f.path]; ok {
+			if err := f.set(raw); err != nil {
+				errs = append(errs, fmt.Sprintf("-%s: %v", f.path, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readFile decodes the YAML file at path over cfg. Unknown keys are errors so
+// that typos don't go unnoticed.
+func readFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	dec := yaml.NewDecoder(file)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Results of a reload
+const (
+	ReloadApplied   = "applied"
+	ReloadUnchanged = "unchanged"
+	ReloadFailed    = "failed"
+)
+
+// Status describes the active configuration and the last reload
+type Status struct {
+	// Version counts the configurations applied, starting at 1 for the one
+	// loaded at startup
+	Version  int
+	Checksum string
+	Path     string
+	// AppliedAt is when the active configuration took effect
+	AppliedAt time.Time
+
+	// LastReloadAt is zero until the first reload
+	LastReloadAt    time.Time
+	LastReloadError string
+	// LastResult is ReloadApplied, ReloadUnchanged or ReloadFailed
+	LastResult string
+	// RestartRequired lists settings that changed in the last loaded
+	// configuration but only take effect on restart
+	RestartRequired []string
+}
+
+// ApplyFunc puts a new configuration into effect. Only settings tagged
+// reload:"true" differ from the active configuration. If it returns an error
+// the active configuration must be left as it was.
+type ApplyFunc func(cfg *Config) error
+
+// Watcher reloads the configuration when its file changes or Reload is called,
+// and applies the settings that can change while the service runs. A
+// configuration that fails to load, validate or apply is rejected and the
+// active one stays in place.
+type Watcher struct {
+	loader   *Loader
+	apply    ApplyFunc
+	logger   *logging.Logger
+	now      func() time.Time
+	onReload func(Status)
+
+	// mu serializes reloads
+	mu      sync.Mutex
+	active  *Config
+	status  Status
+	modTime time.Time
+
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+// NewWatcher starts from active, the configuration the service was built with
+func NewWatcher(loader *Loader, active *Config, apply ApplyFunc, logger *logging.Logger) *Watcher {
+	w := &Watcher{loader: loader, apply: apply, logger: logger, now: time.Now, active: active}
+	w.status = Status{Version: 1, Checksum: checksum(active), Path: loader.Path(), AppliedAt: w.now().UTC()}
+	if info, err := os.Stat(loader.Path()); err == nil {
+		w.modTime = info.ModTime()
+	}
+	return w
+}
+
+// SetClock overrides the time source, for tests
+func (w *Watcher) SetClock(now func() time.Time) {
+	w.now = now
+}
+
+// OnReload registers fn to be called with the status after every reload
+func (w *Watcher) OnReload(fn func(Status)) {
+	w.onReload = fn
+}
+
+// Active returns the active configuration. It must not be modified.
+func (w *Watcher) Active() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.active
+}
+
+// Status returns the active configuration's version and the last reload result
+func (w *Watcher) Status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	st := w.status
+	st.RestartRequired = append([]string(nil), w.status.RestartRequired...)
+	return st
+}
+
+// Reload loads the configuration and applies what changed. The error is the
+// reason a configuration was rejected.
+func (w *Watcher) Reload() (Status, error) {
+	w.mu.Lock()
+	st, err := w.reload()
+	w.mu.Unlock()
+
+	if w.onReload != nil {
+		w.onReload(st)
+	}
+	return st, err
+}
+
+// reload is called with mu held
+func (w *Watcher) reload() (Status, error) {
+	now := w.now().UTC()
+	w.status.LastReloadAt = now
+	if info, err := os.Stat(w.loader.Path()); err == nil {
+		w.modTime = info.ModTime()
+	}
+
+	next, err := w.loader.Load()
+	if err == nil {
+		merged := w.active.withReloadable(next)
+		w.status.RestartRequired = changed(w.active, next, false)
+
+		sum := checksum(merged)
+		if sum == w.status.Checksum {
+			w.status.LastResult = ReloadUnchanged
+			w.status.LastReloadError = ""
+			w.logReload(nil)
+			return w.status, nil
+		}
+
+		if err = w.apply(merged); err == nil {
+			applied := changed(w.active, next, true)
+			w.active = merged
+			w.status.Version++
+			w.status.Checksum = sum
+			w.status.AppliedAt = now
+			w.status.LastResult = ReloadApplied
+			w.status.LastReloadError = ""
+			w.logReload(applied)
+			return w.status, nil
+		}
+	}
+
+	w.status.LastResult = ReloadFailed
+	w.status.LastReloadError = err.Error()
+	w.logger.Error("Configuration rejected, keeping the active configuration", err, map[string]interface{}{
+		"path":    w.status.Path,
+		"version": w.status.Version,
+	})
+	return w.status, err
+}
+
+// logReload logs a successful reload and any settings waiting for a restart
+func (w *Watcher) logReload(applied []string) {
+	fields := map[string]interface{}{"path": w.status.Path, "version": w.status.Version, "checksum": w.status.Checksum}
+	if w.status.LastResult == ReloadApplied {
+		fields["changed"] = applied
+		w.logger.Info("Configuration reloaded", fields)
+	} else {
+		w.logger.Info("Configuration unchanged", fields)
+	}
+	if len(w.status.RestartRequired) > 0 {
+		w.logger.Warn("Configuration changes need a restart to take effect", map[string]interface{}{"settings": w.status.RestartRequired})
+	}
+}
+
+// Start reloads the configuration every interval if its file changed, until
+// Stop is called. It does nothing without a config file.
+func (w *Watcher) Start(interval time.Duration) {
+	path := w.loader.Path()
+	if path == "" {
+		return
+	}
+	w.ticker = time.NewTicker(interval)
+	w.done = make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-w.ticker.C:
+				info, err := os.Stat(path)
+				if err != nil {
+					w.logger.Error("Failed to stat config file", err, map[string]interface{}{"path": path})
+					continue
+				}
+				w.mu.Lock()
+				modified := !info.ModTime().Equal(w.modTime)
+				w.mu.Unlock()
+				if modified {
+					w.Reload()
+				}
+			case <-w.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the polling loop
+func (w *Watcher) Stop() {
+	if w.ticker != nil {
+		w.ticker.Stop()
+		close(w.done)
+		w.ticker = nil
+	}
+}
+
+// withReloadable returns a copy of c with the reloadable settings taken from next
+func (c *Config) withReloadable(next *Config) *Config {
+	merged := *c
+	from := fields(next)
+	for i, f := range fields(&merged) {
+		if f.reload {
+			f.value.Set(from[i].value)
+		}
+	}
+	return &merged
+}
+
+// changed returns the paths of settings that differ between a and b, among
+// those that can be reloaded or those that can't
+func changed(a, b *Config, reloadable bool) []string {
+	var paths []string
+	bFields := fields(b)
+	for i, f := range fields(a) {
+		if f.reload == reloadable && f.format() != bFields[i].format() {
+			paths = append(paths, f.path)
+		}
+	}
+	return paths
+}
+
+// checksum identifies a configuration by a short hash of its settings
+func checksum(cfg *Config) string {
+	raw, _ := yaml.Marshal(cfg)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:6])
+}
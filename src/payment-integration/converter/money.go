@@ -2,70 +2,63 @@ package converter
 
 import (
 	"fmt"
+
+	"github.com/gke-hackathon/payment-integration/money"
 	pb "github.com/gke-hackathon/payment-integration/proto"
 )
 
-// BoutiqueMoneyToCents converts Online Boutique Money format to cents (used by Bank of Anthos)
-// Boutique Money format: units (dollars) + nanos (billionths of a dollar)
-// Bank format: cents (hundredths of a dollar)
-func BoutiqueMoneyToCents(money *pb.Money) (int64, error) {
-	if money == nil {
-		return 0, fmt.Errorf("money cannot be nil")
-	}
-
-	// Convert units to cents (1 unit = 100 cents)
-	cents := money.Units * 100
-
-	// Convert nanos to cents (1 nano = 10^-9 units, 1 cent = 10^-2 units)
-	// So 1 nano = 10^-7 cents, or 10^7 nanos = 1 cent
-	nanosInCents := money.Nanos / 10_000_000
-
-	totalCents := cents + int64(nanosInCents)
+// DefaultRounding is the sub-minor-unit rounding policy used by BoutiqueMoneyToCents
+const DefaultRounding = money.RoundHalfEven
 
-	// Validate the result is reasonable (prevent overflow/underflow)
-	if totalCents < 0 && money.Units >= 0 && money.Nanos >= 0 {
-		return 0, fmt.Errorf("money conversion overflow")
-	}
-
-	return totalCents, nil
+// BoutiqueMoneyToCents converts Online Boutique Money format to the minor units of
+// its currency (cents for USD, used by Bank of Anthos; yen for JPY; fils for KWD)
+// Boutique Money format: units (whole currency units) + nanos (billionths of a unit)
+// Anything finer than a minor unit is rounded half-to-even.
+func BoutiqueMoneyToCents(m *pb.Money) (int64, error) {
+	return BoutiqueMoneyToMinorUnits(m, DefaultRounding)
 }
 
-// CentsToBoutiqueMoney converts cents (Bank of Anthos) to Online Boutique Money format
-func CentsToBoutiqueMoney(cents int64, currencyCode string) *pb.Money {
-	// Calculate whole units (dollars)
-	units := cents / 100
-
-	// Calculate remaining cents and convert to nanos
-	remainingCents := cents % 100
-	nanos := remainingCents * 10_000_000
+// BoutiqueMoneyToMinorUnits converts Money to the minor units of its ISO 4217
+// currency, handling finer fractions according to policy. It rejects unknown
+// currencies, amounts that break the Money invariants and amounts too large to
+// count in minor units.
+func BoutiqueMoneyToMinorUnits(m *pb.Money, policy money.RoundingPolicy) (int64, error) {
+	amount, err := money.FromProto(m)
+	if err != nil {
+		return 0, err
+	}
 
-	// Handle negative amounts
-	if cents < 0 {
-		// For negative amounts, both units and nanos must be negative or zero
-		if units == 0 && nanos > 0 {
-			nanos = -nanos
-		}
+	minor, err := amount.ToMinor(policy)
+	if err != nil {
+		return 0, fmt.Errorf("money conversion failed: %w", err)
 	}
+	return minor, nil
+}
 
-	return &pb.Money{
-		Units:        units,
-		Nanos:        int32(nanos),
-		CurrencyCode: currencyCode,
+// CentsToBoutiqueMoney converts a count of the currency's minor units (cents for
+// Bank of Anthos) to Online Boutique Money format
+func CentsToBoutiqueMoney(minor int64, currencyCode string) (*pb.Money, error) {
+	amount, err := money.FromMinor(minor, currencyCode)
+	if err != nil {
+		return nil, err
 	}
+	return amount.Proto(), nil
 }
 
-// FormatMoney returns a human-readable string representation of Money
-func FormatMoney(money *pb.Money) string {
-	if money == nil {
+// FormatMoney returns a human-readable string representation of Money, using the
+// currency's symbol and precision
+func FormatMoney(m *pb.Money) string {
+	if m == nil {
 		return "$0.00"
 	}
 
-	cents, err := BoutiqueMoneyToCents(money)
+	currency, err := money.LookupCurrency(m.CurrencyCode)
 	if err != nil {
 		return "invalid"
 	}
-
-	// Format as dollars with 2 decimal places
-	dollars := float64(cents) / 100.0
-	return fmt.Sprintf("%s %.2f", money.CurrencyCode, dollars)
+	minor, err := BoutiqueMoneyToCents(m)
+	if err != nil {
+		return "invalid"
+	}
+	return currency.Format(minor)
 }
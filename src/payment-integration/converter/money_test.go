@@ -1,8 +1,12 @@
 package converter
 
 import (
-	pb "github.com/gke-hackathon/payment-integration/proto"
+	"errors"
+	"math"
 	"testing"
+
+	"github.com/gke-hackathon/payment-integration/money"
+	pb "github.com/gke-hackathon/payment-integration/proto"
 )
 
 func TestBoutiqueMoneyToCents(t *testing.T) {
@@ -78,6 +82,90 @@ func TestBoutiqueMoneyToCents(t *testing.T) {
 			expected: 0,
 			hasError: true,
 		},
+		{
+			name: "Zero-decimal currency",
+			money: &pb.Money{
+				Units:        1500,
+				Nanos:        0,
+				CurrencyCode: "JPY",
+			},
+			expected: 1500, // ¥1500 has no minor unit
+			hasError: false,
+		},
+		{
+			name: "Three-decimal currency",
+			money: &pb.Money{
+				Units:        2,
+				Nanos:        125_000_000,
+				CurrencyCode: "KWD",
+			},
+			expected: 2125, // 2.125 KWD = 2125 fils
+			hasError: false,
+		},
+		{
+			name: "Unknown currency",
+			money: &pb.Money{
+				Units:        10,
+				Nanos:        0,
+				CurrencyCode: "XYZ",
+			},
+			hasError: true,
+		},
+		{
+			name: "Missing currency",
+			money: &pb.Money{
+				Units: 10,
+				Nanos: 0,
+			},
+			hasError: true,
+		},
+		{
+			name: "Sub-cent nanos rounded half to even",
+			money: &pb.Money{
+				Units:        1,
+				Nanos:        125_000_000, // 1.125
+				CurrencyCode: "USD",
+			},
+			expected: 112,
+			hasError: false,
+		},
+		{
+			name: "Sub-cent nanos rounded to nearest",
+			money: &pb.Money{
+				Units:        0,
+				Nanos:        999_999_999,
+				CurrencyCode: "USD",
+			},
+			expected: 100,
+			hasError: false,
+		},
+		{
+			name: "Nanos out of range",
+			money: &pb.Money{
+				Units:        1,
+				Nanos:        1_000_000_000,
+				CurrencyCode: "USD",
+			},
+			hasError: true,
+		},
+		{
+			name: "Nanos sign disagrees with units",
+			money: &pb.Money{
+				Units:        5,
+				Nanos:        -250_000_000,
+				CurrencyCode: "USD",
+			},
+			hasError: true,
+		},
+		{
+			name: "Units overflow cents",
+			money: &pb.Money{
+				Units:        math.MaxInt64 / 10,
+				Nanos:        0,
+				CurrencyCode: "USD",
+			},
+			hasError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -100,6 +188,36 @@ func TestBoutiqueMoneyToCents(t *testing.T) {
 	}
 }
 
+func TestBoutiqueMoneyToMinorUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		nanos    int32
+		policy   money.RoundingPolicy
+		expected int64
+		err      error
+	}{
+		{"Reject exact", 250_000_000, money.RoundReject, 25, nil},
+		{"Reject sub-cent", 255_000_000, money.RoundReject, 0, money.ErrInexact},
+		{"Half even tie down", 125_000_000, money.RoundHalfEven, 12, nil},
+		{"Half even tie up", 135_000_000, money.RoundHalfEven, 14, nil},
+		{"Half up tie", 125_000_000, money.RoundHalfUp, 13, nil},
+		{"Half up below tie", 124_999_999, money.RoundHalfUp, 12, nil},
+		{"Half up negative tie", -125_000_000, money.RoundHalfUp, -13, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := BoutiqueMoneyToMinorUnits(&pb.Money{Nanos: tt.nanos, CurrencyCode: "USD"}, tt.policy)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %d cents, got %d", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestCentsToBoutiqueMoney(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -107,6 +225,7 @@ func TestCentsToBoutiqueMoney(t *testing.T) {
 		currencyCode  string
 		expectedUnits int64
 		expectedNanos int32
+		hasError      bool
 	}{
 		{
 			name:          "Simple dollar amount",
@@ -150,11 +269,40 @@ func TestCentsToBoutiqueMoney(t *testing.T) {
 			expectedUnits: 0,
 			expectedNanos: 10_000_000,
 		},
+		{
+			name:          "Zero-decimal currency",
+			cents:         1500,
+			currencyCode:  "JPY",
+			expectedUnits: 1500,
+			expectedNanos: 0,
+		},
+		{
+			name:          "Three-decimal currency",
+			cents:         -2125,
+			currencyCode:  "BHD",
+			expectedUnits: -2,
+			expectedNanos: -125_000_000,
+		},
+		{
+			name:         "Unknown currency",
+			cents:        100,
+			currencyCode: "XYZ",
+			hasError:     true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := CentsToBoutiqueMoney(tt.cents, tt.currencyCode)
+			result, err := CentsToBoutiqueMoney(tt.cents, tt.currencyCode)
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("Expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
 			if result.Units != tt.expectedUnits {
 				t.Errorf("Expected units %d, got %d", tt.expectedUnits, result.Units)
@@ -182,7 +330,7 @@ func TestFormatMoney(t *testing.T) {
 				Nanos:        0,
 				CurrencyCode: "USD",
 			},
-			expected: "USD 10.00",
+			expected: "$10.00",
 		},
 		{
 			name: "Dollar with cents",
@@ -191,7 +339,52 @@ func TestFormatMoney(t *testing.T) {
 				Nanos:        990_000_000,
 				CurrencyCode: "EUR",
 			},
-			expected: "EUR 15.99",
+			expected: "€15.99",
+		},
+		{
+			name: "Negative cents only",
+			money: &pb.Money{
+				Units:        0,
+				Nanos:        -50_000_000,
+				CurrencyCode: "USD",
+			},
+			expected: "-$0.05",
+		},
+		{
+			name: "Invalid money",
+			money: &pb.Money{
+				Units:        1,
+				Nanos:        -1,
+				CurrencyCode: "USD",
+			},
+			expected: "invalid",
+		},
+		{
+			name: "Zero-decimal currency",
+			money: &pb.Money{
+				Units:        1050,
+				Nanos:        0,
+				CurrencyCode: "JPY",
+			},
+			expected: "¥1050",
+		},
+		{
+			name: "Three-decimal currency without symbol",
+			money: &pb.Money{
+				Units:        10,
+				Nanos:        500_000_000,
+				CurrencyCode: "KWD",
+			},
+			expected: "KWD 10.500",
+		},
+		{
+			name: "Unknown currency",
+			money: &pb.Money{
+				Units:        10,
+				Nanos:        0,
+				CurrencyCode: "XYZ",
+			},
+			expected: "invalid",
 		},
 		{
 			name:     "Nil money",
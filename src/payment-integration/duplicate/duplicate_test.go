@@ -0,0 +1,113 @@
+package duplicate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestGuard() (*Guard, *time.Time) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	g := NewGuard(10*time.Second, ActionReturnFirst)
+	g.SetClock(func() time.Time { return now })
+	return g, &now
+}
+
+func begin(t *testing.T, g *Guard, key string) *Pending {
+	t.Helper()
+	p, err := g.Begin(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Expected %s to begin, got %v", key, err)
+	}
+	return p
+}
+
+func TestSequentialDuplicates(t *testing.T) {
+	g, now := newTestGuard()
+	key := Key("fp-1", "USD 10")
+
+	begin(t, g, key).Finish("tx-1", true)
+	// A different amount or card is not a duplicate
+	begin(t, g, Key("fp-1", "USD 11")).Finish("tx-2", true)
+	begin(t, g, Key("fp-2", "USD 10")).Finish("tx-3", true)
+
+	*now = now.Add(9 * time.Second)
+	_, err := g.Begin(context.Background(), key)
+	var dup *Duplicate
+	if !errors.As(err, &dup) || dup.OriginalID != "tx-1" || dup.Action != ActionReturnFirst || dup.Age != 9*time.Second {
+		t.Fatalf("Expected a duplicate of tx-1, got %v", err)
+	}
+
+	*now = now.Add(time.Second)
+	begin(t, g, key).Finish("tx-4", true)
+}
+
+func TestFailedChargeIsForgotten(t *testing.T) {
+	g, _ := newTestGuard()
+	key := Key("fp-1", "USD 10")
+
+	begin(t, g, key).Finish("", false)
+	p := begin(t, g, key)
+	p.Finish("tx-2", true)
+	// Only the first outcome counts
+	p.Finish("", false)
+
+	if _, err := g.Begin(context.Background(), key); err == nil {
+		t.Error("Expected the successful retry to be remembered")
+	}
+}
+
+func TestConcurrentDuplicateWaits(t *testing.T) {
+	g, _ := newTestGuard()
+	key := Key("fp-1", "USD 10")
+	first := begin(t, g, key)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := g.Begin(context.Background(), key)
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		t.Fatalf("Expected the duplicate to wait for the first charge, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	first.Finish("tx-1", true)
+	var dup *Duplicate
+	if err := <-result; !errors.As(err, &dup) || dup.OriginalID != "tx-1" {
+		t.Errorf("Expected a duplicate of tx-1, got %v", err)
+	}
+
+	// Waiting ends with the caller's deadline
+	second := begin(t, g, Key("fp-2", "USD 10"))
+	defer second.Finish("", false)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := g.Begin(ctx, Key("fp-2", "USD 10")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestDuplicateGRPCError(t *testing.T) {
+	if _, err := ParseAction("ignore"); err == nil {
+		t.Error("Expected an unknown action to be rejected")
+	}
+	if action, err := ParseAction(" Return_First "); err != nil || action != ActionReturnFirst {
+		t.Errorf("Expected return_first, got %q (%v)", action, err)
+	}
+
+	st := status.Convert((&Duplicate{Action: ActionReject, OriginalID: "tx-1"}).ToGRPCError())
+	if st.Code() != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists, got %s", st.Code())
+	}
+	if info, ok := st.Details()[0].(*errdetails.ErrorInfo); !ok || info.Reason != ReasonDuplicateCharge || info.Metadata["transaction_id"] != "tx-1" {
+		t.Errorf("Unexpected error details %v", st.Details())
+	}
+}
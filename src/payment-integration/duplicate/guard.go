@@ -0,0 +1,194 @@
+// Package duplicate detects a charge submitted twice in quick succession, such
+// as a shopper double-clicking "Place order", by its card, amount and currency.
+package duplicate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "payment-integration"
+
+// ReasonDuplicateCharge is the ErrorInfo reason of a rejected duplicate
+const ReasonDuplicateCharge = "DUPLICATE_CHARGE"
+
+// Action is what happens to a duplicate charge
+type Action string
+
+const (
+	// ActionReject fails the duplicate with AlreadyExists
+	ActionReject Action = "reject"
+	// ActionReturnFirst answers the duplicate with the original transaction ID
+	ActionReturnFirst Action = "return_first"
+)
+
+// ParseAction parses "reject" or "return_first"
+func ParseAction(value string) (Action, error) {
+	switch action := Action(strings.ToLower(strings.TrimSpace(value))); action {
+	case ActionReject, ActionReturnFirst:
+		return action, nil
+	}
+	return "", fmt.Errorf("duplicate action %q must be reject or return_first", value)
+}
+
+// Key identifies charges that count as the same: one card, amount and currency
+func Key(cardFingerprint, amount string) string {
+	return cardFingerprint + "|" + amount
+}
+
+// Duplicate reports a charge identical to one that succeeded within the window
+type Duplicate struct {
+	Action Action
+	// OriginalID is the transaction ID of the first charge
+	OriginalID string
+	// Age is how long before the duplicate the first charge began
+	Age time.Duration
+}
+
+// Error implements the error interface
+func (d *Duplicate) Error() string {
+	return fmt.Sprintf("duplicate of transaction %s from %s ago", d.OriginalID, d.Age.Round(time.Millisecond))
+}
+
+// ToGRPCError converts a Duplicate to an AlreadyExists status naming the
+// original transaction
+func (d *Duplicate) ToGRPCError() error {
+	st := status.New(codes.AlreadyExists, "an identical payment was just made")
+	info := &errdetails.ErrorInfo{
+		Reason:   ReasonDuplicateCharge,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"transaction_id": d.OriginalID},
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// entry is a charge in the window. done is closed once it finishes; charges
+// that fail are removed so a retry goes through.
+type entry struct {
+	id    string
+	began time.Time
+	done  chan struct{}
+}
+
+// Guard remembers recent charges for a window
+type Guard struct {
+	window time.Duration
+	action Action
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	lastGC  time.Time
+}
+
+// NewGuard creates a guard treating identical charges within window as
+// duplicates and handling them with action
+func NewGuard(window time.Duration, action Action) *Guard {
+	return &Guard{window: window, action: action, now: time.Now, entries: make(map[string]*entry)}
+}
+
+// SetClock overrides the time source, for tests
+func (g *Guard) SetClock(now func() time.Time) {
+	g.now = now
+}
+
+// Window returns how long charges are remembered
+func (g *Guard) Window() time.Duration {
+	return g.window
+}
+
+// Action returns how duplicates are handled
+func (g *Guard) Action() Action {
+	return g.action
+}
+
+// Begin registers a charge under key. If an identical charge began within the
+// window, Begin waits for it to finish: if it succeeded, a *Duplicate is
+// returned; if it failed, this charge takes its place. Waiting ends with the
+// context's error.
+func (g *Guard) Begin(ctx context.Context, key string) (*Pending, error) {
+	for {
+		g.mu.Lock()
+		now := g.now()
+		g.collectGarbage(now)
+
+		e := g.entries[key]
+		if e == nil || now.Sub(e.began) >= g.window {
+			e = &entry{began: now, done: make(chan struct{})}
+			g.entries[key] = e
+			g.mu.Unlock()
+			return &Pending{guard: g, key: key, entry: e}, nil
+		}
+
+		select {
+		case <-e.done:
+			g.mu.Unlock()
+			return nil, &Duplicate{Action: g.action, OriginalID: e.id, Age: now.Sub(e.began)}
+		default:
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// collectGarbage forgets finished charges outside the window, at most once per
+// window. It is called with mu held.
+func (g *Guard) collectGarbage(now time.Time) {
+	if now.Sub(g.lastGC) < g.window {
+		return
+	}
+	g.lastGC = now
+
+	for key, e := range g.entries {
+		if now.Sub(e.began) >= g.window {
+			select {
+			case <-e.done:
+				delete(g.entries, key)
+			default:
+			}
+		}
+	}
+}
+
+// Pending is a registered charge that hasn't finished yet
+type Pending struct {
+	guard *Guard
+	key   string
+	entry *entry
+	once  sync.Once
+}
+
+// Finish records the charge's outcome. A successful charge's ID is returned to
+// its duplicates; a failed charge is forgotten. Only the first call counts, and
+// a nil Pending is ignored.
+func (p *Pending) Finish(id string, ok bool) {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() {
+		p.guard.mu.Lock()
+		defer p.guard.mu.Unlock()
+
+		if ok {
+			p.entry.id = id
+		} else if p.guard.entries[p.key] == p.entry {
+			delete(p.guard.entries, p.key)
+		}
+		close(p.entry.done)
+	})
+}
@@ -0,0 +1,118 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/money"
+)
+
+// Details records the rate a charge was converted at
+type Details struct {
+	// Rate is the provider's mid rate: ledger currency units per unit of the charge currency
+	Rate string `json:"rate"`
+	// AppliedRate is Rate with the spread added, which the charge was converted at
+	AppliedRate       string    `json:"applied_rate"`
+	SpreadBasisPoints int64     `json:"spread_bps"`
+	RateTimestamp     time.Time `json:"rate_timestamp"`
+	Source            string    `json:"source"`
+}
+
+// Conversion is a charge amount converted into the ledger currency
+type Conversion struct {
+	Original       money.Amount
+	LedgerCurrency string
+	// LedgerAmount is in the ledger currency's minor units
+	LedgerAmount int64
+	// FX is nil when the charge was already in the ledger currency
+	FX *Details
+}
+
+// Converter converts charge amounts into the ledger currency
+type Converter struct {
+	provider          RateProvider
+	ledgerCurrency    string
+	maxAge            time.Duration
+	spreadBasisPoints int64
+	now               func() time.Time
+}
+
+// NewConverter creates a converter into ledgerCurrency. Rates older than maxAge
+// are refused (0 disables the limit), and spreadBasisPoints is added on top of
+// the mid rate. provider may be nil, in which case only charges already in the
+// ledger currency are accepted.
+func NewConverter(provider RateProvider, ledgerCurrency string, maxAge time.Duration, spreadBasisPoints int64) (*Converter, error) {
+	if _, err := money.LookupCurrency(ledgerCurrency); err != nil {
+		return nil, fmt.Errorf("invalid ledger currency: %w", err)
+	}
+	if spreadBasisPoints < 0 || spreadBasisPoints >= 10_000 {
+		return nil, fmt.Errorf("FX spread must be between 0 and 9999 basis points, got %d", spreadBasisPoints)
+	}
+	return &Converter{
+		provider:          provider,
+		ledgerCurrency:    ledgerCurrency,
+		maxAge:            maxAge,
+		spreadBasisPoints: spreadBasisPoints,
+		now:               time.Now,
+	}, nil
+}
+
+// SetClock overrides the time source used for staleness checks
+func (c *Converter) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// LedgerCurrency returns the currency charges are converted into
+func (c *Converter) LedgerCurrency() string {
+	return c.ledgerCurrency
+}
+
+// Convert converts amount into the ledger currency's minor units. Converted
+// amounts are rarely whole minor units, so RoundReject only applies to charges
+// already in the ledger currency and conversions round half-to-even instead.
+func (c *Converter) Convert(ctx context.Context, amount money.Amount, policy money.RoundingPolicy) (*Conversion, error) {
+	if amount.Currency == c.ledgerCurrency {
+		minor, err := amount.ToMinor(policy)
+		if err != nil {
+			return nil, err
+		}
+		return &Conversion{Original: amount, LedgerCurrency: c.ledgerCurrency, LedgerAmount: minor}, nil
+	}
+
+	if c.provider == nil {
+		return nil, fmt.Errorf("%w %s/%s: no rate provider configured", ErrNoRate, amount.Currency, c.ledgerCurrency)
+	}
+	q, err := c.provider.Quote(ctx, amount.Currency, c.ledgerCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if age := c.now().Sub(q.Timestamp); c.maxAge > 0 && age > c.maxAge {
+		return nil, fmt.Errorf("%w: %s/%s rate is %s old, limit is %s", ErrStaleRate, q.From, q.To, age.Round(time.Second), c.maxAge)
+	}
+
+	// The spread is charged to the customer: they pay (1 + spread) times the mid rate
+	applied := new(big.Rat).Mul(q.Rate, big.NewRat(10_000+c.spreadBasisPoints, 10_000))
+
+	if policy == money.RoundReject {
+		policy = money.RoundHalfEven
+	}
+	minor, err := amount.ExchangeToMinor(applied, c.ledgerCurrency, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conversion{
+		Original:       amount,
+		LedgerCurrency: c.ledgerCurrency,
+		LedgerAmount:   minor,
+		FX: &Details{
+			Rate:              FormatRate(q.Rate),
+			AppliedRate:       FormatRate(applied),
+			SpreadBasisPoints: c.spreadBasisPoints,
+			RateTimestamp:     q.Timestamp,
+			Source:            q.Source,
+		},
+	}, nil
+}
@@ -0,0 +1,233 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/money"
+)
+
+func writeRates(t *testing.T, table RateTable) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rates.json")
+	data, err := json.Marshal(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"1.08", true},
+		{"154.3", true},
+		{" 0.0001 ", true},
+		{"0", false},
+		{"-1.2", false},
+		{"1/3", false},
+		{"1e3", false},
+		{"abc", false},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseRate(tt.value)
+		if tt.valid && err != nil {
+			t.Errorf("%q: unexpected error %v", tt.value, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%q: expected error", tt.value)
+		}
+	}
+}
+
+func TestStaticProviderCrossRates(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	path := writeRates(t, RateTable{
+		Base:      "EUR",
+		Timestamp: timestamp,
+		Rates:     map[string]string{"USD": "1.08", "JPY": "162"},
+	})
+
+	provider, err := NewStaticProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		expected string
+	}{
+		{"EUR", "USD", "1.08"},
+		{"USD", "EUR", "0.9259259259"},
+		{"JPY", "USD", "0.0066666667"},
+		{"USD", "USD", "1"},
+	}
+	for _, tt := range tests {
+		q, err := provider.Quote(context.Background(), tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tt.from, tt.to, err)
+		}
+		if got := FormatRate(q.Rate); got != tt.expected {
+			t.Errorf("%s/%s: expected %s, got %s", tt.from, tt.to, tt.expected, got)
+		}
+		if !q.Timestamp.Equal(timestamp) {
+			t.Errorf("Expected timestamp %v, got %v", timestamp, q.Timestamp)
+		}
+	}
+
+	if _, err := provider.Quote(context.Background(), "GBP", "USD"); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Expected ErrNoRate, got %v", err)
+	}
+}
+
+func TestStaticProviderRejectsBadTables(t *testing.T) {
+	tables := map[string]RateTable{
+		"unknown base":     {Base: "XYZ", Rates: map[string]string{"USD": "1"}},
+		"unknown currency": {Base: "USD", Rates: map[string]string{"XYZ": "1"}},
+		"negative rate":    {Base: "USD", Rates: map[string]string{"EUR": "-0.9"}},
+	}
+	for name, table := range tables {
+		if _, err := NewStaticProvider(writeRates(t, table)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if _, err := NewStaticProvider(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
+
+func TestHTTPProviderCachesAndKeepsLastTable(t *testing.T) {
+	var requests int32
+	var fail atomic.Bool
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if fail.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(RateTable{Base: "USD", Timestamp: timestamp, Rates: map[string]string{"EUR": "0.92"}})
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	provider := NewHTTPProvider(server.URL, time.Minute)
+	provider.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := provider.Quote(context.Background(), "EUR", "USD"); err != nil {
+			t.Fatalf("Quote failed: %v", err)
+		}
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected 1 fetch within the refresh interval, got %d", requests)
+	}
+
+	// A failed refresh keeps serving the last table
+	fail.Store(true)
+	now = now.Add(2 * time.Minute)
+	q, err := provider.Quote(context.Background(), "USD", "EUR")
+	if err != nil {
+		t.Fatalf("Expected last table after a failed refresh, got %v", err)
+	}
+	if FormatRate(q.Rate) != "0.92" || !q.Timestamp.Equal(timestamp) {
+		t.Errorf("Unexpected quote %s at %v", FormatRate(q.Rate), q.Timestamp)
+	}
+
+	empty := NewHTTPProvider(server.URL, time.Minute)
+	if _, err := empty.Quote(context.Background(), "USD", "EUR"); err == nil {
+		t.Error("Expected error when no table was ever fetched")
+	}
+}
+
+// stubProvider quotes a fixed rate
+type stubProvider struct {
+	rate      string
+	timestamp time.Time
+}
+
+func (p stubProvider) Quote(ctx context.Context, from, to string) (Quote, error) {
+	rate, _ := ParseRate(p.rate)
+	return Quote{From: from, To: to, Rate: rate, Timestamp: p.timestamp, Source: "stub"}, nil
+}
+
+func TestConverter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		amount   money.Amount
+		rate     string
+		age      time.Duration
+		spread   int64
+		policy   money.RoundingPolicy
+		expected int64
+		err      error
+	}{
+		{"Ledger currency", money.Amount{Currency: "USD", Units: 12, Nanos: 340_000_000}, "", 0, 100, money.RoundReject, 1234, nil},
+		{"Ledger currency sub-cent rejected", money.Amount{Currency: "USD", Nanos: 1}, "", 0, 0, money.RoundReject, 0, money.ErrInexact},
+		{"Mid rate", money.Amount{Currency: "EUR", Units: 10}, "1.08", 0, 0, money.RoundReject, 1080, nil},
+		{"Spread", money.Amount{Currency: "EUR", Units: 10}, "1.08", 0, 250, money.RoundHalfEven, 1107, nil},
+		{"Reject rounds half even", money.Amount{Currency: "EUR", Units: 10}, "1.0805", 0, 0, money.RoundReject, 1080, nil},
+		{"Half up", money.Amount{Currency: "EUR", Units: 10}, "1.0805", 0, 0, money.RoundHalfUp, 1081, nil},
+		{"Zero-decimal source", money.Amount{Currency: "JPY", Units: 1000}, "0.0065", 0, 0, money.RoundHalfEven, 650, nil},
+		{"Stale rate", money.Amount{Currency: "EUR", Units: 10}, "1.08", 2 * time.Hour, 0, money.RoundHalfEven, 0, ErrStaleRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter, err := NewConverter(stubProvider{rate: tt.rate, timestamp: now.Add(-tt.age)}, "USD", time.Hour, tt.spread)
+			if err != nil {
+				t.Fatal(err)
+			}
+			converter.SetClock(func() time.Time { return now })
+
+			conversion, err := converter.Convert(context.Background(), tt.amount, tt.policy)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if conversion.LedgerAmount != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, conversion.LedgerAmount)
+			}
+			if (conversion.FX == nil) != (tt.rate == "") {
+				t.Errorf("Unexpected FX details %+v", conversion.FX)
+			}
+		})
+	}
+}
+
+func TestConverterConfiguration(t *testing.T) {
+	if _, err := NewConverter(nil, "XYZ", 0, 0); !errors.Is(err, money.ErrUnknownCurrency) {
+		t.Errorf("Expected ErrUnknownCurrency, got %v", err)
+	}
+	if _, err := NewConverter(nil, "USD", 0, 10_000); err == nil {
+		t.Error("Expected error for a 100% spread")
+	}
+
+	converter, err := NewConverter(nil, "USD", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := converter.Convert(context.Background(), money.Amount{Currency: "EUR", Units: 1}, money.RoundHalfEven); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Expected ErrNoRate without a provider, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPProvider fetches a rate table document from an HTTP endpoint and caches it
+// for the refresh interval. If a refresh fails, the last table keeps being served
+// until the staleness limit rejects it.
+type HTTPProvider struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+	now     func() time.Time
+
+	mu        sync.Mutex
+	rates     map[string]*big.Rat
+	timestamp time.Time
+	fetchedAt time.Time
+}
+
+// NewHTTPProvider creates a provider reading the rate table at url
+func NewHTTPProvider(url string, refresh time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		now:     time.Now,
+	}
+}
+
+// Quote returns the rate between two currencies, refreshing the table when it is due
+func (p *HTTPProvider) Quote(ctx context.Context, from, to string) (Quote, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rates == nil || p.now().Sub(p.fetchedAt) >= p.refresh {
+		if err := p.fetch(ctx); err != nil && p.rates == nil {
+			return Quote{}, err
+		}
+	}
+	return quote(p.rates, from, to, p.timestamp, p.url)
+}
+
+// fetch replaces the cached table. It is called with mu held.
+func (p *HTTPProvider) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create FX rates request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch FX rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch FX rates: status %d", resp.StatusCode)
+	}
+
+	var table RateTable
+	if err := json.NewDecoder(resp.Body).Decode(&table); err != nil {
+		return fmt.Errorf("failed to decode FX rates: %w", err)
+	}
+	rates, err := table.parsed()
+	if err != nil {
+		return err
+	}
+	if table.Timestamp.IsZero() {
+		return fmt.Errorf("FX rates from %s carry no timestamp", p.url)
+	}
+
+	p.rates = rates
+	p.timestamp = table.Timestamp
+	p.fetchedAt = p.now()
+	return nil
+}
@@ -0,0 +1,104 @@
+// Package fx converts charges into the ledger currency using exchange rates
+// from a pluggable rate provider.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/money"
+)
+
+var (
+	// ErrNoRate is returned when a provider has no rate for a currency pair
+	ErrNoRate = errors.New("no exchange rate for currency pair")
+	// ErrStaleRate is returned when the newest available rate is older than the staleness limit
+	ErrStaleRate = errors.New("exchange rate is stale")
+)
+
+// Quote is an exchange rate between two currencies: 1 unit of From buys Rate units of To
+type Quote struct {
+	From      string
+	To        string
+	Rate      *big.Rat
+	Timestamp time.Time
+	Source    string
+}
+
+// RateProvider supplies exchange rates
+type RateProvider interface {
+	Quote(ctx context.Context, from, to string) (Quote, error)
+}
+
+// RateTable is the rate document read by the static and HTTP providers. Each
+// rate is the number of units of that currency per unit of Base, as a decimal string.
+//
+//	{"base": "USD", "timestamp": "2024-05-01T12:00:00Z", "rates": {"EUR": "0.92", "JPY": "154.3"}}
+type RateTable struct {
+	Base      string            `json:"base"`
+	Timestamp time.Time         `json:"timestamp"`
+	Rates     map[string]string `json:"rates"`
+}
+
+// parsed validates the table and parses its rates
+func (t *RateTable) parsed() (map[string]*big.Rat, error) {
+	if _, err := money.LookupCurrency(t.Base); err != nil {
+		return nil, fmt.Errorf("invalid rate table base: %w", err)
+	}
+
+	rates := map[string]*big.Rat{t.Base: big.NewRat(1, 1)}
+	for code, value := range t.Rates {
+		if _, err := money.LookupCurrency(code); err != nil {
+			return nil, fmt.Errorf("invalid rate table entry: %w", err)
+		}
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate for %s: %w", code, err)
+		}
+		rates[code] = rate
+	}
+	return rates, nil
+}
+
+// quote derives the cross rate between two currencies through the table's base
+func quote(rates map[string]*big.Rat, from, to string, timestamp time.Time, source string) (Quote, error) {
+	fromRate, ok := rates[from]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w %s/%s", ErrNoRate, from, to)
+	}
+	toRate, ok := rates[to]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w %s/%s", ErrNoRate, from, to)
+	}
+	return Quote{
+		From:      from,
+		To:        to,
+		Rate:      new(big.Rat).Quo(toRate, fromRate),
+		Timestamp: timestamp,
+		Source:    source,
+	}, nil
+}
+
+// ParseRate parses a positive decimal exchange rate such as "0.9215"
+func ParseRate(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || strings.ContainsAny(value, "/eE") {
+		return nil, fmt.Errorf("%q is not a decimal number", value)
+	}
+	if rate.Sign() <= 0 {
+		return nil, fmt.Errorf("rate %s must be positive", value)
+	}
+	return rate, nil
+}
+
+// FormatRate renders a rate as a decimal with up to 10 places
+func FormatRate(rate *big.Rat) string {
+	s := rate.FloatString(10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
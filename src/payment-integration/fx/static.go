@@ -0,0 +1,52 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+// StaticProvider serves rates from a JSON rate table file. Rates are stamped with
+// the table's timestamp, or the file's modification time if it has none, so a
+// file that is never refreshed eventually trips the staleness limit.
+type StaticProvider struct {
+	path      string
+	rates     map[string]*big.Rat
+	timestamp time.Time
+}
+
+// NewStaticProvider loads a rate table from path
+func NewStaticProvider(path string) (*StaticProvider, error) {
+	var table RateTable
+	found, err := storage.ReadJSON(path, &table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load FX rates: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("FX rates file %s does not exist", path)
+	}
+
+	rates, err := table.parsed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load FX rates from %s: %w", path, err)
+	}
+
+	timestamp := table.Timestamp
+	if timestamp.IsZero() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat FX rates file: %w", err)
+		}
+		timestamp = info.ModTime()
+	}
+	return &StaticProvider{path: path, rates: rates, timestamp: timestamp}, nil
+}
+
+// Quote returns the rate between two currencies in the table
+func (p *StaticProvider) Quote(ctx context.Context, from, to string) (Quote, error) {
+	return quote(p.rates, from, to, p.timestamp, "file:"+p.path)
+}
@@ -14,6 +14,6 @@ export PATH="$PATH:$(go env GOPATH)/bin"
 # Generate Go code from proto files
 protoc --go_out=. --go_opt=paths=source_relative \
     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
-    proto/payment.proto
+    proto/payment.proto proto/admin.proto
 
 echo "Proto files generated successfully"
\ No newline at end of file
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,7 +26,11 @@ const (
 type Logger struct {
 	serviceName string
 	output      *log.Logger
-	minLevel    LogLevel
+	// minLevel holds a LogLevel; it can change while the logger is in use and
+	// is shared with tenant loggers
+	minLevel *atomic.Value
+	// tenant is added to every entry of a tenant logger
+	tenant string
 }
 
 // LogEntry represents a structured log entry
@@ -32,6 +38,10 @@ type LogEntry struct {
 	Timestamp      string                 `json:"timestamp"`
 	Level          LogLevel               `json:"level"`
 	Service        string                 `json:"service"`
+	Tenant         string                 `json:"tenant,omitempty"`
+	Category       string                 `json:"category,omitempty"`
+	Actor          string                 `json:"actor,omitempty"`
+	Action         string                 `json:"action,omitempty"`
 	TransactionID  string                 `json:"transaction_id,omitempty"`
 	CorrelationID  string                 `json:"correlation_id,omitempty"`
 	AccountNumber  string                 `json:"account_number,omitempty"`
@@ -45,20 +55,46 @@ type LogEntry struct {
 	AdditionalData map[string]interface{} `json:"data,omitempty"`
 }
 
-// NewLogger creates a new structured logger
+// NewLogger creates a new structured logger at INFO level
 func NewLogger(serviceName string) *Logger {
-	minLevel := INFO
-	if os.Getenv("LOG_LEVEL") == "DEBUG" {
-		minLevel = DEBUG
-	}
-
-	return &Logger{
+	l := &Logger{
 		serviceName: serviceName,
 		output:      log.New(os.Stdout, "", 0),
-		minLevel:    minLevel,
+		minLevel:    &atomic.Value{},
+	}
+	l.minLevel.Store(INFO)
+	return l
+}
+
+// ForTenant returns a logger that tags every entry, audit entries included,
+// with tenant. It shares the level and output of l.
+func (l *Logger) ForTenant(tenant string) *Logger {
+	child := *l
+	child.tenant = tenant
+	return &child
+}
+
+// ParseLevel parses DEBUG, INFO, WARN or ERROR, in any case
+func ParseLevel(name string) (LogLevel, error) {
+	switch level := LogLevel(strings.ToUpper(strings.TrimSpace(name))); level {
+	case DEBUG, INFO, WARN, ERROR:
+		return level, nil
+	default:
+		return "", fmt.Errorf("unknown log level %q, expected DEBUG, INFO, WARN or ERROR", name)
 	}
 }
 
+// SetLevel sets the lowest level that is written. It is safe to call while
+// other goroutines log.
+func (l *Logger) SetLevel(level LogLevel) {
+	l.minLevel.Store(level)
+}
+
+// Level returns the lowest level that is written
+func (l *Logger) Level() LogLevel {
+	return l.minLevel.Load().(LogLevel)
+}
+
 // shouldLog checks if the message should be logged based on level
 func (l *Logger) shouldLog(level LogLevel) bool {
 	levels := map[LogLevel]int{
@@ -68,7 +104,7 @@ func (l *Logger) shouldLog(level LogLevel) bool {
 		ERROR: 3,
 		FATAL: 4,
 	}
-	return levels[level] >= levels[l.minLevel]
+	return levels[level] >= levels[l.Level()]
 }
 
 // log writes a log entry
@@ -80,6 +116,7 @@ func (l *Logger) log(level LogLevel, entry LogEntry) {
 	entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
 	entry.Level = level
 	entry.Service = l.serviceName
+	entry.Tenant = l.tenant
 
 	jsonData, err := json.Marshal(entry)
 	if err != nil {
@@ -188,6 +225,29 @@ func (l *Logger) LogBankAPICall(txID string, accountNum string, amount int64, du
 	}
 }
 
+// LogAudit records an administrative change. Audit entries are always written,
+// regardless of the configured log level.
+func (l *Logger) LogAudit(actor string, action string, target string, data map[string]interface{}) {
+	entry := LogEntry{
+		Category:       "audit",
+		Actor:          actor,
+		Action:         action,
+		Message:        fmt.Sprintf("Audit: %s %s", action, target),
+		AdditionalData: data,
+	}
+	entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	entry.Level = INFO
+	entry.Service = l.serviceName
+	entry.Tenant = l.tenant
+
+	jsonData, err := json.Marshal(entry)
+	if err != nil {
+		l.Error("Failed to marshal audit entry", err, nil)
+		return
+	}
+	l.output.Println(string(jsonData))
+}
+
 // ExtractCorrelationID extracts correlation ID from context
 func ExtractCorrelationID(ctx context.Context) string {
 	if val := ctx.Value("correlation_id"); val != nil {
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/gke-hackathon/payment-integration/auth"
+	"github.com/gke-hackathon/payment-integration/config"
 	"github.com/gke-hackathon/payment-integration/logging"
 	"github.com/gke-hackathon/payment-integration/metrics"
 	"github.com/gke-hackathon/payment-integration/server"
@@ -17,28 +21,87 @@ import (
 	"google.golang.org/grpc/reflection"
 )
 
-const defaultPort = "50051"
+// jwksMaxAge is how long verifiers may cache the published key set
+const jwksMaxAge = 5 * time.Minute
+
+// configPollInterval is how often the config file is checked for changes
+const configPollInterval = 10 * time.Second
 
 func main() {
-	logger := logging.NewLogger("payment-integration")
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	printConfig := fs.Bool("print-config", false, "print the effective configuration, secrets redacted, and exit")
+	loader, err := config.NewLoader(fs, os.Args[1:], os.LookupEnv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "payment-integration: %v\n", err)
+		os.Exit(2)
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	// Refuse to start on a bad configuration rather than run on guesses
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "payment-integration: %v\n", err)
+		os.Exit(2)
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "payment-integration: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	logger.Info("Starting payment integration service", map[string]interface{}{"port": port})
+	logger := logging.NewLogger("payment-integration")
+	level, _ := logging.ParseLevel(cfg.Server.LogLevel)
+	logger.SetLevel(level)
+
+	logger.Info("Starting payment integration service", map[string]interface{}{"port": cfg.Server.Port, "config_path": loader.Path()})
 
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
 	if err != nil {
 		logger.Fatal("Failed to listen", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	paymentServer, err := server.NewPaymentServer(cfg, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "payment-integration: %v\n", err)
+		os.Exit(2)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(paymentServer.UnaryInterceptor()))
 
-	paymentServer := server.NewPaymentServer()
 	server.RegisterPaymentServiceServer(grpcServer, paymentServer)
 
+	// Reload the configuration when its file changes or on SIGHUP
+	watcher := config.NewWatcher(loader, cfg, paymentServer.ApplyConfig, logger)
+	watcher.OnReload(func(st config.Status) {
+		metrics.GetInstance().RecordConfigReload(st.LastResult)
+		if st.LastResult == config.ReloadApplied {
+			metrics.GetInstance().RecordConfigApplied(st.Version)
+		}
+	})
+	metrics.GetInstance().RecordConfigApplied(watcher.Status().Version)
+	watcher.Start(configPollInterval)
+	defer watcher.Stop()
+
+	go func() {
+		hupChan := make(chan os.Signal, 1)
+		signal.Notify(hupChan, syscall.SIGHUP)
+		for range hupChan {
+			logger.Info("Received SIGHUP, reloading configuration", nil)
+			watcher.Reload()
+		}
+	}()
+
+	// Admin services are only exposed when an admin token is configured
+	var adminServer *server.AdminServer
+	if adminToken := cfg.Server.AdminToken; adminToken != "" {
+		adminServer = server.NewAdminServer(paymentServer, adminToken)
+		adminServer.UseConfigWatcher(watcher)
+		server.RegisterAdminServices(grpcServer, adminServer)
+		logger.Info("Admin services enabled", nil)
+	} else {
+		logger.Warn("ADMIN_TOKEN not set, admin services disabled", nil)
+	}
+
 	// Register health service
 	healthServer := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
@@ -49,7 +112,7 @@ func main() {
 	reflection.Register(grpcServer)
 
 	// Start HTTP server for health checks and metrics
-	go startHTTPServer(logger)
+	go startHTTPServer(logger, cfg.Server.HTTPPort, paymentServer.Authenticator(), adminServer)
 
 	// Handle graceful shutdown
 	go func() {
@@ -67,12 +130,7 @@ func main() {
 }
 
 // startHTTPServer starts the HTTP server for health checks and metrics
-func startHTTPServer(logger *logging.Logger) {
-	httpPort := os.Getenv("HTTP_PORT")
-	if httpPort == "" {
-		httpPort = "8080"
-	}
-
+func startHTTPServer(logger *logging.Logger, httpPort int, authenticator *auth.ServiceAuthenticator, adminServer *server.AdminServer) {
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
@@ -86,8 +144,19 @@ func startHTTPServer(logger *logging.Logger) {
 	// Add Prometheus metrics endpoint
 	http.Handle("/metrics", metrics.PrometheusHandler())
 
+	// Publish signing keys so other services can verify our tokens
+	if authenticator != nil {
+		http.Handle(auth.JWKSPath, auth.JWKSHandler(authenticator, jwksMaxAge))
+		http.Handle(auth.DiscoveryPath, auth.DiscoveryHandler(authenticator.Issuer(), jwksMaxAge))
+	}
+
+	// JSON mirror of the admin gRPC services
+	if adminServer != nil {
+		server.RegisterAdminHTTPHandlers(http.DefaultServeMux, adminServer)
+	}
+
 	logger.Info("Starting HTTP server for health checks", map[string]interface{}{"port": httpPort})
-	if err := http.ListenAndServe(":"+httpPort, nil); err != nil {
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", httpPort), nil); err != nil {
 		logger.Error("HTTP server failed", err, nil)
 	}
 }
@@ -1,17 +1,44 @@
 package mapper
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/card"
+	"github.com/gke-hackathon/payment-integration/vault"
 )
 
+// ErrNoVault is returned when a card token is charged but no vault is configured
+var ErrNoVault = errors.New("no card vault configured")
+
+// RoutingNumberValidator checks routing numbers before they are stored
+type RoutingNumberValidator interface {
+	ValidateRoutingNumber(routingNum string) error
+}
+
 // AccountMapper handles mapping between credit card numbers and bank account numbers
 type AccountMapper struct {
 	DefaultMerchantAccount string
 	DefaultRoutingNumber   string
+
+	links         LinkStore
+	hasher        *CardHasher
+	fingerprints  *CardHasher
+	legacyLastTen bool
+	strategies    []Strategy
+	detokenizer   *vault.Detokenizer
+	routing       RoutingNumberValidator
+
+	// merchantAccounts holds the merchant's account at each bank, keyed by routing number
+	merchantAccounts map[string]string
 }
 
-// NewAccountMapper creates a new account mapper instance
+// NewAccountMapper creates a new account mapper instance. Until a strategy chain is
+// configured, cards resolve only through a link store set with UseLinkStore,
+// followed by the last-10 strategy if EnableLegacyLastTen is called.
 func NewAccountMapper(merchantAccount, routingNumber string) *AccountMapper {
 	// Use defaults if not provided
 	if merchantAccount == "" {
@@ -21,18 +48,243 @@ func NewAccountMapper(merchantAccount, routingNumber string) *AccountMapper {
 		routingNumber = "123456789" // Default routing number
 	}
 
+	// Until a link store brings a configured key, fingerprints use a per-process
+	// key and only stay stable until restart
+	key := make([]byte, 32)
+	rand.Read(key)
+
 	return &AccountMapper{
 		DefaultMerchantAccount: merchantAccount,
 		DefaultRoutingNumber:   routingNumber,
+		fingerprints:           &CardHasher{key: key},
+		merchantAccounts:       make(map[string]string),
 	}
 }
 
-// CardNumberToAccount maps a credit card number to a bank account number
-// Initial implementation: use last 10 digits of card number as account number
-func (m *AccountMapper) CardNumberToAccount(cardNumber string) (accountNum string, routingNum string) {
-	// Remove spaces and dashes from card number
-	cleaned := strings.ReplaceAll(cardNumber, " ", "")
-	cleaned = strings.ReplaceAll(cleaned, "-", "")
+// WithMerchant returns a mapper for another default merchant account that
+// shares this mapper's card link table, fingerprint key and detokenizer, so
+// cards keep their links and fingerprints. The strategy chain, routing
+// validator, merchant accounts at other banks and legacy last-10 opt-in are
+// not carried over.
+func (m *AccountMapper) WithMerchant(merchantAccount, routingNumber string) *AccountMapper {
+	derived := NewAccountMapper(merchantAccount, routingNumber)
+	derived.links = m.links
+	derived.hasher = m.hasher
+	derived.fingerprints = m.fingerprints
+	derived.detokenizer = m.detokenizer
+	return derived
+}
+
+// Fingerprint returns a keyed hash identifying a card without revealing its
+// number. It matches the card's link table hash when a link store is in use.
+// Spaces and dashes in the number are ignored.
+func (m *AccountMapper) Fingerprint(cardNumber string) string {
+	return m.fingerprints.Hash(cleanCardNumber(cardNumber))
+}
+
+// UseRoutingValidator validates routing numbers of new links and merchant accounts
+func (m *AccountMapper) UseRoutingValidator(v RoutingNumberValidator) {
+	m.routing = v
+}
+
+// AddMerchantAccount registers the merchant's account at another bank, so
+// customers of that bank are paid into it instead of the default account
+func (m *AccountMapper) AddMerchantAccount(accountNum, routingNum string) error {
+	if err := m.validateRoutingNumber(routingNum); err != nil {
+		return err
+	}
+	m.merchantAccounts[routingNum] = accountNum
+	return nil
+}
+
+// MerchantAccountFor returns the merchant account a customer at customerRouting
+// pays into: the merchant's account at the same bank if it has one, else the default
+func (m *AccountMapper) MerchantAccountFor(customerRouting string) (accountNum string, routingNum string) {
+	if accountNum, ok := m.merchantAccounts[customerRouting]; ok {
+		return accountNum, customerRouting
+	}
+	return m.DefaultMerchantAccount, m.DefaultRoutingNumber
+}
+
+// validateRoutingNumber applies the routing validator, if one is configured
+func (m *AccountMapper) validateRoutingNumber(routingNum string) error {
+	if m.routing == nil {
+		return nil
+	}
+	return m.routing.ValidateRoutingNumber(routingNum)
+}
+
+// UseLinkStore resolves cards through the given link table, keyed by hasher
+func (m *AccountMapper) UseLinkStore(links LinkStore, hasher *CardHasher) {
+	m.links = links
+	m.hasher = hasher
+	m.fingerprints = hasher
+}
+
+// EnableLegacyLastTen opts in to the hackathon strategy of using a card's last 10
+// digits as its account number when it has no link. It collides across cards.
+func (m *AccountMapper) EnableLegacyLastTen() {
+	m.legacyLastTen = true
+}
+
+// UseDetokenizer lets the mapper resolve vault tokens back to card numbers
+func (m *AccountMapper) UseDetokenizer(d *vault.Detokenizer) {
+	m.detokenizer = d
+}
+
+// TokenToAccount maps a vault card token to a bank account number. The card
+// number is decrypted only for the lookup and never returned to the caller.
+func (m *AccountMapper) TokenToAccount(token string) (accountNum string, routingNum string, err error) {
+	res, err := m.ResolveToken(token)
+	if err != nil {
+		return "", "", err
+	}
+	return res.AccountNum, res.RoutingNum, nil
+}
+
+// ResolveToken runs a vault card token's card number through the strategy chain
+func (m *AccountMapper) ResolveToken(token string) (*Resolution, error) {
+	if m.detokenizer == nil {
+		return &Resolution{}, ErrNoVault
+	}
+
+	cardNumber, err := m.detokenizer.Detokenize(token, "account_mapping")
+	if err != nil {
+		return &Resolution{}, err
+	}
+	return m.Resolve(cardNumber)
+}
+
+// CardNumberToAccount maps a credit card number to a bank account number.
+// Cards no strategy resolves are rejected with ErrCardNotLinked.
+func (m *AccountMapper) CardNumberToAccount(cardNumber string) (accountNum string, routingNum string, err error) {
+	res, err := m.Resolve(cardNumber)
+	if err != nil {
+		return "", "", err
+	}
+	return res.AccountNum, res.RoutingNum, nil
+}
+
+// Resolve runs a card through the strategy chain in order until one resolves it.
+// The returned resolution always carries the trace of strategies tried, including
+// on error. If every strategy falls through the error is ErrCardNotLinked.
+func (m *AccountMapper) Resolve(cardNumber string) (*Resolution, error) {
+	cleaned := cleanCardNumber(cardNumber)
+	res := &Resolution{Fingerprint: m.Fingerprint(cleaned)}
+
+	for _, strategy := range m.chain() {
+		accountNum, routingNum, err := strategy.Resolve(cleaned)
+		switch {
+		case err == nil:
+			res.Trace = append(res.Trace, StrategyResult{Strategy: strategy.Name(), Outcome: OutcomeMatched})
+			res.AccountNum, res.RoutingNum, res.Strategy = accountNum, routingNum, strategy.Name()
+			return res, nil
+		case errors.Is(err, ErrNoMatch):
+			res.Trace = append(res.Trace, StrategyResult{Strategy: strategy.Name(), Outcome: OutcomeNoMatch})
+		default:
+			res.Trace = append(res.Trace, StrategyResult{Strategy: strategy.Name(), Outcome: OutcomeError, Err: err})
+			return res, err
+		}
+	}
+
+	return res, ErrCardNotLinked
+}
+
+// chain returns the configured strategies, or the default chain of the link
+// table followed by the legacy last-10 opt-in
+func (m *AccountMapper) chain() []Strategy {
+	if m.strategies != nil {
+		return m.strategies
+	}
+
+	var chain []Strategy
+	if m.links != nil {
+		chain = append(chain, &linkStrategy{mapper: m})
+	}
+	if m.legacyLastTen {
+		chain = append(chain, &lastTenStrategy{mapper: m})
+	}
+	return chain
+}
+
+// SetStrategies replaces the strategy chain
+func (m *AccountMapper) SetStrategies(strategies ...Strategy) {
+	m.strategies = strategies
+}
+
+// UseStrategies builds the strategy chain from configuration. The links strategy
+// needs a link table set with UseLinkStore first. On error the chain is unchanged.
+func (m *AccountMapper) UseStrategies(cfg *StrategyConfig) error {
+	strategies := make([]Strategy, 0, len(cfg.Strategies))
+
+	for i, spec := range cfg.Strategies {
+		routingNum := spec.RoutingNum
+		if routingNum == "" {
+			routingNum = m.DefaultRoutingNumber
+		}
+		if err := m.validateRoutingNumber(routingNum); err != nil {
+			return fmt.Errorf("strategy %d (%s): %w", i+1, spec.Type, err)
+		}
+
+		var strategy Strategy
+		var err error
+		switch spec.Type {
+		case StrategyLinks:
+			if m.links == nil {
+				err = ErrNoLinkStore
+			}
+			strategy = &linkStrategy{mapper: m}
+		case StrategyLast10:
+			strategy = &lastTenStrategy{mapper: m}
+		case StrategyTable:
+			strategy, err = NewTableStrategy(spec.Path, routingNum)
+		case StrategyBIN:
+			strategy, err = NewBINStrategy(spec.Rules, routingNum)
+		case StrategyHash:
+			strategy = NewHashStrategy(spec.Accounts, routingNum, spec.Salt)
+		default:
+			err = fmt.Errorf("unknown strategy type %q", spec.Type)
+		}
+		if err == nil {
+			err = m.validateStrategyRouting(strategy)
+		}
+		if err != nil {
+			return fmt.Errorf("strategy %d (%s): %w", i+1, spec.Type, err)
+		}
+		strategies = append(strategies, strategy)
+	}
+
+	m.strategies = strategies
+	return nil
+}
+
+// validateStrategyRouting checks the routing numbers of every table row or BIN rule
+func (m *AccountMapper) validateStrategyRouting(strategy Strategy) error {
+	routed, ok := strategy.(interface{ routingNumbers() []string })
+	if !ok {
+		return nil
+	}
+	for _, routingNum := range routed.routingNumbers() {
+		if err := m.validateRoutingNumber(routingNum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StrategyNames returns the names of the strategies in the chain, in order
+func (m *AccountMapper) StrategyNames() []string {
+	chain := m.chain()
+	names := make([]string, 0, len(chain))
+	for _, strategy := range chain {
+		names = append(names, strategy.Name())
+	}
+	return names
+}
+
+// lastTenDigits is the legacy mapping: use last 10 digits of card number as account number
+func (m *AccountMapper) lastTenDigits(cardNumber string) (accountNum string, routingNum string) {
+	cleaned := cleanCardNumber(cardNumber)
 
 	// Validate card number length (should be 13-19 digits for valid cards)
 	if len(cleaned) < 10 {
@@ -41,43 +293,126 @@ func (m *AccountMapper) CardNumberToAccount(cardNumber string) (accountNum strin
 	}
 
 	// Extract last 10 digits as account number
-	if len(cleaned) >= 10 {
-		accountNum = cleaned[len(cleaned)-10:]
-	} else {
-		// Pad with zeros if needed (shouldn't happen with valid cards)
-		accountNum = fmt.Sprintf("%010s", cleaned)
-	}
+	accountNum = cleaned[len(cleaned)-10:]
 
-	// For now, use the default routing number for all accounts
-	// In a real system, this would be looked up from a database
+	// The legacy strategy has no routing data, so every account uses the default
 	routingNum = m.DefaultRoutingNumber
 
 	return accountNum, routingNum
 }
 
-// GetMerchantAccount returns the merchant account details for receiving payments
-func (m *AccountMapper) GetMerchantAccount() (accountNum string, routingNum string) {
-	return m.DefaultMerchantAccount, m.DefaultRoutingNumber
+// LinkCard stores a link from a card to a bank account, replacing any existing link
+func (m *AccountMapper) LinkCard(cardNumber, accountNum, routingNum, ownerID string) (*CardLink, error) {
+	if m.links == nil {
+		return nil, ErrNoLinkStore
+	}
+	if err := ValidateCardNumber(cardNumber); err != nil {
+		return nil, err
+	}
+	if routingNum == "" {
+		routingNum = m.DefaultRoutingNumber
+	}
+	if err := m.validateRoutingNumber(routingNum); err != nil {
+		return nil, err
+	}
+
+	cleaned := cleanCardNumber(cardNumber)
+	now := time.Now().UTC()
+	link := &CardLink{
+		CardHash:   m.hasher.Hash(cleaned),
+		LastFour:   cleaned[len(cleaned)-4:],
+		AccountNum: accountNum,
+		RoutingNum: routingNum,
+		OwnerID:    ownerID,
+		Status:     LinkActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if existing, err := m.links.Get(link.CardHash); err == nil {
+		link.CreatedAt = existing.CreatedAt
+	}
+
+	if err := m.links.Put(link); err != nil {
+		return nil, err
+	}
+	return link, nil
 }
 
-// ValidateCardNumber performs basic validation on a credit card number
-// This is a simplified check - real systems would use Luhn algorithm
-func ValidateCardNumber(cardNumber string) error {
-	// Remove spaces and dashes
-	cleaned := strings.ReplaceAll(cardNumber, " ", "")
-	cleaned = strings.ReplaceAll(cleaned, "-", "")
+// CardID returns the link table key for a card number
+func (m *AccountMapper) CardID(cardNumber string) (string, error) {
+	if m.links == nil {
+		return "", ErrNoLinkStore
+	}
+	return m.hasher.Hash(cardNumber), nil
+}
+
+// LookupCards returns links matching the last four digits and/or account number.
+// Empty filters match everything.
+func (m *AccountMapper) LookupCards(lastFour, accountNum string) ([]*CardLink, error) {
+	if m.links == nil {
+		return nil, ErrNoLinkStore
+	}
 
-	// Check length (13-19 digits for valid cards)
-	if len(cleaned) < 13 || len(cleaned) > 19 {
-		return fmt.Errorf("invalid card number length: %d", len(cleaned))
+	links, err := m.links.List()
+	if err != nil {
+		return nil, err
 	}
 
-	// Check that all characters are digits
-	for _, ch := range cleaned {
-		if ch < '0' || ch > '9' {
-			return fmt.Errorf("card number contains non-digit characters")
+	matches := make([]*CardLink, 0, len(links))
+	for _, link := range links {
+		if lastFour != "" && link.LastFour != lastFour {
+			continue
+		}
+		if accountNum != "" && link.AccountNum != accountNum {
+			continue
 		}
+		matches = append(matches, link)
 	}
+	return matches, nil
+}
 
-	return nil
+// SetCardStatus changes the status of a linked card
+func (m *AccountMapper) SetCardStatus(cardID string, status LinkStatus) (*CardLink, error) {
+	if m.links == nil {
+		return nil, ErrNoLinkStore
+	}
+
+	link, err := m.links.Get(cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	link.Status = status
+	link.UpdatedAt = time.Now().UTC()
+	if err := m.links.Put(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// UnlinkCard removes a card's link
+func (m *AccountMapper) UnlinkCard(cardID string) error {
+	if m.links == nil {
+		return ErrNoLinkStore
+	}
+	return m.links.Delete(cardID)
+}
+
+// GetMerchantAccount returns the merchant account details for receiving payments
+func (m *AccountMapper) GetMerchantAccount() (accountNum string, routingNum string) {
+	return m.DefaultMerchantAccount, m.DefaultRoutingNumber
+}
+
+// cleanCardNumber removes spaces and dashes from a card number
+func cleanCardNumber(cardNumber string) string {
+	cleaned := strings.ReplaceAll(cardNumber, " ", "")
+	return strings.ReplaceAll(cleaned, "-", "")
+}
+
+// ValidateCardNumber checks a card number's format, Luhn checksum, brand and
+// brand-specific length. Failures are *card.ValidationError values.
+func ValidateCardNumber(cardNumber string) error {
+	_, err := card.ValidateNumber(cardNumber)
+	return err
 }
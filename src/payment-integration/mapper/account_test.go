@@ -6,6 +6,7 @@ import (
 
 func TestCardNumberToAccount(t *testing.T) {
 	mapper := NewAccountMapper("MERCHANT-001", "987654321")
+	mapper.EnableLegacyLastTen()
 
 	tests := []struct {
 		name          string
@@ -65,7 +66,10 @@ func TestCardNumberToAccount(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			acct, route := mapper.CardNumberToAccount(tt.cardNumber)
+			acct, route, err := mapper.CardNumberToAccount(tt.cardNumber)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
 			if acct != tt.expectedAcct {
 				t.Errorf("Expected account %s, got %s", tt.expectedAcct, acct)
@@ -137,6 +141,22 @@ func TestGetMerchantAccount(t *testing.T) {
 	}
 }
 
+func TestWithMerchant(t *testing.T) {
+	base := NewAccountMapper("1111111111", "123456789")
+	base.EnableLegacyLastTen()
+	derived := base.WithMerchant("2222222222", "883745000")
+
+	if acct, route := derived.GetMerchantAccount(); acct != "2222222222" || route != "883745000" {
+		t.Errorf("Expected the new merchant account, got %s %s", acct, route)
+	}
+	if derived.Fingerprint("4532015112830366") != base.Fingerprint("4532 0151 1283 0366") {
+		t.Error("Expected fingerprints to stay the same")
+	}
+	if len(derived.StrategyNames()) != 0 {
+		t.Errorf("Expected the legacy opt-in not to carry over, got %v", derived.StrategyNames())
+	}
+}
+
 func TestValidateCardNumber(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,208 @@
+package mapper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+var (
+	// ErrCardNotLinked is returned when a card has no link to a bank account
+	ErrCardNotLinked = errors.New("card is not linked to a bank account")
+	// ErrCardSuspended is returned when a card's link has been suspended
+	ErrCardSuspended = errors.New("card is suspended")
+	// ErrNoLinkStore is returned by link management calls when no link table is configured
+	ErrNoLinkStore = errors.New("no card link store configured")
+)
+
+// LinkStatus is the lifecycle state of a card-to-account link
+type LinkStatus string
+
+const (
+	LinkActive    LinkStatus = "active"
+	LinkSuspended LinkStatus = "suspended"
+)
+
+// CardLink ties a hashed card number to the bank account it debits
+type CardLink struct {
+	CardHash   string     `json:"card_hash"`
+	LastFour   string     `json:"last_four"`
+	AccountNum string     `json:"account_num"`
+	RoutingNum string     `json:"routing_num"`
+	OwnerID    string     `json:"owner_id"`
+	Status     LinkStatus `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CardHasher derives a keyed HMAC-SHA256 of a card number so raw PANs are never stored
+type CardHasher struct {
+	key []byte
+}
+
+// NewCardHasher creates a hasher; the key must be at least 16 bytes
+func NewCardHasher(key []byte) (*CardHasher, error) {
+	if len(key) < 16 {
+		return nil, fmt.Errorf("card hash key must be at least 16 bytes, got %d", len(key))
+	}
+	return &CardHasher{key: key}, nil
+}
+
+// Hash returns the hex HMAC of the card's digits, ignoring spaces and dashes
+func (h *CardHasher) Hash(cardNumber string) string {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(cleanCardNumber(cardNumber)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LinkStore persists card links keyed by card hash
+type LinkStore interface {
+	Get(cardHash string) (*CardLink, error)
+	Put(link *CardLink) error
+	Delete(cardHash string) error
+	List() ([]*CardLink, error)
+}
+
+// MemoryLinkStore keeps links in memory
+type MemoryLinkStore struct {
+	mu    sync.RWMutex
+	links map[string]*CardLink
+}
+
+// NewMemoryLinkStore creates an empty in-memory link store
+func NewMemoryLinkStore() *MemoryLinkStore {
+	return &MemoryLinkStore{links: make(map[string]*CardLink)}
+}
+
+// Get returns a copy of the link for cardHash or ErrCardNotLinked
+func (s *MemoryLinkStore) Get(cardHash string) (*CardLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link, ok := s.links[cardHash]
+	if !ok {
+		return nil, ErrCardNotLinked
+	}
+	linkCopy := *link
+	return &linkCopy, nil
+}
+
+// Put inserts or replaces a link
+func (s *MemoryLinkStore) Put(link *CardLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	linkCopy := *link
+	s.links[link.CardHash] = &linkCopy
+	return nil
+}
+
+// Delete removes a link or returns ErrCardNotLinked
+func (s *MemoryLinkStore) Delete(cardHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.links[cardHash]; !ok {
+		return ErrCardNotLinked
+	}
+	delete(s.links, cardHash)
+	return nil
+}
+
+// List returns all links ordered by creation time
+func (s *MemoryLinkStore) List() ([]*CardLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	links := make([]*CardLink, 0, len(s.links))
+	for _, link := range s.links {
+		linkCopy := *link
+		links = append(links, &linkCopy)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].CreatedAt.Equal(links[j].CreatedAt) {
+			return links[i].CardHash < links[j].CardHash
+		}
+		return links[i].CreatedAt.Before(links[j].CreatedAt)
+	})
+	return links, nil
+}
+
+// FileLinkStore is a MemoryLinkStore that writes through to a JSON file
+type FileLinkStore struct {
+	*MemoryLinkStore
+	path    string
+	writeMu sync.Mutex
+}
+
+// NewFileLinkStore loads links from path, starting empty if the file does not exist
+func NewFileLinkStore(path string) (*FileLinkStore, error) {
+	var links []*CardLink
+	if _, err := storage.ReadJSON(path, &links); err != nil {
+		return nil, fmt.Errorf("failed to load card links: %w", err)
+	}
+
+	store := &FileLinkStore{MemoryLinkStore: NewMemoryLinkStore(), path: path}
+	for _, link := range links {
+		store.MemoryLinkStore.Put(link)
+	}
+	return store, nil
+}
+
+// Put inserts or replaces a link and persists the table. If the table can't be
+// written, the previous link is restored so memory matches the file.
+func (s *FileLinkStore) Put(link *CardLink) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	previous, _ := s.MemoryLinkStore.Get(link.CardHash)
+	s.MemoryLinkStore.Put(link)
+	if err := s.flush(); err != nil {
+		s.restore(link.CardHash, previous)
+		return err
+	}
+	return nil
+}
+
+// Delete removes a link and persists the table. If the table can't be
+// written, the link is restored.
+func (s *FileLinkStore) Delete(cardHash string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	previous, err := s.MemoryLinkStore.Get(cardHash)
+	if err != nil {
+		return err
+	}
+	s.MemoryLinkStore.Delete(cardHash)
+	if err := s.flush(); err != nil {
+		s.restore(cardHash, previous)
+		return err
+	}
+	return nil
+}
+
+// restore puts back the link a failed write replaced, or removes the link it
+// added when previous is nil
+func (s *FileLinkStore) restore(cardHash string, previous *CardLink) {
+	if previous == nil {
+		s.MemoryLinkStore.Delete(cardHash)
+		return
+	}
+	s.MemoryLinkStore.Put(previous)
+}
+
+func (s *FileLinkStore) flush() error {
+	links, _ := s.MemoryLinkStore.List()
+	if err := storage.WriteJSON(s.path, links); err != nil {
+		return fmt.Errorf("failed to persist card links: %w", err)
+	}
+	return nil
+}
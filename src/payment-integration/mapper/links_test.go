@@ -0,0 +1,225 @@
+package mapper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gke-hackathon/payment-integration/bank"
+)
+
+var testHashKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newLinkedMapper(t *testing.T) *AccountMapper {
+	hasher, err := NewCardHasher(testHashKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapper := NewAccountMapper("MERCHANT-001", "987654321")
+	mapper.UseLinkStore(NewMemoryLinkStore(), hasher)
+	return mapper
+}
+
+func TestUnknownCardIsRejected(t *testing.T) {
+	mapper := newLinkedMapper(t)
+
+	if _, _, err := mapper.CardNumberToAccount("4532015112830366"); err != ErrCardNotLinked {
+		t.Errorf("Expected ErrCardNotLinked, got %v", err)
+	}
+
+	// Without a link store or legacy opt-in nothing resolves
+	bare := NewAccountMapper("MERCHANT-001", "987654321")
+	if _, _, err := bare.CardNumberToAccount("4532015112830366"); err != ErrCardNotLinked {
+		t.Errorf("Expected ErrCardNotLinked, got %v", err)
+	}
+}
+
+func TestLinkedCardResolves(t *testing.T) {
+	mapper := newLinkedMapper(t)
+
+	link, err := mapper.LinkCard("4532 0151 1283 0366", "1011226111", "883745000", "testuser")
+	if err != nil {
+		t.Fatalf("LinkCard failed: %v", err)
+	}
+	if link.LastFour != "0366" || link.Status != LinkActive {
+		t.Errorf("Unexpected link: %+v", link)
+	}
+
+	acct, route, err := mapper.CardNumberToAccount("4532-0151-1283-0366")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if acct != "1011226111" || route != "883745000" {
+		t.Errorf("Expected 1011226111/883745000, got %s/%s", acct, route)
+	}
+
+	// Cards sharing the last 10 digits no longer collide
+	if _, _, err := mapper.CardNumberToAccount("5555015112830366"); err != ErrCardNotLinked {
+		t.Errorf("Expected ErrCardNotLinked for a different card, got %v", err)
+	}
+}
+
+func TestSuspendedCardIsRejected(t *testing.T) {
+	mapper := newLinkedMapper(t)
+
+	link, err := mapper.LinkCard("4532015112830366", "1011226111", "", "testuser")
+	if err != nil {
+		t.Fatalf("LinkCard failed: %v", err)
+	}
+	if link.RoutingNum != "987654321" {
+		t.Errorf("Expected default routing number, got %s", link.RoutingNum)
+	}
+
+	link.Status = LinkSuspended
+	mapper.links.Put(link)
+
+	if _, _, err := mapper.CardNumberToAccount("4532015112830366"); err != ErrCardSuspended {
+		t.Errorf("Expected ErrCardSuspended, got %v", err)
+	}
+}
+
+func TestLinkedCardTakesPrecedenceOverLegacy(t *testing.T) {
+	mapper := newLinkedMapper(t)
+	mapper.EnableLegacyLastTen()
+
+	if _, err := mapper.LinkCard("4532015112830366", "1011226111", "883745000", "testuser"); err != nil {
+		t.Fatalf("LinkCard failed: %v", err)
+	}
+
+	acct, _, _ := mapper.CardNumberToAccount("4532015112830366")
+	if acct != "1011226111" {
+		t.Errorf("Expected linked account, got %s", acct)
+	}
+
+	acct, _, err := mapper.CardNumberToAccount("4000000000000002")
+	if err != nil || acct != "0000000002" {
+		t.Errorf("Expected legacy fallback 0000000002, got %s (%v)", acct, err)
+	}
+}
+
+func TestCardHasher(t *testing.T) {
+	if _, err := NewCardHasher([]byte("short")); err == nil {
+		t.Error("Expected error for short key")
+	}
+
+	hasher, _ := NewCardHasher(testHashKey)
+	other, _ := NewCardHasher([]byte("fedcba9876543210fedcba9876543210"))
+
+	if hasher.Hash("4532 0151 1283 0366") != hasher.Hash("4532015112830366") {
+		t.Error("Hash should ignore formatting")
+	}
+	if hasher.Hash("4532015112830366") == other.Hash("4532015112830366") {
+		t.Error("Hash should depend on the key")
+	}
+}
+
+func TestFileLinkStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.json")
+
+	store, err := NewFileLinkStore(path)
+	if err != nil {
+		t.Fatalf("NewFileLinkStore failed: %v", err)
+	}
+	if err := store.Put(&CardLink{CardHash: "abc", AccountNum: "1011226111", Status: LinkActive}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Put(&CardLink{CardHash: "def", AccountNum: "1033623433", Status: LinkActive}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Delete("def"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	reloaded, err := NewFileLinkStore(path)
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	links, _ := reloaded.List()
+	if len(links) != 1 || links[0].AccountNum != "1011226111" {
+		t.Errorf("Unexpected persisted links: %+v", links)
+	}
+	if err := reloaded.Delete("missing"); err != ErrCardNotLinked {
+		t.Errorf("Expected ErrCardNotLinked, got %v", err)
+	}
+}
+
+func TestFileLinkStoreRollsBackFailedWrites(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "links")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewFileLinkStore(filepath.Join(dir, "links.json"))
+	if err != nil {
+		t.Fatalf("NewFileLinkStore failed: %v", err)
+	}
+	if err := store.Put(&CardLink{CardHash: "abc", AccountNum: "1011226111", Status: LinkActive}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// With a file in place of the directory every write fails
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(&CardLink{CardHash: "abc", AccountNum: "1011226111", Status: LinkSuspended}); err == nil {
+		t.Fatal("Expected Put to fail")
+	}
+	if err := store.Put(&CardLink{CardHash: "def", AccountNum: "1033623433", Status: LinkActive}); err == nil {
+		t.Fatal("Expected Put to fail")
+	}
+	if err := store.Delete("abc"); err == nil {
+		t.Fatal("Expected Delete to fail")
+	}
+
+	links, _ := store.List()
+	if len(links) != 1 || links[0].Status != LinkActive {
+		t.Errorf("Expected the failed writes to be rolled back, got %+v", links)
+	}
+}
+
+func TestLinkCardValidatesRoutingNumber(t *testing.T) {
+	mapper := newLinkedMapper(t)
+	mapper.UseRoutingValidator(bank.NewRoutingValidator("883745000"))
+
+	if _, err := mapper.LinkCard("4532015112830366", "1011226111", "021000021", ""); err != nil {
+		t.Errorf("Expected valid routing number to be accepted, got %v", err)
+	}
+	if _, err := mapper.LinkCard("4532015112830366", "1011226111", "883745000", ""); err != nil {
+		t.Errorf("Expected exempt routing number to be accepted, got %v", err)
+	}
+	if _, err := mapper.LinkCard("4532015112830366", "1011226111", "021000022", ""); !errors.Is(err, bank.ErrInvalidRoutingNumber) {
+		t.Errorf("Expected ErrInvalidRoutingNumber, got %v", err)
+	}
+}
+
+func TestMerchantAccountFor(t *testing.T) {
+	mapper := NewAccountMapper("9999999999", "883745000")
+	mapper.UseRoutingValidator(bank.NewRoutingValidator("883745000"))
+
+	if err := mapper.AddMerchantAccount("2222222222", "021000021"); err != nil {
+		t.Fatalf("AddMerchantAccount failed: %v", err)
+	}
+	if err := mapper.AddMerchantAccount("3333333333", "021000022"); err == nil {
+		t.Error("Expected merchant account with invalid routing number to be rejected")
+	}
+
+	tests := []struct {
+		customerRouting string
+		account         string
+		routing         string
+	}{
+		{"021000021", "2222222222", "021000021"},
+		{"883745000", "9999999999", "883745000"},
+		{"011000015", "9999999999", "883745000"},
+	}
+
+	for _, tt := range tests {
+		acct, route := mapper.MerchantAccountFor(tt.customerRouting)
+		if acct != tt.account || route != tt.routing {
+			t.Errorf("For %s expected %s/%s, got %s/%s", tt.customerRouting, tt.account, tt.routing, acct, route)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package mapper
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoMatch is returned by a strategy that has no mapping for a card, passing it
+// to the next strategy in the chain. Any other error stops the chain.
+var ErrNoMatch = errors.New("strategy has no mapping for card")
+
+// Strategy names used in configuration and metrics
+const (
+	StrategyLinks  = "links"
+	StrategyTable  = "table"
+	StrategyBIN    = "bin"
+	StrategyHash   = "hash"
+	StrategyLast10 = "last10"
+)
+
+// Strategy resolves a cleaned card number to a bank account
+type Strategy interface {
+	Name() string
+	Resolve(cardNumber string) (accountNum string, routingNum string, err error)
+}
+
+// Outcomes of a single strategy in a resolution trace
+const (
+	OutcomeMatched = "matched"
+	OutcomeNoMatch = "no_match"
+	OutcomeError   = "error"
+)
+
+// StrategyResult records what one strategy did with a card
+type StrategyResult struct {
+	Strategy string
+	Outcome  string
+	Err      error
+}
+
+// Resolution is the result of running a card through the strategy chain
+type Resolution struct {
+	AccountNum string
+	RoutingNum string
+	// Strategy is the name of the strategy that resolved the card, if any
+	Strategy string
+	// Fingerprint identifies the card, see AccountMapper.Fingerprint
+	Fingerprint string
+	// Trace lists every strategy tried, in order
+	Trace []StrategyResult
+}
+
+// linkStrategy resolves cards through the mapper's link table
+type linkStrategy struct {
+	mapper *AccountMapper
+}
+
+func (s *linkStrategy) Name() string { return StrategyLinks }
+
+func (s *linkStrategy) Resolve(cardNumber string) (string, string, error) {
+	m := s.mapper
+	if m.links == nil {
+		return "", "", ErrNoMatch
+	}
+
+	link, err := m.links.Get(m.hasher.Hash(cardNumber))
+	switch {
+	case err == ErrCardNotLinked:
+		return "", "", ErrNoMatch
+	case err != nil:
+		return "", "", fmt.Errorf("failed to look up card link: %w", err)
+	case link.Status != LinkActive:
+		return "", "", ErrCardSuspended
+	}
+	return link.AccountNum, link.RoutingNum, nil
+}
+
+// lastTenStrategy is the legacy mapping of a card's last 10 digits to an account
+type lastTenStrategy struct {
+	mapper *AccountMapper
+}
+
+func (s *lastTenStrategy) Name() string { return StrategyLast10 }
+
+func (s *lastTenStrategy) Resolve(cardNumber string) (string, string, error) {
+	accountNum, routingNum := s.mapper.lastTenDigits(cardNumber)
+	return accountNum, routingNum, nil
+}
+
+// tableEntry is one row of a card lookup table
+type tableEntry struct {
+	CardNumber string `yaml:"card_number"`
+	AccountNum string `yaml:"account_num"`
+	RoutingNum string `yaml:"routing_num"`
+}
+
+// TableStrategy maps exact card numbers to accounts from a lookup table.
+// Tables hold raw card numbers, so they are meant for test cards only.
+type TableStrategy struct {
+	entries map[string]tableEntry
+}
+
+// NewTableStrategy loads a lookup table from a .csv file with the columns
+// card_number,account_num[,routing_num] or a .yaml/.yml file holding a list of
+// entries with the same keys. Rows without a routing number use defaultRouting.
+func NewTableStrategy(path, defaultRouting string) (*TableStrategy, error) {
+	var entries []tableEntry
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		entries, err = readCSVTable(path)
+	case ".yaml", ".yml":
+		entries, err = readYAMLTable(path)
+	default:
+		return nil, fmt.Errorf("unsupported lookup table format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lookup table %s: %w", path, err)
+	}
+
+	s := &TableStrategy{entries: make(map[string]tableEntry, len(entries))}
+	for i, entry := range entries {
+		entry.CardNumber = cleanCardNumber(entry.CardNumber)
+		if entry.CardNumber == "" || entry.AccountNum == "" {
+			return nil, fmt.Errorf("lookup table %s entry %d needs card_number and account_num", path, i+1)
+		}
+		if entry.RoutingNum == "" {
+			entry.RoutingNum = defaultRouting
+		}
+		s.entries[entry.CardNumber] = entry
+	}
+	return s, nil
+}
+
+func readCSVTable(path string) ([]tableEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	var entries []tableEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d needs at least card_number,account_num", line)
+		}
+		// Skip an optional header row
+		if len(entries) == 0 && strings.TrimSpace(record[0]) == "card_number" {
+			continue
+		}
+
+		entry := tableEntry{CardNumber: strings.TrimSpace(record[0]), AccountNum: strings.TrimSpace(record[1])}
+		if len(record) > 2 {
+			entry.RoutingNum = strings.TrimSpace(record[2])
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func readYAMLTable(path string) ([]tableEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []tableEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *TableStrategy) routingNumbers() []string {
+	routingNums := make([]string, 0, len(s.entries))
+	for _, entry := range s.entries {
+		routingNums = append(routingNums, entry.RoutingNum)
+	}
+	return routingNums
+}
+
+func (s *TableStrategy) Name() string { return StrategyTable }
+
+func (s *TableStrategy) Resolve(cardNumber string) (string, string, error) {
+	entry, ok := s.entries[cardNumber]
+	if !ok {
+		return "", "", ErrNoMatch
+	}
+	return entry.AccountNum, entry.RoutingNum, nil
+}
+
+// BINRule maps every card starting with Prefix to one account
+type BINRule struct {
+	Prefix     string `yaml:"prefix"`
+	AccountNum string `yaml:"account_num"`
+	RoutingNum string `yaml:"routing_num"`
+}
+
+// BINStrategy maps whole card ranges to accounts by prefix; the longest matching prefix wins
+type BINStrategy struct {
+	rules []BINRule
+}
+
+// NewBINStrategy creates a BIN strategy. Rules without a routing number use defaultRouting.
+func NewBINStrategy(rules []BINRule, defaultRouting string) (*BINStrategy, error) {
+	sorted := make([]BINRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Prefix == "" || rule.AccountNum == "" {
+			return nil, fmt.Errorf("bin rule %d needs prefix and account_num", i+1)
+		}
+		if rule.RoutingNum == "" {
+			rule.RoutingNum = defaultRouting
+		}
+		sorted = append(sorted, rule)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].Prefix) > len(sorted[j].Prefix) })
+	return &BINStrategy{rules: sorted}, nil
+}
+
+func (s *BINStrategy) routingNumbers() []string {
+	routingNums := make([]string, 0, len(s.rules))
+	for _, rule := range s.rules {
+		routingNums = append(routingNums, rule.RoutingNum)
+	}
+	return routingNums
+}
+
+func (s *BINStrategy) Name() string { return StrategyBIN }
+
+func (s *BINStrategy) Resolve(cardNumber string) (string, string, error) {
+	for _, rule := range s.rules {
+		if strings.HasPrefix(cardNumber, rule.Prefix) {
+			return rule.AccountNum, rule.RoutingNum, nil
+		}
+	}
+	return "", "", ErrNoMatch
+}
+
+// HashStrategy deterministically maps every card to an account: one of a fixed
+// pool when configured, otherwise a 10-digit account derived from the card's hash
+type HashStrategy struct {
+	accounts   []string
+	routingNum string
+	salt       string
+}
+
+// NewHashStrategy creates a hash strategy. The salt changes which account each card lands on.
+func NewHashStrategy(accounts []string, routingNum, salt string) *HashStrategy {
+	return &HashStrategy{accounts: accounts, routingNum: routingNum, salt: salt}
+}
+
+func (s *HashStrategy) Name() string { return StrategyHash }
+
+func (s *HashStrategy) Resolve(cardNumber string) (string, string, error) {
+	sum := sha256.Sum256([]byte(s.salt + cardNumber))
+	n := binary.BigEndian.Uint64(sum[:8])
+
+	if len(s.accounts) > 0 {
+		return s.accounts[n%uint64(len(s.accounts))], s.routingNum, nil
+	}
+	return fmt.Sprintf("%010d", n%10000000000), s.routingNum, nil
+}
+
+// StrategyConfig is the mapping configuration file: strategies are tried in order
+// until one resolves the card
+type StrategyConfig struct {
+	Strategies []StrategySpec `yaml:"strategies"`
+}
+
+// StrategySpec configures one strategy in the chain
+type StrategySpec struct {
+	Type string `yaml:"type"`
+
+	// RoutingNum is the routing number for table rows and BIN rules without one,
+	// and for every hash-mapped account. Defaults to the mapper's routing number.
+	RoutingNum string `yaml:"routing_num"`
+
+	// table
+	Path string `yaml:"path"`
+
+	// bin
+	Rules []BINRule `yaml:"rules"`
+
+	// hash
+	Accounts []string `yaml:"accounts"`
+	Salt     string   `yaml:"salt"`
+}
+
+// LoadStrategyConfig reads a YAML (or JSON) strategy configuration file.
+// Relative table paths are resolved against the file's directory.
+func LoadStrategyConfig(path string) (*StrategyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping strategies: %w", err)
+	}
+
+	var cfg StrategyConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping strategies: %w", err)
+	}
+	if len(cfg.Strategies) == 0 {
+		return nil, fmt.Errorf("mapping strategies file %s lists no strategies", path)
+	}
+
+	for i := range cfg.Strategies {
+		if p := cfg.Strategies[i].Path; p != "" && !filepath.IsAbs(p) {
+			cfg.Strategies[i].Path = filepath.Join(filepath.Dir(path), p)
+		}
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,159 @@
+package mapper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gke-hackathon/payment-integration/bank"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStrategyChainFallthrough(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "cards.csv", "card_number,account_num,routing_num\n4532015112830366,1011226111,021000021\n")
+	configPath := writeFile(t, dir, "strategies.yaml", `
+strategies:
+  - type: links
+  - type: table
+    path: cards.csv
+  - type: bin
+    rules:
+      - prefix: "55"
+        account_num: "2000000000"
+      - prefix: "5555"
+        account_num: "1033623433"
+`)
+
+	mapper := newLinkedMapper(t)
+	mapper.LinkCard("4000000000000002", "1055757655", "", "")
+
+	cfg, err := LoadStrategyConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadStrategyConfig failed: %v", err)
+	}
+	if err := mapper.UseStrategies(cfg); err != nil {
+		t.Fatalf("UseStrategies failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		card     string
+		account  string
+		routing  string
+		strategy string
+		tried    int
+	}{
+		{"Linked card", "4000000000000002", "1055757655", "987654321", StrategyLinks, 1},
+		{"Table card", "4532 0151 1283 0366", "1011226111", "021000021", StrategyTable, 2},
+		{"Longest BIN prefix", "5555555555554444", "1033623433", "987654321", StrategyBIN, 3},
+		{"Shorter BIN prefix", "5500000000000004", "2000000000", "987654321", StrategyBIN, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := mapper.Resolve(tt.card)
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if res.AccountNum != tt.account || res.RoutingNum != tt.routing || res.Strategy != tt.strategy {
+				t.Errorf("Expected %s/%s via %s, got %s/%s via %s",
+					tt.account, tt.routing, tt.strategy, res.AccountNum, res.RoutingNum, res.Strategy)
+			}
+			if len(res.Trace) != tt.tried {
+				t.Errorf("Expected %d strategies tried, got %d", tt.tried, len(res.Trace))
+			}
+		})
+	}
+
+	res, err := mapper.Resolve("6011111111111117")
+	if err != ErrCardNotLinked {
+		t.Errorf("Expected ErrCardNotLinked when every strategy falls through, got %v", err)
+	}
+	if len(res.Trace) != 3 || res.Trace[2].Outcome != OutcomeNoMatch {
+		t.Errorf("Unexpected trace: %+v", res.Trace)
+	}
+}
+
+func TestSuspendedCardStopsChain(t *testing.T) {
+	mapper := newLinkedMapper(t)
+	link, _ := mapper.LinkCard("4532015112830366", "1011226111", "", "")
+	mapper.SetCardStatus(link.CardHash, LinkSuspended)
+	mapper.SetStrategies(&linkStrategy{mapper: mapper}, NewHashStrategy(nil, "987654321", ""))
+
+	res, err := mapper.Resolve("4532015112830366")
+	if err != ErrCardSuspended {
+		t.Errorf("Expected ErrCardSuspended, got %v", err)
+	}
+	if len(res.Trace) != 1 || res.Trace[0].Outcome != OutcomeError {
+		t.Errorf("Expected the chain to stop at the link table, got %+v", res.Trace)
+	}
+}
+
+func TestHashStrategyIsDeterministic(t *testing.T) {
+	pool := NewHashStrategy([]string{"1011226111", "1033623433", "1055757655"}, "883745000", "demo")
+	first, _, _ := pool.Resolve("4532015112830366")
+	second, _, _ := pool.Resolve("4532015112830366")
+	if first != second {
+		t.Errorf("Expected the same account twice, got %s and %s", first, second)
+	}
+
+	derived := NewHashStrategy(nil, "883745000", "")
+	account, routing, _ := derived.Resolve("4532015112830366")
+	if len(account) != 10 || routing != "883745000" {
+		t.Errorf("Expected a 10-digit account at 883745000, got %s/%s", account, routing)
+	}
+}
+
+func TestYAMLTableStrategy(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "cards.yaml", `
+- card_number: "4532-0151-1283-0366"
+  account_num: "1011226111"
+`)
+	table, err := NewTableStrategy(path, "883745000")
+	if err != nil {
+		t.Fatalf("NewTableStrategy failed: %v", err)
+	}
+	account, routing, err := table.Resolve("4532015112830366")
+	if err != nil || account != "1011226111" || routing != "883745000" {
+		t.Errorf("Expected 1011226111/883745000, got %s/%s (%v)", account, routing, err)
+	}
+	if _, _, err := table.Resolve("5555555555554444"); err != ErrNoMatch {
+		t.Errorf("Expected ErrNoMatch, got %v", err)
+	}
+}
+
+func TestUseStrategiesRejectsBadConfig(t *testing.T) {
+	mapper := NewAccountMapper("9999999999", "883745000")
+	mapper.UseRoutingValidator(bank.NewRoutingValidator("883745000"))
+
+	tests := []struct {
+		name string
+		cfg  StrategyConfig
+	}{
+		{"Unknown type", StrategyConfig{Strategies: []StrategySpec{{Type: "magic"}}}},
+		{"Links without link table", StrategyConfig{Strategies: []StrategySpec{{Type: StrategyLinks}}}},
+		{"Invalid routing number", StrategyConfig{Strategies: []StrategySpec{{Type: StrategyHash, RoutingNum: "123456789"}}}},
+		{"Invalid BIN rule routing", StrategyConfig{Strategies: []StrategySpec{{Type: StrategyBIN, Rules: []BINRule{{Prefix: "4", AccountNum: "1", RoutingNum: "021000022"}}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := mapper.UseStrategies(&tt.cfg); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+
+	if _, _, err := mapper.CardNumberToAccount("4532015112830366"); !errors.Is(err, ErrCardNotLinked) {
+		t.Errorf("Expected the default chain to be kept after a failed config, got %v", err)
+	}
+}
@@ -44,11 +44,131 @@ var (
 		[]string{"type"},
 	)
 
-	// Rate limiting metrics
-	rateLimitRejections = promauto.NewCounter(
+	// Card mapping metrics, per strategy in the chain
+	cardMappingTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_card_mapping_total",
+			Help: "Total number of card mapping attempts by strategy and outcome",
+		},
+		[]string{"strategy", "outcome"},
+	)
+
+	// Rate limiting metrics, per rule that rejected the charge
+	rateLimitRejections = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "payment_rate_limit_rejections_total",
-			Help: "Total number of rate limit rejections",
+			Help: "Total number of rate limit rejections by rule",
+		},
+		[]string{"rule"},
+	)
+
+	// Risk engine metrics
+	riskDecisions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_risk_decisions_total",
+			Help: "Total number of risk engine decisions by outcome and reason",
+		},
+		[]string{"outcome", "reason"},
+	)
+
+	riskScore = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "payment_risk_score",
+			Help:    "Risk scores of assessed charges",
+			Buckets: []float64{0, 10, 20, 30, 40, 50, 60, 80, 100},
+		},
+	)
+
+	// Manual review metrics
+	reviewDecisions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_review_decisions_total",
+			Help: "Total number of manual review decisions by decision",
+		},
+		[]string{"decision"},
+	)
+
+	// Blocklist metrics
+	blocklistRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_blocklist_rejections_total",
+			Help: "Total number of charges refused by the blocklist by entry kind",
+		},
+		[]string{"kind"},
+	)
+
+	// Duplicate charge metrics
+	duplicateCharges = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_duplicate_charges_total",
+			Help: "Total number of duplicate charges detected by action taken",
+		},
+		[]string{"action"},
+	)
+
+	// Spend cap metrics
+	spendCapBreaches = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_spend_cap_breaches_total",
+			Help: "Total number of charges rejected for exceeding a spend cap by scope and period",
+		},
+		[]string{"scope", "period"},
+	)
+
+	// Tenant metrics
+	tenantRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_tenant_requests_total",
+			Help: "Total number of payment requests by tenant and status",
+		},
+		[]string{"tenant", "status"},
+	)
+
+	tenantAmount = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_tenant_transaction_amount_cents_total",
+			Help: "Total transaction amount in cents by tenant",
+		},
+		[]string{"tenant"},
+	)
+
+	tenantRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_tenant_rejections_total",
+			Help: "Total number of requests rejected for an unknown or mismatched tenant by reason",
+		},
+		[]string{"reason"},
+	)
+
+	// Configuration reload metrics
+	configVersion = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "payment_config_version",
+			Help: "Version of the active configuration, incremented each time a reload changes it",
+		},
+	)
+
+	configReloads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_config_reloads_total",
+			Help: "Total number of configuration reloads by result",
+		},
+		[]string{"result"},
+	)
+
+	configLastReloadSuccess = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "payment_config_last_reload_success",
+			Help: "Whether the last configuration reload succeeded (1) or was rejected (0)",
+		},
+	)
+
+	// Behavioural profile metrics
+	anomalyScore = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "payment_anomaly_score",
+			Help:    "Behavioural anomaly scores of charges on accounts with a profile",
+			Buckets: []float64{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100},
 		},
 	)
 )
@@ -76,14 +196,49 @@ func (m *Metrics) RecordRequest(success bool, latency time.Duration, amount int6
 	}
 }
 
+// RecordTenantRequest records a tenant's payment request and, when it
+// succeeded, the amount charged
+func (m *Metrics) RecordTenantRequest(tenant string, success bool, amount int64) {
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	tenantRequests.WithLabelValues(tenant, status).Inc()
+	if success && amount > 0 {
+		tenantAmount.WithLabelValues(tenant).Add(float64(amount))
+	}
+}
+
+// RecordTenantRejection records a request refused because its tenant is
+// unknown or not the caller's
+func (m *Metrics) RecordTenantRejection(reason string) {
+	tenantRejections.WithLabelValues(reason).Inc()
+}
+
 // RecordError records an error
 func (m *Metrics) RecordError(errorType string) {
 	errorsTotal.WithLabelValues(errorType).Inc()
 }
 
-// RecordRejection records a rate-limited rejection
-func (m *Metrics) RecordRejection() {
-	rateLimitRejections.Inc()
+// RecordMapping records the outcome of one card mapping strategy
+func (m *Metrics) RecordMapping(strategy, outcome string) {
+	cardMappingTotal.WithLabelValues(strategy, outcome).Inc()
+}
+
+// RecordRejection records a rate-limited rejection under the rule that fired
+func (m *Metrics) RecordRejection(rule string) {
+	rateLimitRejections.WithLabelValues(rule).Inc()
+}
+
+// RecordRiskDecision records a risk engine decision and its score
+func (m *Metrics) RecordRiskDecision(outcome, reason string, score int) {
+	riskDecisions.WithLabelValues(outcome, reason).Inc()
+	riskScore.Observe(float64(score))
+}
+
+// RecordReviewDecision records an approved, rejected or expired review
+func (m *Metrics) RecordReviewDecision(decision string) {
+	reviewDecisions.WithLabelValues(decision).Inc()
 }
 
 // GetStats returns current metrics as a map (for backward compatibility)
@@ -99,3 +254,43 @@ func (m *Metrics) GetStats() map[string]interface{} {
 func PrometheusHandler() http.Handler {
 	return promhttp.Handler()
 }
+
+// RecordAnomalyScore records the behavioural anomaly score of a charge
+func (m *Metrics) RecordAnomalyScore(score float64) {
+	anomalyScore.Observe(score)
+}
+
+// RecordSpendCapBreach records a charge rejected by a customer or merchant spend cap
+func (m *Metrics) RecordSpendCapBreach(scope, period string) {
+	spendCapBreaches.WithLabelValues(scope, period).Inc()
+}
+
+// RecordDuplicate records a duplicate charge and whether it was rejected or
+// answered with the original
+func (m *Metrics) RecordDuplicate(action string) {
+	duplicateCharges.WithLabelValues(action).Inc()
+}
+
+// RecordBlocklistRejection records a charge refused by a blocklisted account,
+// card or BIN
+func (m *Metrics) RecordBlocklistRejection(kind string) {
+	blocklistRejections.WithLabelValues(kind).Inc()
+}
+
+// RecordConfigApplied records the version of a configuration that took effect,
+// at startup or on reload
+func (m *Metrics) RecordConfigApplied(version int) {
+	configVersion.Set(float64(version))
+	configLastReloadSuccess.Set(1)
+}
+
+// RecordConfigReload records a configuration reload: "applied", "unchanged" or
+// "failed"
+func (m *Metrics) RecordConfigReload(result string) {
+	configReloads.WithLabelValues(result).Inc()
+	if result == "failed" {
+		configLastReloadSuccess.Set(0)
+	} else {
+		configLastReloadSuccess.Set(1)
+	}
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Quota response headers set on every rate limited call
+const (
+	// HeaderLimit is the most requests the key can make at once
+	HeaderLimit = "x-ratelimit-limit"
+	// HeaderRemaining is the quota left after this request
+	HeaderRemaining = "x-ratelimit-remaining"
+	// HeaderReset is the number of seconds until the quota is fully restored
+	HeaderReset = "x-ratelimit-reset"
+)
+
+// ReasonRateLimited is the ErrorInfo reason of a rate limit rejection
+const ReasonRateLimited = "RATE_LIMIT_EXCEEDED"
+
+const errorDomain = "payment-integration"
+
+// KeyFunc extracts the key a request is limited by. An empty key skips the
+// limit for that request, and an error fails the call. It may return a derived
+// context, e.g. one carrying work the handler can reuse.
+type KeyFunc func(ctx context.Context, fullMethod string, req interface{}) (context.Context, string, error)
+
+// RateLimit applies one limiter to the requests of some methods
+type RateLimit struct {
+	// Rule names the limit in rejections and metrics
+	Rule    string
+	Limiter Limiter
+	Key     KeyFunc
+	// Methods are the full method names limited, e.g.
+	// "/hipstershop.PaymentService/Charge"; empty limits every method
+	Methods []string
+}
+
+func (rl RateLimit) applies(fullMethod string) bool {
+	if len(rl.Methods) == 0 {
+		return true
+	}
+	for _, method := range rl.Methods {
+		if method == fullMethod {
+			return true
+		}
+	}
+	return false
+}
+
+// RejectFunc is told about every request a limit rejects
+type RejectFunc func(ctx context.Context, rule, key string, d Decision)
+
+// UnaryRateLimitInterceptor checks each request against the limits that apply
+// to its method, in order. The tightest decision is reported in the quota
+// headers; the first rejection fails the call with ResourceExhausted carrying
+// RetryInfo, and later limits are not charged. onReject may be nil.
+func UnaryRateLimitInterceptor(onReject RejectFunc, limits ...RateLimit) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var tightest *Decision
+		for _, limit := range limits {
+			if !limit.applies(info.FullMethod) {
+				continue
+			}
+
+			var key string
+			var err error
+			ctx, key, err = limit.Key(ctx, info.FullMethod, req)
+			if err != nil {
+				return nil, err
+			}
+			if key == "" {
+				continue
+			}
+
+			d := limit.Limiter.Decide(key)
+			if tightest == nil || !d.Allowed || d.Remaining < tightest.Remaining {
+				tightest = &d
+			}
+			if !d.Allowed {
+				setQuotaHeaders(ctx, d)
+				if onReject != nil {
+					onReject(ctx, limit.Rule, key, d)
+				}
+				return nil, rejection(limit.Rule, d)
+			}
+		}
+
+		if tightest != nil {
+			setQuotaHeaders(ctx, *tightest)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// setQuotaHeaders reports a decision in the response headers. Calls outside a
+// gRPC server, e.g. in tests, have no stream to set them on.
+func setQuotaHeaders(ctx context.Context, d Decision) {
+	grpc.SetHeader(ctx, metadata.Pairs(
+		HeaderLimit, strconv.Itoa(d.Limit),
+		HeaderRemaining, strconv.Itoa(d.Remaining),
+		HeaderReset, strconv.FormatInt(int64(math.Ceil(d.ResetAfter.Seconds())), 10),
+	))
+}
+
+// rejection is the ResourceExhausted status of a rejected request
+func rejection(rule string, d Decision) error {
+	st := status.New(codes.ResourceExhausted, "too many requests, please try again later")
+	detailed, err := st.WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(d.RetryAfter)},
+		&errdetails.ErrorInfo{
+			Reason:   ReasonRateLimited,
+			Domain:   errorDomain,
+			Metadata: map[string]string{"rule": rule},
+		},
+	)
+	if err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// KeyFromMetadata limits by the first value of an incoming metadata header
+func KeyFromMetadata(header string) KeyFunc {
+	return func(ctx context.Context, fullMethod string, req interface{}) (context.Context, string, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(header); len(values) > 0 {
+				return ctx, values[0], nil
+			}
+		}
+		return ctx, "", nil
+	}
+}
+
+// KeyFromPeer limits by the client's host
+func KeyFromPeer() KeyFunc {
+	return func(ctx context.Context, fullMethod string, req interface{}) (context.Context, string, error) {
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return ctx, "", nil
+		}
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return ctx, host, nil
+		}
+		return ctx, p.Addr.String(), nil
+	}
+}
+
+// FirstKey uses the first of keys to extract a non-empty key
+func FirstKey(keys ...KeyFunc) KeyFunc {
+	return func(ctx context.Context, fullMethod string, req interface{}) (context.Context, string, error) {
+		for _, keyFunc := range keys {
+			var key string
+			var err error
+			ctx, key, err = keyFunc(ctx, fullMethod, req)
+			if err != nil || key != "" {
+				return ctx, key, err
+			}
+		}
+		return ctx, "", nil
+	}
+}
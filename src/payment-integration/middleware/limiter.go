@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limiter decides whether a request for a key (usually an account) may proceed
+type Limiter interface {
+	// Decide consumes one unit of the key's quota if any is left
+	Decide(key string) Decision
+	// Allow is Decide reduced to whether the request may proceed
+	Allow(key string) bool
+	// GetRemaining returns the quota left for key without consuming any
+	GetRemaining(key string) int
+	// Stop releases the limiter's background resources
+	Stop()
+}
+
+// Decision is the outcome of a rate limit check
+type Decision struct {
+	Allowed bool
+	// Limit is the most requests the key can make at once
+	Limit int
+	// Remaining is the quota left after this request
+	Remaining int
+	// ResetAfter is how long until the key's quota is fully restored
+	ResetAfter time.Duration
+	// RetryAfter is how long a rejected caller should wait before retrying
+	RetryAfter time.Duration
+}
+
+// Rate limiting algorithms
+const (
+	AlgorithmFixedWindow = "fixed_window"
+	AlgorithmTokenBucket = "token_bucket"
+)
+
+// BucketConfig is a token bucket's refill rate and capacity
+type BucketConfig struct {
+	// Rate is the number of tokens added per second
+	Rate float64
+	// Burst is the bucket's capacity: the most requests allowed at once
+	Burst int
+}
+
+// ParseBucketConfig parses "RATE/UNIT:BURST", where UNIT is s, m or h, e.g.
+// "5/s:20" for five per second with a burst of twenty. Without ":BURST" the
+// burst is the rate per unit.
+func ParseBucketConfig(spec string) (BucketConfig, error) {
+	rateSpec, burstSpec, hasBurst := strings.Cut(strings.TrimSpace(spec), ":")
+
+	countSpec, unit, ok := strings.Cut(rateSpec, "/")
+	if !ok {
+		return BucketConfig{}, fmt.Errorf("rate %q must look like 5/s, 300/m or 1000/h", rateSpec)
+	}
+	count, err := strconv.ParseFloat(countSpec, 64)
+	if err != nil || count <= 0 {
+		return BucketConfig{}, fmt.Errorf("rate %q must be a positive number", countSpec)
+	}
+
+	var per time.Duration
+	switch unit {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return BucketConfig{}, fmt.Errorf("unknown rate unit %q, expected s, m or h", unit)
+	}
+
+	cfg := BucketConfig{Rate: count / per.Seconds(), Burst: int(count)}
+	if hasBurst {
+		burst, err := strconv.Atoi(burstSpec)
+		if err != nil || burst <= 0 {
+			return BucketConfig{}, fmt.Errorf("burst %q must be a positive integer", burstSpec)
+		}
+		cfg.Burst = burst
+	}
+	if cfg.Burst < 1 {
+		cfg.Burst = 1
+	}
+	return cfg, nil
+}
+
+// ParseBucketOverrides parses comma-separated "KEY=RATE/UNIT:BURST" entries
+func ParseBucketOverrides(value string) (map[string]BucketConfig, error) {
+	overrides := make(map[string]BucketConfig)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, spec, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("override %q must look like KEY=5/s:20", entry)
+		}
+		cfg, err := ParseBucketConfig(spec)
+		if err != nil {
+			return nil, fmt.Errorf("override for %s: %w", key, err)
+		}
+		overrides[strings.TrimSpace(key)] = cfg
+	}
+	return overrides, nil
+}
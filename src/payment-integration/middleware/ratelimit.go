@@ -5,7 +5,9 @@ import (
 	"time"
 )
 
-// RateLimiter implements a simple rate limiter per account
+// RateLimiter implements a simple fixed one-minute window rate limiter per account.
+// A caller can make up to twice the limit across a window boundary; use
+// TokenBucket for smooth limits with an explicit burst.
 type RateLimiter struct {
 	mu            sync.RWMutex
 	limits        map[string]*accountLimit
@@ -34,36 +36,44 @@ func NewRateLimiter(maxPerMinute int) *RateLimiter {
 
 // Allow checks if a request from the given account is allowed
 func (rl *RateLimiter) Allow(accountNumber string) bool {
+	return rl.Decide(accountNumber).Allowed
+}
+
+// Decide counts a request from the given account against its current window
+func (rl *RateLimiter) Decide(accountNumber string) Decision {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
 	now := time.Now()
 	limit, exists := rl.limits[accountNumber]
 
-	if !exists {
-		// First request from this account
-		rl.limits[accountNumber] = &accountLimit{
-			count:     1,
-			resetTime: now.Add(time.Minute),
-		}
-		return true
+	// First request from this account, or the window has expired
+	if !exists || now.After(limit.resetTime) {
+		limit = &accountLimit{resetTime: now.Add(time.Minute)}
+		rl.limits[accountNumber] = limit
 	}
 
-	// Check if we need to reset the counter
-	if now.After(limit.resetTime) {
-		limit.count = 1
-		limit.resetTime = now.Add(time.Minute)
-		return true
-	}
+	d := Decision{Limit: rl.maxPerMinute, ResetAfter: limit.resetTime.Sub(now)}
 
 	// Check if under limit
 	if limit.count < rl.maxPerMinute {
 		limit.count++
-		return true
+		d.Allowed = true
+		d.Remaining = rl.maxPerMinute - limit.count
+		return d
 	}
 
 	// Rate limit exceeded
-	return false
+	d.RetryAfter = d.ResetAfter
+	return d
+}
+
+// SetLimit changes the number of requests allowed per window. Requests already
+// counted in the current window still count against the new limit.
+func (rl *RateLimiter) SetLimit(maxPerMinute int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.maxPerMinute = maxPerMinute
 }
 
 // GetRemaining returns the number of requests remaining for an account
@@ -107,22 +117,3 @@ func (rl *RateLimiter) cleanup() {
 func (rl *RateLimiter) Stop() {
 	rl.cleanupTicker.Stop()
 }
-
-// GlobalRateLimiter is a singleton instance
-var globalRateLimiter *RateLimiter
-
-// InitRateLimiter initializes the global rate limiter
-func InitRateLimiter(maxPerMinute int) {
-	if maxPerMinute <= 0 {
-		maxPerMinute = 10 // Default: 10 transactions per minute per account
-	}
-	globalRateLimiter = NewRateLimiter(maxPerMinute)
-}
-
-// GetRateLimiter returns the global rate limiter instance
-func GetRateLimiter() *RateLimiter {
-	if globalRateLimiter == nil {
-		InitRateLimiter(10) // Initialize with default if not set
-	}
-	return globalRateLimiter
-}
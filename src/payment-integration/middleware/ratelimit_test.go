@@ -0,0 +1,424 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestFixedWindowLimiter(t *testing.T) {
+	rl := NewRateLimiter(3)
+	defer rl.Stop()
+
+	for i := 0; i < 3; i++ {
+		d := rl.Decide("acct")
+		if !d.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+		if d.Remaining != 2-i {
+			t.Errorf("Expected %d remaining, got %d", 2-i, d.Remaining)
+		}
+	}
+
+	d := rl.Decide("acct")
+	if d.Allowed {
+		t.Error("Expected the fourth request to be rejected")
+	}
+	if d.RetryAfter <= 0 || d.RetryAfter > time.Minute {
+		t.Errorf("Expected retry within the window, got %v", d.RetryAfter)
+	}
+	if !rl.Allow("other") {
+		t.Error("Expected a different account to be allowed")
+	}
+}
+
+func TestTokenBucketBurstAndRefill(t *testing.T) {
+	now := time.Now()
+	tb := NewTokenBucket(BucketConfig{Rate: 5, Burst: 20})
+	defer tb.Stop()
+	tb.SetClock(func() time.Time { return now })
+
+	for i := 0; i < 20; i++ {
+		if !tb.Allow("acct") {
+			t.Fatalf("Expected request %d of the burst to be allowed", i+1)
+		}
+	}
+
+	d := tb.Decide("acct")
+	if d.Allowed {
+		t.Fatal("Expected the request after the burst to be rejected")
+	}
+	if d.RetryAfter != 200*time.Millisecond {
+		t.Errorf("Expected retry after 200ms, got %v", d.RetryAfter)
+	}
+	if d.ResetAfter != 4*time.Second {
+		t.Errorf("Expected the bucket to refill in 4s, got %v", d.ResetAfter)
+	}
+
+	// Five tokens per second
+	now = now.Add(time.Second)
+	for i := 0; i < 5; i++ {
+		if !tb.Allow("acct") {
+			t.Fatalf("Expected refilled request %d to be allowed", i+1)
+		}
+	}
+	if tb.Allow("acct") {
+		t.Error("Expected only five tokens after one second")
+	}
+
+	// Refills never exceed the burst
+	now = now.Add(time.Hour)
+	if remaining := tb.GetRemaining("acct"); remaining != 20 {
+		t.Errorf("Expected a full bucket of 20, got %d", remaining)
+	}
+}
+
+func TestTokenBucketOverrides(t *testing.T) {
+	now := time.Now()
+	tb := NewTokenBucket(BucketConfig{Rate: 1, Burst: 1})
+	defer tb.Stop()
+	tb.SetClock(func() time.Time { return now })
+
+	vip, err := ParseBucketConfig("5/s:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tb.SetOverride("vip", vip)
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if tb.Allow("vip") {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("Expected the override's burst of 3, got %d", allowed)
+	}
+
+	if !tb.Allow("regular") || tb.Allow("regular") {
+		t.Error("Expected the default burst of 1 for other keys")
+	}
+}
+
+func TestTokenBucketReconfigure(t *testing.T) {
+	now := time.Now()
+	tb := NewTokenBucket(BucketConfig{Rate: 1, Burst: 5})
+	defer tb.Stop()
+	tb.SetClock(func() time.Time { return now })
+	tb.SetOverride("vip", BucketConfig{Rate: 10, Burst: 10})
+
+	for i := 0; i < 3; i++ {
+		tb.Allow("acct")
+	}
+
+	// Buckets keep their tokens, capped at the new burst; old overrides are dropped
+	tb.Reconfigure(BucketConfig{Rate: 1, Burst: 1}, map[string]BucketConfig{"gold": {Rate: 1, Burst: 4}})
+	if remaining := tb.GetRemaining("acct"); remaining != 1 {
+		t.Errorf("Expected the 2 tokens left to be capped at 1, got %d", remaining)
+	}
+	if remaining := tb.GetRemaining("gold"); remaining != 4 {
+		t.Errorf("Expected the new override's burst of 4, got %d", remaining)
+	}
+	if !tb.Allow("vip") || tb.Allow("vip") {
+		t.Error("Expected the removed override to fall back to the default burst of 1")
+	}
+}
+
+func TestParseBucketConfig(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected BucketConfig
+		valid    bool
+	}{
+		{"5/s:20", BucketConfig{Rate: 5, Burst: 20}, true},
+		{"120/m", BucketConfig{Rate: 2, Burst: 120}, true},
+		{"3600/h:10", BucketConfig{Rate: 1, Burst: 10}, true},
+		{"0.5/s", BucketConfig{Rate: 0.5, Burst: 1}, true},
+		{"5", BucketConfig{}, false},
+		{"5/d", BucketConfig{}, false},
+		{"-1/s", BucketConfig{}, false},
+		{"5/s:0", BucketConfig{}, false},
+	}
+
+	for _, tt := range tests {
+		cfg, err := ParseBucketConfig(tt.spec)
+		if tt.valid && (err != nil || cfg != tt.expected) {
+			t.Errorf("%q: expected %+v, got %+v (%v)", tt.spec, tt.expected, cfg, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%q: expected error", tt.spec)
+		}
+	}
+
+	overrides, err := ParseBucketOverrides("1111111111=5/s:20, 2222222222=60/m")
+	if err != nil || len(overrides) != 2 || overrides["2222222222"].Burst != 60 {
+		t.Errorf("Unexpected overrides %+v (%v)", overrides, err)
+	}
+	if _, err := ParseBucketOverrides("nokey"); err == nil {
+		t.Error("Expected error for an override without a key")
+	}
+}
+
+func newTestRedisLimiter(t *testing.T, addr string, opts RedisOptions) *RedisLimiter {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	r := NewRedisLimiter(client, NewTokenBucket(BucketConfig{Rate: 5, Burst: 20}), opts)
+	t.Cleanup(r.Stop)
+	return r
+}
+
+func TestRedisLimiterSharedAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	now := time.Unix(1_700_000_000, 0)
+	mr.SetTime(now)
+
+	replicaA := newTestRedisLimiter(t, mr.Addr(), RedisOptions{})
+	replicaB := newTestRedisLimiter(t, mr.Addr(), RedisOptions{})
+
+	// Both replicas draw from the same bucket of 20
+	for i := 0; i < 20; i++ {
+		replica := replicaA
+		if i%2 == 1 {
+			replica = replicaB
+		}
+		if d := replica.Decide("acct"); !d.Allowed {
+			t.Fatalf("Expected request %d of the shared burst to be allowed", i+1)
+		}
+	}
+	d := replicaB.Decide("acct")
+	if d.Allowed {
+		t.Fatal("Expected the request after the shared burst to be rejected")
+	}
+	if d.RetryAfter != 200*time.Millisecond {
+		t.Errorf("Expected retry after 200ms, got %v", d.RetryAfter)
+	}
+
+	// The bucket refills by Redis time, five tokens per second
+	mr.SetTime(now.Add(time.Second))
+	if remaining := replicaA.GetRemaining("acct"); remaining != 5 {
+		t.Errorf("Expected 5 tokens after one second, got %d", remaining)
+	}
+
+	if ttl := mr.TTL("ratelimit:acct"); ttl <= 0 {
+		t.Errorf("Expected the bucket key to expire, got TTL %v", ttl)
+	}
+}
+
+func TestRedisLimiterFailureModes(t *testing.T) {
+	tests := []struct {
+		mode     FailureMode
+		expected []bool
+	}{
+		{FailOpen, []bool{true, true, true}},
+		{FailClosed, []bool{false, false, false}},
+		// The local bucket is a fresh one with the default burst of 20
+		{FailLocal, []bool{true, true, true}},
+	}
+
+	for _, tt := range tests {
+		mr := miniredis.RunT(t)
+		var outage error
+		r := newTestRedisLimiter(t, mr.Addr(), RedisOptions{
+			FailureMode:   tt.mode,
+			Timeout:       100 * time.Millisecond,
+			OnStateChange: func(err error) { outage = err },
+		})
+		if !r.Allow("acct") {
+			t.Fatalf("%s: expected the first request to be allowed", tt.mode)
+		}
+
+		mr.Close()
+		for i, expected := range tt.expected {
+			if allowed := r.Allow("acct"); allowed != expected {
+				t.Errorf("%s: request %d expected allowed=%v, got %v", tt.mode, i+1, expected, allowed)
+			}
+		}
+		if outage == nil {
+			t.Errorf("%s: expected the outage to be reported", tt.mode)
+		}
+	}
+}
+
+func TestRedisLimiterRecovers(t *testing.T) {
+	mr := miniredis.RunT(t)
+	var states []error
+	r := newTestRedisLimiter(t, mr.Addr(), RedisOptions{
+		FailureMode:   FailClosed,
+		OnStateChange: func(err error) { states = append(states, err) },
+	})
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	if r.Allow("acct") {
+		t.Fatal("Expected rejection while Redis fails")
+	}
+
+	// Redis isn't retried until the cooldown has passed
+	mr.SetError("")
+	if r.Allow("acct") {
+		t.Error("Expected rejection during the cooldown")
+	}
+	now = now.Add(time.Second)
+	if !r.Allow("acct") {
+		t.Error("Expected Redis to be used again after the cooldown")
+	}
+
+	if len(states) != 2 || states[0] == nil || states[1] != nil {
+		t.Errorf("Expected an outage then a recovery, got %v", states)
+	}
+}
+
+func TestParseFailureMode(t *testing.T) {
+	if mode, err := ParseFailureMode(" Closed "); err != nil || mode != FailClosed {
+		t.Errorf("Expected closed, got %q (%v)", mode, err)
+	}
+	if _, err := ParseFailureMode("sometimes"); err == nil {
+		t.Error("Expected error for an unknown mode")
+	}
+}
+
+// headerStream captures the response headers set by an interceptor
+type headerStream struct {
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "/test.Service/Limited" }
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
+
+func TestUnaryRateLimitInterceptor(t *testing.T) {
+	now := time.Now()
+	tb := NewTokenBucket(BucketConfig{Rate: 1, Burst: 2})
+	defer tb.Stop()
+	tb.SetClock(func() time.Time { return now })
+
+	var rejected []string
+	interceptor := UnaryRateLimitInterceptor(
+		func(ctx context.Context, rule, key string, d Decision) { rejected = append(rejected, rule+":"+key) },
+		RateLimit{Rule: "per_caller", Limiter: tb, Key: KeyFromMetadata("x-caller-id"), Methods: []string{"/test.Service/Limited"}},
+	)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	call := func(method, caller string) (*headerStream, error) {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		if caller != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-caller-id", caller))
+		}
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return stream, err
+	}
+
+	stream, err := call("/test.Service/Limited", "checkout")
+	if err != nil {
+		t.Fatalf("Expected the first call to be allowed, got %v", err)
+	}
+	expected := map[string]string{HeaderLimit: "2", HeaderRemaining: "1", HeaderReset: "1"}
+	for header, value := range expected {
+		if got := stream.header.Get(header); len(got) != 1 || got[0] != value {
+			t.Errorf("Expected %s %s, got %v", header, value, got)
+		}
+	}
+
+	call("/test.Service/Limited", "checkout")
+	stream, err = call("/test.Service/Limited", "checkout")
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted, got %v", err)
+	}
+	var retry *errdetails.RetryInfo
+	var rule string
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.RetryInfo:
+			retry = d
+		case *errdetails.ErrorInfo:
+			rule = d.Metadata["rule"]
+		}
+	}
+	if retry == nil || retry.RetryDelay.AsDuration() != time.Second {
+		t.Errorf("Expected RetryInfo with a 1s delay, got %v", retry)
+	}
+	if rule != "per_caller" {
+		t.Errorf("Expected the rejection to name per_caller, got %q", rule)
+	}
+	if got := stream.header.Get(HeaderRemaining); len(got) != 1 || got[0] != "0" {
+		t.Errorf("Expected 0 remaining on rejection, got %v", got)
+	}
+	if len(rejected) != 1 || rejected[0] != "per_caller:checkout" {
+		t.Errorf("Expected one reported rejection, got %v", rejected)
+	}
+
+	// Other methods and requests without a key aren't limited
+	if stream, err := call("/test.Service/Other", "checkout"); err != nil || len(stream.header) != 0 {
+		t.Errorf("Expected other methods to pass untouched, got %v %v", stream.header, err)
+	}
+	if _, err := call("/test.Service/Limited", ""); err != nil {
+		t.Errorf("Expected calls without a key to pass, got %v", err)
+	}
+}
+
+func TestFirstKey(t *testing.T) {
+	key := FirstKey(KeyFromMetadata("x-caller-id"), KeyFromMetadata("x-tenant"))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant", "acme"))
+	if _, got, err := key(ctx, "", nil); err != nil || got != "acme" {
+		t.Errorf("Expected acme, got %q (%v)", got, err)
+	}
+	if _, got, _ := key(context.Background(), "", nil); got != "" {
+		t.Errorf("Expected no key, got %q", got)
+	}
+}
+
+// benchmarkLimiter runs Decide from many goroutines over a pool of accounts, so
+// the fixed window's single mutex can be compared with the sharded token bucket
+func benchmarkLimiter(b *testing.B, limiter Limiter, accounts int) {
+	keys := make([]string, accounts)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%010d", i)
+	}
+	var next uint64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := atomic.AddUint64(&next, 1) * 7919
+		for pb.Next() {
+			limiter.Decide(keys[i%uint64(accounts)])
+			i++
+		}
+	})
+}
+
+func BenchmarkFixedWindow(b *testing.B) {
+	for _, accounts := range []int{1, 1000, 100000} {
+		b.Run(fmt.Sprintf("accounts=%d", accounts), func(b *testing.B) {
+			rl := NewRateLimiter(1 << 30)
+			defer rl.Stop()
+			benchmarkLimiter(b, rl, accounts)
+		})
+	}
+}
+
+func BenchmarkTokenBucket(b *testing.B) {
+	for _, accounts := range []int{1, 1000, 100000} {
+		b.Run(fmt.Sprintf("accounts=%d", accounts), func(b *testing.B) {
+			tb := NewTokenBucket(BucketConfig{Rate: 1 << 30, Burst: 1 << 30})
+			defer tb.Stop()
+			benchmarkLimiter(b, tb, accounts)
+		})
+	}
+}
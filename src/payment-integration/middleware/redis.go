@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FailureMode decides what a RedisLimiter does while Redis cannot be reached
+type FailureMode string
+
+const (
+	// FailLocal limits each replica on its own with an in-process token bucket
+	FailLocal FailureMode = "local"
+	// FailOpen allows every request
+	FailOpen FailureMode = "open"
+	// FailClosed rejects every request
+	FailClosed FailureMode = "closed"
+)
+
+// ParseFailureMode parses "local", "open" or "closed"
+func ParseFailureMode(value string) (FailureMode, error) {
+	switch mode := FailureMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case FailLocal, FailOpen, FailClosed:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown failure mode %q, expected local, open or closed", value)
+	}
+}
+
+// bucketScript is the token bucket refill-and-take, run atomically in Redis.
+// Time comes from the Redis server so replicas with skewed clocks agree; this
+// needs script effects replication, the default since Redis 5.
+//
+// KEYS[1] bucket, ARGV[1] rate per second, ARGV[2] burst, ARGV[3] tokens to take.
+// Returns {allowed, tokens left}, the tokens as a string to keep the fraction.
+var bucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+	tokens = burst
+	updated = now
+end
+if now > updated then
+	tokens = math.min(burst, tokens + (now - updated) * rate)
+	updated = now
+end
+
+local allowed = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+end
+
+if cost > 0 then
+	redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(updated))
+	-- A bucket left alone long enough to refill is the same as no bucket
+	redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+end
+return {allowed, tostring(tokens)}
+`)
+
+// RedisOptions configures a RedisLimiter
+type RedisOptions struct {
+	// Prefix is prepended to every key stored in Redis. Defaults to "ratelimit:".
+	Prefix string
+	// Timeout bounds each Redis call. Defaults to 50ms.
+	Timeout time.Duration
+	// FailureMode applies while Redis is unreachable. Defaults to FailLocal.
+	FailureMode FailureMode
+	// Cooldown is how long Redis is left alone after a failed call before it is
+	// tried again, so an outage doesn't add Timeout to every request. Defaults to 1s.
+	Cooldown time.Duration
+	// OnStateChange, if set, is called when Redis becomes unreachable (with the
+	// error) and again when it recovers (with nil)
+	OnStateChange func(err error)
+}
+
+// RedisLimiter is a token bucket limiter whose buckets live in Redis, so that
+// every replica shares one quota per key
+type RedisLimiter struct {
+	client redis.UniversalClient
+	local  *TokenBucket
+	opts   RedisOptions
+	now    func() time.Time
+
+	// downUntil is the UnixNano time before which Redis is not tried; zero while healthy
+	downUntil atomic.Int64
+}
+
+// NewRedisLimiter creates a limiter sharing buckets through client. The local
+// token bucket supplies each key's rate and burst, including overrides, and
+// serves requests under FailLocal. The limiter takes ownership of both.
+func NewRedisLimiter(client redis.UniversalClient, local *TokenBucket, opts RedisOptions) *RedisLimiter {
+	if opts.Prefix == "" {
+		opts.Prefix = "ratelimit:"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 50 * time.Millisecond
+	}
+	if opts.FailureMode == "" {
+		opts.FailureMode = FailLocal
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = time.Second
+	}
+	return &RedisLimiter{client: client, local: local, opts: opts, now: time.Now}
+}
+
+// run executes the bucket script, taking cost tokens from key's bucket
+func (r *RedisLimiter) run(key string, cfg BucketConfig, cost int) (bool, float64, error) {
+	if r.now().UnixNano() < r.downUntil.Load() {
+		return false, 0, fmt.Errorf("redis unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.Timeout)
+	defer cancel()
+
+	result, err := bucketScript.Run(ctx, r.client, []string{r.opts.Prefix + key}, cfg.Rate, cfg.Burst, cost).Slice()
+	if err == nil && len(result) != 2 {
+		err = fmt.Errorf("unexpected bucket script result %v", result)
+	}
+	var tokens float64
+	if err == nil {
+		tokens, err = strconv.ParseFloat(fmt.Sprint(result[1]), 64)
+	}
+	if err != nil {
+		r.markDown(err)
+		return false, 0, err
+	}
+
+	r.markUp()
+	allowed, _ := result[0].(int64)
+	return allowed == 1, tokens, nil
+}
+
+func (r *RedisLimiter) markDown(err error) {
+	if r.downUntil.Swap(r.now().Add(r.opts.Cooldown).UnixNano()) == 0 && r.opts.OnStateChange != nil {
+		r.opts.OnStateChange(err)
+	}
+}
+
+func (r *RedisLimiter) markUp() {
+	if r.downUntil.Swap(0) != 0 && r.opts.OnStateChange != nil {
+		r.opts.OnStateChange(nil)
+	}
+}
+
+// Decide takes a token from key's shared bucket, or applies the failure mode
+// if Redis cannot be reached
+func (r *RedisLimiter) Decide(key string) Decision {
+	cfg := r.local.config(key)
+	allowed, tokens, err := r.run(key, cfg, 1)
+	if err == nil {
+		return bucketDecision(cfg, allowed, tokens)
+	}
+
+	switch r.opts.FailureMode {
+	case FailOpen:
+		return Decision{Allowed: true, Limit: cfg.Burst, Remaining: cfg.Burst}
+	case FailClosed:
+		return Decision{Limit: cfg.Burst, RetryAfter: r.opts.Cooldown, ResetAfter: r.opts.Cooldown}
+	default:
+		return r.local.Decide(key)
+	}
+}
+
+// Allow checks if a request for key is allowed
+func (r *RedisLimiter) Allow(key string) bool {
+	return r.Decide(key).Allowed
+}
+
+// GetRemaining returns the whole tokens left in key's shared bucket
+func (r *RedisLimiter) GetRemaining(key string) int {
+	cfg := r.local.config(key)
+	_, tokens, err := r.run(key, cfg, 0)
+	if err == nil {
+		return int(tokens)
+	}
+
+	switch r.opts.FailureMode {
+	case FailOpen:
+		return cfg.Burst
+	case FailClosed:
+		return 0
+	default:
+		return r.local.GetRemaining(key)
+	}
+}
+
+// Reconfigure replaces the rate and burst of every key, in Redis and locally
+func (r *RedisLimiter) Reconfigure(defaults BucketConfig, overrides map[string]BucketConfig) {
+	r.local.Reconfigure(defaults, overrides)
+}
+
+// Stop stops the local bucket and closes the Redis client
+func (r *RedisLimiter) Stop() {
+	r.local.Stop()
+	r.client.Close()
+}
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// bucketShards spreads keys over independently locked maps so that concurrent
+// requests for different accounts rarely wait on each other
+const bucketShards = 64
+
+// TokenBucket is a per-key token bucket limiter. Each key's bucket holds up to
+// Burst tokens and refills at Rate tokens per second; a request takes one token.
+type TokenBucket struct {
+	limits atomic.Pointer[bucketLimits]
+	shards [bucketShards]bucketShard
+	// clock is swapped by SetClock while the cleanup goroutine reads it
+	clock atomic.Pointer[func() time.Time]
+
+	cleanupTicker *time.Ticker
+}
+
+// bucketLimits is the rate and burst of every key. It is replaced, never
+// modified, so readers don't take a lock.
+type bucketLimits struct {
+	defaults  BucketConfig
+	overrides map[string]BucketConfig
+}
+
+type bucketShard struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewTokenBucket creates a token bucket limiter using defaults for every key
+// without an override
+func NewTokenBucket(defaults BucketConfig) *TokenBucket {
+	tb := &TokenBucket{
+		cleanupTicker: time.NewTicker(5 * time.Minute),
+	}
+	tb.SetClock(time.Now)
+	for i := range tb.shards {
+		tb.shards[i].buckets = make(map[string]*bucket)
+	}
+	tb.limits.Store(&bucketLimits{defaults: defaults, overrides: map[string]BucketConfig{}})
+
+	// Cleanup refilled buckets periodically
+	go tb.cleanup()
+
+	return tb
+}
+
+// SetOverride gives key its own rate and burst
+func (tb *TokenBucket) SetOverride(key string, cfg BucketConfig) {
+	for {
+		current := tb.limits.Load()
+		// Copy on write so readers never take a lock
+		next := &bucketLimits{defaults: current.defaults, overrides: make(map[string]BucketConfig, len(current.overrides)+1)}
+		for k, v := range current.overrides {
+			next.overrides[k] = v
+		}
+		next.overrides[key] = cfg
+		if tb.limits.CompareAndSwap(current, next) {
+			return
+		}
+	}
+}
+
+// Reconfigure replaces the defaults and every override at once. Buckets keep
+// their tokens, capped at the new burst on their next request.
+func (tb *TokenBucket) Reconfigure(defaults BucketConfig, overrides map[string]BucketConfig) {
+	next := &bucketLimits{defaults: defaults, overrides: make(map[string]BucketConfig, len(overrides))}
+	for k, v := range overrides {
+		next.overrides[k] = v
+	}
+	tb.limits.Store(next)
+}
+
+// SetClock overrides the time source, for tests
+func (tb *TokenBucket) SetClock(now func() time.Time) {
+	tb.clock.Store(&now)
+}
+
+func (tb *TokenBucket) now() time.Time {
+	return (*tb.clock.Load())()
+}
+
+// config returns the rate and burst for key
+func (tb *TokenBucket) config(key string) BucketConfig {
+	limits := tb.limits.Load()
+	if cfg, ok := limits.overrides[key]; ok {
+		return cfg
+	}
+	return limits.defaults
+}
+
+func (tb *TokenBucket) shard(key string) *bucketShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &tb.shards[h.Sum32()%bucketShards]
+}
+
+// refill returns the key's bucket topped up to now. It is called with the shard locked.
+func (s *bucketShard) refill(key string, cfg BucketConfig, now time.Time) *bucket {
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(cfg.Burst), updated: now}
+		s.buckets[key] = b
+		return b
+	}
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * cfg.Rate
+		b.updated = now
+	}
+	// The burst may have shrunk since the bucket was last used
+	b.tokens = math.Min(float64(cfg.Burst), b.tokens)
+	return b
+}
+
+// Decide takes a token from key's bucket if one is available
+func (tb *TokenBucket) Decide(key string) Decision {
+	cfg := tb.config(key)
+	s := tb.shard(key)
+	now := tb.now()
+
+	s.mu.Lock()
+	b := s.refill(key, cfg, now)
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	tokens := b.tokens
+	s.mu.Unlock()
+
+	return bucketDecision(cfg, allowed, tokens)
+}
+
+// bucketDecision describes a bucket holding tokens after a request was allowed or not
+func bucketDecision(cfg BucketConfig, allowed bool, tokens float64) Decision {
+	d := Decision{
+		Allowed:    allowed,
+		Limit:      cfg.Burst,
+		Remaining:  int(tokens),
+		ResetAfter: refillTime(float64(cfg.Burst)-tokens, cfg.Rate),
+	}
+	if !allowed {
+		d.RetryAfter = refillTime(1-tokens, cfg.Rate)
+	}
+	return d
+}
+
+// Allow checks if a request for key is allowed
+func (tb *TokenBucket) Allow(key string) bool {
+	return tb.Decide(key).Allowed
+}
+
+// GetRemaining returns the whole tokens left in key's bucket
+func (tb *TokenBucket) GetRemaining(key string) int {
+	cfg := tb.config(key)
+	s := tb.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int(s.refill(key, cfg, tb.now()).tokens)
+}
+
+// refillTime is how long it takes to add missing tokens at rate per second
+func refillTime(missing, rate float64) time.Duration {
+	if missing <= 0 || rate <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(missing / rate * float64(time.Second)))
+}
+
+// cleanup drops buckets that have refilled completely, since a new bucket starts full anyway
+func (tb *TokenBucket) cleanup() {
+	for range tb.cleanupTicker.C {
+		now := tb.now()
+		for i := range tb.shards {
+			s := &tb.shards[i]
+			s.mu.Lock()
+			for key := range s.buckets {
+				cfg := tb.config(key)
+				if b := s.refill(key, cfg, now); b.tokens >= float64(cfg.Burst) {
+					delete(s.buckets, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Stop stops the cleanup goroutine
+func (tb *TokenBucket) Stop() {
+	tb.cleanupTicker.Stop()
+}
@@ -0,0 +1,128 @@
+package money
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownCurrency is returned for currency codes missing from the ISO 4217 table
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+//go:embed iso4217.csv
+var iso4217CSV string
+
+// Currency is an ISO 4217 currency
+type Currency struct {
+	Code    string
+	Numeric string
+	// MinorUnits is the number of decimal places, e.g. 2 for USD, 0 for JPY and 3 for KWD
+	MinorUnits int
+	// Symbol is empty when it would be ambiguous
+	Symbol string
+}
+
+var currencies = mustLoadCurrencies(iso4217CSV)
+
+func mustLoadCurrencies(table string) map[string]Currency {
+	reader := csv.NewReader(strings.NewReader(table))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 4
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("invalid ISO 4217 table: %v", err))
+	}
+
+	byCode := make(map[string]Currency, len(records))
+	for _, record := range records {
+		minorUnits, err := strconv.Atoi(record[2])
+		if err != nil || minorUnits < 0 || minorUnits > 9 {
+			panic(fmt.Sprintf("invalid ISO 4217 table: bad minor units for %s", record[0]))
+		}
+		if _, dup := byCode[record[0]]; dup {
+			panic(fmt.Sprintf("invalid ISO 4217 table: duplicate code %s", record[0]))
+		}
+		byCode[record[0]] = Currency{Code: record[0], Numeric: record[1], MinorUnits: minorUnits, Symbol: record[3]}
+	}
+	return byCode
+}
+
+// LookupCurrency returns the ISO 4217 currency for a three-letter code. Codes are case-sensitive.
+func LookupCurrency(code string) (Currency, error) {
+	currency, ok := currencies[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+	}
+	return currency, nil
+}
+
+// Format renders an amount in the currency's minor units with its real precision,
+// e.g. "$10.50", "¥1050" or "KWD 10.500"
+func (c Currency) Format(minor int64) string {
+	sign, abs := "", uint64(minor)
+	if minor < 0 {
+		sign, abs = "-", -abs
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if c.MinorUnits > 0 {
+		if len(digits) <= c.MinorUnits {
+			digits = strings.Repeat("0", c.MinorUnits-len(digits)+1) + digits
+		}
+		point := len(digits) - c.MinorUnits
+		digits = digits[:point] + "." + digits[point:]
+	}
+
+	if c.Symbol != "" {
+		return sign + c.Symbol + digits
+	}
+	return c.Code + " " + sign + digits
+}
+
+// ToMinor converts the amount to its currency's minor units, rounding any finer
+// fraction according to policy
+func (a Amount) ToMinor(policy RoundingPolicy) (int64, error) {
+	currency, err := LookupCurrency(a.Currency)
+	if err != nil {
+		return 0, err
+	}
+	return a.ToMinorUnits(currency.MinorUnits, policy)
+}
+
+// FromMinor converts a count of the currency's minor units to an Amount
+func FromMinor(minor int64, code string) (Amount, error) {
+	currency, err := LookupCurrency(code)
+	if err != nil {
+		return Amount{}, err
+	}
+	return FromMinorUnits(minor, currency.MinorUnits, code)
+}
+
+// ExchangeToMinor converts the amount at rate (units of target per unit of the
+// amount's currency) into the target currency's minor units, rounding once
+// according to policy
+func (a Amount) ExchangeToMinor(rate *big.Rat, target string, policy RoundingPolicy) (int64, error) {
+	if rate == nil || rate.Sign() <= 0 {
+		return 0, fmt.Errorf("%w: exchange rate must be positive", ErrInvalid)
+	}
+	currency, err := LookupCurrency(target)
+	if err != nil {
+		return 0, err
+	}
+
+	num := new(big.Int).Mul(a.nanos(), rate.Num())
+	den := new(big.Int).Mul(rate.Denom(), nanosPerMinorUnit(currency.MinorUnits))
+	minor, err := divRound(num, den, policy)
+	if err != nil {
+		return 0, err
+	}
+	if !minor.IsInt64() {
+		return 0, fmt.Errorf("%w: %s does not fit in %s minor units", ErrOverflow, a, target)
+	}
+	return minor.Int64(), nil
+}
@@ -0,0 +1,193 @@
+// Package money does exact arithmetic on amounts in the units+nanos form of
+// the Money proto. Every operation validates its inputs, detects overflow and
+// makes rounding an explicit choice.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	pb "github.com/gke-hackathon/payment-integration/proto"
+)
+
+// NanosPerUnit is the number of nanos in one whole unit
+const NanosPerUnit = 1_000_000_000
+
+var (
+	// ErrInvalid is returned for amounts that break the Money proto's invariants
+	ErrInvalid = errors.New("invalid money")
+	// ErrOverflow is returned when a result does not fit in units+nanos or the target type
+	ErrOverflow = errors.New("money overflow")
+	// ErrInexact is returned under RoundReject when a result would need rounding
+	ErrInexact = errors.New("amount is not representable at the required precision")
+	// ErrCurrencyMismatch is returned when combining amounts in different currencies
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+var (
+	nanosPerUnit = big.NewInt(NanosPerUnit)
+	maxNanos     = new(big.Int).Add(new(big.Int).Mul(big.NewInt(math.MaxInt64), nanosPerUnit), big.NewInt(NanosPerUnit-1))
+	minNanos     = new(big.Int).Neg(maxNanos)
+)
+
+// Amount is a validated amount of money. Units and Nanos always share a sign.
+type Amount struct {
+	Currency string `json:"currency"`
+	Units    int64  `json:"units"`
+	Nanos    int32  `json:"nanos"`
+}
+
+// FromProto validates a Money message and converts it to an Amount
+func FromProto(m *pb.Money) (Amount, error) {
+	if m == nil {
+		return Amount{}, fmt.Errorf("%w: money cannot be nil", ErrInvalid)
+	}
+	a := Amount{Currency: m.CurrencyCode, Units: m.Units, Nanos: m.Nanos}
+	if err := a.Validate(); err != nil {
+		return Amount{}, err
+	}
+	return a, nil
+}
+
+// Validate checks the invariants documented on the Money proto
+func (a Amount) Validate() error {
+	if a.Nanos <= -NanosPerUnit || a.Nanos >= NanosPerUnit {
+		return fmt.Errorf("%w: nanos %d must be between -999,999,999 and +999,999,999", ErrInvalid, a.Nanos)
+	}
+	if a.Units > 0 && a.Nanos < 0 {
+		return fmt.Errorf("%w: nanos must not be negative when units is positive", ErrInvalid)
+	}
+	if a.Units < 0 && a.Nanos > 0 {
+		return fmt.Errorf("%w: nanos must not be positive when units is negative", ErrInvalid)
+	}
+	return nil
+}
+
+// Proto converts the amount to a Money message
+func (a Amount) Proto() *pb.Money {
+	return &pb.Money{CurrencyCode: a.Currency, Units: a.Units, Nanos: a.Nanos}
+}
+
+// Sign returns -1, 0 or +1
+func (a Amount) Sign() int {
+	switch {
+	case a.Units > 0 || a.Nanos > 0:
+		return 1
+	case a.Units < 0 || a.Nanos < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// IsZero reports whether the amount is zero
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// Add returns a + b
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+	return fromNanos(a.Currency, new(big.Int).Add(a.nanos(), b.nanos()))
+}
+
+// Sub returns a - b
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+	return fromNanos(a.Currency, new(big.Int).Sub(a.nanos(), b.nanos()))
+}
+
+// Neg returns -a
+func (a Amount) Neg() Amount {
+	return Amount{Currency: a.Currency, Units: -a.Units, Nanos: -a.Nanos}
+}
+
+// Mul returns a * factor
+func (a Amount) Mul(factor int64) (Amount, error) {
+	return fromNanos(a.Currency, new(big.Int).Mul(a.nanos(), big.NewInt(factor)))
+}
+
+// Percentage returns basisPoints/10000 of a (100 basis points = 1%), rounded to
+// the nearest nano according to policy
+func (a Amount) Percentage(basisPoints int64, policy RoundingPolicy) (Amount, error) {
+	scaled := new(big.Int).Mul(a.nanos(), big.NewInt(basisPoints))
+	nanos, err := divRound(scaled, big.NewInt(10_000), policy)
+	if err != nil {
+		return Amount{}, err
+	}
+	return fromNanos(a.Currency, nanos)
+}
+
+// ToMinorUnits converts the amount to an integer count of 10^-decimals units,
+// e.g. cents for decimals=2, rounding any finer fraction according to policy
+func (a Amount) ToMinorUnits(decimals int, policy RoundingPolicy) (int64, error) {
+	if decimals < 0 || decimals > 9 {
+		return 0, fmt.Errorf("%w: unsupported precision of %d decimals", ErrInvalid, decimals)
+	}
+
+	minor, err := divRound(a.nanos(), nanosPerMinorUnit(decimals), policy)
+	if err != nil {
+		return 0, err
+	}
+	if !minor.IsInt64() {
+		return 0, fmt.Errorf("%w: %s does not fit in minor units", ErrOverflow, a)
+	}
+	return minor.Int64(), nil
+}
+
+// FromMinorUnits converts an integer count of 10^-decimals units to an Amount
+func FromMinorUnits(minor int64, decimals int, currency string) (Amount, error) {
+	if decimals < 0 || decimals > 9 {
+		return Amount{}, fmt.Errorf("%w: unsupported precision of %d decimals", ErrInvalid, decimals)
+	}
+	return fromNanos(currency, new(big.Int).Mul(big.NewInt(minor), nanosPerMinorUnit(decimals)))
+}
+
+// String formats the amount exactly, with all nine nano digits trimmed of trailing zeros
+func (a Amount) String() string {
+	sign := ""
+	units, nanos := a.Units, int64(a.Nanos)
+	if a.Sign() < 0 {
+		sign = "-"
+		units, nanos = -units, -nanos
+	}
+
+	s := fmt.Sprintf("%s%d", sign, uint64(units))
+	if nanos != 0 {
+		frac := fmt.Sprintf("%09d", nanos)
+		for frac[len(frac)-1] == '0' {
+			frac = frac[:len(frac)-1]
+		}
+		s += "." + frac
+	}
+	if a.Currency != "" {
+		s = a.Currency + " " + s
+	}
+	return s
+}
+
+// nanos returns the amount as a total count of nanos
+func (a Amount) nanos() *big.Int {
+	n := new(big.Int).Mul(big.NewInt(a.Units), nanosPerUnit)
+	return n.Add(n, big.NewInt(int64(a.Nanos)))
+}
+
+// fromNanos splits a total count of nanos into units and nanos, failing on overflow
+func fromNanos(currency string, n *big.Int) (Amount, error) {
+	if n.Cmp(maxNanos) > 0 || n.Cmp(minNanos) < 0 {
+		return Amount{}, ErrOverflow
+	}
+	units, nanos := new(big.Int).QuoRem(n, nanosPerUnit, new(big.Int))
+	return Amount{Currency: currency, Units: units.Int64(), Nanos: int32(nanos.Int64())}, nil
+}
+
+// nanosPerMinorUnit returns 10^(9-decimals)
+func nanosPerMinorUnit(decimals int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(9-decimals)), nil)
+}
@@ -0,0 +1,401 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	pb "github.com/gke-hackathon/payment-integration/proto"
+)
+
+func TestFromProto(t *testing.T) {
+	tests := []struct {
+		name  string
+		money *pb.Money
+		valid bool
+	}{
+		{"Positive", &pb.Money{Units: 5, Nanos: 250_000_000}, true},
+		{"Negative", &pb.Money{Units: -5, Nanos: -250_000_000}, true},
+		{"Negative nanos only", &pb.Money{Units: 0, Nanos: -1}, true},
+		{"Zero", &pb.Money{}, true},
+		{"Largest nanos", &pb.Money{Units: math.MaxInt64, Nanos: 999_999_999}, true},
+		{"Nanos too large", &pb.Money{Units: 1, Nanos: 1_000_000_000}, false},
+		{"Nanos too small", &pb.Money{Units: -1, Nanos: -1_000_000_000}, false},
+		{"Positive units negative nanos", &pb.Money{Units: 1, Nanos: -1}, false},
+		{"Negative units positive nanos", &pb.Money{Units: -1, Nanos: 1}, false},
+		{"Nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromProto(tt.money)
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid money, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalid) {
+				t.Errorf("Expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	usd := func(units int64, nanos int32) Amount { return Amount{Currency: "USD", Units: units, Nanos: nanos} }
+
+	sum, err := usd(1, 600_000_000).Add(usd(2, 500_000_000))
+	if err != nil || sum != usd(4, 100_000_000) {
+		t.Errorf("Expected USD 4.1, got %v (%v)", sum, err)
+	}
+
+	diff, err := usd(1, 0).Sub(usd(1, 10_000_000))
+	if err != nil || diff != usd(0, -10_000_000) {
+		t.Errorf("Expected USD -0.01, got %v (%v)", diff, err)
+	}
+
+	product, err := usd(0, 333_333_333).Mul(3)
+	if err != nil || product != usd(0, 999_999_999) {
+		t.Errorf("Expected USD 0.999999999, got %v (%v)", product, err)
+	}
+
+	if _, err := usd(1, 0).Add(Amount{Currency: "EUR", Units: 1}); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := usd(math.MaxInt64, 999_999_999).Add(usd(0, 1)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected ErrOverflow on add, got %v", err)
+	}
+	if _, err := usd(math.MaxInt64/2+1, 0).Mul(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected ErrOverflow on multiply, got %v", err)
+	}
+}
+
+func TestPercentage(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      Amount
+		basisPoints int64
+		policy      RoundingPolicy
+		expected    Amount
+		err         error
+	}{
+		{"2.9% fee", Amount{Units: 100}, 290, RoundReject, Amount{Units: 2, Nanos: 900_000_000}, nil},
+		{"Full refund", Amount{Units: 12, Nanos: 340_000_000}, 10_000, RoundReject, Amount{Units: 12, Nanos: 340_000_000}, nil},
+		{"Sub-nano rejected", Amount{Nanos: 1}, 5_000, RoundReject, Amount{}, ErrInexact},
+		{"Sub-nano half even", Amount{Nanos: 1}, 5_000, RoundHalfEven, Amount{}, nil},
+		{"Sub-nano half up", Amount{Nanos: 1}, 5_000, RoundHalfUp, Amount{Nanos: 1}, nil},
+		{"Negative half up", Amount{Nanos: -3}, 5_000, RoundHalfUp, Amount{Nanos: -2}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.amount.Percentage(tt.basisPoints, tt.policy)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestToMinorUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Amount
+		decimals int
+		policy   RoundingPolicy
+		expected int64
+		err      error
+	}{
+		{"Cents", Amount{Units: 15, Nanos: 990_000_000}, 2, RoundReject, 1599, nil},
+		{"Whole units", Amount{Units: 7}, 0, RoundReject, 7, nil},
+		{"Three decimals", Amount{Units: 1, Nanos: 234_000_000}, 3, RoundReject, 1234, nil},
+		{"Half even down", Amount{Nanos: 5_000_000}, 2, RoundHalfEven, 0, nil},
+		{"Half even up", Amount{Nanos: 15_000_000}, 2, RoundHalfEven, 2, nil},
+		{"Half up", Amount{Nanos: 5_000_000}, 2, RoundHalfUp, 1, nil},
+		{"Negative half even", Amount{Units: -1, Nanos: -15_000_000}, 2, RoundHalfEven, -102, nil},
+		{"Inexact", Amount{Nanos: 1}, 2, RoundReject, 0, ErrInexact},
+		{"Overflow", Amount{Units: math.MaxInt64}, 2, RoundReject, 0, ErrOverflow},
+		{"Bad precision", Amount{Units: 1}, 10, RoundReject, 0, ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.amount.ToMinorUnits(tt.decimals, tt.policy)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseRoundingPolicy(t *testing.T) {
+	for _, policy := range []RoundingPolicy{RoundReject, RoundHalfEven, RoundHalfUp} {
+		parsed, err := ParseRoundingPolicy(policy.String())
+		if err != nil || parsed != policy {
+			t.Errorf("Expected %v, got %v (%v)", policy, parsed, err)
+		}
+	}
+	if _, err := ParseRoundingPolicy("truncate"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		amount   Amount
+		expected string
+	}{
+		{Amount{Currency: "USD", Units: 10}, "USD 10"},
+		{Amount{Currency: "USD", Units: 1, Nanos: 500_000_000}, "USD 1.5"},
+		{Amount{Units: 0, Nanos: -1}, "-0.000000001"},
+		{Amount{Units: math.MinInt64 + 1}, "-9223372036854775807"},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, got)
+		}
+	}
+}
+
+func TestLookupCurrency(t *testing.T) {
+	tests := []struct {
+		code       string
+		minorUnits int
+		err        error
+	}{
+		{"USD", 2, nil},
+		{"JPY", 0, nil},
+		{"KWD", 3, nil},
+		{"BHD", 3, nil},
+		{"CLF", 4, nil},
+		{"usd", 0, ErrUnknownCurrency},
+		{"", 0, ErrUnknownCurrency},
+		{"XYZ", 0, ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		currency, err := LookupCurrency(tt.code)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%q: expected error %v, got %v", tt.code, tt.err, err)
+			continue
+		}
+		if currency.MinorUnits != tt.minorUnits {
+			t.Errorf("%q: expected %d minor units, got %d", tt.code, tt.minorUnits, currency.MinorUnits)
+		}
+	}
+
+	for code, currency := range currencies {
+		if len(code) != 3 || strings.ToUpper(code) != code || len(currency.Numeric) != 3 {
+			t.Errorf("Malformed ISO 4217 entry %+v", currency)
+		}
+	}
+}
+
+func TestCurrencyFormat(t *testing.T) {
+	tests := []struct {
+		code     string
+		minor    int64
+		expected string
+	}{
+		{"USD", 1050, "$10.50"},
+		{"USD", 5, "$0.05"},
+		{"USD", -5, "-$0.05"},
+		{"EUR", 0, "€0.00"},
+		{"JPY", 1050, "¥1050"},
+		{"KWD", 10500, "KWD 10.500"},
+		{"KWD", -7, "KWD -0.007"},
+		{"USD", math.MinInt64, "-$92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		currency, err := LookupCurrency(tt.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := currency.Format(tt.minor); got != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, got)
+		}
+	}
+}
+
+func TestToMinor(t *testing.T) {
+	tests := []struct {
+		amount   Amount
+		expected int64
+		err      error
+	}{
+		{Amount{Currency: "USD", Units: 1, Nanos: 500_000_000}, 150, nil},
+		{Amount{Currency: "JPY", Units: 100, Nanos: 500_000_000}, 100, nil},
+		{Amount{Currency: "JPY", Units: 101, Nanos: 500_000_000}, 102, nil},
+		{Amount{Currency: "BHD", Units: 1, Nanos: 234_500_000}, 1234, nil},
+		{Amount{Currency: "XYZ", Units: 1}, 0, ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		result, err := tt.amount.ToMinor(RoundHalfEven)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%v: expected error %v, got %v", tt.amount, tt.err, err)
+		}
+		if result != tt.expected {
+			t.Errorf("%v: expected %d, got %d", tt.amount, tt.expected, result)
+		}
+	}
+
+	back, err := FromMinor(2125, "KWD")
+	if err != nil || back != (Amount{Currency: "KWD", Units: 2, Nanos: 125_000_000}) {
+		t.Errorf("Expected KWD 2.125, got %v (%v)", back, err)
+	}
+}
+
+// Generate produces valid amounts, biased towards the edges of the range so the
+// property tests exercise overflow as well as ordinary values
+func (Amount) Generate(r *rand.Rand, size int) reflect.Value {
+	var units int64
+	switch r.Intn(4) {
+	case 0:
+		units = r.Int63() // anywhere up to MaxInt64
+	case 1:
+		units = math.MaxInt64 - r.Int63n(3)
+	default:
+		units = r.Int63n(1_000_000)
+	}
+	nanos := int32(r.Intn(NanosPerUnit))
+	if r.Intn(2) == 0 {
+		units, nanos = -units, -nanos
+	}
+	return reflect.ValueOf(Amount{Currency: "USD", Units: units, Nanos: nanos})
+}
+
+// exact returns the amount's exact value for comparison against big.Int arithmetic
+func exact(a Amount) *big.Int { return a.nanos() }
+
+func TestPropertyResultsAreValid(t *testing.T) {
+	valid := func(a, b Amount, factor int64) bool {
+		for _, op := range []func() (Amount, error){
+			func() (Amount, error) { return a.Add(b) },
+			func() (Amount, error) { return a.Sub(b) },
+			func() (Amount, error) { return a.Mul(factor) },
+			func() (Amount, error) { return a.Percentage(factor%20_000, RoundHalfEven) },
+		} {
+			result, err := op()
+			if err == nil && result.Validate() != nil {
+				return false
+			}
+			if err != nil && !errors.Is(err, ErrOverflow) {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(valid, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyArithmeticIsExact(t *testing.T) {
+	addExact := func(a, b Amount) bool {
+		sum, err := a.Add(b)
+		want := new(big.Int).Add(exact(a), exact(b))
+		if err != nil {
+			// Overflow must only be reported when the true result is out of range
+			return errors.Is(err, ErrOverflow) && (want.Cmp(maxNanos) > 0 || want.Cmp(minNanos) < 0)
+		}
+		return exact(sum).Cmp(want) == 0
+	}
+	if err := quick.Check(addExact, nil); err != nil {
+		t.Error(err)
+	}
+
+	mulExact := func(a Amount, factor int64) bool {
+		product, err := a.Mul(factor)
+		want := new(big.Int).Mul(exact(a), big.NewInt(factor))
+		if err != nil {
+			return errors.Is(err, ErrOverflow) && (want.Cmp(maxNanos) > 0 || want.Cmp(minNanos) < 0)
+		}
+		return exact(product).Cmp(want) == 0
+	}
+	if err := quick.Check(mulExact, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyAddSubRoundTrip(t *testing.T) {
+	roundTrip := func(a, b Amount) bool {
+		sum, err := a.Add(b)
+		if err != nil {
+			return true
+		}
+		back, err := sum.Sub(b)
+		return err == nil && back == a
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+
+	commutes := func(a, b Amount) bool {
+		ab, errAB := a.Add(b)
+		ba, errBA := b.Add(a)
+		return ab == ba && (errAB == nil) == (errBA == nil)
+	}
+	if err := quick.Check(commutes, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyRoundingIsNearest(t *testing.T) {
+	// Every rounding policy lands within half a cent of the exact value, and
+	// converting back from cents is exact
+	halfCent := big.NewInt(5_000_000)
+	nearest := func(a Amount) bool {
+		for _, policy := range []RoundingPolicy{RoundHalfEven, RoundHalfUp} {
+			cents, err := a.ToMinorUnits(2, policy)
+			if err != nil {
+				if !errors.Is(err, ErrOverflow) {
+					return false
+				}
+				continue
+			}
+			back, err := FromMinorUnits(cents, 2, a.Currency)
+			if err != nil {
+				return false
+			}
+			distance := new(big.Int).Sub(exact(back), exact(a))
+			if distance.Abs(distance).Cmp(halfCent) > 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(nearest, nil); err != nil {
+		t.Error(err)
+	}
+
+	// Rejecting only succeeds for whole cents
+	reject := func(a Amount) bool {
+		_, err := a.ToMinorUnits(2, RoundReject)
+		wholeCents := a.Nanos%10_000_000 == 0
+		return (err == nil || errors.Is(err, ErrOverflow)) == wholeCents
+	}
+	if err := quick.Check(reject, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyProtoRoundTrip(t *testing.T) {
+	roundTrip := func(a Amount) bool {
+		back, err := FromProto(a.Proto())
+		return err == nil && back == a
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
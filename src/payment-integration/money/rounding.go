@@ -0,0 +1,78 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RoundingPolicy decides what happens to amounts finer than the target precision
+type RoundingPolicy int
+
+const (
+	// RoundReject fails with ErrInexact instead of dropping any fraction
+	RoundReject RoundingPolicy = iota
+	// RoundHalfEven rounds to nearest, ties to the even neighbour (banker's rounding)
+	RoundHalfEven
+	// RoundHalfUp rounds to nearest, ties away from zero
+	RoundHalfUp
+)
+
+// String returns the policy's configuration name
+func (p RoundingPolicy) String() string {
+	switch p {
+	case RoundReject:
+		return "reject"
+	case RoundHalfEven:
+		return "half_even"
+	case RoundHalfUp:
+		return "half_up"
+	default:
+		return fmt.Sprintf("RoundingPolicy(%d)", int(p))
+	}
+}
+
+// ParseRoundingPolicy parses "reject", "half_even" or "half_up"
+func ParseRoundingPolicy(name string) (RoundingPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "reject":
+		return RoundReject, nil
+	case "half_even", "half-even", "bankers":
+		return RoundHalfEven, nil
+	case "half_up", "half-up":
+		return RoundHalfUp, nil
+	default:
+		return RoundReject, fmt.Errorf("unknown rounding policy %q", name)
+	}
+}
+
+// divRound returns n/d rounded according to policy. d must be positive.
+func divRound(n, d *big.Int, policy RoundingPolicy) (*big.Int, error) {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 {
+		return q, nil
+	}
+
+	// Compare twice the remainder's magnitude to the divisor to find the nearest neighbour
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	cmp := twice.Cmp(d)
+
+	away := false
+	switch policy {
+	case RoundReject:
+		return nil, ErrInexact
+	case RoundHalfUp:
+		away = cmp >= 0
+	case RoundHalfEven:
+		away = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+	default:
+		return nil, fmt.Errorf("unknown rounding policy %d", int(policy))
+	}
+
+	// QuoRem truncates toward zero, so rounding away moves in the direction of n's sign
+	if away {
+		q.Add(q, big.NewInt(int64(n.Sign())))
+	}
+	return q, nil
+}
@@ -0,0 +1,118 @@
+// Package profile keeps rolling per-account statistics of completed charges and
+// scores new charges by how far they stray from the account's usual behaviour.
+package profile
+
+import (
+	"math"
+	"time"
+)
+
+// Profile is an account's charging behaviour. Amounts, hours and intervals are
+// exponentially weighted moving averages, so recent charges count the most.
+type Profile struct {
+	Account string `json:"account"`
+	// Charges is the number of completed charges observed
+	Charges int64 `json:"charges"`
+	// AmountEWMA and AmountVariance describe the typical charge in ledger cents
+	AmountEWMA     float64 `json:"amount_ewma"`
+	AmountVariance float64 `json:"amount_variance"`
+	// HourWeights is a decayed histogram of the UTC hour of day charges are made
+	HourWeights [24]float64 `json:"hour_weights"`
+	// IntervalEWMA is the typical number of seconds between charges
+	IntervalEWMA float64 `json:"interval_ewma_seconds"`
+	// Cards maps the fingerprints of cards used on the account to their last use
+	Cards       map[string]time.Time `json:"cards,omitempty"`
+	FirstCharge time.Time            `json:"first_charge"`
+	LastCharge  time.Time            `json:"last_charge"`
+}
+
+// AmountStdDev returns the standard deviation of the account's charge amounts
+func (p *Profile) AmountStdDev() float64 {
+	return math.Sqrt(p.AmountVariance)
+}
+
+// TypicalHour returns the UTC hour of day the account charges most often, or -1
+// before its first charge
+func (p *Profile) TypicalHour() int {
+	hour, max := -1, 0.0
+	for h, weight := range p.HourWeights {
+		if weight > max {
+			hour, max = h, weight
+		}
+	}
+	return hour
+}
+
+// DistinctCards returns how many cards the account has been charged with
+func (p *Profile) DistinctCards() int {
+	return len(p.Cards)
+}
+
+// observe folds a completed charge into the profile
+func (p *Profile) observe(card string, amount int64, at time.Time, alpha float64, maxCards int) {
+	at = at.UTC()
+	x := float64(amount)
+
+	if p.Charges == 0 {
+		p.AmountEWMA = x
+		p.HourWeights[at.Hour()] = 1
+		p.FirstCharge = at
+	} else {
+		// Exponentially weighted mean and variance, after Finch (2009)
+		diff := x - p.AmountEWMA
+		incr := alpha * diff
+		p.AmountEWMA += incr
+		p.AmountVariance = (1 - alpha) * (p.AmountVariance + diff*incr)
+
+		for h := range p.HourWeights {
+			p.HourWeights[h] *= 1 - alpha
+		}
+		p.HourWeights[at.Hour()] += alpha
+
+		if elapsed := at.Sub(p.LastCharge).Seconds(); elapsed >= 0 {
+			if p.IntervalEWMA == 0 {
+				p.IntervalEWMA = elapsed
+			} else {
+				p.IntervalEWMA += alpha * (elapsed - p.IntervalEWMA)
+			}
+		}
+	}
+
+	if card != "" {
+		if p.Cards == nil {
+			p.Cards = make(map[string]time.Time)
+		}
+		p.Cards[card] = at
+		p.forgetCards(maxCards)
+	}
+
+	p.Charges++
+	if at.After(p.LastCharge) {
+		p.LastCharge = at
+	}
+}
+
+// forgetCards drops the least recently used cards beyond max
+func (p *Profile) forgetCards(max int) {
+	for len(p.Cards) > max {
+		var oldest string
+		for card, used := range p.Cards {
+			if oldest == "" || used.Before(p.Cards[oldest]) {
+				oldest = card
+			}
+		}
+		delete(p.Cards, oldest)
+	}
+}
+
+// clone returns a deep copy of the profile
+func (p *Profile) clone() *Profile {
+	c := *p
+	if p.Cards != nil {
+		c.Cards = make(map[string]time.Time, len(p.Cards))
+		for card, used := range p.Cards {
+			c.Cards[card] = used
+		}
+	}
+	return &c
+}
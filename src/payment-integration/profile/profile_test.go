@@ -0,0 +1,125 @@
+package profile
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var noon = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// observeDaily records one charge a day from start with the given amounts and
+// returns when the next one is due
+func observeDaily(t *testing.T, tracker *Tracker, account, card string, start time.Time, amounts ...int64) time.Time {
+	t.Helper()
+	at := start
+	for _, amount := range amounts {
+		if err := tracker.Observe(account, card, amount, at); err != nil {
+			t.Fatal(err)
+		}
+		at = at.Add(24 * time.Hour)
+	}
+	return at
+}
+
+func TestProfileStatistics(t *testing.T) {
+	tracker := NewTracker(NewMemoryStore(), Options{Alpha: 0.5})
+	observeDaily(t, tracker, "a", "c1", noon, 1000, 2000, 1000)
+
+	p, err := tracker.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1000, then 1000+0.5*1000 = 1500, then 1500-0.5*500 = 1250
+	if p.AmountEWMA != 1250 || p.Charges != 3 {
+		t.Errorf("Expected an EWMA of 1250 over 3 charges, got %v over %d", p.AmountEWMA, p.Charges)
+	}
+	if p.AmountStdDev() <= 0 {
+		t.Errorf("Expected a positive deviation, got %v", p.AmountStdDev())
+	}
+	if p.TypicalHour() != 12 || p.DistinctCards() != 1 {
+		t.Errorf("Expected hour 12 and one card, got %d and %d", p.TypicalHour(), p.DistinctCards())
+	}
+	if p.IntervalEWMA != 86400 {
+		t.Errorf("Expected a daily interval, got %v seconds", p.IntervalEWMA)
+	}
+
+	if _, err := tracker.Get("unknown"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestAnomalyScore(t *testing.T) {
+	tracker := NewTracker(NewMemoryStore(), Options{MinHistory: 5})
+
+	next := observeDaily(t, tracker, "a", "c1", noon, 1000, 1100, 900, 1000)
+	if a, _ := tracker.Score("a", "c1", 50000, next); !a.Learning || a.Score != 0 {
+		t.Fatalf("Expected no score while learning, got %+v", a)
+	}
+	next = observeDaily(t, tracker, "a", "c1", next, 1000, 1000, 1050, 950, 1000, 1000)
+
+	tests := []struct {
+		name     string
+		card     string
+		amount   int64
+		at       time.Time
+		min, max float64
+	}{
+		{"typical", "c1", 1000, next, 0, 5},
+		{"large amount", "c1", 50000, next, 40, 40},
+		{"new card", "c2", 1000, next, 20, 20},
+		{"3am", "c1", 1000, next.Add(15 * time.Hour), 20, 20},
+		{"minutes after the last", "c1", 1000, next.Add(-24*time.Hour + 5*time.Minute), 19, 20},
+		{"large, new card, right away", "c2", 50000, next.Add(-24*time.Hour + 5*time.Minute), 79, 80},
+	}
+
+	for _, tt := range tests {
+		a, err := tracker.Score("a", tt.card, tt.amount, tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Learning || a.Score < tt.min || a.Score > tt.max {
+			t.Errorf("%s: expected a score from %v to %v, got %+v", tt.name, tt.min, tt.max, a)
+		}
+	}
+}
+
+func TestCardsAreCapped(t *testing.T) {
+	tracker := NewTracker(NewMemoryStore(), Options{MaxCards: 2})
+	for i, card := range []string{"c1", "c2", "c3"} {
+		if err := tracker.Observe("a", card, 1000, noon.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, _ := tracker.Get("a")
+	if _, ok := p.Cards["c1"]; ok || p.DistinctCards() != 2 {
+		t.Errorf("Expected the oldest card to be forgotten, got %v", p.Cards)
+	}
+}
+
+func TestFileStorePersistsProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	observeDaily(t, NewTracker(store, Options{}), "a", "c1", noon, 1000, 3000)
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := reopened.Get("a")
+	if err != nil {
+		t.Fatalf("Expected profile after reopening, got %v", err)
+	}
+	if p.Charges != 2 || math.Abs(p.AmountEWMA-1200) > 1e-9 || p.TypicalHour() != 12 {
+		t.Errorf("Unexpected reopened profile %+v", p)
+	}
+	if _, ok := p.Cards["c1"]; !ok {
+		t.Errorf("Expected card c1 to be remembered, got %v", p.Cards)
+	}
+}
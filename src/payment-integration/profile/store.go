@@ -0,0 +1,97 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+// ErrNotFound is returned for accounts without a profile
+var ErrNotFound = errors.New("profile not found")
+
+// Store persists account profiles
+type Store interface {
+	Put(profile *Profile) error
+	Get(account string) (*Profile, error)
+}
+
+// MemoryStore keeps profiles in memory
+type MemoryStore struct {
+	mu       sync.RWMutex
+	profiles map[string]*Profile
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{profiles: make(map[string]*Profile)}
+}
+
+// Put inserts or replaces a profile
+func (s *MemoryStore) Put(profile *Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.profiles[profile.Account] = profile.clone()
+	return nil
+}
+
+// Get returns a copy of the account's profile or ErrNotFound
+func (s *MemoryStore) Get(account string) (*Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profile, ok := s.profiles[account]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return profile.clone(), nil
+}
+
+// list returns all profiles ordered by account
+func (s *MemoryStore) list() []*Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profiles := make([]*Profile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Account < profiles[j].Account })
+	return profiles
+}
+
+// FileStore is a MemoryStore that writes through to a JSON file
+type FileStore struct {
+	*MemoryStore
+	path    string
+	writeMu sync.Mutex
+}
+
+// NewFileStore loads profiles from path, starting empty if the file does not exist
+func NewFileStore(path string) (*FileStore, error) {
+	var profiles []*Profile
+	if _, err := storage.ReadJSON(path, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to load profiles: %w", err)
+	}
+
+	store := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	for _, profile := range profiles {
+		store.MemoryStore.Put(profile)
+	}
+	return store, nil
+}
+
+// Put inserts or replaces a profile and persists the store
+func (s *FileStore) Put(profile *Profile) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.MemoryStore.Put(profile)
+	if err := storage.WriteJSON(s.path, s.MemoryStore.list()); err != nil {
+		return fmt.Errorf("failed to persist profiles: %w", err)
+	}
+	return nil
+}
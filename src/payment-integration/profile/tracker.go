@@ -0,0 +1,145 @@
+package profile
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Defaults for Options fields left zero
+const (
+	DefaultAlpha      = 0.1
+	DefaultMinHistory = 5
+	DefaultMaxCards   = 20
+)
+
+// Weights of the anomaly components in the overall score; they sum to 1
+const (
+	amountWeight    = 0.4
+	hourWeight      = 0.2
+	frequencyWeight = 0.2
+	cardWeight      = 0.2
+)
+
+// Options tunes how profiles learn and score
+type Options struct {
+	// Alpha is the EWMA smoothing factor, between 0 and 1; higher adapts faster
+	Alpha float64
+	// MinHistory is how many charges an account needs before it is scored
+	MinHistory int
+	// MaxCards is how many of an account's most recent cards are remembered
+	MaxCards int
+}
+
+// Anomaly scores how unusual a charge is for its account, from 0 (typical) to
+// 100. Each component is between 0 and 1.
+type Anomaly struct {
+	Score float64 `json:"score"`
+	// Amount grows with how many standard deviations the charge is above the average
+	Amount float64 `json:"amount"`
+	// Hour is how rarely the account charges at this hour of day
+	Hour float64 `json:"hour"`
+	// Frequency is how much sooner than usual the charge follows the last one
+	Frequency float64 `json:"frequency"`
+	// Card is 1 for a card the account hasn't used before
+	Card float64 `json:"card"`
+	// Learning is set while the account has too little history to be scored
+	Learning bool `json:"learning,omitempty"`
+}
+
+// Tracker maintains account profiles in a store and scores charges against them
+type Tracker struct {
+	store Store
+	opts  Options
+
+	// mu serializes read-modify-write updates of profiles
+	mu sync.Mutex
+}
+
+// NewTracker creates a tracker over store
+func NewTracker(store Store, opts Options) *Tracker {
+	if opts.Alpha <= 0 || opts.Alpha >= 1 {
+		opts.Alpha = DefaultAlpha
+	}
+	if opts.MinHistory <= 0 {
+		opts.MinHistory = DefaultMinHistory
+	}
+	if opts.MaxCards <= 0 {
+		opts.MaxCards = DefaultMaxCards
+	}
+	return &Tracker{store: store, opts: opts}
+}
+
+// Get returns the account's profile or ErrNotFound
+func (t *Tracker) Get(account string) (*Profile, error) {
+	return t.store.Get(account)
+}
+
+// Observe folds a completed charge into the account's profile
+func (t *Tracker) Observe(account, card string, amount int64, at time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, err := t.store.Get(account)
+	if errors.Is(err, ErrNotFound) {
+		p, err = &Profile{Account: account}, nil
+	}
+	if err != nil {
+		return err
+	}
+	p.observe(card, amount, at, t.opts.Alpha, t.opts.MaxCards)
+	return t.store.Put(p)
+}
+
+// Score rates how unusual a charge is for the account. Accounts with fewer than
+// MinHistory charges are still learning and score 0.
+func (t *Tracker) Score(account, card string, amount int64, at time.Time) (Anomaly, error) {
+	p, err := t.store.Get(account)
+	if errors.Is(err, ErrNotFound) {
+		return Anomaly{Learning: true}, nil
+	}
+	if err != nil {
+		return Anomaly{}, err
+	}
+	if p.Charges < int64(t.opts.MinHistory) {
+		return Anomaly{Learning: true}, nil
+	}
+	return score(p, card, amount, at.UTC()), nil
+}
+
+func score(p *Profile, card string, amount int64, at time.Time) Anomaly {
+	var a Anomaly
+
+	// Only charges above the average are suspicious. The deviation is floored
+	// so an account that always charges the same amount isn't flagged for a
+	// cent more.
+	stddev := math.Max(p.AmountStdDev(), math.Max(p.AmountEWMA*0.1, 100))
+	if z := (float64(amount) - p.AmountEWMA) / stddev; z > 1 {
+		a.Amount = clamp((z - 1) / 3)
+	}
+
+	if typical := p.TypicalHour(); typical >= 0 {
+		a.Hour = 1 - p.HourWeights[at.Hour()]/p.HourWeights[typical]
+	}
+
+	if p.IntervalEWMA > 0 {
+		if elapsed := at.Sub(p.LastCharge).Seconds(); elapsed >= 0 {
+			a.Frequency = clamp(1 - elapsed/p.IntervalEWMA)
+		}
+	}
+
+	if card != "" {
+		if _, known := p.Cards[card]; !known {
+			a.Card = 1
+		}
+	}
+
+	a.Score = 100 * (amountWeight*a.Amount + hourWeight*a.Hour + frequencyWeight*a.Frequency + cardWeight*a.Card)
+	a.Score = math.Round(a.Score*10) / 10
+	return a
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,2055 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v4.24.4
+// source: proto/admin.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CardLink struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Keyed hash of the card number, used to address the link
+	CardId     string `protobuf:"bytes,1,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`
+	LastFour   string `protobuf:"bytes,2,opt,name=last_four,json=lastFour,proto3" json:"last_four,omitempty"`
+	AccountNum string `protobuf:"bytes,3,opt,name=account_num,json=accountNum,proto3" json:"account_num,omitempty"`
+	RoutingNum string `protobuf:"bytes,4,opt,name=routing_num,json=routingNum,proto3" json:"routing_num,omitempty"`
+	OwnerId    string `protobuf:"bytes,5,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`
+	// "active" or "suspended"
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CardLink) Reset() {
+	*x = CardLink{}
+	mi := &file_proto_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CardLink) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CardLink) ProtoMessage() {}
+
+func (x *CardLink) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CardLink.ProtoReflect.Descriptor instead.
+func (*CardLink) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CardLink) GetCardId() string {
+	if x != nil {
+		return x.CardId
+	}
+	return ""
+}
+
+func (x *CardLink) GetLastFour() string {
+	if x != nil {
+		return x.LastFour
+	}
+	return ""
+}
+
+func (x *CardLink) GetAccountNum() string {
+	if x != nil {
+		return x.AccountNum
+	}
+	return ""
+}
+
+func (x *CardLink) GetRoutingNum() string {
+	if x != nil {
+		return x.RoutingNum
+	}
+	return ""
+}
+
+func (x *CardLink) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+func (x *CardLink) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *CardLink) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *CardLink) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type EnrollCardRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	CardNumber string                 `protobuf:"bytes,1,opt,name=card_number,json=cardNumber,proto3" json:"card_number,omitempty"`
+	AccountNum string                 `protobuf:"bytes,2,opt,name=account_num,json=accountNum,proto3" json:"account_num,omitempty"`
+	// Defaults to the service's routing number when empty
+	RoutingNum    string `protobuf:"bytes,3,opt,name=routing_num,json=routingNum,proto3" json:"routing_num,omitempty"`
+	OwnerId       string `protobuf:"bytes,4,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrollCardRequest) Reset() {
+	*x = EnrollCardRequest{}
+	mi := &file_proto_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrollCardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrollCardRequest) ProtoMessage() {}
+
+func (x *EnrollCardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrollCardRequest.ProtoReflect.Descriptor instead.
+func (*EnrollCardRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EnrollCardRequest) GetCardNumber() string {
+	if x != nil {
+		return x.CardNumber
+	}
+	return ""
+}
+
+func (x *EnrollCardRequest) GetAccountNum() string {
+	if x != nil {
+		return x.AccountNum
+	}
+	return ""
+}
+
+func (x *EnrollCardRequest) GetRoutingNum() string {
+	if x != nil {
+		return x.RoutingNum
+	}
+	return ""
+}
+
+func (x *EnrollCardRequest) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+// Filters are combined; an empty request lists every link
+type ListCardLinksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LastFour      string                 `protobuf:"bytes,1,opt,name=last_four,json=lastFour,proto3" json:"last_four,omitempty"`
+	AccountNum    string                 `protobuf:"bytes,2,opt,name=account_num,json=accountNum,proto3" json:"account_num,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCardLinksRequest) Reset() {
+	*x = ListCardLinksRequest{}
+	mi := &file_proto_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCardLinksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCardLinksRequest) ProtoMessage() {}
+
+func (x *ListCardLinksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCardLinksRequest.ProtoReflect.Descriptor instead.
+func (*ListCardLinksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListCardLinksRequest) GetLastFour() string {
+	if x != nil {
+		return x.LastFour
+	}
+	return ""
+}
+
+func (x *ListCardLinksRequest) GetAccountNum() string {
+	if x != nil {
+		return x.AccountNum
+	}
+	return ""
+}
+
+type ListCardLinksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Links         []*CardLink            `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCardLinksResponse) Reset() {
+	*x = ListCardLinksResponse{}
+	mi := &file_proto_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCardLinksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCardLinksResponse) ProtoMessage() {}
+
+func (x *ListCardLinksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCardLinksResponse.ProtoReflect.Descriptor instead.
+func (*ListCardLinksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListCardLinksResponse) GetLinks() []*CardLink {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+// Cards are addressed by card_id or, if it is empty, by card_number
+type SuspendCardRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CardId        string                 `protobuf:"bytes,1,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`
+	CardNumber    string                 `protobuf:"bytes,2,opt,name=card_number,json=cardNumber,proto3" json:"card_number,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuspendCardRequest) Reset() {
+	*x = SuspendCardRequest{}
+	mi := &file_proto_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuspendCardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendCardRequest) ProtoMessage() {}
+
+func (x *SuspendCardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendCardRequest.ProtoReflect.Descriptor instead.
+func (*SuspendCardRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SuspendCardRequest) GetCardId() string {
+	if x != nil {
+		return x.CardId
+	}
+	return ""
+}
+
+func (x *SuspendCardRequest) GetCardNumber() string {
+	if x != nil {
+		return x.CardNumber
+	}
+	return ""
+}
+
+func (x *SuspendCardRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type UnlinkCardRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CardId        string                 `protobuf:"bytes,1,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`
+	CardNumber    string                 `protobuf:"bytes,2,opt,name=card_number,json=cardNumber,proto3" json:"card_number,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlinkCardRequest) Reset() {
+	*x = UnlinkCardRequest{}
+	mi := &file_proto_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlinkCardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlinkCardRequest) ProtoMessage() {}
+
+func (x *UnlinkCardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlinkCardRequest.ProtoReflect.Descriptor instead.
+func (*UnlinkCardRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UnlinkCardRequest) GetCardId() string {
+	if x != nil {
+		return x.CardId
+	}
+	return ""
+}
+
+func (x *UnlinkCardRequest) GetCardNumber() string {
+	if x != nil {
+		return x.CardNumber
+	}
+	return ""
+}
+
+func (x *UnlinkCardRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type UnlinkCardResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CardId        string                 `protobuf:"bytes,1,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlinkCardResponse) Reset() {
+	*x = UnlinkCardResponse{}
+	mi := &file_proto_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlinkCardResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlinkCardResponse) ProtoMessage() {}
+
+func (x *UnlinkCardResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlinkCardResponse.ProtoReflect.Descriptor instead.
+func (*UnlinkCardResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UnlinkCardResponse) GetCardId() string {
+	if x != nil {
+		return x.CardId
+	}
+	return ""
+}
+
+type RiskRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Score         int32                  `protobuf:"varint,2,opt,name=score,proto3" json:"score,omitempty"`
+	Detail        string                 `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RiskRule) Reset() {
+	*x = RiskRule{}
+	mi := &file_proto_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RiskRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RiskRule) ProtoMessage() {}
+
+func (x *RiskRule) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RiskRule.ProtoReflect.Descriptor instead.
+func (*RiskRule) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RiskRule) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *RiskRule) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *RiskRule) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+type ReviewItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	// "pending_review", "completed", "simulated" or "declined"
+	Status       string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	FromAccount  string `protobuf:"bytes,3,opt,name=from_account,json=fromAccount,proto3" json:"from_account,omitempty"`
+	FromRouting  string `protobuf:"bytes,4,opt,name=from_routing,json=fromRouting,proto3" json:"from_routing,omitempty"`
+	ToAccount    string `protobuf:"bytes,5,opt,name=to_account,json=toAccount,proto3" json:"to_account,omitempty"`
+	ToRouting    string `protobuf:"bytes,6,opt,name=to_routing,json=toRouting,proto3" json:"to_routing,omitempty"`
+	CardLastFour string `protobuf:"bytes,7,opt,name=card_last_four,json=cardLastFour,proto3" json:"card_last_four,omitempty"`
+	// Amount in the ledger currency's minor units
+	Amount   int64  `protobuf:"varint,8,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency string `protobuf:"bytes,9,opt,name=currency,proto3" json:"currency,omitempty"`
+	// The amount as charged, e.g. "EUR 10.5"
+	OriginalAmount string                 `protobuf:"bytes,10,opt,name=original_amount,json=originalAmount,proto3" json:"original_amount,omitempty"`
+	RiskScore      int32                  `protobuf:"varint,11,opt,name=risk_score,json=riskScore,proto3" json:"risk_score,omitempty"`
+	RiskRules      []*RiskRule            `protobuf:"bytes,12,rep,name=risk_rules,json=riskRules,proto3" json:"risk_rules,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Deadline       *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	// "approved", "rejected" or "expired" once decided
+	Decision      string                 `protobuf:"bytes,15,opt,name=decision,proto3" json:"decision,omitempty"`
+	Reviewer      string                 `protobuf:"bytes,16,opt,name=reviewer,proto3" json:"reviewer,omitempty"`
+	Note          string                 `protobuf:"bytes,17,opt,name=note,proto3" json:"note,omitempty"`
+	DecidedAt     *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=decided_at,json=decidedAt,proto3" json:"decided_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReviewItem) Reset() {
+	*x = ReviewItem{}
+	mi := &file_proto_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReviewItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewItem) ProtoMessage() {}
+
+func (x *ReviewItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewItem.ProtoReflect.Descriptor instead.
+func (*ReviewItem) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReviewItem) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetFromAccount() string {
+	if x != nil {
+		return x.FromAccount
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetFromRouting() string {
+	if x != nil {
+		return x.FromRouting
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetToAccount() string {
+	if x != nil {
+		return x.ToAccount
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetToRouting() string {
+	if x != nil {
+		return x.ToRouting
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetCardLastFour() string {
+	if x != nil {
+		return x.CardLastFour
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ReviewItem) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetOriginalAmount() string {
+	if x != nil {
+		return x.OriginalAmount
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetRiskScore() int32 {
+	if x != nil {
+		return x.RiskScore
+	}
+	return 0
+}
+
+func (x *ReviewItem) GetRiskRules() []*RiskRule {
+	if x != nil {
+		return x.RiskRules
+	}
+	return nil
+}
+
+func (x *ReviewItem) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *ReviewItem) GetDeadline() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Deadline
+	}
+	return nil
+}
+
+func (x *ReviewItem) GetDecision() string {
+	if x != nil {
+		return x.Decision
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetReviewer() string {
+	if x != nil {
+		return x.Reviewer
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *ReviewItem) GetDecidedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DecidedAt
+	}
+	return nil
+}
+
+// Lists the charges awaiting review, soonest deadline first
+type ListReviewsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountNum    string                 `protobuf:"bytes,1,opt,name=account_num,json=accountNum,proto3" json:"account_num,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReviewsRequest) Reset() {
+	*x = ListReviewsRequest{}
+	mi := &file_proto_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReviewsRequest) ProtoMessage() {}
+
+func (x *ListReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReviewsRequest.ProtoReflect.Descriptor instead.
+func (*ListReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListReviewsRequest) GetAccountNum() string {
+	if x != nil {
+		return x.AccountNum
+	}
+	return ""
+}
+
+type ListReviewsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*ReviewItem          `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReviewsResponse) Reset() {
+	*x = ListReviewsResponse{}
+	mi := &file_proto_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReviewsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReviewsResponse) ProtoMessage() {}
+
+func (x *ListReviewsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReviewsResponse.ProtoReflect.Descriptor instead.
+func (*ListReviewsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListReviewsResponse) GetItems() []*ReviewItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type GetReviewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReviewRequest) Reset() {
+	*x = GetReviewRequest{}
+	mi := &file_proto_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReviewRequest) ProtoMessage() {}
+
+func (x *GetReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReviewRequest.ProtoReflect.Descriptor instead.
+func (*GetReviewRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetReviewRequest) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+type DecideReviewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	Note          string                 `protobuf:"bytes,2,opt,name=note,proto3" json:"note,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecideReviewRequest) Reset() {
+	*x = DecideReviewRequest{}
+	mi := &file_proto_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecideReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecideReviewRequest) ProtoMessage() {}
+
+func (x *DecideReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecideReviewRequest.ProtoReflect.Descriptor instead.
+func (*DecideReviewRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DecideReviewRequest) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+func (x *DecideReviewRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+type GetAccountProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountNum    string                 `protobuf:"bytes,1,opt,name=account_num,json=accountNum,proto3" json:"account_num,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountProfileRequest) Reset() {
+	*x = GetAccountProfileRequest{}
+	mi := &file_proto_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountProfileRequest) ProtoMessage() {}
+
+func (x *GetAccountProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountProfileRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountProfileRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetAccountProfileRequest) GetAccountNum() string {
+	if x != nil {
+		return x.AccountNum
+	}
+	return ""
+}
+
+// Rolling statistics of an account's completed charges. Averages are
+// exponentially weighted, so recent charges count the most.
+type AccountProfile struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	AccountNum string                 `protobuf:"bytes,1,opt,name=account_num,json=accountNum,proto3" json:"account_num,omitempty"`
+	Charges    int64                  `protobuf:"varint,2,opt,name=charges,proto3" json:"charges,omitempty"`
+	// Average and standard deviation of the amount in ledger minor units
+	AverageAmount float64 `protobuf:"fixed64,3,opt,name=average_amount,json=averageAmount,proto3" json:"average_amount,omitempty"`
+	AmountStddev  float64 `protobuf:"fixed64,4,opt,name=amount_stddev,json=amountStddev,proto3" json:"amount_stddev,omitempty"`
+	// UTC hour of day the account charges most often
+	TypicalHour int32 `protobuf:"varint,5,opt,name=typical_hour,json=typicalHour,proto3" json:"typical_hour,omitempty"`
+	// Share of charges made in each UTC hour of day, 0 to 23
+	HourWeights            []float64              `protobuf:"fixed64,6,rep,packed,name=hour_weights,json=hourWeights,proto3" json:"hour_weights,omitempty"`
+	AverageIntervalSeconds float64                `protobuf:"fixed64,7,opt,name=average_interval_seconds,json=averageIntervalSeconds,proto3" json:"average_interval_seconds,omitempty"`
+	DistinctCards          int32                  `protobuf:"varint,8,opt,name=distinct_cards,json=distinctCards,proto3" json:"distinct_cards,omitempty"`
+	FirstCharge            *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=first_charge,json=firstCharge,proto3" json:"first_charge,omitempty"`
+	LastCharge             *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=last_charge,json=lastCharge,proto3" json:"last_charge,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *AccountProfile) Reset() {
+	*x = AccountProfile{}
+	mi := &file_proto_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccountProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccountProfile) ProtoMessage() {}
+
+func (x *AccountProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccountProfile.ProtoReflect.Descriptor instead.
+func (*AccountProfile) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *AccountProfile) GetAccountNum() string {
+	if x != nil {
+		return x.AccountNum
+	}
+	return ""
+}
+
+func (x *AccountProfile) GetCharges() int64 {
+	if x != nil {
+		return x.Charges
+	}
+	return 0
+}
+
+func (x *AccountProfile) GetAverageAmount() float64 {
+	if x != nil {
+		return x.AverageAmount
+	}
+	return 0
+}
+
+func (x *AccountProfile) GetAmountStddev() float64 {
+	if x != nil {
+		return x.AmountStddev
+	}
+	return 0
+}
+
+func (x *AccountProfile) GetTypicalHour() int32 {
+	if x != nil {
+		return x.TypicalHour
+	}
+	return 0
+}
+
+func (x *AccountProfile) GetHourWeights() []float64 {
+	if x != nil {
+		return x.HourWeights
+	}
+	return nil
+}
+
+func (x *AccountProfile) GetAverageIntervalSeconds() float64 {
+	if x != nil {
+		return x.AverageIntervalSeconds
+	}
+	return 0
+}
+
+func (x *AccountProfile) GetDistinctCards() int32 {
+	if x != nil {
+		return x.DistinctCards
+	}
+	return 0
+}
+
+func (x *AccountProfile) GetFirstCharge() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FirstCharge
+	}
+	return nil
+}
+
+func (x *AccountProfile) GetLastCharge() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastCharge
+	}
+	return nil
+}
+
+type GetSpendAllowanceRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	AccountNum string                 `protobuf:"bytes,1,opt,name=account_num,json=accountNum,proto3" json:"account_num,omitempty"`
+	// "customer" (the default) or "merchant"
+	Scope         string `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSpendAllowanceRequest) Reset() {
+	*x = GetSpendAllowanceRequest{}
+	mi := &file_proto_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSpendAllowanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSpendAllowanceRequest) ProtoMessage() {}
+
+func (x *GetSpendAllowanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSpendAllowanceRequest.ProtoReflect.Descriptor instead.
+func (*GetSpendAllowanceRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetSpendAllowanceRequest) GetAccountNum() string {
+	if x != nil {
+		return x.AccountNum
+	}
+	return ""
+}
+
+func (x *GetSpendAllowanceRequest) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+// Amounts are in ledger minor units
+type SpendWindow struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 0 when the period is uncapped
+	Cap           int64                  `protobuf:"varint,1,opt,name=cap,proto3" json:"cap,omitempty"`
+	Spent         int64                  `protobuf:"varint,2,opt,name=spent,proto3" json:"spent,omitempty"`
+	Remaining     int64                  `protobuf:"varint,3,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	ResetsAt      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=resets_at,json=resetsAt,proto3" json:"resets_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpendWindow) Reset() {
+	*x = SpendWindow{}
+	mi := &file_proto_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpendWindow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpendWindow) ProtoMessage() {}
+
+func (x *SpendWindow) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpendWindow.ProtoReflect.Descriptor instead.
+func (*SpendWindow) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SpendWindow) GetCap() int64 {
+	if x != nil {
+		return x.Cap
+	}
+	return 0
+}
+
+func (x *SpendWindow) GetSpent() int64 {
+	if x != nil {
+		return x.Spent
+	}
+	return 0
+}
+
+func (x *SpendWindow) GetRemaining() int64 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+func (x *SpendWindow) GetResetsAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ResetsAt
+	}
+	return nil
+}
+
+type SpendAllowance struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountNum    string                 `protobuf:"bytes,1,opt,name=account_num,json=accountNum,proto3" json:"account_num,omitempty"`
+	Scope         string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
+	Daily         *SpendWindow           `protobuf:"bytes,3,opt,name=daily,proto3" json:"daily,omitempty"`
+	Monthly       *SpendWindow           `protobuf:"bytes,4,opt,name=monthly,proto3" json:"monthly,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpendAllowance) Reset() {
+	*x = SpendAllowance{}
+	mi := &file_proto_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpendAllowance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpendAllowance) ProtoMessage() {}
+
+func (x *SpendAllowance) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpendAllowance.ProtoReflect.Descriptor instead.
+func (*SpendAllowance) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SpendAllowance) GetAccountNum() string {
+	if x != nil {
+		return x.AccountNum
+	}
+	return ""
+}
+
+func (x *SpendAllowance) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *SpendAllowance) GetDaily() *SpendWindow {
+	if x != nil {
+		return x.Daily
+	}
+	return nil
+}
+
+func (x *SpendAllowance) GetMonthly() *SpendWindow {
+	if x != nil {
+		return x.Monthly
+	}
+	return nil
+}
+
+type BlockEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "account", "card" or "bin"
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// Account number, card fingerprint, or BIN prefix or range such as "400000-400999"
+	Value  string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Unset for entries that never expire
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	CreatedBy string                 `protobuf:"bytes,5,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// "admin" or "file"
+	Source        string `protobuf:"bytes,7,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockEntry) Reset() {
+	*x = BlockEntry{}
+	mi := &file_proto_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockEntry) ProtoMessage() {}
+
+func (x *BlockEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockEntry.ProtoReflect.Descriptor instead.
+func (*BlockEntry) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BlockEntry) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *BlockEntry) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *BlockEntry) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *BlockEntry) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *BlockEntry) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *BlockEntry) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *BlockEntry) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type AddBlockRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Value string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// For card entries, the card number to fingerprint instead of a value
+	CardNumber string                 `protobuf:"bytes,3,opt,name=card_number,json=cardNumber,proto3" json:"card_number,omitempty"`
+	Reason     string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	ExpiresAt  *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Alternative to expires_at: expire this many seconds from now
+	TtlSeconds    int64 `protobuf:"varint,6,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddBlockRequest) Reset() {
+	*x = AddBlockRequest{}
+	mi := &file_proto_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddBlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddBlockRequest) ProtoMessage() {}
+
+func (x *AddBlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddBlockRequest.ProtoReflect.Descriptor instead.
+func (*AddBlockRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AddBlockRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *AddBlockRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *AddBlockRequest) GetCardNumber() string {
+	if x != nil {
+		return x.CardNumber
+	}
+	return ""
+}
+
+func (x *AddBlockRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *AddBlockRequest) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *AddBlockRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type RemoveBlockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	CardNumber    string                 `protobuf:"bytes,3,opt,name=card_number,json=cardNumber,proto3" json:"card_number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveBlockRequest) Reset() {
+	*x = RemoveBlockRequest{}
+	mi := &file_proto_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveBlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveBlockRequest) ProtoMessage() {}
+
+func (x *RemoveBlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveBlockRequest.ProtoReflect.Descriptor instead.
+func (*RemoveBlockRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RemoveBlockRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *RemoveBlockRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *RemoveBlockRequest) GetCardNumber() string {
+	if x != nil {
+		return x.CardNumber
+	}
+	return ""
+}
+
+type ListBlocksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only list entries of this kind when set
+	Kind          string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBlocksRequest) Reset() {
+	*x = ListBlocksRequest{}
+	mi := &file_proto_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBlocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBlocksRequest) ProtoMessage() {}
+
+func (x *ListBlocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBlocksRequest.ProtoReflect.Descriptor instead.
+func (*ListBlocksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListBlocksRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+type ListBlocksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*BlockEntry          `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBlocksResponse) Reset() {
+	*x = ListBlocksResponse{}
+	mi := &file_proto_admin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBlocksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBlocksResponse) ProtoMessage() {}
+
+func (x *ListBlocksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBlocksResponse.ProtoReflect.Descriptor instead.
+func (*ListBlocksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListBlocksResponse) GetEntries() []*BlockEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type GetConfigStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigStatusRequest) Reset() {
+	*x = GetConfigStatusRequest{}
+	mi := &file_proto_admin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigStatusRequest) ProtoMessage() {}
+
+func (x *GetConfigStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetConfigStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{23}
+}
+
+type ReloadConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadConfigRequest) Reset() {
+	*x = ReloadConfigRequest{}
+	mi := &file_proto_admin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigRequest) ProtoMessage() {}
+
+func (x *ReloadConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigRequest.ProtoReflect.Descriptor instead.
+func (*ReloadConfigRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{24}
+}
+
+type ConfigStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Counts the configurations applied, 1 for the one loaded at startup
+	Version   int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Checksum  string                 `protobuf:"bytes,2,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Path      string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	AppliedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=applied_at,json=appliedAt,proto3" json:"applied_at,omitempty"`
+	// Unset until the first reload
+	LastReloadAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_reload_at,json=lastReloadAt,proto3" json:"last_reload_at,omitempty"`
+	// "applied", "unchanged" or "failed"
+	LastReloadResult string   `protobuf:"bytes,6,opt,name=last_reload_result,json=lastReloadResult,proto3" json:"last_reload_result,omitempty"`
+	LastReloadError  string   `protobuf:"bytes,7,opt,name=last_reload_error,json=lastReloadError,proto3" json:"last_reload_error,omitempty"`
+	RestartRequired  []string `protobuf:"bytes,8,rep,name=restart_required,json=restartRequired,proto3" json:"restart_required,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ConfigStatus) Reset() {
+	*x = ConfigStatus{}
+	mi := &file_proto_admin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigStatus) ProtoMessage() {}
+
+func (x *ConfigStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigStatus.ProtoReflect.Descriptor instead.
+func (*ConfigStatus) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ConfigStatus) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ConfigStatus) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *ConfigStatus) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ConfigStatus) GetAppliedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AppliedAt
+	}
+	return nil
+}
+
+func (x *ConfigStatus) GetLastReloadAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastReloadAt
+	}
+	return nil
+}
+
+func (x *ConfigStatus) GetLastReloadResult() string {
+	if x != nil {
+		return x.LastReloadResult
+	}
+	return ""
+}
+
+func (x *ConfigStatus) GetLastReloadError() string {
+	if x != nil {
+		return x.LastReloadError
+	}
+	return ""
+}
+
+func (x *ConfigStatus) GetRestartRequired() []string {
+	if x != nil {
+		return x.RestartRequired
+	}
+	return nil
+}
+
+var File_proto_admin_proto protoreflect.FileDescriptor
+
+const file_proto_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x11proto/admin.proto\x12\fpaymentadmin\x1a\x1fgoogle/protobuf/timestamp.proto\"\xab\x02\n" +
+	"\bCardLink\x12\x17\n" +
+	"\acard_id\x18\x01 \x01(\tR\x06cardId\x12\x1b\n" +
+	"\tlast_four\x18\x02 \x01(\tR\blastFour\x12\x1f\n" +
+	"\vaccount_num\x18\x03 \x01(\tR\n" +
+	"accountNum\x12\x1f\n" +
+	"\vrouting_num\x18\x04 \x01(\tR\n" +
+	"routingNum\x12\x19\n" +
+	"\bowner_id\x18\x05 \x01(\tR\aownerId\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x91\x01\n" +
+	"\x11EnrollCardRequest\x12\x1f\n" +
+	"\vcard_number\x18\x01 \x01(\tR\n" +
+	"cardNumber\x12\x1f\n" +
+	"\vaccount_num\x18\x02 \x01(\tR\n" +
+	"accountNum\x12\x1f\n" +
+	"\vrouting_num\x18\x03 \x01(\tR\n" +
+	"routingNum\x12\x19\n" +
+	"\bowner_id\x18\x04 \x01(\tR\aownerId\"T\n" +
+	"\x14ListCardLinksRequest\x12\x1b\n" +
+	"\tlast_four\x18\x01 \x01(\tR\blastFour\x12\x1f\n" +
+	"\vaccount_num\x18\x02 \x01(\tR\n" +
+	"accountNum\"E\n" +
+	"\x15ListCardLinksResponse\x12,\n" +
+	"\x05links\x18\x01 \x03(\v2\x16.paymentadmin.CardLinkR\x05links\"f\n" +
+	"\x12SuspendCardRequest\x12\x17\n" +
+	"\acard_id\x18\x01 \x01(\tR\x06cardId\x12\x1f\n" +
+	"\vcard_number\x18\x02 \x01(\tR\n" +
+	"cardNumber\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"e\n" +
+	"\x11UnlinkCardRequest\x12\x17\n" +
+	"\acard_id\x18\x01 \x01(\tR\x06cardId\x12\x1f\n" +
+	"\vcard_number\x18\x02 \x01(\tR\n" +
+	"cardNumber\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"-\n" +
+	"\x12UnlinkCardResponse\x12\x17\n" +
+	"\acard_id\x18\x01 \x01(\tR\x06cardId\"L\n" +
+	"\bRiskRule\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x05R\x05score\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\"\xa2\x05\n" +
+	"\n" +
+	"ReviewItem\x12%\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12!\n" +
+	"\ffrom_account\x18\x03 \x01(\tR\vfromAccount\x12!\n" +
+	"\ffrom_routing\x18\x04 \x01(\tR\vfromRouting\x12\x1d\n" +
+	"\n" +
+	"to_account\x18\x05 \x01(\tR\ttoAccount\x12\x1d\n" +
+	"\n" +
+	"to_routing\x18\x06 \x01(\tR\ttoRouting\x12$\n" +
+	"\x0ecard_last_four\x18\a \x01(\tR\fcardLastFour\x12\x16\n" +
+	"\x06amount\x18\b \x01(\x03R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\t \x01(\tR\bcurrency\x12'\n" +
+	"\x0foriginal_amount\x18\n" +
+	" \x01(\tR\x0eoriginalAmount\x12\x1d\n" +
+	"\n" +
+	"risk_score\x18\v \x01(\x05R\triskScore\x125\n" +
+	"\n" +
+	"risk_rules\x18\f \x03(\v2\x16.paymentadmin.RiskRuleR\triskRules\x129\n" +
+	"\n" +
+	"created_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x126\n" +
+	"\bdeadline\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\bdeadline\x12\x1a\n" +
+	"\bdecision\x18\x0f \x01(\tR\bdecision\x12\x1a\n" +
+	"\breviewer\x18\x10 \x01(\tR\breviewer\x12\x12\n" +
+	"\x04note\x18\x11 \x01(\tR\x04note\x129\n" +
+	"\n" +
+	"decided_at\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\tdecidedAt\"5\n" +
+	"\x12ListReviewsRequest\x12\x1f\n" +
+	"\vaccount_num\x18\x01 \x01(\tR\n" +
+	"accountNum\"E\n" +
+	"\x13ListReviewsResponse\x12.\n" +
+	"\x05items\x18\x01 \x03(\v2\x18.paymentadmin.ReviewItemR\x05items\"9\n" +
+	"\x10GetReviewRequest\x12%\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\"P\n" +
+	"\x13DecideReviewRequest\x12%\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\x12\x12\n" +
+	"\x04note\x18\x02 \x01(\tR\x04note\";\n" +
+	"\x18GetAccountProfileRequest\x12\x1f\n" +
+	"\vaccount_num\x18\x01 \x01(\tR\n" +
+	"accountNum\"\xba\x03\n" +
+	"\x0eAccountProfile\x12\x1f\n" +
+	"\vaccount_num\x18\x01 \x01(\tR\n" +
+	"accountNum\x12\x18\n" +
+	"\acharges\x18\x02 \x01(\x03R\acharges\x12%\n" +
+	"\x0eaverage_amount\x18\x03 \x01(\x01R\raverageAmount\x12#\n" +
+	"\ramount_stddev\x18\x04 \x01(\x01R\famountStddev\x12!\n" +
+	"\ftypical_hour\x18\x05 \x01(\x05R\vtypicalHour\x12!\n" +
+	"\fhour_weights\x18\x06 \x03(\x01R\vhourWeights\x128\n" +
+	"\x18average_interval_seconds\x18\a \x01(\x01R\x16averageIntervalSeconds\x12%\n" +
+	"\x0edistinct_cards\x18\b \x01(\x05R\rdistinctCards\x12=\n" +
+	"\ffirst_charge\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vfirstCharge\x12;\n" +
+	"\vlast_charge\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastCharge\"Q\n" +
+	"\x18GetSpendAllowanceRequest\x12\x1f\n" +
+	"\vaccount_num\x18\x01 \x01(\tR\n" +
+	"accountNum\x12\x14\n" +
+	"\x05scope\x18\x02 \x01(\tR\x05scope\"\x8c\x01\n" +
+	"\vSpendWindow\x12\x10\n" +
+	"\x03cap\x18\x01 \x01(\x03R\x03cap\x12\x14\n" +
+	"\x05spent\x18\x02 \x01(\x03R\x05spent\x12\x1c\n" +
+	"\tremaining\x18\x03 \x01(\x03R\tremaining\x127\n" +
+	"\tresets_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\bresetsAt\"\xad\x01\n" +
+	"\x0eSpendAllowance\x12\x1f\n" +
+	"\vaccount_num\x18\x01 \x01(\tR\n" +
+	"accountNum\x12\x14\n" +
+	"\x05scope\x18\x02 \x01(\tR\x05scope\x12/\n" +
+	"\x05daily\x18\x03 \x01(\v2\x19.paymentadmin.SpendWindowR\x05daily\x123\n" +
+	"\amonthly\x18\x04 \x01(\v2\x19.paymentadmin.SpendWindowR\amonthly\"\xfb\x01\n" +
+	"\n" +
+	"BlockEntry\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x129\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x05 \x01(\tR\tcreatedBy\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x16\n" +
+	"\x06source\x18\a \x01(\tR\x06source\"\xd0\x01\n" +
+	"\x0fAddBlockRequest\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1f\n" +
+	"\vcard_number\x18\x03 \x01(\tR\n" +
+	"cardNumber\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x129\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1f\n" +
+	"\vttl_seconds\x18\x06 \x01(\x03R\n" +
+	"ttlSeconds\"_\n" +
+	"\x12RemoveBlockRequest\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1f\n" +
+	"\vcard_number\x18\x03 \x01(\tR\n" +
+	"cardNumber\"'\n" +
+	"\x11ListBlocksRequest\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\"H\n" +
+	"\x12ListBlocksResponse\x122\n" +
+	"\aentries\x18\x01 \x03(\v2\x18.paymentadmin.BlockEntryR\aentries\"\x18\n" +
+	"\x16GetConfigStatusRequest\"\x15\n" +
+	"\x13ReloadConfigRequest\"\xda\x02\n" +
+	"\fConfigStatus\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x12\x1a\n" +
+	"\bchecksum\x18\x02 \x01(\tR\bchecksum\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x129\n" +
+	"\n" +
+	"applied_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tappliedAt\x12@\n" +
+	"\x0elast_reload_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\flastReloadAt\x12,\n" +
+	"\x12last_reload_result\x18\x06 \x01(\tR\x10lastReloadResult\x12*\n" +
+	"\x11last_reload_error\x18\a \x01(\tR\x0flastReloadError\x12)\n" +
+	"\x10restart_required\x18\b \x03(\tR\x0frestartRequired2\xd5\x02\n" +
+	"\x10CardAdminService\x12G\n" +
+	"\n" +
+	"EnrollCard\x12\x1f.paymentadmin.EnrollCardRequest\x1a\x16.paymentadmin.CardLink\"\x00\x12Z\n" +
+	"\rListCardLinks\x12\".paymentadmin.ListCardLinksRequest\x1a#.paymentadmin.ListCardLinksResponse\"\x00\x12I\n" +
+	"\vSuspendCard\x12 .paymentadmin.SuspendCardRequest\x1a\x16.paymentadmin.CardLink\"\x00\x12Q\n" +
+	"\n" +
+	"UnlinkCard\x12\x1f.paymentadmin.UnlinkCardRequest\x1a .paymentadmin.UnlinkCardResponse\"\x002\xd2\x02\n" +
+	"\x12ReviewAdminService\x12T\n" +
+	"\vListReviews\x12 .paymentadmin.ListReviewsRequest\x1a!.paymentadmin.ListReviewsResponse\"\x00\x12G\n" +
+	"\tGetReview\x12\x1e.paymentadmin.GetReviewRequest\x1a\x18.paymentadmin.ReviewItem\"\x00\x12N\n" +
+	"\rApproveReview\x12!.paymentadmin.DecideReviewRequest\x1a\x18.paymentadmin.ReviewItem\"\x00\x12M\n" +
+	"\fRejectReview\x12!.paymentadmin.DecideReviewRequest\x1a\x18.paymentadmin.ReviewItem\"\x002r\n" +
+	"\x13ProfileAdminService\x12[\n" +
+	"\x11GetAccountProfile\x12&.paymentadmin.GetAccountProfileRequest\x1a\x1c.paymentadmin.AccountProfile\"\x002s\n" +
+	"\x14SpendCapAdminService\x12[\n" +
+	"\x11GetSpendAllowance\x12&.paymentadmin.GetSpendAllowanceRequest\x1a\x1c.paymentadmin.SpendAllowance\"\x002\xfe\x01\n" +
+	"\x15BlocklistAdminService\x12E\n" +
+	"\bAddBlock\x12\x1d.paymentadmin.AddBlockRequest\x1a\x18.paymentadmin.BlockEntry\"\x00\x12K\n" +
+	"\vRemoveBlock\x12 .paymentadmin.RemoveBlockRequest\x1a\x18.paymentadmin.BlockEntry\"\x00\x12Q\n" +
+	"\n" +
+	"ListBlocks\x12\x1f.paymentadmin.ListBlocksRequest\x1a .paymentadmin.ListBlocksResponse\"\x002\xbc\x01\n" +
+	"\x12ConfigAdminService\x12U\n" +
+	"\x0fGetConfigStatus\x12$.paymentadmin.GetConfigStatusRequest\x1a\x1a.paymentadmin.ConfigStatus\"\x00\x12O\n" +
+	"\fReloadConfig\x12!.paymentadmin.ReloadConfigRequest\x1a\x1a.paymentadmin.ConfigStatus\"\x00B4Z2github.com/gke-hackathon/payment-integration/protob\x06proto3"
+
+var (
+	file_proto_admin_proto_rawDescOnce sync.Once
+	file_proto_admin_proto_rawDescData []byte
+)
+
+func file_proto_admin_proto_rawDescGZIP() []byte {
+	file_proto_admin_proto_rawDescOnce.Do(func() {
+		file_proto_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_admin_proto_rawDesc), len(file_proto_admin_proto_rawDesc)))
+	})
+	return file_proto_admin_proto_rawDescData
+}
+
+var file_proto_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_proto_admin_proto_goTypes = []any{
+	(*CardLink)(nil),                 // 0: paymentadmin.CardLink
+	(*EnrollCardRequest)(nil),        // 1: paymentadmin.EnrollCardRequest
+	(*ListCardLinksRequest)(nil),     // 2: paymentadmin.ListCardLinksRequest
+	(*ListCardLinksResponse)(nil),    // 3: paymentadmin.ListCardLinksResponse
+	(*SuspendCardRequest)(nil),       // 4: paymentadmin.SuspendCardRequest
+	(*UnlinkCardRequest)(nil),        // 5: paymentadmin.UnlinkCardRequest
+	(*UnlinkCardResponse)(nil),       // 6: paymentadmin.UnlinkCardResponse
+	(*RiskRule)(nil),                 // 7: paymentadmin.RiskRule
+	(*ReviewItem)(nil),               // 8: paymentadmin.ReviewItem
+	(*ListReviewsRequest)(nil),       // 9: paymentadmin.ListReviewsRequest
+	(*ListReviewsResponse)(nil),      // 10: paymentadmin.ListReviewsResponse
+	(*GetReviewRequest)(nil),         // 11: paymentadmin.GetReviewRequest
+	(*DecideReviewRequest)(nil),      // 12: paymentadmin.DecideReviewRequest
+	(*GetAccountProfileRequest)(nil), // 13: paymentadmin.GetAccountProfileRequest
+	(*AccountProfile)(nil),           // 14: paymentadmin.AccountProfile
+	(*GetSpendAllowanceRequest)(nil), // 15: paymentadmin.GetSpendAllowanceRequest
+	(*SpendWindow)(nil),              // 16: paymentadmin.SpendWindow
+	(*SpendAllowance)(nil),           // 17: paymentadmin.SpendAllowance
+	(*BlockEntry)(nil),               // 18: paymentadmin.BlockEntry
+	(*AddBlockRequest)(nil),          // 19: paymentadmin.AddBlockRequest
+	(*RemoveBlockRequest)(nil),       // 20: paymentadmin.RemoveBlockRequest
+	(*ListBlocksRequest)(nil),        // 21: paymentadmin.ListBlocksRequest
+	(*ListBlocksResponse)(nil),       // 22: paymentadmin.ListBlocksResponse
+	(*GetConfigStatusRequest)(nil),   // 23: paymentadmin.GetConfigStatusRequest
+	(*ReloadConfigRequest)(nil),      // 24: paymentadmin.ReloadConfigRequest
+	(*ConfigStatus)(nil),             // 25: paymentadmin.ConfigStatus
+	(*timestamppb.Timestamp)(nil),    // 26: google.protobuf.Timestamp
+}
+var file_proto_admin_proto_depIdxs = []int32{
+	26, // 0: paymentadmin.CardLink.created_at:type_name -> google.protobuf.Timestamp
+	26, // 1: paymentadmin.CardLink.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: paymentadmin.ListCardLinksResponse.links:type_name -> paymentadmin.CardLink
+	7,  // 3: paymentadmin.ReviewItem.risk_rules:type_name -> paymentadmin.RiskRule
+	26, // 4: paymentadmin.ReviewItem.created_at:type_name -> google.protobuf.Timestamp
+	26, // 5: paymentadmin.ReviewItem.deadline:type_name -> google.protobuf.Timestamp
+	26, // 6: paymentadmin.ReviewItem.decided_at:type_name -> google.protobuf.Timestamp
+	8,  // 7: paymentadmin.ListReviewsResponse.items:type_name -> paymentadmin.ReviewItem
+	26, // 8: paymentadmin.AccountProfile.first_charge:type_name -> google.protobuf.Timestamp
+	26, // 9: paymentadmin.AccountProfile.last_charge:type_name -> google.protobuf.Timestamp
+	26, // 10: paymentadmin.SpendWindow.resets_at:type_name -> google.protobuf.Timestamp
+	16, // 11: paymentadmin.SpendAllowance.daily:type_name -> paymentadmin.SpendWindow
+	16, // 12: paymentadmin.SpendAllowance.monthly:type_name -> paymentadmin.SpendWindow
+	26, // 13: paymentadmin.BlockEntry.expires_at:type_name -> google.protobuf.Timestamp
+	26, // 14: paymentadmin.BlockEntry.created_at:type_name -> google.protobuf.Timestamp
+	26, // 15: paymentadmin.AddBlockRequest.expires_at:type_name -> google.protobuf.Timestamp
+	18, // 16: paymentadmin.ListBlocksResponse.entries:type_name -> paymentadmin.BlockEntry
+	26, // 17: paymentadmin.ConfigStatus.applied_at:type_name -> google.protobuf.Timestamp
+	26, // 18: paymentadmin.ConfigStatus.last_reload_at:type_name -> google.protobuf.Timestamp
+	1,  // 19: paymentadmin.CardAdminService.EnrollCard:input_type -> paymentadmin.EnrollCardRequest
+	2,  // 20: paymentadmin.CardAdminService.ListCardLinks:input_type -> paymentadmin.ListCardLinksRequest
+	4,  // 21: paymentadmin.CardAdminService.SuspendCard:input_type -> paymentadmin.SuspendCardRequest
+	5,  // 22: paymentadmin.CardAdminService.UnlinkCard:input_type -> paymentadmin.UnlinkCardRequest
+	9,  // 23: paymentadmin.ReviewAdminService.ListReviews:input_type -> paymentadmin.ListReviewsRequest
+	11, // 24: paymentadmin.ReviewAdminService.GetReview:input_type -> paymentadmin.GetReviewRequest
+	12, // 25: paymentadmin.ReviewAdminService.ApproveReview:input_type -> paymentadmin.DecideReviewRequest
+	12, // 26: paymentadmin.ReviewAdminService.RejectReview:input_type -> paymentadmin.DecideReviewRequest
+	13, // 27: paymentadmin.ProfileAdminService.GetAccountProfile:input_type -> paymentadmin.GetAccountProfileRequest
+	15, // 28: paymentadmin.SpendCapAdminService.GetSpendAllowance:input_type -> paymentadmin.GetSpendAllowanceRequest
+	19, // 29: paymentadmin.BlocklistAdminService.AddBlock:input_type -> paymentadmin.AddBlockRequest
+	20, // 30: paymentadmin.BlocklistAdminService.RemoveBlock:input_type -> paymentadmin.RemoveBlockRequest
+	21, // 31: paymentadmin.BlocklistAdminService.ListBlocks:input_type -> paymentadmin.ListBlocksRequest
+	23, // 32: paymentadmin.ConfigAdminService.GetConfigStatus:input_type -> paymentadmin.GetConfigStatusRequest
+	24, // 33: paymentadmin.ConfigAdminService.ReloadConfig:input_type -> paymentadmin.ReloadConfigRequest
+	0,  // 34: paymentadmin.CardAdminService.EnrollCard:output_type -> paymentadmin.CardLink
+	3,  // 35: paymentadmin.CardAdminService.ListCardLinks:output_type -> paymentadmin.ListCardLinksResponse
+	0,  // 36: paymentadmin.CardAdminService.SuspendCard:output_type -> paymentadmin.CardLink
+	6,  // 37: paymentadmin.CardAdminService.UnlinkCard:output_type -> paymentadmin.UnlinkCardResponse
+	10, // 38: paymentadmin.ReviewAdminService.ListReviews:output_type -> paymentadmin.ListReviewsResponse
+	8,  // 39: paymentadmin.ReviewAdminService.GetReview:output_type -> paymentadmin.ReviewItem
+	8,  // 40: paymentadmin.ReviewAdminService.ApproveReview:output_type -> paymentadmin.ReviewItem
+	8,  // 41: paymentadmin.ReviewAdminService.RejectReview:output_type -> paymentadmin.ReviewItem
+	14, // 42: paymentadmin.ProfileAdminService.GetAccountProfile:output_type -> paymentadmin.AccountProfile
+	17, // 43: paymentadmin.SpendCapAdminService.GetSpendAllowance:output_type -> paymentadmin.SpendAllowance
+	18, // 44: paymentadmin.BlocklistAdminService.AddBlock:output_type -> paymentadmin.BlockEntry
+	18, // 45: paymentadmin.BlocklistAdminService.RemoveBlock:output_type -> paymentadmin.BlockEntry
+	22, // 46: paymentadmin.BlocklistAdminService.ListBlocks:output_type -> paymentadmin.ListBlocksResponse
+	25, // 47: paymentadmin.ConfigAdminService.GetConfigStatus:output_type -> paymentadmin.ConfigStatus
+	25, // 48: paymentadmin.ConfigAdminService.ReloadConfig:output_type -> paymentadmin.ConfigStatus
+	34, // [34:49] is the sub-list for method output_type
+	19, // [19:34] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
+}
+
+func init() { file_proto_admin_proto_init() }
+func file_proto_admin_proto_init() {
+	if File_proto_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_admin_proto_rawDesc), len(file_proto_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   26,
+			NumExtensions: 0,
+			NumServices:   6,
+		},
+		GoTypes:           file_proto_admin_proto_goTypes,
+		DependencyIndexes: file_proto_admin_proto_depIdxs,
+		MessageInfos:      file_proto_admin_proto_msgTypes,
+	}.Build()
+	File_proto_admin_proto = out.File
+	file_proto_admin_proto_goTypes = nil
+	file_proto_admin_proto_depIdxs = nil
+}
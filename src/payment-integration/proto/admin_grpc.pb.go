@@ -0,0 +1,1013 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v4.24.4
+// source: proto/admin.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CardAdminService_EnrollCard_FullMethodName    = "/paymentadmin.CardAdminService/EnrollCard"
+	CardAdminService_ListCardLinks_FullMethodName = "/paymentadmin.CardAdminService/ListCardLinks"
+	CardAdminService_SuspendCard_FullMethodName   = "/paymentadmin.CardAdminService/SuspendCard"
+	CardAdminService_UnlinkCard_FullMethodName    = "/paymentadmin.CardAdminService/UnlinkCard"
+)
+
+// CardAdminServiceClient is the client API for CardAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Manages the card-to-account link table. Every call requires the admin token
+// in `authorization` and the operator's identity in `x-admin-actor`.
+type CardAdminServiceClient interface {
+	EnrollCard(ctx context.Context, in *EnrollCardRequest, opts ...grpc.CallOption) (*CardLink, error)
+	ListCardLinks(ctx context.Context, in *ListCardLinksRequest, opts ...grpc.CallOption) (*ListCardLinksResponse, error)
+	SuspendCard(ctx context.Context, in *SuspendCardRequest, opts ...grpc.CallOption) (*CardLink, error)
+	UnlinkCard(ctx context.Context, in *UnlinkCardRequest, opts ...grpc.CallOption) (*UnlinkCardResponse, error)
+}
+
+type cardAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCardAdminServiceClient(cc grpc.ClientConnInterface) CardAdminServiceClient {
+	return &cardAdminServiceClient{cc}
+}
+
+func (c *cardAdminServiceClient) EnrollCard(ctx context.Context, in *EnrollCardRequest, opts ...grpc.CallOption) (*CardLink, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CardLink)
+	err := c.cc.Invoke(ctx, CardAdminService_EnrollCard_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardAdminServiceClient) ListCardLinks(ctx context.Context, in *ListCardLinksRequest, opts ...grpc.CallOption) (*ListCardLinksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCardLinksResponse)
+	err := c.cc.Invoke(ctx, CardAdminService_ListCardLinks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardAdminServiceClient) SuspendCard(ctx context.Context, in *SuspendCardRequest, opts ...grpc.CallOption) (*CardLink, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CardLink)
+	err := c.cc.Invoke(ctx, CardAdminService_SuspendCard_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardAdminServiceClient) UnlinkCard(ctx context.Context, in *UnlinkCardRequest, opts ...grpc.CallOption) (*UnlinkCardResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnlinkCardResponse)
+	err := c.cc.Invoke(ctx, CardAdminService_UnlinkCard_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CardAdminServiceServer is the server API for CardAdminService service.
+// All implementations must embed UnimplementedCardAdminServiceServer
+// for forward compatibility.
+//
+// Manages the card-to-account link table. Every call requires the admin token
+// in `authorization` and the operator's identity in `x-admin-actor`.
+type CardAdminServiceServer interface {
+	EnrollCard(context.Context, *EnrollCardRequest) (*CardLink, error)
+	ListCardLinks(context.Context, *ListCardLinksRequest) (*ListCardLinksResponse, error)
+	SuspendCard(context.Context, *SuspendCardRequest) (*CardLink, error)
+	UnlinkCard(context.Context, *UnlinkCardRequest) (*UnlinkCardResponse, error)
+	mustEmbedUnimplementedCardAdminServiceServer()
+}
+
+// UnimplementedCardAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCardAdminServiceServer struct{}
+
+func (UnimplementedCardAdminServiceServer) EnrollCard(context.Context, *EnrollCardRequest) (*CardLink, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollCard not implemented")
+}
+func (UnimplementedCardAdminServiceServer) ListCardLinks(context.Context, *ListCardLinksRequest) (*ListCardLinksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCardLinks not implemented")
+}
+func (UnimplementedCardAdminServiceServer) SuspendCard(context.Context, *SuspendCardRequest) (*CardLink, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SuspendCard not implemented")
+}
+func (UnimplementedCardAdminServiceServer) UnlinkCard(context.Context, *UnlinkCardRequest) (*UnlinkCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlinkCard not implemented")
+}
+func (UnimplementedCardAdminServiceServer) mustEmbedUnimplementedCardAdminServiceServer() {}
+func (UnimplementedCardAdminServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeCardAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CardAdminServiceServer will
+// result in compilation errors.
+type UnsafeCardAdminServiceServer interface {
+	mustEmbedUnimplementedCardAdminServiceServer()
+}
+
+func RegisterCardAdminServiceServer(s grpc.ServiceRegistrar, srv CardAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCardAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CardAdminService_ServiceDesc, srv)
+}
+
+func _CardAdminService_EnrollCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardAdminServiceServer).EnrollCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CardAdminService_EnrollCard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardAdminServiceServer).EnrollCard(ctx, req.(*EnrollCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardAdminService_ListCardLinks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCardLinksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardAdminServiceServer).ListCardLinks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CardAdminService_ListCardLinks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardAdminServiceServer).ListCardLinks(ctx, req.(*ListCardLinksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardAdminService_SuspendCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuspendCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardAdminServiceServer).SuspendCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CardAdminService_SuspendCard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardAdminServiceServer).SuspendCard(ctx, req.(*SuspendCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardAdminService_UnlinkCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlinkCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardAdminServiceServer).UnlinkCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CardAdminService_UnlinkCard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardAdminServiceServer).UnlinkCard(ctx, req.(*UnlinkCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CardAdminService_ServiceDesc is the grpc.ServiceDesc for CardAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CardAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "paymentadmin.CardAdminService",
+	HandlerType: (*CardAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "EnrollCard",
+			Handler:    _CardAdminService_EnrollCard_Handler,
+		},
+		{
+			MethodName: "ListCardLinks",
+			Handler:    _CardAdminService_ListCardLinks_Handler,
+		},
+		{
+			MethodName: "SuspendCard",
+			Handler:    _CardAdminService_SuspendCard_Handler,
+		},
+		{
+			MethodName: "UnlinkCard",
+			Handler:    _CardAdminService_UnlinkCard_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin.proto",
+}
+
+const (
+	ReviewAdminService_ListReviews_FullMethodName   = "/paymentadmin.ReviewAdminService/ListReviews"
+	ReviewAdminService_GetReview_FullMethodName     = "/paymentadmin.ReviewAdminService/GetReview"
+	ReviewAdminService_ApproveReview_FullMethodName = "/paymentadmin.ReviewAdminService/ApproveReview"
+	ReviewAdminService_RejectReview_FullMethodName  = "/paymentadmin.ReviewAdminService/RejectReview"
+)
+
+// ReviewAdminServiceClient is the client API for ReviewAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Works the queue of charges flagged by the risk engine. Approving runs the
+// bank transfer; rejecting declines the charge. The reviewer's identity is
+// taken from `x-admin-actor`.
+type ReviewAdminServiceClient interface {
+	ListReviews(ctx context.Context, in *ListReviewsRequest, opts ...grpc.CallOption) (*ListReviewsResponse, error)
+	GetReview(ctx context.Context, in *GetReviewRequest, opts ...grpc.CallOption) (*ReviewItem, error)
+	ApproveReview(ctx context.Context, in *DecideReviewRequest, opts ...grpc.CallOption) (*ReviewItem, error)
+	RejectReview(ctx context.Context, in *DecideReviewRequest, opts ...grpc.CallOption) (*ReviewItem, error)
+}
+
+type reviewAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReviewAdminServiceClient(cc grpc.ClientConnInterface) ReviewAdminServiceClient {
+	return &reviewAdminServiceClient{cc}
+}
+
+func (c *reviewAdminServiceClient) ListReviews(ctx context.Context, in *ListReviewsRequest, opts ...grpc.CallOption) (*ListReviewsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListReviewsResponse)
+	err := c.cc.Invoke(ctx, ReviewAdminService_ListReviews_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewAdminServiceClient) GetReview(ctx context.Context, in *GetReviewRequest, opts ...grpc.CallOption) (*ReviewItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReviewItem)
+	err := c.cc.Invoke(ctx, ReviewAdminService_GetReview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewAdminServiceClient) ApproveReview(ctx context.Context, in *DecideReviewRequest, opts ...grpc.CallOption) (*ReviewItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReviewItem)
+	err := c.cc.Invoke(ctx, ReviewAdminService_ApproveReview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewAdminServiceClient) RejectReview(ctx context.Context, in *DecideReviewRequest, opts ...grpc.CallOption) (*ReviewItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReviewItem)
+	err := c.cc.Invoke(ctx, ReviewAdminService_RejectReview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReviewAdminServiceServer is the server API for ReviewAdminService service.
+// All implementations must embed UnimplementedReviewAdminServiceServer
+// for forward compatibility.
+//
+// Works the queue of charges flagged by the risk engine. Approving runs the
+// bank transfer; rejecting declines the charge. The reviewer's identity is
+// taken from `x-admin-actor`.
+type ReviewAdminServiceServer interface {
+	ListReviews(context.Context, *ListReviewsRequest) (*ListReviewsResponse, error)
+	GetReview(context.Context, *GetReviewRequest) (*ReviewItem, error)
+	ApproveReview(context.Context, *DecideReviewRequest) (*ReviewItem, error)
+	RejectReview(context.Context, *DecideReviewRequest) (*ReviewItem, error)
+	mustEmbedUnimplementedReviewAdminServiceServer()
+}
+
+// UnimplementedReviewAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedReviewAdminServiceServer struct{}
+
+func (UnimplementedReviewAdminServiceServer) ListReviews(context.Context, *ListReviewsRequest) (*ListReviewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReviews not implemented")
+}
+func (UnimplementedReviewAdminServiceServer) GetReview(context.Context, *GetReviewRequest) (*ReviewItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReview not implemented")
+}
+func (UnimplementedReviewAdminServiceServer) ApproveReview(context.Context, *DecideReviewRequest) (*ReviewItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveReview not implemented")
+}
+func (UnimplementedReviewAdminServiceServer) RejectReview(context.Context, *DecideReviewRequest) (*ReviewItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectReview not implemented")
+}
+func (UnimplementedReviewAdminServiceServer) mustEmbedUnimplementedReviewAdminServiceServer() {}
+func (UnimplementedReviewAdminServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeReviewAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReviewAdminServiceServer will
+// result in compilation errors.
+type UnsafeReviewAdminServiceServer interface {
+	mustEmbedUnimplementedReviewAdminServiceServer()
+}
+
+func RegisterReviewAdminServiceServer(s grpc.ServiceRegistrar, srv ReviewAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedReviewAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ReviewAdminService_ServiceDesc, srv)
+}
+
+func _ReviewAdminService_ListReviews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReviewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewAdminServiceServer).ListReviews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewAdminService_ListReviews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewAdminServiceServer).ListReviews(ctx, req.(*ListReviewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewAdminService_GetReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewAdminServiceServer).GetReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewAdminService_GetReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewAdminServiceServer).GetReview(ctx, req.(*GetReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewAdminService_ApproveReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecideReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewAdminServiceServer).ApproveReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewAdminService_ApproveReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewAdminServiceServer).ApproveReview(ctx, req.(*DecideReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewAdminService_RejectReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecideReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewAdminServiceServer).RejectReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewAdminService_RejectReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewAdminServiceServer).RejectReview(ctx, req.(*DecideReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ReviewAdminService_ServiceDesc is the grpc.ServiceDesc for ReviewAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ReviewAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "paymentadmin.ReviewAdminService",
+	HandlerType: (*ReviewAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListReviews",
+			Handler:    _ReviewAdminService_ListReviews_Handler,
+		},
+		{
+			MethodName: "GetReview",
+			Handler:    _ReviewAdminService_GetReview_Handler,
+		},
+		{
+			MethodName: "ApproveReview",
+			Handler:    _ReviewAdminService_ApproveReview_Handler,
+		},
+		{
+			MethodName: "RejectReview",
+			Handler:    _ReviewAdminService_RejectReview_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin.proto",
+}
+
+const (
+	ProfileAdminService_GetAccountProfile_FullMethodName = "/paymentadmin.ProfileAdminService/GetAccountProfile"
+)
+
+// ProfileAdminServiceClient is the client API for ProfileAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Shows the behavioural profiles that charges are scored against for anomalies
+type ProfileAdminServiceClient interface {
+	GetAccountProfile(ctx context.Context, in *GetAccountProfileRequest, opts ...grpc.CallOption) (*AccountProfile, error)
+}
+
+type profileAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProfileAdminServiceClient(cc grpc.ClientConnInterface) ProfileAdminServiceClient {
+	return &profileAdminServiceClient{cc}
+}
+
+func (c *profileAdminServiceClient) GetAccountProfile(ctx context.Context, in *GetAccountProfileRequest, opts ...grpc.CallOption) (*AccountProfile, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountProfile)
+	err := c.cc.Invoke(ctx, ProfileAdminService_GetAccountProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProfileAdminServiceServer is the server API for ProfileAdminService service.
+// All implementations must embed UnimplementedProfileAdminServiceServer
+// for forward compatibility.
+//
+// Shows the behavioural profiles that charges are scored against for anomalies
+type ProfileAdminServiceServer interface {
+	GetAccountProfile(context.Context, *GetAccountProfileRequest) (*AccountProfile, error)
+	mustEmbedUnimplementedProfileAdminServiceServer()
+}
+
+// UnimplementedProfileAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedProfileAdminServiceServer struct{}
+
+func (UnimplementedProfileAdminServiceServer) GetAccountProfile(context.Context, *GetAccountProfileRequest) (*AccountProfile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountProfile not implemented")
+}
+func (UnimplementedProfileAdminServiceServer) mustEmbedUnimplementedProfileAdminServiceServer() {}
+func (UnimplementedProfileAdminServiceServer) testEmbeddedByValue()                             {}
+
+// UnsafeProfileAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProfileAdminServiceServer will
+// result in compilation errors.
+type UnsafeProfileAdminServiceServer interface {
+	mustEmbedUnimplementedProfileAdminServiceServer()
+}
+
+func RegisterProfileAdminServiceServer(s grpc.ServiceRegistrar, srv ProfileAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedProfileAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ProfileAdminService_ServiceDesc, srv)
+}
+
+func _ProfileAdminService_GetAccountProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProfileAdminServiceServer).GetAccountProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProfileAdminService_GetAccountProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProfileAdminServiceServer).GetAccountProfile(ctx, req.(*GetAccountProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProfileAdminService_ServiceDesc is the grpc.ServiceDesc for ProfileAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProfileAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "paymentadmin.ProfileAdminService",
+	HandlerType: (*ProfileAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAccountProfile",
+			Handler:    _ProfileAdminService_GetAccountProfile_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin.proto",
+}
+
+const (
+	SpendCapAdminService_GetSpendAllowance_FullMethodName = "/paymentadmin.SpendCapAdminService/GetSpendAllowance"
+)
+
+// SpendCapAdminServiceClient is the client API for SpendCapAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Reports how much an account can still spend, or a merchant still receive,
+// under the daily and monthly spend caps
+type SpendCapAdminServiceClient interface {
+	GetSpendAllowance(ctx context.Context, in *GetSpendAllowanceRequest, opts ...grpc.CallOption) (*SpendAllowance, error)
+}
+
+type spendCapAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSpendCapAdminServiceClient(cc grpc.ClientConnInterface) SpendCapAdminServiceClient {
+	return &spendCapAdminServiceClient{cc}
+}
+
+func (c *spendCapAdminServiceClient) GetSpendAllowance(ctx context.Context, in *GetSpendAllowanceRequest, opts ...grpc.CallOption) (*SpendAllowance, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SpendAllowance)
+	err := c.cc.Invoke(ctx, SpendCapAdminService_GetSpendAllowance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SpendCapAdminServiceServer is the server API for SpendCapAdminService service.
+// All implementations must embed UnimplementedSpendCapAdminServiceServer
+// for forward compatibility.
+//
+// Reports how much an account can still spend, or a merchant still receive,
+// under the daily and monthly spend caps
+type SpendCapAdminServiceServer interface {
+	GetSpendAllowance(context.Context, *GetSpendAllowanceRequest) (*SpendAllowance, error)
+	mustEmbedUnimplementedSpendCapAdminServiceServer()
+}
+
+// UnimplementedSpendCapAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSpendCapAdminServiceServer struct{}
+
+func (UnimplementedSpendCapAdminServiceServer) GetSpendAllowance(context.Context, *GetSpendAllowanceRequest) (*SpendAllowance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpendAllowance not implemented")
+}
+func (UnimplementedSpendCapAdminServiceServer) mustEmbedUnimplementedSpendCapAdminServiceServer() {}
+func (UnimplementedSpendCapAdminServiceServer) testEmbeddedByValue()                              {}
+
+// UnsafeSpendCapAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SpendCapAdminServiceServer will
+// result in compilation errors.
+type UnsafeSpendCapAdminServiceServer interface {
+	mustEmbedUnimplementedSpendCapAdminServiceServer()
+}
+
+func RegisterSpendCapAdminServiceServer(s grpc.ServiceRegistrar, srv SpendCapAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSpendCapAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SpendCapAdminService_ServiceDesc, srv)
+}
+
+func _SpendCapAdminService_GetSpendAllowance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSpendAllowanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpendCapAdminServiceServer).GetSpendAllowance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpendCapAdminService_GetSpendAllowance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpendCapAdminServiceServer).GetSpendAllowance(ctx, req.(*GetSpendAllowanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SpendCapAdminService_ServiceDesc is the grpc.ServiceDesc for SpendCapAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SpendCapAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "paymentadmin.SpendCapAdminService",
+	HandlerType: (*SpendCapAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSpendAllowance",
+			Handler:    _SpendCapAdminService_GetSpendAllowance_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin.proto",
+}
+
+const (
+	BlocklistAdminService_AddBlock_FullMethodName    = "/paymentadmin.BlocklistAdminService/AddBlock"
+	BlocklistAdminService_RemoveBlock_FullMethodName = "/paymentadmin.BlocklistAdminService/RemoveBlock"
+	BlocklistAdminService_ListBlocks_FullMethodName  = "/paymentadmin.BlocklistAdminService/ListBlocks"
+)
+
+// BlocklistAdminServiceClient is the client API for BlocklistAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Freezes bank accounts, cards and BIN ranges. Charges involving an entry are
+// declined with PermissionDenied until it is removed or expires. Entries from
+// the blocklist file are listed but can only be changed in the file.
+type BlocklistAdminServiceClient interface {
+	AddBlock(ctx context.Context, in *AddBlockRequest, opts ...grpc.CallOption) (*BlockEntry, error)
+	RemoveBlock(ctx context.Context, in *RemoveBlockRequest, opts ...grpc.CallOption) (*BlockEntry, error)
+	ListBlocks(ctx context.Context, in *ListBlocksRequest, opts ...grpc.CallOption) (*ListBlocksResponse, error)
+}
+
+type blocklistAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBlocklistAdminServiceClient(cc grpc.ClientConnInterface) BlocklistAdminServiceClient {
+	return &blocklistAdminServiceClient{cc}
+}
+
+func (c *blocklistAdminServiceClient) AddBlock(ctx context.Context, in *AddBlockRequest, opts ...grpc.CallOption) (*BlockEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BlockEntry)
+	err := c.cc.Invoke(ctx, BlocklistAdminService_AddBlock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blocklistAdminServiceClient) RemoveBlock(ctx context.Context, in *RemoveBlockRequest, opts ...grpc.CallOption) (*BlockEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BlockEntry)
+	err := c.cc.Invoke(ctx, BlocklistAdminService_RemoveBlock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blocklistAdminServiceClient) ListBlocks(ctx context.Context, in *ListBlocksRequest, opts ...grpc.CallOption) (*ListBlocksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBlocksResponse)
+	err := c.cc.Invoke(ctx, BlocklistAdminService_ListBlocks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BlocklistAdminServiceServer is the server API for BlocklistAdminService service.
+// All implementations must embed UnimplementedBlocklistAdminServiceServer
+// for forward compatibility.
+//
+// Freezes bank accounts, cards and BIN ranges. Charges involving an entry are
+// declined with PermissionDenied until it is removed or expires. Entries from
+// the blocklist file are listed but can only be changed in the file.
+type BlocklistAdminServiceServer interface {
+	AddBlock(context.Context, *AddBlockRequest) (*BlockEntry, error)
+	RemoveBlock(context.Context, *RemoveBlockRequest) (*BlockEntry, error)
+	ListBlocks(context.Context, *ListBlocksRequest) (*ListBlocksResponse, error)
+	mustEmbedUnimplementedBlocklistAdminServiceServer()
+}
+
+// UnimplementedBlocklistAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBlocklistAdminServiceServer struct{}
+
+func (UnimplementedBlocklistAdminServiceServer) AddBlock(context.Context, *AddBlockRequest) (*BlockEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBlock not implemented")
+}
+func (UnimplementedBlocklistAdminServiceServer) RemoveBlock(context.Context, *RemoveBlockRequest) (*BlockEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveBlock not implemented")
+}
+func (UnimplementedBlocklistAdminServiceServer) ListBlocks(context.Context, *ListBlocksRequest) (*ListBlocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBlocks not implemented")
+}
+func (UnimplementedBlocklistAdminServiceServer) mustEmbedUnimplementedBlocklistAdminServiceServer() {}
+func (UnimplementedBlocklistAdminServiceServer) testEmbeddedByValue()                               {}
+
+// UnsafeBlocklistAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BlocklistAdminServiceServer will
+// result in compilation errors.
+type UnsafeBlocklistAdminServiceServer interface {
+	mustEmbedUnimplementedBlocklistAdminServiceServer()
+}
+
+func RegisterBlocklistAdminServiceServer(s grpc.ServiceRegistrar, srv BlocklistAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedBlocklistAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BlocklistAdminService_ServiceDesc, srv)
+}
+
+func _BlocklistAdminService_AddBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlocklistAdminServiceServer).AddBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BlocklistAdminService_AddBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlocklistAdminServiceServer).AddBlock(ctx, req.(*AddBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlocklistAdminService_RemoveBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlocklistAdminServiceServer).RemoveBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BlocklistAdminService_RemoveBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlocklistAdminServiceServer).RemoveBlock(ctx, req.(*RemoveBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlocklistAdminService_ListBlocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBlocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlocklistAdminServiceServer).ListBlocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BlocklistAdminService_ListBlocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlocklistAdminServiceServer).ListBlocks(ctx, req.(*ListBlocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BlocklistAdminService_ServiceDesc is the grpc.ServiceDesc for BlocklistAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BlocklistAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "paymentadmin.BlocklistAdminService",
+	HandlerType: (*BlocklistAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddBlock",
+			Handler:    _BlocklistAdminService_AddBlock_Handler,
+		},
+		{
+			MethodName: "RemoveBlock",
+			Handler:    _BlocklistAdminService_RemoveBlock_Handler,
+		},
+		{
+			MethodName: "ListBlocks",
+			Handler:    _BlocklistAdminService_ListBlocks_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin.proto",
+}
+
+const (
+	ConfigAdminService_GetConfigStatus_FullMethodName = "/paymentadmin.ConfigAdminService/GetConfigStatus"
+	ConfigAdminService_ReloadConfig_FullMethodName    = "/paymentadmin.ConfigAdminService/ReloadConfig"
+)
+
+// ConfigAdminServiceClient is the client API for ConfigAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Reports and reloads the service's configuration. Merchant accounts, bank
+// routes, card mapping, rate limits and the log level are applied on reload;
+// other settings are listed in restart_required until the service restarts.
+type ConfigAdminServiceClient interface {
+	GetConfigStatus(ctx context.Context, in *GetConfigStatusRequest, opts ...grpc.CallOption) (*ConfigStatus, error)
+	ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ConfigStatus, error)
+}
+
+type configAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConfigAdminServiceClient(cc grpc.ClientConnInterface) ConfigAdminServiceClient {
+	return &configAdminServiceClient{cc}
+}
+
+func (c *configAdminServiceClient) GetConfigStatus(ctx context.Context, in *GetConfigStatusRequest, opts ...grpc.CallOption) (*ConfigStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfigStatus)
+	err := c.cc.Invoke(ctx, ConfigAdminService_GetConfigStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configAdminServiceClient) ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ConfigStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfigStatus)
+	err := c.cc.Invoke(ctx, ConfigAdminService_ReloadConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConfigAdminServiceServer is the server API for ConfigAdminService service.
+// All implementations must embed UnimplementedConfigAdminServiceServer
+// for forward compatibility.
+//
+// Reports and reloads the service's configuration. Merchant accounts, bank
+// routes, card mapping, rate limits and the log level are applied on reload;
+// other settings are listed in restart_required until the service restarts.
+type ConfigAdminServiceServer interface {
+	GetConfigStatus(context.Context, *GetConfigStatusRequest) (*ConfigStatus, error)
+	ReloadConfig(context.Context, *ReloadConfigRequest) (*ConfigStatus, error)
+	mustEmbedUnimplementedConfigAdminServiceServer()
+}
+
+// UnimplementedConfigAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConfigAdminServiceServer struct{}
+
+func (UnimplementedConfigAdminServiceServer) GetConfigStatus(context.Context, *GetConfigStatusRequest) (*ConfigStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfigStatus not implemented")
+}
+func (UnimplementedConfigAdminServiceServer) ReloadConfig(context.Context, *ReloadConfigRequest) (*ConfigStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadConfig not implemented")
+}
+func (UnimplementedConfigAdminServiceServer) mustEmbedUnimplementedConfigAdminServiceServer() {}
+func (UnimplementedConfigAdminServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeConfigAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConfigAdminServiceServer will
+// result in compilation errors.
+type UnsafeConfigAdminServiceServer interface {
+	mustEmbedUnimplementedConfigAdminServiceServer()
+}
+
+func RegisterConfigAdminServiceServer(s grpc.ServiceRegistrar, srv ConfigAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedConfigAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConfigAdminService_ServiceDesc, srv)
+}
+
+func _ConfigAdminService_GetConfigStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConfigStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigAdminServiceServer).GetConfigStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConfigAdminService_GetConfigStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigAdminServiceServer).GetConfigStatus(ctx, req.(*GetConfigStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConfigAdminService_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigAdminServiceServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConfigAdminService_ReloadConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigAdminServiceServer).ReloadConfig(ctx, req.(*ReloadConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ConfigAdminService_ServiceDesc is the grpc.ServiceDesc for ConfigAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConfigAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "paymentadmin.ConfigAdminService",
+	HandlerType: (*ConfigAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetConfigStatus",
+			Handler:    _ConfigAdminService_GetConfigStatus_Handler,
+		},
+		{
+			MethodName: "ReloadConfig",
+			Handler:    _ConfigAdminService_ReloadConfig_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin.proto",
+}
@@ -159,9 +159,11 @@ func (x *CreditCardInfo) GetCreditCardExpirationMonth() int32 {
 }
 
 type ChargeRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Amount        *Money                 `protobuf:"bytes,1,opt,name=amount,proto3" json:"amount,omitempty"`
-	CreditCard    *CreditCardInfo        `protobuf:"bytes,2,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Amount     *Money                 `protobuf:"bytes,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	CreditCard *CreditCardInfo        `protobuf:"bytes,2,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
+	// A token from Tokenize, used instead of credit_card.
+	CardToken     string `protobuf:"bytes,3,opt,name=card_token,json=cardToken,proto3" json:"card_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -210,6 +212,13 @@ func (x *ChargeRequest) GetCreditCard() *CreditCardInfo {
 	return nil
 }
 
+func (x *ChargeRequest) GetCardToken() string {
+	if x != nil {
+		return x.CardToken
+	}
+	return ""
+}
+
 type ChargeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
@@ -254,6 +263,102 @@ func (x *ChargeResponse) GetTransactionId() string {
 	return ""
 }
 
+type TokenizeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CreditCard    *CreditCardInfo        `protobuf:"bytes,1,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenizeRequest) Reset() {
+	*x = TokenizeRequest{}
+	mi := &file_proto_payment_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenizeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenizeRequest) ProtoMessage() {}
+
+func (x *TokenizeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_payment_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenizeRequest.ProtoReflect.Descriptor instead.
+func (*TokenizeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_payment_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TokenizeRequest) GetCreditCard() *CreditCardInfo {
+	if x != nil {
+		return x.CreditCard
+	}
+	return nil
+}
+
+type TokenizeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CardToken     string                 `protobuf:"bytes,1,opt,name=card_token,json=cardToken,proto3" json:"card_token,omitempty"`
+	LastFour      string                 `protobuf:"bytes,2,opt,name=last_four,json=lastFour,proto3" json:"last_four,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenizeResponse) Reset() {
+	*x = TokenizeResponse{}
+	mi := &file_proto_payment_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenizeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenizeResponse) ProtoMessage() {}
+
+func (x *TokenizeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_payment_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenizeResponse.ProtoReflect.Descriptor instead.
+func (*TokenizeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_payment_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TokenizeResponse) GetCardToken() string {
+	if x != nil {
+		return x.CardToken
+	}
+	return ""
+}
+
+func (x *TokenizeResponse) GetLastFour() string {
+	if x != nil {
+		return x.LastFour
+	}
+	return ""
+}
+
 var File_proto_payment_proto protoreflect.FileDescriptor
 
 const file_proto_payment_proto_rawDesc = "" +
@@ -267,15 +372,25 @@ const file_proto_payment_proto_rawDesc = "" +
 	"\x12credit_card_number\x18\x01 \x01(\tR\x10creditCardNumber\x12&\n" +
 	"\x0fcredit_card_cvv\x18\x02 \x01(\x05R\rcreditCardCvv\x12=\n" +
 	"\x1bcredit_card_expiration_year\x18\x03 \x01(\x05R\x18creditCardExpirationYear\x12?\n" +
-	"\x1ccredit_card_expiration_month\x18\x04 \x01(\x05R\x19creditCardExpirationMonth\"y\n" +
+	"\x1ccredit_card_expiration_month\x18\x04 \x01(\x05R\x19creditCardExpirationMonth\"\x98\x01\n" +
 	"\rChargeRequest\x12*\n" +
 	"\x06amount\x18\x01 \x01(\v2\x12.hipstershop.MoneyR\x06amount\x12<\n" +
 	"\vcredit_card\x18\x02 \x01(\v2\x1b.hipstershop.CreditCardInfoR\n" +
-	"creditCard\"7\n" +
+	"creditCard\x12\x1d\n" +
+	"\n" +
+	"card_token\x18\x03 \x01(\tR\tcardToken\"7\n" +
 	"\x0eChargeResponse\x12%\n" +
-	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId2U\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\"O\n" +
+	"\x0fTokenizeRequest\x12<\n" +
+	"\vcredit_card\x18\x01 \x01(\v2\x1b.hipstershop.CreditCardInfoR\n" +
+	"creditCard\"N\n" +
+	"\x10TokenizeResponse\x12\x1d\n" +
+	"\n" +
+	"card_token\x18\x01 \x01(\tR\tcardToken\x12\x1b\n" +
+	"\tlast_four\x18\x02 \x01(\tR\blastFour2\xa0\x01\n" +
 	"\x0ePaymentService\x12C\n" +
-	"\x06Charge\x12\x1a.hipstershop.ChargeRequest\x1a\x1b.hipstershop.ChargeResponse\"\x00B4Z2github.com/gke-hackathon/payment-integration/protob\x06proto3"
+	"\x06Charge\x12\x1a.hipstershop.ChargeRequest\x1a\x1b.hipstershop.ChargeResponse\"\x00\x12I\n" +
+	"\bTokenize\x12\x1c.hipstershop.TokenizeRequest\x1a\x1d.hipstershop.TokenizeResponse\"\x00B4Z2github.com/gke-hackathon/payment-integration/protob\x06proto3"
 
 var (
 	file_proto_payment_proto_rawDescOnce sync.Once
@@ -289,23 +404,28 @@ func file_proto_payment_proto_rawDescGZIP() []byte {
 	return file_proto_payment_proto_rawDescData
 }
 
-var file_proto_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_proto_payment_proto_goTypes = []any{
-	(*Money)(nil),          // 0: hipstershop.Money
-	(*CreditCardInfo)(nil), // 1: hipstershop.CreditCardInfo
-	(*ChargeRequest)(nil),  // 2: hipstershop.ChargeRequest
-	(*ChargeResponse)(nil), // 3: hipstershop.ChargeResponse
+	(*Money)(nil),            // 0: hipstershop.Money
+	(*CreditCardInfo)(nil),   // 1: hipstershop.CreditCardInfo
+	(*ChargeRequest)(nil),    // 2: hipstershop.ChargeRequest
+	(*ChargeResponse)(nil),   // 3: hipstershop.ChargeResponse
+	(*TokenizeRequest)(nil),  // 4: hipstershop.TokenizeRequest
+	(*TokenizeResponse)(nil), // 5: hipstershop.TokenizeResponse
 }
 var file_proto_payment_proto_depIdxs = []int32{
 	0, // 0: hipstershop.ChargeRequest.amount:type_name -> hipstershop.Money
 	1, // 1: hipstershop.ChargeRequest.credit_card:type_name -> hipstershop.CreditCardInfo
-	2, // 2: hipstershop.PaymentService.Charge:input_type -> hipstershop.ChargeRequest
-	3, // 3: hipstershop.PaymentService.Charge:output_type -> hipstershop.ChargeResponse
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	1, // 2: hipstershop.TokenizeRequest.credit_card:type_name -> hipstershop.CreditCardInfo
+	2, // 3: hipstershop.PaymentService.Charge:input_type -> hipstershop.ChargeRequest
+	4, // 4: hipstershop.PaymentService.Tokenize:input_type -> hipstershop.TokenizeRequest
+	3, // 5: hipstershop.PaymentService.Charge:output_type -> hipstershop.ChargeResponse
+	5, // 6: hipstershop.PaymentService.Tokenize:output_type -> hipstershop.TokenizeResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_proto_payment_proto_init() }
@@ -319,7 +439,7 @@ func file_proto_payment_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_payment_proto_rawDesc), len(file_proto_payment_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
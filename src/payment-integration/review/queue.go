@@ -0,0 +1,246 @@
+// Package review holds charges flagged by the risk engine until a reviewer
+// approves or rejects them, declining any left undecided past their SLA.
+package review
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/logging"
+	"github.com/gke-hackathon/payment-integration/transaction"
+)
+
+var (
+	// ErrNotFound is returned for charges that were never queued for review
+	ErrNotFound = errors.New("review item not found")
+	// ErrDecided is returned when deciding a charge that has already been decided
+	ErrDecided = errors.New("review item has already been decided")
+)
+
+// Review decisions
+const (
+	DecisionApproved = "approved"
+	DecisionRejected = "rejected"
+	DecisionExpired  = "expired"
+)
+
+// SLAReviewer is the reviewer recorded on charges declined for missing their SLA
+const SLAReviewer = "system:review-sla"
+
+// Executor runs the bank transfer of an approved charge and returns its final status
+type Executor func(ctx context.Context, record *transaction.Record) (transaction.Status, error)
+
+// Releaser is called for a rejected or expired charge, whose money never moved
+type Releaser func(ctx context.Context, record *transaction.Record)
+
+// Queue parks flagged charges in the transaction store as pending review
+type Queue struct {
+	store   transaction.Store
+	sla     time.Duration
+	execute Executor
+	release Releaser
+	logger  *logging.Logger
+	// clock is swapped by SetClock while the expiry sweep reads it
+	clock atomic.Pointer[func() time.Time]
+
+	// mu serializes decisions so a charge can't be approved twice
+	mu     sync.Mutex
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+// NewQueue creates a review queue over store. Charges not decided within sla
+// are declined by the expiry sweep; release may be nil.
+func NewQueue(store transaction.Store, sla time.Duration, execute Executor, release Releaser, logger *logging.Logger) *Queue {
+	q := &Queue{
+		store:   store,
+		sla:     sla,
+		execute: execute,
+		release: release,
+		logger:  logger,
+	}
+	q.SetClock(time.Now)
+	return q
+}
+
+// SetClock overrides the time source, for tests
+func (q *Queue) SetClock(now func() time.Time) {
+	q.clock.Store(&now)
+}
+
+func (q *Queue) now() time.Time {
+	return (*q.clock.Load())()
+}
+
+// SLA returns how long a charge may wait for review
+func (q *Queue) SLA() time.Duration {
+	return q.sla
+}
+
+// Park stores a flagged charge as pending review
+func (q *Queue) Park(record *transaction.Record) error {
+	record.Status = transaction.StatusPendingReview
+	record.Review = &transaction.Review{Deadline: q.now().Add(q.sla).UTC()}
+	return q.store.Put(record)
+}
+
+// Pending returns the charges awaiting review, soonest deadline first
+func (q *Queue) Pending() ([]*transaction.Record, error) {
+	records, err := q.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*transaction.Record
+	for _, record := range records {
+		if record.Status == transaction.StatusPendingReview && record.Review != nil {
+			pending = append(pending, record)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Review.Deadline.Before(pending[j].Review.Deadline)
+	})
+	return pending, nil
+}
+
+// Get returns a charge that was queued for review, decided or not
+func (q *Queue) Get(id string) (*transaction.Record, error) {
+	record, err := q.store.Get(id)
+	if errors.Is(err, transaction.ErrNotFound) || (err == nil && record.Review == nil) {
+		return nil, ErrNotFound
+	}
+	return record, err
+}
+
+// Approve runs the charge's bank transfer and records the reviewer's approval.
+// If the transfer fails the charge stays pending and the error is returned.
+func (q *Queue) Approve(ctx context.Context, id, reviewer, note string) (*transaction.Record, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	record, err := q.pending(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	q.decide(record, DecisionApproved, reviewer, note)
+	txStatus, err := q.execute(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	record.Status = txStatus
+	return record, q.store.Put(record)
+}
+
+// Reject declines the charge and records the reviewer's rejection
+func (q *Queue) Reject(ctx context.Context, id, reviewer, note string) (*transaction.Record, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	record, err := q.pending(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return record, q.decline(ctx, record, DecisionRejected, reviewer, note)
+}
+
+// ExpireOverdue declines every pending charge past its deadline and returns how
+// many were declined
+func (q *Queue) ExpireOverdue(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending, err := q.Pending()
+	if err != nil {
+		return 0, err
+	}
+
+	now := q.now()
+	expired := 0
+	for _, record := range pending {
+		if now.Before(record.Review.Deadline) {
+			break
+		}
+		if err := q.expire(ctx, record); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// pending returns the charge if it is still awaiting review. A charge past its
+// deadline is expired on the spot. It is called with mu held.
+func (q *Queue) pending(ctx context.Context, id string) (*transaction.Record, error) {
+	record, err := q.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != transaction.StatusPendingReview {
+		return nil, ErrDecided
+	}
+	if !q.now().Before(record.Review.Deadline) {
+		if err := q.expire(ctx, record); err != nil {
+			return nil, err
+		}
+		return nil, ErrDecided
+	}
+	return record, nil
+}
+
+func (q *Queue) expire(ctx context.Context, record *transaction.Record) error {
+	q.logger.LogAudit(SLAReviewer, "review.expire", record.ID, map[string]interface{}{
+		"account":  record.FromAccount,
+		"amount":   record.Amount,
+		"deadline": record.Review.Deadline,
+	})
+	return q.decline(ctx, record, DecisionExpired, SLAReviewer, "not reviewed within the SLA")
+}
+
+func (q *Queue) decline(ctx context.Context, record *transaction.Record, decision, reviewer, note string) error {
+	q.decide(record, decision, reviewer, note)
+	if q.release != nil {
+		q.release(ctx, record)
+	}
+	record.Status = transaction.StatusDeclined
+	return q.store.Put(record)
+}
+
+func (q *Queue) decide(record *transaction.Record, decision, reviewer, note string) {
+	record.Review.Decision = decision
+	record.Review.Reviewer = reviewer
+	record.Review.Note = note
+	record.Review.DecidedAt = q.now().UTC()
+}
+
+// Start sweeps for overdue charges every interval until Stop is called
+func (q *Queue) Start(interval time.Duration) {
+	q.ticker = time.NewTicker(interval)
+	q.done = make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-q.ticker.C:
+				if _, err := q.ExpireOverdue(context.Background()); err != nil {
+					q.logger.Error("Failed to expire overdue reviews", err, nil)
+				}
+			case <-q.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the expiry sweep
+func (q *Queue) Stop() {
+	if q.ticker != nil {
+		q.ticker.Stop()
+		close(q.done)
+		q.ticker = nil
+	}
+}
@@ -0,0 +1,136 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/logging"
+	"github.com/gke-hackathon/payment-integration/transaction"
+)
+
+// fakeBank counts transfers and releases, failing transfers while fail is set
+type fakeBank struct {
+	transfers []string
+	releases  []string
+	fail      bool
+}
+
+func (b *fakeBank) execute(ctx context.Context, record *transaction.Record) (transaction.Status, error) {
+	if b.fail {
+		return "", errors.New("bank unavailable")
+	}
+	b.transfers = append(b.transfers, record.ID)
+	return transaction.StatusCompleted, nil
+}
+
+func (b *fakeBank) release(ctx context.Context, record *transaction.Record) {
+	b.releases = append(b.releases, record.ID+":"+record.Review.Decision)
+}
+
+func newTestQueue(t *testing.T) (*Queue, *fakeBank, *time.Time) {
+	t.Helper()
+	bank := &fakeBank{}
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	q := NewQueue(transaction.NewMemoryStore(), time.Hour, bank.execute, bank.release, logging.NewLogger("test"))
+	q.SetClock(func() time.Time { return now })
+	return q, bank, &now
+}
+
+func park(t *testing.T, q *Queue, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := q.Park(&transaction.Record{ID: id, FromAccount: "1011226111", Amount: 5000}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestApproveRunsTransfer(t *testing.T) {
+	q, bank, _ := newTestQueue(t)
+	park(t, q, "tx-1")
+
+	pending, err := q.Pending()
+	if err != nil || len(pending) != 1 || pending[0].Status != transaction.StatusPendingReview {
+		t.Fatalf("Expected one pending charge, got %v (%v)", pending, err)
+	}
+
+	// A failed transfer leaves the charge pending for another try
+	bank.fail = true
+	if _, err := q.Approve(context.Background(), "tx-1", "alice", ""); err == nil {
+		t.Fatal("Expected the bank failure to be returned")
+	}
+	if record, _ := q.Get("tx-1"); record.Status != transaction.StatusPendingReview || record.Review.Decision != "" {
+		t.Fatalf("Expected the charge to stay pending, got %+v", record)
+	}
+
+	bank.fail = false
+	record, err := q.Approve(context.Background(), "tx-1", "alice", "customer confirmed")
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if record.Status != transaction.StatusCompleted || record.Review.Reviewer != "alice" || record.Review.Decision != DecisionApproved {
+		t.Errorf("Unexpected approved record %+v %+v", record, record.Review)
+	}
+	if len(bank.transfers) != 1 {
+		t.Errorf("Expected one transfer, got %v", bank.transfers)
+	}
+
+	// Decisions are final
+	if _, err := q.Approve(context.Background(), "tx-1", "bob", ""); !errors.Is(err, ErrDecided) {
+		t.Errorf("Expected ErrDecided on a second approval, got %v", err)
+	}
+	if _, err := q.Reject(context.Background(), "tx-1", "bob", ""); !errors.Is(err, ErrDecided) {
+		t.Errorf("Expected ErrDecided on a rejection after approval, got %v", err)
+	}
+	if pending, _ := q.Pending(); len(pending) != 0 {
+		t.Errorf("Expected nothing pending, got %d", len(pending))
+	}
+}
+
+func TestRejectReleases(t *testing.T) {
+	q, bank, _ := newTestQueue(t)
+	park(t, q, "tx-1")
+
+	record, err := q.Reject(context.Background(), "tx-1", "alice", "stolen card")
+	if err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+	if record.Status != transaction.StatusDeclined || record.Review.Note != "stolen card" {
+		t.Errorf("Unexpected rejected record %+v %+v", record, record.Review)
+	}
+	if len(bank.transfers) != 0 || len(bank.releases) != 1 || bank.releases[0] != "tx-1:rejected" {
+		t.Errorf("Expected only a release, got transfers %v releases %v", bank.transfers, bank.releases)
+	}
+
+	if _, err := q.Get("unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestExpireOverdue(t *testing.T) {
+	q, bank, now := newTestQueue(t)
+	park(t, q, "tx-1")
+	*now = now.Add(30 * time.Minute)
+	park(t, q, "tx-2")
+
+	*now = now.Add(45 * time.Minute)
+	expired, err := q.ExpireOverdue(context.Background())
+	if err != nil || expired != 1 {
+		t.Fatalf("Expected 1 expired charge, got %d (%v)", expired, err)
+	}
+	record, _ := q.Get("tx-1")
+	if record.Status != transaction.StatusDeclined || record.Review.Decision != DecisionExpired || record.Review.Reviewer != SLAReviewer {
+		t.Errorf("Unexpected expired record %+v %+v", record, record.Review)
+	}
+
+	// A charge past its deadline can't be approved even before the sweep runs
+	*now = now.Add(time.Hour)
+	if _, err := q.Approve(context.Background(), "tx-2", "alice", ""); !errors.Is(err, ErrDecided) {
+		t.Errorf("Expected ErrDecided for an overdue charge, got %v", err)
+	}
+	if len(bank.transfers) != 0 || len(bank.releases) != 2 {
+		t.Errorf("Expected two releases and no transfers, got %v %v", bank.transfers, bank.releases)
+	}
+}
@@ -0,0 +1,280 @@
+package risk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "payment-integration"
+
+// defaultRemember is how long a charged card counts as seen
+const defaultRemember = 30 * 24 * time.Hour
+
+// Outcome is the engine's decision on a charge
+type Outcome string
+
+const (
+	OutcomeApprove Outcome = "approve"
+	OutcomeDecline Outcome = "decline"
+	OutcomeReview  Outcome = "review"
+)
+
+// Decline reason codes
+const (
+	ReasonBINBlocked     = "BIN_BLOCKED"
+	ReasonAccountBlocked = "ACCOUNT_BLOCKED"
+	ReasonHighRiskScore  = "HIGH_RISK_SCORE"
+)
+
+// Rule names, as reported in assessments
+const (
+	RuleAccountBlocklist = "account_blocklist"
+	RuleBINBlocklist     = "bin_blocklist"
+	RuleAmount           = "amount"
+	RuleFirstSeenCard    = "first_seen_card"
+	RuleCardCycling      = "card_cycling"
+	RuleOddHours         = "odd_hours"
+	RuleSpendDeviation   = "spend_deviation"
+	RuleAnomaly          = "anomaly"
+)
+
+// Request is a charge as seen by the risk rules
+type Request struct {
+	Account string
+	// Card is the card fingerprint
+	Card string
+	// BIN is the card number's first binLength digits, if known
+	BIN string
+	// Amount is in ledger cents
+	Amount int64
+	Time   time.Time
+	// Anomaly is the account's behavioural anomaly score from 0 to 100, if known
+	Anomaly *float64
+}
+
+// Contribution is one rule that fired and what it added to the score
+type Contribution struct {
+	Rule   string `json:"rule"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+// Assessment is the score breakdown and decision for a charge
+type Assessment struct {
+	Score   int     `json:"score"`
+	Outcome Outcome `json:"outcome"`
+	// Reason is the decline reason code
+	Reason string         `json:"reason,omitempty"`
+	Rules  []Contribution `json:"rules,omitempty"`
+}
+
+// ToGRPCError converts a declined assessment to a PermissionDenied status
+// carrying the reason code
+func (a *Assessment) ToGRPCError() error {
+	st := status.New(codes.PermissionDenied, "the payment was declined")
+	info := &errdetails.ErrorInfo{
+		Reason:   a.Reason,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"score": strconv.Itoa(a.Score)},
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Engine applies a risk policy, remembering the cards and spend it has seen
+type Engine struct {
+	policy          *Policy
+	amounts         []AmountThreshold
+	blockedAccounts map[string]bool
+	location        *time.Location
+
+	mu           sync.Mutex
+	seenCards    map[string]time.Time            // card -> last completed charge
+	accountCards map[string]map[string]time.Time // account -> card -> last attempt
+	spend        map[string]*spendStats          // account -> completed charges
+	lastGC       time.Time
+}
+
+type spendStats struct {
+	count int64
+	total int64
+}
+
+// NewEngine creates an engine for a validated policy
+func NewEngine(policy *Policy) (*Engine, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	e := &Engine{
+		policy:          policy,
+		amounts:         policy.sortedAmounts(),
+		blockedAccounts: make(map[string]bool),
+		location:        time.UTC,
+		seenCards:       make(map[string]time.Time),
+		accountCards:    make(map[string]map[string]time.Time),
+		spend:           make(map[string]*spendStats),
+	}
+	for _, account := range policy.BlockedAccounts {
+		e.blockedAccounts[account] = true
+	}
+	if policy.OddHours != nil {
+		e.location, _ = time.LoadLocation(policy.OddHours.Timezone)
+	}
+	return e, nil
+}
+
+// Assess scores a charge. Unless it is declined, the charge's card is counted
+// towards the account's card cycling window.
+func (e *Engine) Assess(req Request) *Assessment {
+	if e.blockedAccounts[req.Account] {
+		return &Assessment{Outcome: OutcomeDecline, Reason: ReasonAccountBlocked, Rules: []Contribution{
+			{Rule: RuleAccountBlocklist, Detail: "account is blocklisted"},
+		}}
+	}
+	for _, bin := range e.policy.BlockedBINs {
+		if req.BIN != "" && strings.HasPrefix(req.BIN, bin) {
+			return &Assessment{Outcome: OutcomeDecline, Reason: ReasonBINBlocked, Rules: []Contribution{
+				{Rule: RuleBINBlocklist, Detail: fmt.Sprintf("BIN matches blocked prefix %s", bin)},
+			}}
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.collectGarbage(req.Time)
+
+	a := &Assessment{}
+	add := func(rule string, score int, detail string, args ...interface{}) {
+		a.Score += score
+		a.Rules = append(a.Rules, Contribution{Rule: rule, Score: score, Detail: fmt.Sprintf(detail, args...)})
+	}
+
+	for _, threshold := range e.amounts {
+		if req.Amount > threshold.AboveCents {
+			add(RuleAmount, threshold.Score, "amount %d above %d cents", req.Amount, threshold.AboveCents)
+			break
+		}
+	}
+
+	if r := e.policy.FirstSeenCard; r != nil && req.Card != "" {
+		if _, seen := e.seenCards[req.Card]; !seen {
+			add(RuleFirstSeenCard, r.Score, "card not charged before")
+		}
+	}
+
+	if r := e.policy.CardCycling; r != nil && req.Card != "" {
+		cards := 1
+		for card, used := range e.accountCards[req.Account] {
+			if card != req.Card && req.Time.Sub(used) < time.Duration(r.Window) {
+				cards++
+			}
+		}
+		if cards > r.MaxCards {
+			add(RuleCardCycling, r.Score, "%d cards on the account within %s", cards, r.Window)
+		}
+	}
+
+	if r := e.policy.OddHours; r != nil {
+		hour := req.Time.In(e.location).Hour()
+		inRange := hour >= r.Start && hour < r.End
+		if r.Start > r.End {
+			inRange = hour >= r.Start || hour < r.End
+		}
+		if inRange {
+			add(RuleOddHours, r.Score, "charged at %02d:00 %s", hour, e.location)
+		}
+	}
+
+	if r := e.policy.SpendDeviation; r != nil {
+		if stats := e.spend[req.Account]; stats != nil && stats.count >= int64(r.MinHistory) {
+			average := float64(stats.total) / float64(stats.count)
+			if float64(req.Amount) > r.Multiplier*average {
+				add(RuleSpendDeviation, r.Score, "amount %d is %.1fx the account average of %.0f",
+					req.Amount, float64(req.Amount)/average, average)
+			}
+		}
+	}
+
+	if r := e.policy.Anomaly; r != nil && req.Anomaly != nil && *req.Anomaly >= r.Above {
+		add(RuleAnomaly, r.Score, "anomaly score %.1f at or above %.1f", *req.Anomaly, r.Above)
+	}
+
+	switch {
+	case e.policy.DeclineAt > 0 && a.Score >= e.policy.DeclineAt:
+		a.Outcome = OutcomeDecline
+		a.Reason = ReasonHighRiskScore
+	case e.policy.ReviewAt > 0 && a.Score >= e.policy.ReviewAt:
+		a.Outcome = OutcomeReview
+	default:
+		a.Outcome = OutcomeApprove
+	}
+
+	if a.Outcome != OutcomeDecline && e.policy.CardCycling != nil && req.Card != "" {
+		cards := e.accountCards[req.Account]
+		if cards == nil {
+			cards = make(map[string]time.Time)
+			e.accountCards[req.Account] = cards
+		}
+		cards[req.Card] = req.Time
+	}
+	return a
+}
+
+// Observe records a completed charge, so its card is no longer first seen and
+// its amount counts towards the account's average
+func (e *Engine) Observe(req Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if req.Card != "" {
+		e.seenCards[req.Card] = req.Time
+	}
+	stats := e.spend[req.Account]
+	if stats == nil {
+		stats = &spendStats{}
+		e.spend[req.Account] = stats
+	}
+	stats.count++
+	stats.total += req.Amount
+}
+
+// collectGarbage forgets cards outside the first-seen and cycling windows, at
+// most once a minute. It is called with mu held.
+func (e *Engine) collectGarbage(now time.Time) {
+	if now.Sub(e.lastGC) < time.Minute {
+		return
+	}
+	e.lastGC = now
+
+	remember := defaultRemember
+	if r := e.policy.FirstSeenCard; r != nil && r.Remember > 0 {
+		remember = time.Duration(r.Remember)
+	}
+	for card, seen := range e.seenCards {
+		if now.Sub(seen) > remember {
+			delete(e.seenCards, card)
+		}
+	}
+
+	if r := e.policy.CardCycling; r != nil {
+		for account, cards := range e.accountCards {
+			for card, used := range cards {
+				if now.Sub(used) > time.Duration(r.Window) {
+					delete(cards, card)
+				}
+			}
+			if len(cards) == 0 {
+				delete(e.accountCards, account)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package risk scores charges against configurable fraud rules and decides
+// whether each one is approved, declined or flagged for manual review.
+package risk
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/velocity"
+	"gopkg.in/yaml.v3"
+)
+
+// binLength is how many leading card digits a Request's BIN carries
+const binLength = 6
+
+// Policy configures the risk rules. Every rule is optional; a scoring rule adds
+// its score to the charge's total when it fires, and the total is compared with
+// ReviewAt and DeclineAt. Blocklisted BINs and accounts are always declined.
+type Policy struct {
+	// ReviewAt is the score from which charges are flagged for review, 0 never flags
+	ReviewAt int `yaml:"review_at"`
+	// DeclineAt is the score from which charges are declined, 0 never declines on score
+	DeclineAt int `yaml:"decline_at"`
+
+	// Amount scores charges above thresholds; the highest threshold crossed applies
+	Amount []AmountThreshold `yaml:"amount"`
+	// FirstSeenCard scores cards the service hasn't charged before
+	FirstSeenCard *FirstSeenCardRule `yaml:"first_seen_card"`
+	// CardCycling scores accounts charged with many different cards in a short time
+	CardCycling *CardCyclingRule `yaml:"card_cycling"`
+	// OddHours scores charges made at unusual times
+	OddHours *OddHoursRule `yaml:"odd_hours"`
+	// SpendDeviation scores charges far above the account's usual amount
+	SpendDeviation *SpendDeviationRule `yaml:"spend_deviation"`
+	// Anomaly scores charges whose behavioural profile anomaly score is high
+	Anomaly *AnomalyRule `yaml:"anomaly"`
+
+	// BlockedBINs are card number prefixes of up to binLength digits that
+	// are always declined
+	BlockedBINs []string `yaml:"blocked_bins"`
+	// BlockedAccounts are bank accounts that are always declined
+	BlockedAccounts []string `yaml:"blocked_accounts"`
+}
+
+// AmountThreshold scores charges above an amount in ledger cents
+type AmountThreshold struct {
+	AboveCents int64 `yaml:"above_cents"`
+	Score      int   `yaml:"score"`
+}
+
+// FirstSeenCardRule scores cards not charged within Remember
+type FirstSeenCardRule struct {
+	Score int `yaml:"score"`
+	// Remember is how long a charged card is remembered; defaults to 30 days
+	Remember velocity.Window `yaml:"remember"`
+}
+
+// CardCyclingRule scores an account charged with more than MaxCards distinct
+// cards within Window
+type CardCyclingRule struct {
+	Score    int             `yaml:"score"`
+	MaxCards int             `yaml:"max_cards"`
+	Window   velocity.Window `yaml:"window"`
+}
+
+// OddHoursRule scores charges between Start and End o'clock. The range wraps
+// past midnight when Start is after End, e.g. 23 to 5.
+type OddHoursRule struct {
+	Score int `yaml:"score"`
+	Start int `yaml:"start"`
+	End   int `yaml:"end"`
+	// Timezone is an IANA zone such as "America/New_York"; defaults to UTC
+	Timezone string `yaml:"timezone"`
+}
+
+// SpendDeviationRule scores charges above Multiplier times the account's
+// average, once the account has MinHistory completed charges
+type SpendDeviationRule struct {
+	Score      int     `yaml:"score"`
+	Multiplier float64 `yaml:"multiplier"`
+	MinHistory int     `yaml:"min_history"`
+}
+
+// AnomalyRule scores charges whose anomaly score, from 0 to 100, is at least
+// Above. Accounts still building a profile are never scored.
+type AnomalyRule struct {
+	Score int     `yaml:"score"`
+	Above float64 `yaml:"above"`
+}
+
+// Validate checks the policy's thresholds and rules
+func (p *Policy) Validate() error {
+	if p.ReviewAt < 0 || p.DeclineAt < 0 {
+		return fmt.Errorf("risk review_at and decline_at must not be negative")
+	}
+	if p.ReviewAt > 0 && p.DeclineAt > 0 && p.ReviewAt >= p.DeclineAt {
+		return fmt.Errorf("risk review_at (%d) must be below decline_at (%d)", p.ReviewAt, p.DeclineAt)
+	}
+	for _, threshold := range p.Amount {
+		if threshold.AboveCents <= 0 {
+			return fmt.Errorf("risk amount threshold needs a positive above_cents")
+		}
+	}
+	if r := p.CardCycling; r != nil && (r.MaxCards < 1 || r.Window <= 0) {
+		return fmt.Errorf("risk card_cycling needs a positive max_cards and a window")
+	}
+	if r := p.OddHours; r != nil {
+		if r.Start < 0 || r.Start > 23 || r.End < 0 || r.End > 23 || r.Start == r.End {
+			return fmt.Errorf("risk odd_hours start and end must be different hours from 0 to 23")
+		}
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			return fmt.Errorf("risk odd_hours timezone: %w", err)
+		}
+	}
+	if r := p.SpendDeviation; r != nil && (r.Multiplier <= 1 || r.MinHistory < 1) {
+		return fmt.Errorf("risk spend_deviation needs a multiplier above 1 and a positive min_history")
+	}
+	if r := p.Anomaly; r != nil && (r.Above <= 0 || r.Above > 100) {
+		return fmt.Errorf("risk anomaly above must be between 0 and 100")
+	}
+	for _, bin := range p.BlockedBINs {
+		if bin == "" || len(bin) > binLength || strings.Trim(bin, "0123456789") != "" {
+			return fmt.Errorf("blocked BIN %q must be up to %d digits", bin, binLength)
+		}
+	}
+	return nil
+}
+
+// sortedAmounts returns the amount thresholds, highest first
+func (p *Policy) sortedAmounts() []AmountThreshold {
+	thresholds := append([]AmountThreshold(nil), p.Amount...)
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i].AboveCents > thresholds[j].AboveCents })
+	return thresholds
+}
+
+// LoadPolicy reads a YAML (or JSON) risk policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk policy: %w", err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse risk policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
@@ -0,0 +1,225 @@
+package risk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/velocity"
+)
+
+func mustEngine(t *testing.T, policy *Policy) *Engine {
+	t.Helper()
+	e, err := NewEngine(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func firedRules(a *Assessment) []string {
+	var rules []string
+	for _, c := range a.Rules {
+		rules = append(rules, c.Rule)
+	}
+	return rules
+}
+
+// noon is well clear of the odd hours used in these tests
+var noon = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "risk.yaml")
+	policy := `
+review_at: 40
+decline_at: 80
+amount:
+  - above_cents: 50000
+    score: 20
+  - above_cents: 200000
+    score: 50
+first_seen_card:
+  score: 15
+card_cycling:
+  score: 40
+  max_cards: 3
+  window: hour
+odd_hours:
+  score: 10
+  start: 1
+  end: 5
+  timezone: America/New_York
+spend_deviation:
+  score: 30
+  multiplier: 5
+  min_history: 3
+anomaly:
+  score: 25
+  above: 70
+blocked_bins: ["400000"]
+blocked_accounts: ["1234567890"]
+`
+	if err := os.WriteFile(path, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	if loaded.CardCycling.Window != velocity.Window(time.Hour) || loaded.OddHours.Timezone != "America/New_York" {
+		t.Errorf("Unexpected policy %+v", loaded)
+	}
+	if _, err := NewEngine(loaded); err != nil {
+		t.Errorf("NewEngine failed: %v", err)
+	}
+}
+
+func TestPolicyValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+	}{
+		{"review above decline", Policy{ReviewAt: 50, DeclineAt: 50}},
+		{"zero amount threshold", Policy{Amount: []AmountThreshold{{Score: 10}}}},
+		{"cycling without window", Policy{CardCycling: &CardCyclingRule{Score: 10, MaxCards: 2}}},
+		{"odd hours out of range", Policy{OddHours: &OddHoursRule{Score: 10, Start: 22, End: 24}}},
+		{"odd hours unknown zone", Policy{OddHours: &OddHoursRule{Score: 10, Start: 1, End: 5, Timezone: "Mars/Olympus"}}},
+		{"deviation multiplier", Policy{SpendDeviation: &SpendDeviationRule{Score: 10, Multiplier: 1, MinHistory: 1}}},
+		{"anomaly above 100", Policy{Anomaly: &AnomalyRule{Score: 10, Above: 120}}},
+		{"non-digit BIN", Policy{BlockedBINs: []string{"4x"}}},
+		// Charges only carry the first 6 digits, so this could never match
+		{"BIN longer than 6 digits", Policy{BlockedBINs: []string{"41111111"}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewEngine(&tt.policy); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestBlocklists(t *testing.T) {
+	e := mustEngine(t, &Policy{BlockedBINs: []string{"4111"}, BlockedAccounts: []string{"1234567890"}})
+
+	tests := []struct {
+		req    Request
+		reason string
+	}{
+		{Request{Account: "1234567890", BIN: "555555", Time: noon}, ReasonAccountBlocked},
+		{Request{Account: "1111111111", BIN: "411111", Time: noon}, ReasonBINBlocked},
+		{Request{Account: "1111111111", BIN: "555555", Time: noon}, ""},
+		// Without a BIN, e.g. for older tokens, the BIN blocklist can't apply
+		{Request{Account: "1111111111", Time: noon}, ""},
+	}
+
+	for _, tt := range tests {
+		a := e.Assess(tt.req)
+		if a.Reason != tt.reason {
+			t.Errorf("%+v: expected reason %q, got %q", tt.req, tt.reason, a.Reason)
+		}
+		if (tt.reason != "") != (a.Outcome == OutcomeDecline) {
+			t.Errorf("%+v: unexpected outcome %s", tt.req, a.Outcome)
+		}
+	}
+}
+
+func TestScoringOutcomes(t *testing.T) {
+	e := mustEngine(t, &Policy{
+		ReviewAt:      30,
+		DeclineAt:     60,
+		Amount:        []AmountThreshold{{AboveCents: 10000, Score: 10}, {AboveCents: 50000, Score: 40}},
+		FirstSeenCard: &FirstSeenCardRule{Score: 20},
+		OddHours:      &OddHoursRule{Score: 25, Start: 23, End: 5},
+	})
+
+	tests := []struct {
+		name    string
+		req     Request
+		score   int
+		outcome Outcome
+	}{
+		{"small, new card", Request{Account: "a", Card: "c1", Amount: 500, Time: noon}, 20, OutcomeApprove},
+		{"medium, new card", Request{Account: "a", Card: "c1", Amount: 20000, Time: noon}, 30, OutcomeReview},
+		{"large, new card", Request{Account: "a", Card: "c1", Amount: 60000, Time: noon}, 60, OutcomeDecline},
+		// The range wraps past midnight
+		{"small, new card at 2am", Request{Account: "a", Card: "c1", Amount: 500, Time: noon.Add(14 * time.Hour)}, 45, OutcomeReview},
+	}
+
+	for _, tt := range tests {
+		a := e.Assess(tt.req)
+		if a.Score != tt.score || a.Outcome != tt.outcome {
+			t.Errorf("%s: expected %d/%s, got %d/%s (%v)", tt.name, tt.score, tt.outcome, a.Score, a.Outcome, firedRules(a))
+		}
+	}
+
+	// Once the card has completed a charge it is no longer first seen
+	e.Observe(Request{Account: "a", Card: "c1", Amount: 500, Time: noon})
+	if a := e.Assess(Request{Account: "a", Card: "c1", Amount: 20000, Time: noon}); a.Score != 10 {
+		t.Errorf("Expected only the amount rule for a known card, got %d (%v)", a.Score, firedRules(a))
+	}
+}
+
+func TestCardCycling(t *testing.T) {
+	e := mustEngine(t, &Policy{
+		ReviewAt:    10,
+		CardCycling: &CardCyclingRule{Score: 10, MaxCards: 2, Window: velocity.Window(time.Hour)},
+	})
+
+	for _, card := range []string{"c1", "c2", "c1"} {
+		if a := e.Assess(Request{Account: "a", Card: card, Time: noon}); a.Outcome != OutcomeApprove {
+			t.Fatalf("Expected %s to be approved, got %s", card, a.Outcome)
+		}
+	}
+	if a := e.Assess(Request{Account: "a", Card: "c3", Time: noon}); a.Outcome != OutcomeReview {
+		t.Errorf("Expected a third card to be flagged, got %s", a.Outcome)
+	}
+	if a := e.Assess(Request{Account: "a", Card: "c4", Time: noon.Add(2 * time.Hour)}); a.Outcome != OutcomeApprove {
+		t.Errorf("Expected the window to have passed, got %s (%v)", a.Outcome, firedRules(a))
+	}
+}
+
+func TestSpendDeviation(t *testing.T) {
+	e := mustEngine(t, &Policy{
+		ReviewAt:       10,
+		SpendDeviation: &SpendDeviationRule{Score: 10, Multiplier: 4, MinHistory: 3},
+	})
+
+	big := Request{Account: "a", Amount: 5000, Time: noon}
+	for i := 0; i < 3; i++ {
+		if a := e.Assess(big); a.Outcome != OutcomeApprove {
+			t.Fatalf("Expected approval without enough history, got %s", a.Outcome)
+		}
+		e.Observe(Request{Account: "a", Amount: 1000, Time: noon})
+	}
+
+	if a := e.Assess(big); a.Outcome != OutcomeReview {
+		t.Errorf("Expected 5x the average to be flagged, got %s", a.Outcome)
+	}
+	if a := e.Assess(Request{Account: "a", Amount: 3000, Time: noon}); a.Outcome != OutcomeApprove {
+		t.Errorf("Expected 3x the average to be approved, got %s", a.Outcome)
+	}
+}
+
+func TestAnomaly(t *testing.T) {
+	e := mustEngine(t, &Policy{ReviewAt: 10, Anomaly: &AnomalyRule{Score: 10, Above: 70}})
+
+	score := func(v float64) *float64 { return &v }
+	tests := []struct {
+		anomaly *float64
+		outcome Outcome
+	}{
+		{nil, OutcomeApprove},
+		{score(69.9), OutcomeApprove},
+		{score(70), OutcomeReview},
+		{score(95), OutcomeReview},
+	}
+
+	for _, tt := range tests {
+		a := e.Assess(Request{Account: "a", Amount: 1000, Time: noon, Anomaly: tt.anomaly})
+		if a.Outcome != tt.outcome {
+			t.Errorf("Anomaly %v: expected %s, got %s (%v)", tt.anomaly, tt.outcome, a.Outcome, firedRules(a))
+		}
+	}
+}
@@ -0,0 +1,660 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/bank"
+	"github.com/gke-hackathon/payment-integration/blocklist"
+	"github.com/gke-hackathon/payment-integration/config"
+	"github.com/gke-hackathon/payment-integration/logging"
+	"github.com/gke-hackathon/payment-integration/mapper"
+	"github.com/gke-hackathon/payment-integration/profile"
+	pb "github.com/gke-hackathon/payment-integration/proto"
+	"github.com/gke-hackathon/payment-integration/review"
+	"github.com/gke-hackathon/payment-integration/spendcap"
+	"github.com/gke-hackathon/payment-integration/transaction"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// adminActorHeader carries the identity of the operator making an admin call
+const adminActorHeader = "x-admin-actor"
+
+// AdminServer implements the operator-facing admin gRPC services
+type AdminServer struct {
+	pb.UnimplementedCardAdminServiceServer
+	pb.UnimplementedReviewAdminServiceServer
+	pb.UnimplementedProfileAdminServiceServer
+	pb.UnimplementedSpendCapAdminServiceServer
+	pb.UnimplementedBlocklistAdminServiceServer
+	pb.UnimplementedConfigAdminServiceServer
+	// payment supplies the account mapper, which is replaced on reload
+	payment    *PaymentServer
+	reviews    *review.Queue
+	profiles   *profile.Tracker
+	spendCaps  *spendcap.Caps
+	blocklist  *blocklist.Blocklist
+	config     *config.Watcher
+	logger     *logging.Logger
+	adminToken string
+}
+
+// NewAdminServer creates the admin services on top of a payment server's components.
+// Every call must present adminToken as a bearer token.
+func NewAdminServer(payment *PaymentServer, adminToken string) *AdminServer {
+	return &AdminServer{
+		payment:    payment,
+		reviews:    payment.reviews,
+		profiles:   payment.profiles,
+		spendCaps:  payment.spendCaps,
+		blocklist:  payment.blocklist,
+		logger:     payment.logger,
+		adminToken: adminToken,
+	}
+}
+
+// UseConfigWatcher lets operators see and reload the configuration w manages
+func (a *AdminServer) UseConfigWatcher(w *config.Watcher) {
+	a.config = w
+}
+
+// RegisterAdminServices registers the admin services with the gRPC server
+func RegisterAdminServices(s *grpc.Server, srv *AdminServer) {
+	pb.RegisterCardAdminServiceServer(s, srv)
+	pb.RegisterReviewAdminServiceServer(s, srv)
+	pb.RegisterProfileAdminServiceServer(s, srv)
+	pb.RegisterSpendCapAdminServiceServer(s, srv)
+	pb.RegisterBlocklistAdminServiceServer(s, srv)
+	pb.RegisterConfigAdminServiceServer(s, srv)
+}
+
+// authorize checks the admin token and returns the calling operator's identity
+func (a *AdminServer) authorize(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	token := strings.TrimPrefix(firstValue(md, "authorization"), "Bearer ")
+	if a.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.adminToken)) != 1 {
+		return "", status.Error(codes.Unauthenticated, "invalid admin token")
+	}
+
+	actor := firstValue(md, adminActorHeader)
+	if actor == "" {
+		return "", status.Errorf(codes.InvalidArgument, "%s is required", adminActorHeader)
+	}
+	return actor, nil
+}
+
+// EnrollCard links a card to a bank account
+func (a *AdminServer) EnrollCard(ctx context.Context, req *pb.EnrollCardRequest) (*pb.CardLink, error) {
+	actor, err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mapper.ValidateCardNumber(req.CardNumber); err != nil {
+		return nil, cardValidationError(err)
+	}
+	if err := validateDigits("account_num", req.AccountNum, 10); err != nil {
+		return nil, err
+	}
+	if req.RoutingNum != "" {
+		if err := validateDigits("routing_num", req.RoutingNum, 9); err != nil {
+			return nil, err
+		}
+	}
+
+	link, err := a.payment.currentMapper().LinkCard(req.CardNumber, req.AccountNum, req.RoutingNum, req.OwnerId)
+	if err != nil {
+		return nil, linkStoreError(err)
+	}
+
+	a.logger.LogAudit(actor, "card.enroll", link.CardHash, map[string]interface{}{
+		"card_last4":  link.LastFour,
+		"account_num": link.AccountNum,
+		"routing_num": link.RoutingNum,
+		"owner_id":    link.OwnerID,
+	})
+	return cardLinkToProto(link), nil
+}
+
+// ListCardLinks looks up links by last four digits and/or account number
+func (a *AdminServer) ListCardLinks(ctx context.Context, req *pb.ListCardLinksRequest) (*pb.ListCardLinksResponse, error) {
+	if _, err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.LastFour != "" {
+		if err := validateDigits("last_four", req.LastFour, 4); err != nil {
+			return nil, err
+		}
+	}
+
+	links, err := a.payment.currentMapper().LookupCards(req.LastFour, req.AccountNum)
+	if err != nil {
+		return nil, linkStoreError(err)
+	}
+
+	resp := &pb.ListCardLinksResponse{}
+	for _, link := range links {
+		resp.Links = append(resp.Links, cardLinkToProto(link))
+	}
+	return resp, nil
+}
+
+// SuspendCard stops a linked card from being charged
+func (a *AdminServer) SuspendCard(ctx context.Context, req *pb.SuspendCardRequest) (*pb.CardLink, error) {
+	actor, err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cardID, err := a.resolveCardID(req.CardId, req.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := a.payment.currentMapper().SetCardStatus(cardID, mapper.LinkSuspended)
+	if err != nil {
+		return nil, linkStoreError(err)
+	}
+
+	a.logger.LogAudit(actor, "card.suspend", cardID, map[string]interface{}{
+		"card_last4":  link.LastFour,
+		"account_num": link.AccountNum,
+		"reason":      req.Reason,
+	})
+	return cardLinkToProto(link), nil
+}
+
+// UnlinkCard removes a card's link to its bank account
+func (a *AdminServer) UnlinkCard(ctx context.Context, req *pb.UnlinkCardRequest) (*pb.UnlinkCardResponse, error) {
+	actor, err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cardID, err := a.resolveCardID(req.CardId, req.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.payment.currentMapper().UnlinkCard(cardID); err != nil {
+		return nil, linkStoreError(err)
+	}
+
+	a.logger.LogAudit(actor, "card.unlink", cardID, map[string]interface{}{"reason": req.Reason})
+	return &pb.UnlinkCardResponse{CardId: cardID}, nil
+}
+
+// resolveCardID returns the card id, hashing the card number if no id was given
+func (a *AdminServer) resolveCardID(cardID, cardNumber string) (string, error) {
+	if cardID != "" {
+		return cardID, nil
+	}
+	if cardNumber == "" {
+		return "", status.Error(codes.InvalidArgument, "card_id or card_number is required")
+	}
+	if err := mapper.ValidateCardNumber(cardNumber); err != nil {
+		return "", cardValidationError(err)
+	}
+
+	id, err := a.payment.currentMapper().CardID(cardNumber)
+	if err != nil {
+		return "", linkStoreError(err)
+	}
+	return id, nil
+}
+
+// ListReviews lists the charges awaiting review, optionally for one account
+func (a *AdminServer) ListReviews(ctx context.Context, req *pb.ListReviewsRequest) (*pb.ListReviewsResponse, error) {
+	if _, err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if a.reviews == nil {
+		return nil, reviewError(nil)
+	}
+
+	pending, err := a.reviews.Pending()
+	if err != nil {
+		return nil, reviewError(err)
+	}
+
+	resp := &pb.ListReviewsResponse{}
+	for _, record := range pending {
+		if req.AccountNum == "" || record.FromAccount == req.AccountNum {
+			resp.Items = append(resp.Items, reviewItemToProto(record))
+		}
+	}
+	return resp, nil
+}
+
+// GetReview returns a charge that was queued for review, with its risk breakdown
+func (a *AdminServer) GetReview(ctx context.Context, req *pb.GetReviewRequest) (*pb.ReviewItem, error) {
+	if _, err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if a.reviews == nil {
+		return nil, reviewError(nil)
+	}
+
+	record, err := a.reviews.Get(req.TransactionId)
+	if err != nil {
+		return nil, reviewError(err)
+	}
+	return reviewItemToProto(record), nil
+}
+
+// ApproveReview runs the bank transfer of a queued charge
+func (a *AdminServer) ApproveReview(ctx context.Context, req *pb.DecideReviewRequest) (*pb.ReviewItem, error) {
+	return a.decideReview(ctx, req, review.DecisionApproved, a.reviews.Approve)
+}
+
+// RejectReview declines a queued charge
+func (a *AdminServer) RejectReview(ctx context.Context, req *pb.DecideReviewRequest) (*pb.ReviewItem, error) {
+	return a.decideReview(ctx, req, review.DecisionRejected, a.reviews.Reject)
+}
+
+func (a *AdminServer) decideReview(ctx context.Context, req *pb.DecideReviewRequest, decision string,
+	decide func(ctx context.Context, id, reviewer, note string) (*transaction.Record, error)) (*pb.ReviewItem, error) {
+	actor, err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.reviews == nil {
+		return nil, reviewError(nil)
+	}
+	if req.TransactionId == "" {
+		return nil, status.Error(codes.InvalidArgument, "transaction_id is required")
+	}
+
+	record, err := decide(ctx, req.TransactionId, actor, req.Note)
+	if err != nil {
+		return nil, reviewError(err)
+	}
+
+	a.logger.LogAudit(actor, "review."+decision, record.ID, map[string]interface{}{
+		"account": record.FromAccount,
+		"amount":  record.Amount,
+		"status":  string(record.Status),
+		"note":    req.Note,
+	})
+	return reviewItemToProto(record), nil
+}
+
+// reviewError converts a review queue failure into a gRPC error. A nil error
+// means the queue isn't configured. Errors from the bank transfer of an
+// approval are already gRPC statuses and pass through.
+func reviewError(err error) error {
+	switch {
+	case err == nil:
+		return status.Error(codes.FailedPrecondition, "the review queue is not enabled")
+	case errors.Is(err, review.ErrNotFound):
+		return status.Error(codes.NotFound, "review item not found")
+	case errors.Is(err, review.ErrDecided):
+		return status.Error(codes.FailedPrecondition, "the charge has already been decided")
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Errorf(codes.Internal, "review queue error: %v", err)
+}
+
+func reviewItemToProto(record *transaction.Record) *pb.ReviewItem {
+	item := &pb.ReviewItem{
+		TransactionId:  record.ID,
+		Status:         string(record.Status),
+		FromAccount:    record.FromAccount,
+		FromRouting:    record.FromRouting,
+		ToAccount:      record.ToAccount,
+		ToRouting:      record.ToRouting,
+		CardLastFour:   record.CardLast4,
+		Amount:         record.Amount,
+		Currency:       record.Currency,
+		OriginalAmount: record.Original.String(),
+		CreatedAt:      timestamppb.New(record.CreatedAt),
+	}
+	if record.Risk != nil {
+		item.RiskScore = int32(record.Risk.Score)
+		for _, rule := range record.Risk.Rules {
+			item.RiskRules = append(item.RiskRules, &pb.RiskRule{Rule: rule.Rule, Score: int32(rule.Score), Detail: rule.Detail})
+		}
+	}
+	if r := record.Review; r != nil {
+		item.Deadline = timestamppb.New(r.Deadline)
+		item.Decision = r.Decision
+		item.Reviewer = r.Reviewer
+		item.Note = r.Note
+		if !r.DecidedAt.IsZero() {
+			item.DecidedAt = timestamppb.New(r.DecidedAt)
+		}
+	}
+	return item
+}
+
+// GetAccountProfile returns the behavioural profile of an account
+func (a *AdminServer) GetAccountProfile(ctx context.Context, req *pb.GetAccountProfileRequest) (*pb.AccountProfile, error) {
+	if _, err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateDigits("account_num", req.AccountNum, 10); err != nil {
+		return nil, err
+	}
+	if a.profiles == nil {
+		return nil, status.Error(codes.FailedPrecondition, "account profiles are not enabled")
+	}
+
+	p, err := a.profiles.Get(req.AccountNum)
+	if errors.Is(err, profile.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "no charges observed for the account")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "profile store error: %v", err)
+	}
+	return accountProfileToProto(p), nil
+}
+
+func accountProfileToProto(p *profile.Profile) *pb.AccountProfile {
+	return &pb.AccountProfile{
+		AccountNum:             p.Account,
+		Charges:                p.Charges,
+		AverageAmount:          p.AmountEWMA,
+		AmountStddev:           p.AmountStdDev(),
+		TypicalHour:            int32(p.TypicalHour()),
+		HourWeights:            p.HourWeights[:],
+		AverageIntervalSeconds: p.IntervalEWMA,
+		DistinctCards:          int32(p.DistinctCards()),
+		FirstCharge:            timestamppb.New(p.FirstCharge),
+		LastCharge:             timestamppb.New(p.LastCharge),
+	}
+}
+
+// GetSpendAllowance returns an account's standing against its spend caps
+func (a *AdminServer) GetSpendAllowance(ctx context.Context, req *pb.GetSpendAllowanceRequest) (*pb.SpendAllowance, error) {
+	if _, err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateDigits("account_num", req.AccountNum, 10); err != nil {
+		return nil, err
+	}
+	scope := spendcap.ScopeCustomer
+	if req.Scope != "" {
+		var err error
+		if scope, err = spendcap.ParseScope(req.Scope); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if a.spendCaps == nil {
+		return nil, status.Error(codes.FailedPrecondition, "spend caps are not enabled")
+	}
+
+	allowance, err := a.spendCaps.Allowance(scope, req.AccountNum)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "spend cap store error: %v", err)
+	}
+	return &pb.SpendAllowance{
+		AccountNum: allowance.Account,
+		Scope:      string(allowance.Scope),
+		Daily:      spendWindowToProto(allowance.Daily),
+		Monthly:    spendWindowToProto(allowance.Monthly),
+	}, nil
+}
+
+func spendWindowToProto(w spendcap.Window) *pb.SpendWindow {
+	return &pb.SpendWindow{
+		Cap:       w.Cap,
+		Spent:     w.Spent,
+		Remaining: w.Remaining,
+		ResetsAt:  timestamppb.New(w.ResetsAt),
+	}
+}
+
+// AddBlock freezes an account, card or BIN range
+func (a *AdminServer) AddBlock(ctx context.Context, req *pb.AddBlockRequest) (*pb.BlockEntry, error) {
+	actor, err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.blocklist == nil {
+		return nil, blocklistError(nil)
+	}
+	kind, value, err := a.blockTarget(req.Kind, req.Value, req.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+
+	entry := blocklist.Entry{Kind: kind, Value: value, Reason: req.Reason, CreatedBy: actor}
+	switch {
+	case req.ExpiresAt != nil && req.TtlSeconds != 0:
+		return nil, status.Error(codes.InvalidArgument, "set expires_at or ttl_seconds, not both")
+	case req.ExpiresAt != nil:
+		if entry.ExpiresAt = req.ExpiresAt.AsTime(); !entry.ExpiresAt.After(time.Now()) {
+			return nil, status.Error(codes.InvalidArgument, "expires_at must be in the future")
+		}
+	case req.TtlSeconds < 0:
+		return nil, status.Error(codes.InvalidArgument, "ttl_seconds must not be negative")
+	case req.TtlSeconds > 0:
+		entry.ExpiresAt = time.Now().UTC().Add(time.Duration(req.TtlSeconds) * time.Second)
+	}
+
+	entry, err = a.blocklist.Add(entry)
+	if err != nil {
+		return nil, blocklistError(err)
+	}
+
+	a.logger.LogAudit(actor, "blocklist.add", blocklist.Key(entry.Kind, entry.Value), map[string]interface{}{
+		"reason":     entry.Reason,
+		"expires_at": entry.ExpiresAt,
+	})
+	return blockEntryToProto(entry), nil
+}
+
+// RemoveBlock unfreezes an account, card or BIN range added through the admin API
+func (a *AdminServer) RemoveBlock(ctx context.Context, req *pb.RemoveBlockRequest) (*pb.BlockEntry, error) {
+	actor, err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.blocklist == nil {
+		return nil, blocklistError(nil)
+	}
+	kind, value, err := a.blockTarget(req.Kind, req.Value, req.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := a.blocklist.Remove(kind, value)
+	if err != nil {
+		return nil, blocklistError(err)
+	}
+
+	a.logger.LogAudit(actor, "blocklist.remove", blocklist.Key(entry.Kind, entry.Value), map[string]interface{}{
+		"reason": entry.Reason,
+	})
+	return blockEntryToProto(entry), nil
+}
+
+// ListBlocks lists the active blocklist entries, optionally of one kind
+func (a *AdminServer) ListBlocks(ctx context.Context, req *pb.ListBlocksRequest) (*pb.ListBlocksResponse, error) {
+	if _, err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if a.blocklist == nil {
+		return nil, blocklistError(nil)
+	}
+	var kind blocklist.Kind
+	if req.Kind != "" {
+		var err error
+		if kind, err = blocklist.ParseKind(req.Kind); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	resp := &pb.ListBlocksResponse{}
+	for _, entry := range a.blocklist.List() {
+		if kind == "" || entry.Kind == kind {
+			resp.Entries = append(resp.Entries, blockEntryToProto(entry))
+		}
+	}
+	return resp, nil
+}
+
+// blockTarget returns the kind and value an add or remove request names. Card
+// entries may give the card number, which is fingerprinted, instead of a value.
+func (a *AdminServer) blockTarget(kindName, value, cardNumber string) (blocklist.Kind, string, error) {
+	kind, err := blocklist.ParseKind(kindName)
+	if err != nil {
+		return "", "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	if cardNumber != "" {
+		if kind != blocklist.KindCard || value != "" {
+			return "", "", status.Error(codes.InvalidArgument, "card_number only applies to card entries without a value")
+		}
+		if err := mapper.ValidateCardNumber(cardNumber); err != nil {
+			return "", "", cardValidationError(err)
+		}
+		value = a.payment.currentMapper().Fingerprint(cardNumber)
+	}
+	entry := blocklist.Entry{Kind: kind, Value: value}
+	if err := entry.Validate(); err != nil {
+		return "", "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	return kind, value, nil
+}
+
+// GetConfigStatus reports the active configuration's version and the last reload
+func (a *AdminServer) GetConfigStatus(ctx context.Context, req *pb.GetConfigStatusRequest) (*pb.ConfigStatus, error) {
+	if _, err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if a.config == nil {
+		return nil, status.Error(codes.FailedPrecondition, "configuration reload is not enabled")
+	}
+	return configStatusToProto(a.config.Status()), nil
+}
+
+// ReloadConfig reloads the configuration now. A rejected configuration is
+// reported in the status rather than as an error, since the service keeps
+// running on the active one.
+func (a *AdminServer) ReloadConfig(ctx context.Context, req *pb.ReloadConfigRequest) (*pb.ConfigStatus, error) {
+	actor, err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.config == nil {
+		return nil, status.Error(codes.FailedPrecondition, "configuration reload is not enabled")
+	}
+
+	st, _ := a.config.Reload()
+	a.logger.LogAudit(actor, "config.reload", st.Path, map[string]interface{}{
+		"result":           st.LastResult,
+		"version":          st.Version,
+		"checksum":         st.Checksum,
+		"restart_required": st.RestartRequired,
+	})
+	return configStatusToProto(st), nil
+}
+
+func configStatusToProto(st config.Status) *pb.ConfigStatus {
+	msg := &pb.ConfigStatus{
+		Version:          int64(st.Version),
+		Checksum:         st.Checksum,
+		Path:             st.Path,
+		AppliedAt:        timestamppb.New(st.AppliedAt),
+		LastReloadResult: st.LastResult,
+		LastReloadError:  st.LastReloadError,
+		RestartRequired:  st.RestartRequired,
+	}
+	if !st.LastReloadAt.IsZero() {
+		msg.LastReloadAt = timestamppb.New(st.LastReloadAt)
+	}
+	return msg
+}
+
+// blocklistError converts a blocklist failure into a gRPC error. A nil error
+// means the blocklist isn't available.
+func blocklistError(err error) error {
+	switch {
+	case err == nil:
+		return status.Error(codes.FailedPrecondition, "the blocklist is not enabled")
+	case errors.Is(err, blocklist.ErrNotFound):
+		return status.Error(codes.NotFound, "blocklist entry not found")
+	case errors.Is(err, blocklist.ErrFileEntry):
+		return status.Error(codes.FailedPrecondition, "the entry is managed in the blocklist file")
+	}
+	return status.Errorf(codes.Internal, "blocklist error: %v", err)
+}
+
+func blockEntryToProto(entry blocklist.Entry) *pb.BlockEntry {
+	msg := &pb.BlockEntry{
+		Kind:      string(entry.Kind),
+		Value:     entry.Value,
+		Reason:    entry.Reason,
+		CreatedBy: entry.CreatedBy,
+		Source:    entry.Source,
+	}
+	if !entry.ExpiresAt.IsZero() {
+		msg.ExpiresAt = timestamppb.New(entry.ExpiresAt)
+	}
+	if !entry.CreatedAt.IsZero() {
+		msg.CreatedAt = timestamppb.New(entry.CreatedAt)
+	}
+	return msg
+}
+
+// linkStoreError converts a link table failure into a gRPC error
+func linkStoreError(err error) error {
+	switch {
+	case errors.Is(err, mapper.ErrCardNotLinked):
+		return status.Error(codes.NotFound, "card link not found")
+	case errors.Is(err, bank.ErrInvalidRoutingNumber):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, mapper.ErrNoLinkStore):
+		return status.Error(codes.FailedPrecondition, "card link table is not configured")
+	default:
+		return status.Errorf(codes.Internal, "card link table error: %v", err)
+	}
+}
+
+// validateDigits checks that value is exactly n digits
+func validateDigits(field, value string, n int) error {
+	if len(value) != n {
+		return status.Errorf(codes.InvalidArgument, "%s must be %d digits", field, n)
+	}
+	for _, ch := range value {
+		if ch < '0' || ch > '9' {
+			return status.Errorf(codes.InvalidArgument, "%s must be %d digits", field, n)
+		}
+	}
+	return nil
+}
+
+func cardLinkToProto(link *mapper.CardLink) *pb.CardLink {
+	return &pb.CardLink{
+		CardId:     link.CardHash,
+		LastFour:   link.LastFour,
+		AccountNum: link.AccountNum,
+		RoutingNum: link.RoutingNum,
+		OwnerId:    link.OwnerID,
+		Status:     string(link.Status),
+		CreatedAt:  timestamppb.New(link.CreatedAt),
+		UpdatedAt:  timestamppb.New(link.UpdatedAt),
+	}
+}
+
+// firstValue returns the first metadata value for key, or an empty string
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
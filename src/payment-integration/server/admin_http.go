@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// RegisterAdminHTTPHandlers mirrors the admin gRPC services as JSON over HTTP.
+// Requests are POSTs carrying the same Authorization and X-Admin-Actor headers
+// as the gRPC metadata, with the request message as a protojson body.
+func RegisterAdminHTTPHandlers(mux *http.ServeMux, admin *AdminServer) {
+	mux.Handle("/admin/v1/cards/enroll", adminRPC(admin.EnrollCard))
+	mux.Handle("/admin/v1/cards/list", adminRPC(admin.ListCardLinks))
+	mux.Handle("/admin/v1/cards/suspend", adminRPC(admin.SuspendCard))
+	mux.Handle("/admin/v1/cards/unlink", adminRPC(admin.UnlinkCard))
+	mux.Handle("/admin/v1/reviews/list", adminRPC(admin.ListReviews))
+	mux.Handle("/admin/v1/reviews/get", adminRPC(admin.GetReview))
+	mux.Handle("/admin/v1/reviews/approve", adminRPC(admin.ApproveReview))
+	mux.Handle("/admin/v1/reviews/reject", adminRPC(admin.RejectReview))
+	mux.Handle("/admin/v1/profiles/get", adminRPC(admin.GetAccountProfile))
+	mux.Handle("/admin/v1/spend-caps/allowance", adminRPC(admin.GetSpendAllowance))
+	mux.Handle("/admin/v1/blocklist/add", adminRPC(admin.AddBlock))
+	mux.Handle("/admin/v1/blocklist/remove", adminRPC(admin.RemoveBlock))
+	mux.Handle("/admin/v1/blocklist/list", adminRPC(admin.ListBlocks))
+	mux.Handle("/admin/v1/config/status", adminRPC(admin.GetConfigStatus))
+	mux.Handle("/admin/v1/config/reload", adminRPC(admin.ReloadConfig))
+}
+
+// adminRPC adapts a unary gRPC method into a JSON HTTP handler
+func adminRPC[Req any, Resp proto.Message, PReq interface {
+	*Req
+	proto.Message
+}](call func(context.Context, PReq) (Resp, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			writeHTTPError(w, status.Error(codes.InvalidArgument, "failed to read request body"))
+			return
+		}
+
+		req := PReq(new(Req))
+		if len(body) > 0 {
+			if err := protojson.Unmarshal(body, req); err != nil {
+				writeHTTPError(w, status.Errorf(codes.InvalidArgument, "invalid request body: %v", err))
+				return
+			}
+		}
+
+		md := metadata.Pairs(
+			"authorization", r.Header.Get("Authorization"),
+			adminActorHeader, r.Header.Get(adminActorHeader),
+		)
+		resp, err := call(metadata.NewIncomingContext(r.Context(), md), req)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+
+		out, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(resp)
+		if err != nil {
+			writeHTTPError(w, status.Error(codes.Internal, "failed to encode response"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	})
+}
+
+// writeHTTPError writes a gRPC status as a JSON error with the matching HTTP status
+func writeHTTPError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatusFromCode(st.Code()))
+	json.NewEncoder(w).Encode(map[string]string{
+		"code":    st.Code().String(),
+		"message": st.Message(),
+	})
+}
+
+// httpStatusFromCode maps gRPC codes to HTTP statuses the way grpc-gateway does
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
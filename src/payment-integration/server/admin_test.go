@@ -0,0 +1,462 @@
+package server
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/blocklist"
+	"github.com/gke-hackathon/payment-integration/config"
+	"github.com/gke-hackathon/payment-integration/logging"
+	"github.com/gke-hackathon/payment-integration/mapper"
+	"github.com/gke-hackathon/payment-integration/middleware"
+	"github.com/gke-hackathon/payment-integration/profile"
+	pb "github.com/gke-hackathon/payment-integration/proto"
+	"github.com/gke-hackathon/payment-integration/review"
+	"github.com/gke-hackathon/payment-integration/risk"
+	"github.com/gke-hackathon/payment-integration/spendcap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAdminServer(t *testing.T) *AdminServer {
+	hasher, err := mapper.NewCardHasher([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountMapper := mapper.NewAccountMapper("9999999999", "883745000")
+	accountMapper.UseLinkStore(mapper.NewMemoryLinkStore(), hasher)
+
+	payment := &PaymentServer{accountMapper: accountMapper, logger: logging.NewLogger("test")}
+	return NewAdminServer(payment, "secret-token")
+}
+
+func adminContext(token, actor string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"authorization", "Bearer "+token,
+		adminActorHeader, actor,
+	))
+}
+
+func TestAdminRequiresTokenAndActor(t *testing.T) {
+	admin := newTestAdminServer(t)
+
+	_, err := admin.ListCardLinks(adminContext("wrong", "alice"), &pb.ListCardLinksRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+
+	_, err = admin.ListCardLinks(adminContext("secret-token", ""), &pb.ListCardLinksRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for missing actor, got %v", err)
+	}
+}
+
+func TestAdminCardLifecycle(t *testing.T) {
+	admin := newTestAdminServer(t)
+	ctx := adminContext("secret-token", "alice")
+
+	link, err := admin.EnrollCard(ctx, &pb.EnrollCardRequest{
+		CardNumber: "4532015112830366",
+		AccountNum: "1011226111",
+		OwnerId:    "testuser",
+	})
+	if err != nil {
+		t.Fatalf("EnrollCard failed: %v", err)
+	}
+	if link.LastFour != "0366" || link.RoutingNum != "883745000" || link.Status != "active" {
+		t.Errorf("Unexpected link: %v", link)
+	}
+
+	list, err := admin.ListCardLinks(ctx, &pb.ListCardLinksRequest{LastFour: "0366"})
+	if err != nil || len(list.Links) != 1 {
+		t.Fatalf("Expected 1 link by last four, got %v (%v)", list, err)
+	}
+	list, _ = admin.ListCardLinks(ctx, &pb.ListCardLinksRequest{AccountNum: "1033623433"})
+	if len(list.Links) != 0 {
+		t.Errorf("Expected no links for other account, got %d", len(list.Links))
+	}
+
+	suspended, err := admin.SuspendCard(ctx, &pb.SuspendCardRequest{CardNumber: "4532 0151 1283 0366", Reason: "lost"})
+	if err != nil {
+		t.Fatalf("SuspendCard failed: %v", err)
+	}
+	if suspended.Status != "suspended" {
+		t.Errorf("Expected suspended status, got %s", suspended.Status)
+	}
+
+	if _, err := admin.UnlinkCard(ctx, &pb.UnlinkCardRequest{CardId: link.CardId}); err != nil {
+		t.Fatalf("UnlinkCard failed: %v", err)
+	}
+	_, err = admin.UnlinkCard(ctx, &pb.UnlinkCardRequest{CardId: link.CardId})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for second unlink, got %v", err)
+	}
+}
+
+func TestAdminEnrollValidation(t *testing.T) {
+	admin := newTestAdminServer(t)
+	ctx := adminContext("secret-token", "alice")
+
+	tests := []struct {
+		name string
+		req  *pb.EnrollCardRequest
+	}{
+		{"Invalid card", &pb.EnrollCardRequest{CardNumber: "1234", AccountNum: "1011226111"}},
+		{"Short account", &pb.EnrollCardRequest{CardNumber: "4532015112830366", AccountNum: "123"}},
+		{"Bad routing", &pb.EnrollCardRequest{CardNumber: "4532015112830366", AccountNum: "1011226111", RoutingNum: "12AB"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := admin.EnrollCard(ctx, tt.req); status.Code(err) != codes.InvalidArgument {
+				t.Errorf("Expected InvalidArgument, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAdminHTTPMirror(t *testing.T) {
+	admin := newTestAdminServer(t)
+	mux := http.NewServeMux()
+	RegisterAdminHTTPHandlers(mux, admin)
+
+	body := `{"card_number": "4532015112830366", "account_num": "1011226111"}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/v1/cards/enroll", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Admin-Actor", "alice")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"last_four":"0366"`) {
+		t.Errorf("Unexpected response: %s", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/v1/cards/list", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+}
+
+func TestAdminReviewQueue(t *testing.T) {
+	payment := newTestPaymentServer(t)
+	engine, err := risk.NewEngine(&risk.Policy{ReviewAt: 10, Amount: []risk.AmountThreshold{{AboveCents: 5000, Score: 10}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment.risk = engine
+	payment.reviews = review.NewQueue(payment.transactions, time.Hour, payment.executeReviewed, payment.releaseReviewed, payment.logger)
+	if payment.blocklist, err = blocklist.New(blocklist.NewMemoryStore(), "", payment.logger); err != nil {
+		t.Fatal(err)
+	}
+	admin := NewAdminServer(payment, "secret-token")
+	ctx := adminContext("secret-token", "alice")
+
+	charge := func() string {
+		stream := &headerStream{}
+		chargeCtx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		resp, err := payment.Charge(chargeCtx, &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: 75}, CreditCard: testCard()})
+		if err != nil {
+			t.Fatalf("Charge failed: %v", err)
+		}
+		if got := stream.header.Get("x-payment-status"); len(got) != 1 || got[0] != "pending_review" {
+			t.Errorf("Expected x-payment-status pending_review, got %v", got)
+		}
+		return resp.TransactionId
+	}
+	approved, rejected := charge(), charge()
+
+	list, err := admin.ListReviews(ctx, &pb.ListReviewsRequest{})
+	if err != nil || len(list.Items) != 2 {
+		t.Fatalf("Expected 2 queued charges, got %v (%v)", list, err)
+	}
+	if item := list.Items[0]; item.RiskScore != 10 || len(item.RiskRules) != 1 || item.Amount != 7500 || item.Deadline == nil {
+		t.Errorf("Unexpected review item %v", item)
+	}
+
+	item, err := admin.ApproveReview(ctx, &pb.DecideReviewRequest{TransactionId: approved, Note: "confirmed with customer"})
+	if err != nil {
+		t.Fatalf("ApproveReview failed: %v", err)
+	}
+	// Without a bank the approved transfer is simulated
+	if item.Status != "simulated" || item.Decision != "approved" || item.Reviewer != "alice" {
+		t.Errorf("Unexpected approved item %v", item)
+	}
+
+	item, err = admin.RejectReview(adminContext("secret-token", "bob"), &pb.DecideReviewRequest{TransactionId: rejected})
+	if err != nil {
+		t.Fatalf("RejectReview failed: %v", err)
+	}
+	if item.Status != "declined" || item.Reviewer != "bob" {
+		t.Errorf("Unexpected rejected item %v", item)
+	}
+
+	if _, err := admin.ApproveReview(ctx, &pb.DecideReviewRequest{TransactionId: rejected}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for a decided charge, got %v", err)
+	}
+	if _, err := admin.GetReview(ctx, &pb.GetReviewRequest{TransactionId: "unknown"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+	if list, _ := admin.ListReviews(ctx, &pb.ListReviewsRequest{}); len(list.Items) != 0 {
+		t.Errorf("Expected an empty queue, got %d", len(list.Items))
+	}
+
+	// A BIN blocked while the charge waited stops its approval
+	blocked := charge()
+	if _, err := admin.AddBlock(ctx, &pb.AddBlockRequest{Kind: "bin", Value: "453201", Reason: "compromised issuer"}); err != nil {
+		t.Fatalf("AddBlock failed: %v", err)
+	}
+	if _, err := admin.ApproveReview(ctx, &pb.DecideReviewRequest{TransactionId: blocked}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a blocked BIN, got %v", err)
+	}
+
+	// Without a queue the review RPCs are unavailable
+	admin = newTestAdminServer(t)
+	if _, err := admin.ListReviews(ctx, &pb.ListReviewsRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without a queue, got %v", err)
+	}
+}
+
+func TestAdminAccountProfile(t *testing.T) {
+	payment := newTestPaymentServer(t)
+	engine, err := risk.NewEngine(&risk.Policy{ReviewAt: 10, Anomaly: &risk.AnomalyRule{Score: 10, Above: 30}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment.risk = engine
+	payment.profiles = profile.NewTracker(profile.NewMemoryStore(), profile.Options{MinHistory: 2})
+	admin := NewAdminServer(payment, "secret-token")
+	ctx := adminContext("secret-token", "alice")
+
+	charge := func(units int64) *risk.Assessment {
+		resp, err := payment.Charge(context.Background(), &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: units}, CreditCard: testCard()})
+		if err != nil {
+			t.Fatalf("Charge failed: %v", err)
+		}
+		record, err := payment.transactions.Get(resp.TransactionId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return record.Risk
+	}
+
+	// The account is learning for its first two charges, then a charge far
+	// above its usual amount is flagged
+	for _, units := range []int64{10, 12} {
+		if a := charge(units); a.Outcome != risk.OutcomeApprove {
+			t.Fatalf("Expected $%d to be approved, got %s", units, a.Outcome)
+		}
+	}
+	if a := charge(500); a.Outcome != risk.OutcomeReview || a.Rules[0].Rule != risk.RuleAnomaly {
+		t.Errorf("Expected an anomalous charge to be flagged, got %+v", a)
+	}
+
+	p, err := admin.GetAccountProfile(ctx, &pb.GetAccountProfileRequest{AccountNum: "5112830366"})
+	if err != nil {
+		t.Fatalf("GetAccountProfile failed: %v", err)
+	}
+	if p.Charges != 3 || p.DistinctCards != 1 || len(p.HourWeights) != 24 || p.AverageAmount <= 1000 || p.LastCharge == nil {
+		t.Errorf("Unexpected profile %v", p)
+	}
+
+	if _, err := admin.GetAccountProfile(ctx, &pb.GetAccountProfileRequest{AccountNum: "1234567890"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unseen account, got %v", err)
+	}
+	if _, err := admin.GetAccountProfile(ctx, &pb.GetAccountProfileRequest{AccountNum: "12345"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a short account number, got %v", err)
+	}
+}
+
+func TestAdminSpendAllowance(t *testing.T) {
+	payment := newTestPaymentServer(t)
+	caps, err := spendcap.NewCaps(&spendcap.Policy{
+		Customers: spendcap.ScopePolicy{Default: spendcap.Limits{Daily: 10000}},
+	}, spendcap.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment.spendCaps = caps
+	admin := NewAdminServer(payment, "secret-token")
+	ctx := adminContext("secret-token", "alice")
+
+	charge := func(units int64) error {
+		_, err := payment.Charge(context.Background(), &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: units}, CreditCard: testCard()})
+		return err
+	}
+	if err := charge(60); err != nil {
+		t.Fatalf("Charge failed: %v", err)
+	}
+	err = charge(50)
+	st := status.Convert(err)
+	if st.Code() != codes.FailedPrecondition || len(st.Details()) != 1 {
+		t.Fatalf("Expected FailedPrecondition over the daily cap, got %v", err)
+	}
+	if info, ok := st.Details()[0].(*errdetails.ErrorInfo); !ok || info.Reason != spendcap.ReasonSpendCapExceeded || info.Metadata["remaining_cents"] != "4000" {
+		t.Errorf("Unexpected error details %v", st.Details())
+	}
+
+	allowance, err := admin.GetSpendAllowance(ctx, &pb.GetSpendAllowanceRequest{AccountNum: "5112830366"})
+	if err != nil {
+		t.Fatalf("GetSpendAllowance failed: %v", err)
+	}
+	if allowance.Scope != "customer" || allowance.Daily.Cap != 10000 || allowance.Daily.Remaining != 4000 || allowance.Monthly.Cap != 0 {
+		t.Errorf("Unexpected customer allowance %v", allowance)
+	}
+
+	allowance, err = admin.GetSpendAllowance(ctx, &pb.GetSpendAllowanceRequest{AccountNum: "9999999999", Scope: "merchant"})
+	if err != nil || allowance.Daily.Spent != 6000 {
+		t.Errorf("Expected the merchant to have received 6000, got %v (%v)", allowance, err)
+	}
+
+	if _, err := admin.GetSpendAllowance(ctx, &pb.GetSpendAllowanceRequest{AccountNum: "9999999999", Scope: "bank"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an unknown scope, got %v", err)
+	}
+	if _, err := newTestAdminServer(t).GetSpendAllowance(ctx, &pb.GetSpendAllowanceRequest{AccountNum: "9999999999"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without spend caps, got %v", err)
+	}
+}
+
+func TestAdminBlocklist(t *testing.T) {
+	payment := newTestPaymentServer(t)
+	list, err := blocklist.New(blocklist.NewMemoryStore(), "", payment.logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment.blocklist = list
+	admin := NewAdminServer(payment, "secret-token")
+	ctx := adminContext("secret-token", "alice")
+
+	chargeReason := func() string {
+		_, err := payment.Charge(context.Background(), &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: 10}, CreditCard: testCard()})
+		if err == nil {
+			return ""
+		}
+		st := status.Convert(err)
+		if st.Code() != codes.PermissionDenied || len(st.Details()) != 1 {
+			t.Fatalf("Expected PermissionDenied, got %v", err)
+		}
+		return st.Details()[0].(*errdetails.ErrorInfo).Reason
+	}
+
+	entry, err := admin.AddBlock(ctx, &pb.AddBlockRequest{Kind: "card", CardNumber: "4532 0151 1283 0366", Reason: "reported stolen"})
+	if err != nil {
+		t.Fatalf("AddBlock failed: %v", err)
+	}
+	if entry.Value != payment.accountMapper.Fingerprint("4532015112830366") || entry.CreatedBy != "alice" || entry.Source != "admin" {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+	if reason := chargeReason(); reason != "CARD_FROZEN" {
+		t.Errorf("Expected CARD_FROZEN, got %q", reason)
+	}
+
+	if _, err := admin.RemoveBlock(ctx, &pb.RemoveBlockRequest{Kind: "card", Value: entry.Value}); err != nil {
+		t.Fatalf("RemoveBlock failed: %v", err)
+	}
+	if reason := chargeReason(); reason != "" {
+		t.Errorf("Expected the charge to go through once unblocked, got %q", reason)
+	}
+
+	if _, err := admin.AddBlock(ctx, &pb.AddBlockRequest{Kind: "account", Value: "5112830366", Reason: "incident", TtlSeconds: 60}); err != nil {
+		t.Fatalf("AddBlock failed: %v", err)
+	}
+	if reason := chargeReason(); reason != "ACCOUNT_FROZEN" {
+		t.Errorf("Expected ACCOUNT_FROZEN, got %q", reason)
+	}
+	listed, err := admin.ListBlocks(ctx, &pb.ListBlocksRequest{Kind: "account"})
+	if err != nil || len(listed.Entries) != 1 || listed.Entries[0].ExpiresAt == nil {
+		t.Errorf("Expected one expiring account entry, got %v (%v)", listed, err)
+	}
+
+	invalid := []*pb.AddBlockRequest{
+		{Kind: "account", Value: "5112830366"},
+		{Kind: "iban", Value: "DE89", Reason: "incident"},
+		{Kind: "bin", Value: "4111-4", Reason: "incident"},
+		{Kind: "account", CardNumber: "4532015112830366", Reason: "incident"},
+		{Kind: "bin", Value: "4111", Reason: "incident", TtlSeconds: -1},
+	}
+	for _, req := range invalid {
+		if _, err := admin.AddBlock(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: expected InvalidArgument, got %v", req, err)
+		}
+	}
+	if _, err := admin.RemoveBlock(ctx, &pb.RemoveBlockRequest{Kind: "bin", Value: "4111"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestAdminConfigReload(t *testing.T) {
+	payment := newTestPaymentServer(t)
+	payment.baseMapper = payment.accountMapper
+	limiter := middleware.NewTokenBucket(middleware.BucketConfig{Rate: 1, Burst: 5})
+	defer limiter.Stop()
+	payment.rateLimiter = limiter
+	admin := NewAdminServer(payment, "secret-token")
+	ctx := adminContext("secret-token", "alice")
+
+	if _, err := admin.GetConfigStatus(ctx, &pb.GetConfigStatusRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without a watcher, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("mapper:\n  legacy_last10: true\n")
+	noEnv := func(string) (string, bool) { return "", false }
+	loader, err := config.NewLoader(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", path}, noEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin.UseConfigWatcher(config.NewWatcher(loader, active, payment.ApplyConfig, payment.logger))
+
+	write("merchant:\n  account: \"2222222222\"\n  routing_number: \"021000021\"\nmapper:\n  legacy_last10: true\nrate_limit:\n  per_minute: 60\n  burst: 1\n")
+	st, err := admin.ReloadConfig(ctx, &pb.ReloadConfigRequest{})
+	if err != nil || st.Version != 2 || st.LastReloadResult != config.ReloadApplied || st.LastReloadAt == nil {
+		t.Fatalf("Expected version 2 to be applied, got %v (%v)", st, err)
+	}
+	if acct, route := payment.currentMapper().GetMerchantAccount(); acct != "2222222222" || route != "021000021" {
+		t.Errorf("Expected the reloaded merchant account, got %s %s", acct, route)
+	}
+	if !limiter.Allow("acct") || limiter.Allow("acct") {
+		t.Error("Expected the reloaded burst of 1")
+	}
+	if _, err := payment.Charge(context.Background(), &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: 10}, CreditCard: testCard()}); err != nil {
+		t.Errorf("Expected charges to keep working after a reload, got %v", err)
+	}
+
+	// A strategy file that can't be loaded rejects the whole configuration
+	write("merchant:\n  account: \"3333333333\"\nmapper:\n  strategies_path: /nonexistent/strategies.yaml\n")
+	st, err = admin.ReloadConfig(ctx, &pb.ReloadConfigRequest{})
+	if err != nil || st.Version != 2 || st.LastReloadResult != config.ReloadFailed || st.LastReloadError == "" {
+		t.Fatalf("Expected the reload to be rejected, got %v (%v)", st, err)
+	}
+	if acct, _ := payment.currentMapper().GetMerchantAccount(); acct != "2222222222" {
+		t.Errorf("Expected the active merchant account to be kept, got %s", acct)
+	}
+
+	got, err := admin.GetConfigStatus(ctx, &pb.GetConfigStatusRequest{})
+	if err != nil || got.Version != 2 || got.Checksum == "" || got.Path != path {
+		t.Errorf("Unexpected status %v (%v)", got, err)
+	}
+}
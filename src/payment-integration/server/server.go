@@ -2,248 +2,1965 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gke-hackathon/payment-integration/auth"
 	"github.com/gke-hackathon/payment-integration/bank"
+	"github.com/gke-hackathon/payment-integration/blocklist"
+	"github.com/gke-hackathon/payment-integration/card"
+	"github.com/gke-hackathon/payment-integration/config"
 	"github.com/gke-hackathon/payment-integration/converter"
+	"github.com/gke-hackathon/payment-integration/duplicate"
+	"github.com/gke-hackathon/payment-integration/fx"
 	"github.com/gke-hackathon/payment-integration/logging"
 	"github.com/gke-hackathon/payment-integration/mapper"
 	"github.com/gke-hackathon/payment-integration/metrics"
 	"github.com/gke-hackathon/payment-integration/middleware"
+	"github.com/gke-hackathon/payment-integration/money"
+	"github.com/gke-hackathon/payment-integration/profile"
 	pb "github.com/gke-hackathon/payment-integration/proto"
+	"github.com/gke-hackathon/payment-integration/review"
+	"github.com/gke-hackathon/payment-integration/risk"
+	"github.com/gke-hackathon/payment-integration/spendcap"
+	"github.com/gke-hackathon/payment-integration/tenant"
+	"github.com/gke-hackathon/payment-integration/transaction"
 	"github.com/gke-hackathon/payment-integration/utils"
+	"github.com/gke-hackathon/payment-integration/vault"
+	"github.com/gke-hackathon/payment-integration/velocity"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 // PaymentServer implements the PaymentService gRPC server
 type PaymentServer struct {
 	pb.UnimplementedPaymentServiceServer
-	accountMapper      *mapper.AccountMapper
 	authenticator      *auth.ServiceAuthenticator
-	bankClient         *bank.Client
+	vault              *vault.Vault
+	cardValidator      *card.Validator
+	roundingPolicy     money.RoundingPolicy
+	fxConverter        *fx.Converter
+	transactions       transaction.Store
+	velocity           *velocity.Checker
+	risk               *risk.Engine
+	profiles           *profile.Tracker
+	spendCaps          *spendcap.Caps
+	duplicates         *duplicate.Guard
+	blocklist          *blocklist.Blocklist
+	reviews            *review.Queue
+	rateLimiter        middleware.Limiter
+	rateLimitKey       string
 	transactionCounter int64
 	logger             *logging.Logger
+
+	// tenantRegistry is nil when the service serves a single storefront
+	tenantRegistry *tenant.Registry
+	tenants        map[string]*tenantRuntime
+	tenantLimiter  middleware.Limiter
+
+	// runtimeMu guards what is rebuilt when the configuration is reloaded
+	runtimeMu     sync.RWMutex
+	accountMapper *mapper.AccountMapper
+	bankRouter    *bank.Router
+	// baseMapper holds the card links and fingerprint key every rebuilt
+	// mapper shares
+	baseMapper *mapper.AccountMapper
+	bankAuth   bank.Authenticator
+	// clients are the bank clients of the active routing table, reused by a
+	// reload for the routes whose backend and credentials are unchanged
+	clients clientSet
+}
+
+// NewPaymentServer creates a new instance of PaymentServer from a validated
+// configuration. It fails if a policy, store or routing file that the
+// configuration names can't be read or is invalid.
+func NewPaymentServer(cfg *config.Config, logger *logging.Logger) (*PaymentServer, error) {
+	// Initialize bank authentication. "forge" signs tokens with the bank's private key,
+	// "login" obtains them from the userservice with stored credentials.
+	var authenticator *auth.ServiceAuthenticator
+	var bankAuth bank.Authenticator
+
+	switch cfg.Auth.Mode {
+	case "login":
+		if loginAuth := newLoginAuthenticator(cfg.Auth, logger); loginAuth != nil {
+			bankAuth = loginAuth
+		}
+	case "forge":
+		authenticator = newServiceAuthenticator(cfg.Auth, logger)
+		if authenticator != nil {
+			bankAuth = authenticator
+		}
+	default:
+		logger.Warn("Unknown auth mode, bank authentication disabled", map[string]interface{}{"auth_mode": cfg.Auth.Mode})
+	}
+
+	registry, tenants, err := newTenants(cfg, bankAuth, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	baseMapper, err := newAccountMapper(cfg.Merchant, cfg.Mapper, logger)
+	if err != nil {
+		return nil, err
+	}
+	cardVault, err := newVault(cfg.Vault, logger)
+	if err != nil {
+		return nil, err
+	}
+	if cardVault != nil {
+		baseMapper.UseDetokenizer(vault.NewDetokenizer(cardVault, "mapper", logger))
+	}
+
+	clients := newClientBuilder(nil, cfg.Auth, bankAuth, logger)
+	accountMapper, bankRouter, err := newRouting(cfg, baseMapper, clients, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	fxConverter, err := newFXConverter(cfg.FX, logger)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := newTransactionStore(cfg.Transactions, logger)
+	if err != nil {
+		return nil, err
+	}
+	velocityChecker, err := newVelocityChecker(cfg.Velocity, logger)
+	if err != nil {
+		return nil, err
+	}
+	riskEngine, err := newRiskEngine(cfg.Risk, logger)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := newProfileTracker(cfg.Profiles, logger)
+	if err != nil {
+		return nil, err
+	}
+	spendCaps, err := newSpendCaps(cfg.SpendCaps, registry, logger)
+	if err != nil {
+		return nil, err
+	}
+	// The blocklist is opened last as it starts reloading its file
+	list, err := newBlocklist(cfg.Blocklist, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PaymentServer{
+		accountMapper:      accountMapper,
+		authenticator:      authenticator,
+		bankRouter:         bankRouter,
+		baseMapper:         baseMapper,
+		bankAuth:           bankAuth,
+		clients:            clients.built,
+		vault:              cardVault,
+		cardValidator:      newCardValidator(cfg.Card, logger),
+		roundingPolicy:     newRoundingPolicy(cfg.Money, logger),
+		fxConverter:        fxConverter,
+		transactions:       transactions,
+		velocity:           velocityChecker,
+		risk:               riskEngine,
+		profiles:           profiles,
+		spendCaps:          spendCaps,
+		duplicates:         newDuplicateGuard(cfg.Duplicates, logger),
+		blocklist:          list,
+		rateLimiter:        newRateLimiter(cfg.RateLimit, logger),
+		rateLimitKey:       newRateLimitKey(cfg.RateLimit, logger),
+		transactionCounter: 0,
+		logger:             logger,
+		tenantRegistry:     registry,
+		tenants:            tenants,
+		tenantLimiter:      newTenantLimiter(registry, logger),
+	}
+	s.reviews = newReviewQueue(s, cfg.Review, logger)
+	return s, nil
+}
+
+// ApplyConfig puts a reloaded configuration into effect: the log level, the
+// merchant accounts, bank routes and card mapping, and the rate limits. The
+// new mapper and router are built before anything changes, so a routing table
+// or strategy file that fails to load rejects the whole configuration. Bank
+// clients are only built for routes whose backend or credentials changed.
+func (s *PaymentServer) ApplyConfig(cfg *config.Config) error {
+	level, err := logging.ParseLevel(cfg.Server.LogLevel)
+	if err != nil {
+		return err
+	}
+	defaults, overrides, err := bucketLimits(cfg.RateLimit)
+	if err != nil {
+		return err
+	}
+	s.runtimeMu.RLock()
+	clients := newClientBuilder(s.clients, cfg.Auth, s.bankAuth, s.logger)
+	s.runtimeMu.RUnlock()
+	accountMapper, bankRouter, err := newRouting(cfg, s.baseMapper, clients, s.logger)
+	if err != nil {
+		return err
+	}
+
+	s.logger.SetLevel(level)
+	switch limiter := s.rateLimiter.(type) {
+	case interface {
+		Reconfigure(middleware.BucketConfig, map[string]middleware.BucketConfig)
+	}:
+		limiter.Reconfigure(defaults, overrides)
+	case *middleware.RateLimiter:
+		limiter.SetLimit(perMinute(cfg.RateLimit))
+	}
+
+	s.runtimeMu.Lock()
+	s.accountMapper = accountMapper
+	s.bankRouter = bankRouter
+	s.clients = clients.built
+	s.runtimeMu.Unlock()
+	return nil
+}
+
+// currentMapper returns the account mapper of the active configuration
+func (s *PaymentServer) currentMapper() *mapper.AccountMapper {
+	s.runtimeMu.RLock()
+	defer s.runtimeMu.RUnlock()
+	return s.accountMapper
+}
+
+// currentRouter returns the bank router of the active configuration, nil
+// without a bank
+func (s *PaymentServer) currentRouter() *bank.Router {
+	s.runtimeMu.RLock()
+	defer s.runtimeMu.RUnlock()
+	return s.bankRouter
+}
+
+// newRouting builds the account mapper and bank router for the merchant, bank
+// and mapper settings on top of base, which holds what survives a reload. The
+// error reports a routing table or strategy file that could not be loaded; the
+// configuration must not be used, though the mapper and router still work
+// without them.
+func newRouting(cfg *config.Config, base *mapper.AccountMapper, clients *clientBuilder,
+	logger *logging.Logger) (*mapper.AccountMapper, *bank.Router, error) {
+	accountMapper := base.WithMerchant(cfg.Merchant.Account, cfg.Merchant.RoutingNumber)
+	if cfg.Mapper.LegacyLastTen {
+		accountMapper.EnableLegacyLastTen()
+		logger.Warn("Legacy last-10-digit card mapping enabled", nil)
+	}
+
+	routingValidator := bank.NewRoutingValidator(cfg.Bank.ChecksumExempt...)
+	accountMapper.UseRoutingValidator(routingValidator)
+	bankRouter, routesErr := newBankRouter(cfg.Merchant.RoutingNumber, cfg.Bank, clients, routingValidator, accountMapper, logger)
+
+	// Strategies are configured last so routing exemptions from the bank routes apply
+	strategiesErr := configureMappingStrategies(accountMapper, cfg.Mapper.StrategiesPath, logger)
+	return accountMapper, bankRouter, errors.Join(routesErr, strategiesErr)
+}
+
+// newReviewQueue creates the queue for charges the risk engine flags for review.
+// Charges not reviewed within the SLA are declined. It returns nil when no risk
+// engine is configured.
+func newReviewQueue(s *PaymentServer, cfg config.ReviewConfig, logger *logging.Logger) *review.Queue {
+	if s.risk == nil {
+		return nil
+	}
+
+	sla := time.Duration(cfg.SLASeconds) * time.Second
+	if sla <= 0 {
+		sla = time.Hour
+	}
+	queue := review.NewQueue(s.transactions, sla, s.executeReviewed, s.releaseReviewed, logger)
+
+	// Sweep often enough that charges are declined close to their deadline
+	interval := sla / 10
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	queue.Start(interval)
+
+	logger.Info("Review queue initialized", map[string]interface{}{"sla": sla.String()})
+	return queue
+}
+
+// tenantRuntime is what a tenant's charges use in place of the service's
+// merchant account, bank and logger
+type tenantRuntime struct {
+	tenant *tenant.Tenant
+	// router is nil if the tenant's bank could not be authenticated; its
+	// charges are then simulated
+	router *bank.Router
+	logger *logging.Logger
 }
 
-// NewPaymentServer creates a new instance of PaymentServer
-func NewPaymentServer() *PaymentServer {
-	// Initialize logger
-	logger := logging.NewLogger("payment-integration")
+// newTenants loads the storefronts sharing the service and builds each one's
+// bank client, which serves every routing number like the default bank. It
+// returns a nil registry without a tenants file, and an error if the file
+// fails to load.
+func newTenants(cfg *config.Config, defaultAuth bank.Authenticator, logger *logging.Logger) (*tenant.Registry, map[string]*tenantRuntime, error) {
+	path := cfg.Tenants.Path
+	if path == "" {
+		return nil, nil, nil
+	}
+
+	registry, err := tenant.Load(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load tenants %s: %w", path, err)
+	}
+
+	runtimes := make(map[string]*tenantRuntime)
+	var ids []string
+	for _, t := range registry.List() {
+		tenantLogger := logger.ForTenant(t.ID)
+		rt := &tenantRuntime{tenant: t, logger: tenantLogger}
+
+		route := bank.Route{RoutingNumber: t.RoutingNumber, Auth: t.Bank.Auth}
+		if tenantAuth := newRouteAuthenticator(route, cfg.Auth, defaultAuth, tenantLogger); tenantAuth != nil {
+			apiURL := t.Bank.APIURL
+			if apiURL == "" {
+				apiURL = cfg.Bank.APIURL
+			}
+			client := newBankClient(apiURL, tenantAuth, tenantLogger)
+			rt.router = bank.NewRouter(bank.NewRoutingValidator(cfg.Bank.ChecksumExempt...))
+			if err := rt.router.AddRoute(t.RoutingNumber, client); err != nil {
+				tenantLogger.Warn("Tenant routing number is invalid", map[string]interface{}{"error": err.Error()})
+			}
+			rt.router.SetFallback(client)
+		} else {
+			tenantLogger.Warn("Tenant bank client not initialized due to missing authenticator, charges will be simulated", nil)
+		}
+
+		runtimes[t.ID] = rt
+		ids = append(ids, t.ID)
+	}
+	logger.Info("Tenants loaded", map[string]interface{}{"path": path, "tenants": ids})
+	return registry, runtimes, nil
+}
+
+// newTenantLimiter builds the limiter holding each tenant's own rate limit,
+// keyed by tenant ID. It returns nil if no tenant has one.
+func newTenantLimiter(registry *tenant.Registry, logger *logging.Logger) middleware.Limiter {
+	if registry == nil {
+		return nil
+	}
+	limits := make(map[string]middleware.BucketConfig)
+	for _, t := range registry.List() {
+		if t.RateLimit == "" {
+			continue
+		}
+		// Tenants' rate limits were validated when the file was loaded
+		cfg, _ := middleware.ParseBucketConfig(t.RateLimit)
+		limits[t.ID] = cfg
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	// Only tenants with a limit are checked, so the defaults never apply
+	defaults := middleware.BucketConfig{Rate: 1, Burst: 1}
+	limiter := middleware.NewTokenBucket(defaults)
+	limiter.Reconfigure(defaults, limits)
+	logger.Info("Tenant rate limits initialized", map[string]interface{}{"tenants": len(limits)})
+	return limiter
+}
+
+// newBankRouter builds the bank backends. Without a routes table a single bank at
+// the bank API URL serves every routing number; with it, each routing number goes
+// to the backend in the table and unlisted routing numbers are rejected.
+// It returns a nil router if no backend could be authenticated, and an error if
+// the routes table could not be loaded.
+func newBankRouter(routingNumber string, cfg config.BankConfig, clients *clientBuilder,
+	validator *bank.RoutingValidator, accountMapper *mapper.AccountMapper, logger *logging.Logger) (*bank.Router, error) {
+	router := bank.NewRouter(validator)
+
+	defaultClient := clients.client(bank.Route{RoutingNumber: routingNumber, BaseURL: cfg.APIURL})
+	if defaultClient == nil {
+		logger.Warn("Default bank client not initialized due to missing authenticator", nil)
+	}
+
+	routesPath := cfg.RoutesPath
+	if routesPath == "" {
+		if defaultClient == nil {
+			return nil, nil
+		}
+		if err := router.AddRoute(routingNumber, defaultClient); err != nil {
+			logger.Warn("Default routing number is invalid", map[string]interface{}{"error": err.Error()})
+		}
+		router.SetFallback(defaultClient)
+		return router, nil
+	}
+
+	routes, loadErr := bank.LoadRoutes(routesPath)
+	if loadErr != nil {
+		logger.Error("Failed to load bank routing table", loadErr, map[string]interface{}{"path": routesPath})
+	}
+
+	inTable := false
+	for _, route := range routes {
+		if route.ChecksumExempt {
+			validator.Exempt(route.RoutingNumber)
+		}
+		inTable = inTable || route.RoutingNumber == routingNumber
+
+		client := clients.client(route)
+		if client == nil {
+			logger.Warn("Skipping bank route without credentials", map[string]interface{}{"routing_number": route.RoutingNumber})
+			continue
+		}
+		if err := router.AddRoute(route.RoutingNumber, client); err != nil {
+			logger.Error("Skipping invalid bank route", err, map[string]interface{}{"routing_number": route.RoutingNumber})
+			continue
+		}
+		if route.MerchantAccount != "" {
+			if err := accountMapper.AddMerchantAccount(route.MerchantAccount, route.RoutingNumber); err != nil {
+				logger.Error("Invalid merchant account for bank route", err, map[string]interface{}{"routing_number": route.RoutingNumber})
+			}
+		}
+	}
+
+	// The default bank keeps serving its own routing number unless the table overrides it
+	if defaultClient != nil && !inTable {
+		if err := router.AddRoute(routingNumber, defaultClient); err != nil {
+			logger.Error("Default routing number is invalid", err, nil)
+		}
+	}
+
+	routingNumbers := router.RoutingNumbers()
+	if len(routingNumbers) == 0 {
+		logger.Warn("No bank routes configured", nil)
+		return nil, loadErr
+	}
+	logger.Info("Bank routing table loaded", map[string]interface{}{"path": routesPath, "routing_numbers": routingNumbers})
+	return router, loadErr
+}
+
+// clientSet holds the bank clients of a routing table by backend URL and
+// credentials
+type clientSet map[bankClientKey]*bank.Client
+
+type bankClientKey struct {
+	baseURL string
+	auth    bank.RouteAuth
+}
+
+// clientBuilder builds the bank clients of a routing table. Clients of the
+// previous table with the same backend URL and credentials are reused, keeping
+// their authenticators and cached tokens, without reloading keys or checking
+// the backend's health again.
+type clientBuilder struct {
+	previous    clientSet
+	built       clientSet
+	authCfg     config.AuthConfig
+	defaultAuth bank.Authenticator
+	logger      *logging.Logger
+}
+
+func newClientBuilder(previous clientSet, authCfg config.AuthConfig, defaultAuth bank.Authenticator, logger *logging.Logger) *clientBuilder {
+	return &clientBuilder{
+		previous:    previous,
+		built:       make(clientSet),
+		authCfg:     authCfg,
+		defaultAuth: defaultAuth,
+		logger:      logger,
+	}
+}
+
+// client returns the client for the route's backend and credentials. It
+// returns nil if the route has no usable authenticator.
+func (b *clientBuilder) client(route bank.Route) *bank.Client {
+	key := bankClientKey{baseURL: route.BaseURL, auth: route.Auth}
+	if client, ok := b.built[key]; ok {
+		return client
+	}
+	client, ok := b.previous[key]
+	if !ok {
+		authenticator := newRouteAuthenticator(route, b.authCfg, b.defaultAuth, b.logger)
+		if authenticator == nil {
+			return nil
+		}
+		client = newBankClient(route.BaseURL, authenticator, b.logger)
+	}
+	b.built[key] = client
+	return client
+}
+
+// newBankClient creates a bank client and checks that the backend is reachable
+func newBankClient(baseURL string, authenticator bank.Authenticator, logger *logging.Logger) *bank.Client {
+	client := bank.NewClient(baseURL, authenticator)
+	logger.Info("Bank client initialized", map[string]interface{}{"bank_api_url": baseURL})
+
+	// Test bank connectivity
+	if err := client.HealthCheck(); err != nil {
+		logger.Warn("Bank API health check failed", map[string]interface{}{
+			"bank_api_url": baseURL,
+			"error":        err.Error(),
+			"note":         "Transactions may fail until Bank API is available",
+		})
+	} else {
+		logger.Info("Bank API health check successful", map[string]interface{}{"bank_api_url": baseURL})
+	}
+	return client
+}
+
+// newRouteAuthenticator builds the credentials for one bank route. Routes without
+// an auth mode share the default authenticator.
+func newRouteAuthenticator(route bank.Route, authCfg config.AuthConfig, defaultAuth bank.Authenticator, logger *logging.Logger) bank.Authenticator {
+	switch route.Auth.Mode {
+	case "":
+		return defaultAuth
+	case "forge":
+		authenticator, err := auth.NewServiceAuthenticator(route.Auth.PrivateKeyPath, route.Auth.PublicKeyPath, authCfg.TokenExpirySeconds)
+		if err != nil {
+			logger.Warn("Failed to load bank route signing keys", map[string]interface{}{
+				"routing_number": route.RoutingNumber,
+				"error":          err.Error(),
+			})
+			return nil
+		}
+		configureTokenClaims(authenticator, authCfg, logger)
+		return authenticator
+	case "login":
+		credentials, err := auth.NewFileCredentialStore(route.Auth.CredentialsPath)
+		if err != nil {
+			logger.Warn("Failed to load bank route credentials", map[string]interface{}{
+				"routing_number": route.RoutingNumber,
+				"error":          err.Error(),
+			})
+			return nil
+		}
+		return auth.NewLoginAuthenticator(route.Auth.UserserviceURL, credentials)
+	default:
+		logger.Warn("Unknown bank route auth mode", map[string]interface{}{
+			"routing_number": route.RoutingNumber,
+			"auth_mode":      route.Auth.Mode,
+		})
+		return nil
+	}
+}
+
+// newAccountMapper configures the card link table. The merchant accounts and
+// mapping chain are set up by newRouting.
+func newAccountMapper(merchant config.MerchantConfig, cfg config.MapperConfig, logger *logging.Logger) (*mapper.AccountMapper, error) {
+	accountMapper := mapper.NewAccountMapper(merchant.Account, merchant.RoutingNumber)
+
+	if cfg.CardHashKey == "" {
+		logger.Warn("CARD_HASH_KEY not set, card link table disabled", nil)
+		return accountMapper, nil
+	}
+	hasher, err := mapper.NewCardHasher([]byte(cfg.CardHashKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid card hash key: %w", err)
+	}
+
+	storePath := cfg.LinkStorePath
+	if storePath == "" {
+		accountMapper.UseLinkStore(mapper.NewMemoryLinkStore(), hasher)
+		logger.Warn("CARD_LINK_STORE_PATH not set, card links will not survive restarts", nil)
+		return accountMapper, nil
+	}
+	links, err := mapper.NewFileLinkStore(storePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load card link table %s: %w", storePath, err)
+	}
+	accountMapper.UseLinkStore(links, hasher)
+	logger.Info("Card link table loaded", map[string]interface{}{"path": storePath})
+	return accountMapper, nil
+}
+
+// newCardValidator builds the card validator with the accepted brand allowlist
+func newCardValidator(cfg config.CardConfig, logger *logging.Logger) *card.Validator {
+	var accepted []card.Brand
+	for _, name := range cfg.AcceptedBrands {
+		brand, err := card.ParseBrand(name)
+		if err != nil {
+			logger.Warn("Ignoring unknown card brand in CARD_ACCEPTED_BRANDS", map[string]interface{}{"brand": name})
+			continue
+		}
+		accepted = append(accepted, brand)
+	}
+
+	if len(accepted) > 0 {
+		logger.Info("Card brand allowlist configured", map[string]interface{}{"brands": accepted})
+	}
+	return card.NewValidator(accepted)
+}
+
+// newRateLimiter builds the per-account limiter: a token bucket (the default)
+// refilling at the per-minute rate with its burst and per-account overrides, or
+// the fixed one-minute window
+func newRateLimiter(cfg config.RateLimitConfig, logger *logging.Logger) middleware.Limiter {
+	rateLimit := perMinute(cfg)
+
+	algorithm := cfg.Algorithm
+	if algorithm == middleware.AlgorithmFixedWindow {
+		if cfg.RedisURL != "" {
+			logger.Warn("Redis rate limiting needs the token bucket algorithm, limiting locally", nil)
+		}
+		logger.Info("Rate limiter initialized", map[string]interface{}{"algorithm": algorithm, "limit_per_minute": rateLimit})
+		return middleware.NewRateLimiter(rateLimit)
+	}
+	if algorithm != middleware.AlgorithmTokenBucket {
+		logger.Warn("Unknown rate limit algorithm, using token bucket", map[string]interface{}{"algorithm": algorithm})
+	}
+
+	defaults, overrides, err := bucketLimits(cfg)
+	if err != nil {
+		logger.Error("Invalid rate limit overrides, ignoring overrides", err, nil)
+	}
+	limiter := middleware.NewTokenBucket(defaults)
+	limiter.Reconfigure(defaults, overrides)
+
+	fields := map[string]interface{}{
+		"algorithm":        middleware.AlgorithmTokenBucket,
+		"limit_per_minute": rateLimit,
+		"burst":            defaults.Burst,
+		"overrides":        len(overrides),
+	}
+
+	if cfg.RedisURL == "" {
+		logger.Info("Rate limiter initialized", fields)
+		return limiter
+	}
+	redisOpts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		logger.Error("Invalid rate limit Redis URL, limiting locally", err, nil)
+		logger.Info("Rate limiter initialized", fields)
+		return limiter
+	}
+	failureMode, err := middleware.ParseFailureMode(cfg.RedisFailureMode)
+	if err != nil {
+		logger.Error("Invalid rate limit Redis failure mode, falling back to local limits", err, nil)
+		failureMode = middleware.FailLocal
+	}
+
+	fields["backend"] = "redis"
+	fields["redis_addr"] = redisOpts.Addr
+	fields["failure_mode"] = failureMode
+	logger.Info("Rate limiter initialized", fields)
+
+	return middleware.NewRedisLimiter(redis.NewClient(redisOpts), limiter, middleware.RedisOptions{
+		Timeout:     time.Duration(cfg.RedisTimeoutMS) * time.Millisecond,
+		FailureMode: failureMode,
+		OnStateChange: func(err error) {
+			if err != nil {
+				logger.Error("Rate limit store unreachable", err, map[string]interface{}{"failure_mode": failureMode})
+			} else {
+				logger.Info("Rate limit store reachable again", nil)
+			}
+		},
+	})
+}
+
+// perMinute returns the configured charges per minute, 10 if unset
+func perMinute(cfg config.RateLimitConfig) int {
+	if cfg.PerMinute <= 0 {
+		return 10
+	}
+	return cfg.PerMinute
+}
+
+// bucketLimits returns the token bucket's default limits, refilling at the
+// per-minute rate up to the burst, and its per-key overrides. The defaults are
+// returned even when the overrides are invalid.
+func bucketLimits(cfg config.RateLimitConfig) (middleware.BucketConfig, map[string]middleware.BucketConfig, error) {
+	rateLimit := perMinute(cfg)
+	burst := rateLimit
+	if cfg.Burst > 0 {
+		burst = cfg.Burst
+	}
+	defaults := middleware.BucketConfig{Rate: float64(rateLimit) / 60, Burst: burst}
+
+	overrides, err := middleware.ParseBucketOverrides(cfg.Overrides)
+	return defaults, overrides, err
+}
+
+// newRateLimitKey returns what charges are rate limited by: account (the
+// default), card or caller
+func newRateLimitKey(cfg config.RateLimitConfig, logger *logging.Logger) string {
+	switch key := cfg.Key; key {
+	case rateLimitKeyAccount, rateLimitKeyCard, rateLimitKeyCaller:
+		return key
+	default:
+		logger.Warn("Unknown rate limit key, limiting by account", map[string]interface{}{"key": key})
+		return rateLimitKeyAccount
+	}
+}
+
+// newVelocityChecker loads the velocity policy. It returns nil, applying no
+// velocity rules, if the path is unset, and an error if the policy is invalid.
+func newVelocityChecker(cfg config.VelocityConfig, logger *logging.Logger) (*velocity.Checker, error) {
+	policyPath := cfg.PolicyPath
+	if policyPath == "" {
+		return nil, nil
+	}
+
+	policy, err := velocity.LoadPolicy(policyPath)
+	if err == nil {
+		var checker *velocity.Checker
+		if checker, err = velocity.NewChecker(policy); err == nil {
+			logger.Info("Velocity policy loaded", map[string]interface{}{"path": policyPath, "rules": len(policy.Rules)})
+			return checker, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid velocity policy %s: %w", policyPath, err)
+}
+
+// newRiskEngine loads the risk policy. It returns nil, scoring no charges, if
+// the path is unset, and an error if the policy is invalid.
+func newRiskEngine(cfg config.RiskConfig, logger *logging.Logger) (*risk.Engine, error) {
+	policyPath := cfg.PolicyPath
+	if policyPath == "" {
+		return nil, nil
+	}
+
+	policy, err := risk.LoadPolicy(policyPath)
+	if err == nil {
+		var engine *risk.Engine
+		if engine, err = risk.NewEngine(policy); err == nil {
+			logger.Info("Risk policy loaded", map[string]interface{}{
+				"path":       policyPath,
+				"review_at":  policy.ReviewAt,
+				"decline_at": policy.DeclineAt,
+			})
+			return engine, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid risk policy %s: %w", policyPath, err)
+}
+
+// newBlocklist loads the blocklist. Entries added through the admin API are kept
+// in the store file, or in memory if it is unset. Entries in the YAML blocklist
+// file are reloaded every reload interval.
+func newBlocklist(cfg config.BlocklistConfig, logger *logging.Logger) (*blocklist.Blocklist, error) {
+	var store blocklist.Store = blocklist.NewMemoryStore()
+	if storePath := cfg.StorePath; storePath != "" {
+		fileStore, err := blocklist.NewFileStore(storePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open blocklist store %s: %w", storePath, err)
+		}
+		store = fileStore
+	} else {
+		logger.Warn("BLOCKLIST_STORE_PATH not set, blocklist changes will not survive restarts", nil)
+	}
+
+	path := cfg.Path
+	list, err := blocklist.New(store, path, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load blocklist %s: %w", path, err)
+	}
+
+	interval := time.Duration(cfg.ReloadSeconds) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	list.Start(interval)
+
+	logger.Info("Blocklist initialized", map[string]interface{}{"path": path, "entries": len(list.List())})
+	return list, nil
+}
+
+// newDuplicateGuard catches identical charges made within the window, handling
+// them as the action says: "reject" or "return_first" (the default). It returns
+// nil, letting duplicates through, when the window is 0.
+func newDuplicateGuard(cfg config.DuplicatesConfig, logger *logging.Logger) *duplicate.Guard {
+	window := time.Duration(cfg.WindowSeconds) * time.Second
+	if window <= 0 {
+		return nil
+	}
+
+	action, err := duplicate.ParseAction(cfg.Action)
+	if err != nil {
+		logger.Warn("Invalid duplicate action, using return_first", map[string]interface{}{"action": cfg.Action})
+		action = duplicate.ActionReturnFirst
+	}
+
+	logger.Info("Duplicate charge guard enabled", map[string]interface{}{"window": window.String(), "action": string(action)})
+	return duplicate.NewGuard(window, action)
+}
+
+// newProfileTracker opens the behavioural profile store, falling back to memory
+// if it is unset. Accounts are scored once they have the minimum history of
+// completed charges.
+func newProfileTracker(cfg config.ProfilesConfig, logger *logging.Logger) (*profile.Tracker, error) {
+	opts := profile.Options{MinHistory: cfg.MinHistory}
+
+	storePath := cfg.StorePath
+	if storePath == "" {
+		logger.Warn("Profile store path not set, account profiles will not survive restarts", nil)
+		return profile.NewTracker(profile.NewMemoryStore(), opts), nil
+	}
+
+	store, err := profile.NewFileStore(storePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open profile store %s: %w", storePath, err)
+	}
+	return profile.NewTracker(store, opts), nil
+}
+
+// newSpendCaps loads the spend cap policy, counting spend in the store file. It
+// returns nil, capping nothing, if neither the policy path nor any tenant caps
+// are set, and an error if the store can't be opened or the policy is invalid.
+func newSpendCaps(cfg config.SpendCapsConfig, tenants *tenant.Registry, logger *logging.Logger) (*spendcap.Caps, error) {
+	policyPath := cfg.PolicyPath
+	tenantCaps := tenantSpendCaps(tenants)
+	if policyPath == "" && len(tenantCaps) == 0 {
+		return nil, nil
+	}
+
+	var store spendcap.Store = spendcap.NewMemoryStore()
+	if storePath := cfg.StorePath; storePath != "" {
+		fileStore, err := spendcap.NewFileStore(storePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open spend cap store %s: %w", storePath, err)
+		}
+		store = fileStore
+	} else {
+		logger.Warn("Spend cap store path not set, spend will reset on restart", nil)
+	}
+
+	policy := &spendcap.Policy{}
+	var err error
+	if policyPath != "" {
+		policy, err = spendcap.LoadPolicy(policyPath)
+	}
+	if err == nil {
+		// Tenants' own caps replace the policy's for their merchant accounts
+		if policy.Merchants.Accounts == nil {
+			policy.Merchants.Accounts = make(map[string]spendcap.Limits)
+		}
+		for account, limits := range tenantCaps {
+			policy.Merchants.Accounts[account] = limits
+		}
+
+		var caps *spendcap.Caps
+		if caps, err = spendcap.NewCaps(policy, store); err == nil {
+			logger.Info("Spend cap policy loaded", map[string]interface{}{"path": policyPath, "timezone": policy.Timezone})
+			return caps, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid spend cap policy %s: %w", policyPath, err)
+}
+
+// tenantSpendCaps returns the caps of tenants' merchant accounts, for the
+// tenants that have them
+func tenantSpendCaps(tenants *tenant.Registry) map[string]spendcap.Limits {
+	if tenants == nil {
+		return nil
+	}
+	caps := make(map[string]spendcap.Limits)
+	for _, t := range tenants.List() {
+		if t.SpendCaps != (spendcap.Limits{}) {
+			caps[t.MerchantAccount] = t.SpendCaps
+		}
+	}
+	return caps
+}
+
+// newRoundingPolicy parses the rounding policy, which decides how charges with
+// sub-cent amounts are handled
+func newRoundingPolicy(cfg config.MoneyConfig, logger *logging.Logger) money.RoundingPolicy {
+	policy, err := money.ParseRoundingPolicy(cfg.RoundingPolicy)
+	if err != nil {
+		logger.Warn("Invalid rounding policy, using default", map[string]interface{}{
+			"policy":  cfg.RoundingPolicy,
+			"default": converter.DefaultRounding.String(),
+		})
+		return converter.DefaultRounding
+	}
+	logger.Info("Money rounding policy configured", map[string]interface{}{"policy": policy.String()})
+	return policy
+}
+
+// newFXConverter builds the converter into the ledger currency. Rates come from
+// the rates URL when set, otherwise the rates file; without either, only charges
+// already in the ledger currency are accepted.
+func newFXConverter(cfg config.FXConfig, logger *logging.Logger) (*fx.Converter, error) {
+	var provider fx.RateProvider
+	if ratesURL := cfg.RatesURL; ratesURL != "" {
+		refresh := time.Duration(cfg.RefreshSeconds) * time.Second
+		provider = fx.NewHTTPProvider(ratesURL, refresh)
+		logger.Info("FX rates provider configured", map[string]interface{}{"url": ratesURL, "refresh": refresh.String()})
+	} else if ratesPath := cfg.RatesPath; ratesPath != "" {
+		static, err := fx.NewStaticProvider(ratesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load FX rates %s: %w", ratesPath, err)
+		}
+		provider = static
+		logger.Info("FX rates loaded", map[string]interface{}{"path": ratesPath})
+	} else {
+		logger.Warn("No FX rates configured, only ledger currency charges accepted", nil)
+	}
+
+	maxAge := time.Duration(cfg.MaxRateAgeSeconds) * time.Second
+	spread := int64(cfg.SpreadBPS)
+
+	ledgerCurrency := cfg.LedgerCurrency
+	converter, err := fx.NewConverter(provider, ledgerCurrency, maxAge, spread)
+	if err != nil {
+		logger.Error("Invalid FX configuration, using USD ledger without spread", err, map[string]interface{}{
+			"ledger_currency": ledgerCurrency,
+			"spread_bps":      spread,
+		})
+		converter, _ = fx.NewConverter(provider, "USD", maxAge, 0)
+	}
+	return converter, nil
+}
+
+// newTransactionStore opens the transaction record store, falling back to memory
+// if its path is unset
+func newTransactionStore(cfg config.TransactionsConfig, logger *logging.Logger) (transaction.Store, error) {
+	storePath := cfg.StorePath
+	if storePath == "" {
+		return transaction.NewMemoryStore(), nil
+	}
+
+	store, err := transaction.NewFileStore(storePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transaction store %s: %w", storePath, err)
+	}
+	return store, nil
+}
+
+// newVault opens the card tokenization vault. It returns nil if no master key is
+// configured, in which case only raw card numbers can be charged.
+func newVault(cfg config.VaultConfig, logger *logging.Logger) (*vault.Vault, error) {
+	if cfg.MasterKey == "" {
+		logger.Warn("Vault master key not set, card tokenization disabled", nil)
+		return nil, nil
+	}
+
+	masterKey, err := base64.StdEncoding.DecodeString(cfg.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("vault master key is not valid base64: %w", err)
+	}
+
+	rotationHours := cfg.KeyRotationHours
+	storePath := cfg.StorePath
+	if storePath == "" {
+		logger.Warn("Vault store path not set, card tokens will not survive restarts", nil)
+	}
+
+	cardVault, err := vault.New(masterKey, storePath, time.Duration(rotationHours)*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open card vault %s: %w", storePath, err)
+	}
+
+	logger.Info("Card vault opened", map[string]interface{}{
+		"path":           storePath,
+		"active_key_id":  cardVault.ActiveKeyID(),
+		"rotation_hours": rotationHours,
+	})
+	return cardVault, nil
+}
+
+// configureMappingStrategies replaces the default mapping chain with the strategies
+// in strategiesPath, if set. An invalid file keeps the default chain and is
+// returned as the error.
+func configureMappingStrategies(accountMapper *mapper.AccountMapper, strategiesPath string, logger *logging.Logger) error {
+	var err error
+	if strategiesPath != "" {
+		var cfg *mapper.StrategyConfig
+		cfg, err = mapper.LoadStrategyConfig(strategiesPath)
+		if err == nil {
+			err = accountMapper.UseStrategies(cfg)
+		}
+		if err != nil {
+			logger.Error("Failed to configure card mapping strategies, using default chain", err, map[string]interface{}{"path": strategiesPath})
+		}
+	}
+
+	logger.Info("Card mapping strategy chain", map[string]interface{}{"strategies": accountMapper.StrategyNames()})
+	return err
+}
+
+// newServiceAuthenticator loads the signing keys used to forge bank tokens.
+// It returns nil if the keys are unavailable, which is non-fatal during local dev.
+func newServiceAuthenticator(cfg config.AuthConfig, logger *logging.Logger) *auth.ServiceAuthenticator {
+	var authenticator *auth.ServiceAuthenticator
+	var authErr error
+
+	// Try to initialize authenticator (non-fatal if it fails during local dev)
+	authenticator, authErr = auth.NewServiceAuthenticator(cfg.PrivateKeyPath, cfg.PublicKeyPath, cfg.TokenExpirySeconds)
+	if authErr != nil {
+		logger.Warn("Failed to initialize service authenticator", map[string]interface{}{
+			"error": authErr.Error(),
+			"note":  "Service will run without JWT authentication capability",
+		})
+	} else {
+		logger.Info("Service authenticator initialized successfully", map[string]interface{}{"kid": authenticator.KeyID()})
+
+		// Keep publishing the previous key during a rotation so in-flight tokens still verify
+		if prevPublicKeyPath := cfg.PreviousPublicKeyPath; prevPublicKeyPath != "" {
+			if err := authenticator.AddPreviousPublicKey(prevPublicKeyPath); err != nil {
+				logger.Warn("Failed to load previous public key", map[string]interface{}{"error": err.Error()})
+			}
+		}
+
+		configureTokenClaims(authenticator, cfg, logger)
+
+		// Test token generation
+		if testToken, err := authenticator.GenerateServiceToken("TEST_ACCOUNT"); err != nil {
+			logger.Warn("Failed to generate test token", map[string]interface{}{"error": err.Error()})
+		} else {
+			logger.Info("Successfully generated test service token", map[string]interface{}{"token_length": len(testToken)})
+		}
+	}
+
+	return authenticator
+}
+
+// newLoginAuthenticator sets up userservice login with credentials from the secret store
+func newLoginAuthenticator(cfg config.AuthConfig, logger *logging.Logger) *auth.LoginAuthenticator {
+	credentials, err := auth.NewFileCredentialStore(cfg.CredentialsPath)
+	if err != nil {
+		logger.Warn("Failed to load bank credentials", map[string]interface{}{
+			"error": err.Error(),
+			"note":  "Service will run without bank authentication capability",
+		})
+		return nil
+	}
+
+	logger.Info("Login authenticator initialized", map[string]interface{}{"userservice_url": cfg.UserserviceURL})
+	return auth.NewLoginAuthenticator(cfg.UserserviceURL, credentials)
+}
+
+// configureTokenClaims applies issuer, audience, clock skew, replay cache and
+// per-service claim template settings
+func configureTokenClaims(authenticator *auth.ServiceAuthenticator, cfg config.AuthConfig, logger *logging.Logger) {
+	defaults := auth.DefaultClaimTemplate()
+	defaults.Issuer = cfg.Issuer
+	if len(cfg.Audience) > 0 {
+		defaults.Audience = cfg.Audience
+	}
+	authenticator.SetDefaultClaims(defaults)
+
+	if cfg.ClockSkewSeconds >= 0 {
+		authenticator.SetClockSkew(time.Duration(cfg.ClockSkewSeconds) * time.Second)
+	}
+	if cfg.ReplayCacheSize > 0 {
+		authenticator.SetReplayCacheSize(cfg.ReplayCacheSize)
+	}
+
+	if templatesPath := cfg.ClaimTemplatesPath; templatesPath != "" {
+		templates, err := auth.LoadClaimTemplates(templatesPath)
+		if err != nil {
+			logger.Warn("Failed to load claim templates", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		for service, template := range templates {
+			authenticator.SetClaimTemplate(service, template)
+		}
+		logger.Info("Loaded per-service claim templates", map[string]interface{}{"count": len(templates)})
+	}
+}
+
+// Authenticator returns the service authenticator, or nil if keys could not be loaded
+func (s *PaymentServer) Authenticator() *auth.ServiceAuthenticator {
+	return s.authenticator
+}
+
+// RegisterPaymentServiceServer registers the payment service with the gRPC server
+func RegisterPaymentServiceServer(s *grpc.Server, srv *PaymentServer) {
+	pb.RegisterPaymentServiceServer(s, srv)
+}
+
+// Charge processes a payment request
+func (s *PaymentServer) Charge(ctx context.Context, req *pb.ChargeRequest) (resp *pb.ChargeResponse, err error) {
+	start := time.Now()
+
+	// Charges for an unknown tenant are refused before anything else
+	rt, err := s.tenantFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger := s.logger
+	if rt != nil {
+		logger = rt.logger
+	}
+
+	// Validate request
+	if req.Amount == nil {
+		return nil, status.Error(codes.InvalidArgument, "amount is required")
+	}
+
+	// Validate the amount in its own currency, then convert it to ledger cents for Bank of Anthos
+	if _, err := converter.BoutiqueMoneyToMinorUnits(req.Amount, s.roundingPolicy); err != nil {
+		s.logger.Error("Error converting money", err, nil)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid amount: %v", err)
+	}
+	conversion, err := s.convertToLedger(ctx, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	cents := conversion.LedgerAmount
+
+	// Map the card or card token to a bank account, unless the rate limiter already has
+	resolved, err := s.resolveCardOnce(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resolution, cardLast4 := resolved.resolution, resolved.cardLast4
+	fromAccount, fromRouting := resolution.AccountNum, resolution.RoutingNum
+	toAccount, toRouting := s.currentMapper().MerchantAccountFor(fromRouting)
+	if rt != nil {
+		toAccount, toRouting = rt.tenant.MerchantAccount, rt.tenant.RoutingNumber
+	}
+
+	// Refuse frozen accounts, cards and BIN ranges before the charge counts towards anything
+	if err := s.checkBlocklist(blocklist.Charge{Payer: fromAccount, Payee: toAccount, Card: resolution.Fingerprint, BIN: resolved.bin}); err != nil {
+		return nil, err
+	}
+
+	// Catch the same card, amount and currency submitted twice in quick succession
+	pending, original, err := s.checkDuplicate(ctx, resolution.Fingerprint, conversion.Original)
+	if err != nil {
+		return nil, err
+	}
+	if original != "" {
+		return &pb.ChargeResponse{TransactionId: original}, nil
+	}
+	defer func() {
+		if err == nil {
+			pending.Finish(resp.TransactionId, true)
+		} else {
+			pending.Finish("", false)
+		}
+	}()
+
+	// Check the velocity rules across card, account, merchant, caller and globally
+	if err := s.checkVelocity(ctx, resolution.Fingerprint, fromAccount, toAccount, cents); err != nil {
+		return nil, err
+	}
+
+	// Score the charge for fraud risk, including how unusual it is for the account
+	riskReq := risk.Request{Account: fromAccount, Card: resolution.Fingerprint, BIN: resolved.bin, Amount: cents, Time: time.Now()}
+	riskReq.Anomaly = s.scoreAnomaly(riskReq)
+	assessment, err := s.assessRisk(riskReq, cardLast4)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate unique transaction UUID for Bank API
+	transactionUUID := utils.GenerateUUID()
+
+	// Log the payment request
+	logger.LogPaymentRequest(ctx, transactionUUID, cents,
+		conversion.LedgerCurrency, cardLast4)
 
-	// Initialize rate limiter
-	rateLimit := 10 // Default: 10 transactions per minute per account
-	if rateLimitStr := os.Getenv("RATE_LIMIT_PER_MINUTE"); rateLimitStr != "" {
-		if limit, err := strconv.Atoi(rateLimitStr); err == nil && limit > 0 {
-			rateLimit = limit
-		}
+	logger.Debug("Payment details", map[string]interface{}{
+		"transaction_id": transactionUUID,
+		"amount_cents":   cents,
+		"original":       conversion.Original.String(),
+		"from_account":   fromAccount,
+		"from_routing":   fromRouting,
+		"to_account":     toAccount,
+		"to_routing":     toRouting,
+	})
+
+	record := &transaction.Record{
+		ID:              transactionUUID,
+		FromAccount:     fromAccount,
+		FromRouting:     fromRouting,
+		ToAccount:       toAccount,
+		ToRouting:       toRouting,
+		Tenant:          rt.id(),
+		CardLast4:       cardLast4,
+		CardFingerprint: resolution.Fingerprint,
+		BIN:             resolved.bin,
+		Amount:          cents,
+		Currency:        conversion.LedgerCurrency,
+		Original:        conversion.Original,
+		FX:              conversion.FX,
+		Risk:            assessment,
+		CreatedAt:       time.Now().UTC(),
 	}
-	middleware.InitRateLimiter(rateLimit)
-	logger.Info("Rate limiter initialized", map[string]interface{}{"limit_per_minute": rateLimit})
 
-	// Get merchant account from environment or use defaults
-	merchantAccount := os.Getenv("MERCHANT_ACCOUNT")
-	if merchantAccount == "" {
-		merchantAccount = "1111111111"
+	// Park charges flagged for review until a reviewer, or the SLA, decides them
+	if assessment != nil && assessment.Outcome == risk.OutcomeReview && s.reviews != nil {
+		if err := s.reviews.Park(record); err != nil {
+			s.logger.Error("Failed to queue charge for review", err, map[string]interface{}{"transaction_id": record.ID})
+			return nil, status.Error(codes.Internal, "failed to queue the payment for review")
+		}
+		s.logger.Warn("Charge parked for manual review", map[string]interface{}{
+			"transaction_id": record.ID,
+			"deadline":       record.Review.Deadline,
+		})
+		s.setChargeHeaders(ctx, record, conversion)
+		logger.LogPaymentResponse(ctx, record.ID, true, time.Since(start), nil)
+		return &pb.ChargeResponse{TransactionId: record.ID}, nil
 	}
 
-	routingNumber := os.Getenv("ROUTING_NUMBER")
-	if routingNumber == "" {
-		routingNumber = "123456789"
+	// Fallback to simulation if Bank client is not available
+	if s.routerFor(record.Tenant) == nil {
+		s.logger.Warn("Bank client not available, simulating payment", nil)
+		s.transactionCounter++
+		record.ID = fmt.Sprintf("SIM-%d-%d", time.Now().Unix(), s.transactionCounter)
 	}
 
-	// Initialize service authenticator
-	privateKeyPath := os.Getenv("PRIV_KEY_PATH")
-	if privateKeyPath == "" {
-		privateKeyPath = "/tmp/.ssh/privatekey"
+	// Call the Bank of Anthos API to process the real transaction
+	txStatus, err := s.transfer(record)
+	if err != nil {
+		logger.LogPaymentResponse(ctx, record.ID, false, time.Since(start), err)
+		metrics.GetInstance().RecordRequest(false, time.Since(start), 0, "")
+		recordTenantRequest(record.Tenant, false, 0)
+		return nil, err
 	}
 
-	publicKeyPath := os.Getenv("PUB_KEY_PATH")
-	if publicKeyPath == "" {
-		publicKeyPath = "/tmp/.ssh/publickey"
+	record.Status = txStatus
+	s.recordTransaction(ctx, record, conversion)
+	s.observeCharge(riskReq)
+	logger.LogPaymentResponse(ctx, record.ID, true, time.Since(start), nil)
+
+	// Record metrics
+	if txStatus == transaction.StatusCompleted {
+		metrics.GetInstance().RecordRequest(true, time.Since(start), cents, cardLast4)
+		recordTenantRequest(record.Tenant, true, cents)
 	}
 
-	tokenExpiryStr := os.Getenv("TOKEN_EXPIRY_SECONDS")
-	if tokenExpiryStr == "" {
-		tokenExpiryStr = "3600"
+	// Use the transaction UUID as the response ID
+	return &pb.ChargeResponse{TransactionId: record.ID}, nil
+}
+
+// transfer moves a charge's money: it counts the charge against the spend
+// caps, debits the sender's bank and, for cross-bank transfers, credits the
+// recipient's. Without a bank the transfer is simulated. Errors are returned
+// as gRPC statuses.
+func (s *PaymentServer) transfer(record *transaction.Record) (txStatus transaction.Status, err error) {
+	reservation, err := s.reserveSpend(record)
+	if err != nil {
+		return "", err
 	}
-	tokenExpiry, _ := strconv.Atoi(tokenExpiryStr)
+	defer func() {
+		if err != nil {
+			s.releaseSpend(reservation)
+		}
+	}()
 
-	var authenticator *auth.ServiceAuthenticator
-	var authErr error
+	router := s.routerFor(record.Tenant)
+	if router == nil {
+		return transaction.StatusSimulated, nil
+	}
+	logger := s.loggerFor(record.Tenant)
 
-	// Try to initialize authenticator (non-fatal if it fails during local dev)
-	authenticator, authErr = auth.NewServiceAuthenticator(privateKeyPath, publicKeyPath, tokenExpiry)
-	if authErr != nil {
-		logger.Warn("Failed to initialize service authenticator", map[string]interface{}{
-			"error": authErr.Error(),
-			"note":  "Service will run without JWT authentication capability",
+	debitClient, creditClient, err := bankClients(router, record.FromRouting, record.ToRouting)
+	if err != nil {
+		s.logger.Warn("No bank route for transaction", map[string]interface{}{
+			"transaction_id": record.ID,
+			"error":          err.Error(),
 		})
-	} else {
-		logger.Info("Service authenticator initialized successfully", nil)
-		// Test token generation
-		if testToken, err := authenticator.GenerateServiceToken("TEST_ACCOUNT"); err != nil {
-			logger.Warn("Failed to generate test token", map[string]interface{}{"error": err.Error()})
-		} else {
-			logger.Info("Successfully generated test service token", map[string]interface{}{"token_length": len(testToken)})
+		metrics.GetInstance().RecordError("bank_route_error")
+		return "", status.Error(codes.FailedPrecondition, "no bank is configured for the account's routing number")
+	}
+
+	bankReq := &bank.TransactionRequest{
+		FromAccountNum: record.FromAccount,
+		FromRoutingNum: record.FromRouting,
+		ToAccountNum:   record.ToAccount,
+		ToRoutingNum:   record.ToRouting,
+		Amount:         record.Amount,
+		UUID:           record.ID,
+	}
+
+	bankStart := time.Now()
+	_, err = debitClient.CreateTransaction(bankReq)
+	logger.LogBankAPICall(record.ID, record.FromAccount, record.Amount, time.Since(bankStart), err)
+
+	if err != nil {
+		metrics.GetInstance().RecordError("bank_api_error")
+
+		// Handle specific bank errors
+		if bankErr, ok := err.(*bank.BankError); ok {
+			return "", bankErr.ToGRPCError()
 		}
+		return "", bank.HandleBankError(err)
 	}
 
-	// Initialize Bank client
-	bankAPIURL := os.Getenv("BANK_API_URL")
-	if bankAPIURL == "" {
-		bankAPIURL = "http://ledgerwriter.bank-of-anthos.svc.cluster.local:8080"
+	if creditClient != debitClient {
+		s.creditRecipientBank(creditClient, bankReq)
 	}
 
-	var bankClient *bank.Client
-	if authenticator != nil {
-		bankClient = bank.NewClient(bankAPIURL, authenticator)
-		logger.Info("Bank client initialized", map[string]interface{}{"bank_api_url": bankAPIURL})
+	logger.LogTransaction(record.ID, record.FromAccount, record.ToAccount, record.Amount,
+		record.Currency, "Bank transaction successful")
+	return transaction.StatusCompleted, nil
+}
 
-		// Test bank connectivity
-		if err := bankClient.HealthCheck(); err != nil {
-			logger.Warn("Bank API health check failed", map[string]interface{}{
-				"error": err.Error(),
-				"note":  "Transactions may fail until Bank API is available",
-			})
-		} else {
-			logger.Info("Bank API health check successful", nil)
+// accountRateLimitRule is the rejection metric label of the per-account rate limiter
+const accountRateLimitRule = "account_rate_limit"
+
+// tenantRateLimitRule is the rejection metric label of tenants' own rate limits
+const tenantRateLimitRule = "tenant_rate_limit"
+
+// tenantIDHeader names the tenant a request is for. Callers that belong to a
+// tenant may leave it out.
+const tenantIDHeader = "x-tenant-id"
+
+// callerIDHeader lets a client name itself for rate limits and the caller velocity dimension
+const callerIDHeader = "x-caller-id"
+
+// What charges can be rate limited by
+const (
+	rateLimitKeyAccount = "account"
+	rateLimitKeyCard    = "card"
+	rateLimitKeyCaller  = "caller"
+)
+
+// callerKey identifies the client making a request: the x-caller-id header when
+// present, otherwise the peer's host
+var callerKey = middleware.FirstKey(middleware.KeyFromMetadata(callerIDHeader), middleware.KeyFromPeer())
+
+// UnaryInterceptor returns the interceptor applying the tenants' and the
+// server's rate limits to charges. The quota left is reported in
+// x-ratelimit-* response headers.
+func (s *PaymentServer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	// The tenant rule comes first so charges for unknown tenants are rejected
+	// before they count against any limit. Its limiter is only consulted for
+	// tenants with a rate limit.
+	var limits []middleware.RateLimit
+	if s.tenantRegistry != nil {
+		limits = append(limits, middleware.RateLimit{
+			Rule:    tenantRateLimitRule,
+			Limiter: s.tenantLimiter,
+			Key:     s.tenantKey,
+			Methods: []string{pb.PaymentService_Charge_FullMethodName},
+		})
+	}
+
+	if s.rateLimiter != nil {
+		key := s.accountKey
+		switch s.rateLimitKey {
+		case rateLimitKeyCard:
+			key = s.cardKey
+		case rateLimitKeyCaller:
+			key = callerKey
 		}
-	} else {
-		logger.Warn("Bank client not initialized due to missing authenticator", nil)
+		limits = append(limits, middleware.RateLimit{
+			Rule:    accountRateLimitRule,
+			Limiter: s.rateLimiter,
+			Key:     key,
+			Methods: []string{pb.PaymentService_Charge_FullMethodName},
+		})
 	}
 
-	return &PaymentServer{
-		accountMapper:      mapper.NewAccountMapper(merchantAccount, routingNumber),
-		authenticator:      authenticator,
-		bankClient:         bankClient,
-		transactionCounter: 0,
-		logger:             logger,
+	if len(limits) == 0 {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(ctx, req)
+		}
 	}
+	return middleware.UnaryRateLimitInterceptor(s.rateLimitRejected, limits...)
 }
 
-// RegisterPaymentServiceServer registers the payment service with the gRPC server
-func RegisterPaymentServiceServer(s *grpc.Server, srv *PaymentServer) {
-	pb.RegisterPaymentServiceServer(s, srv)
+// tenantKey rate limits a charge by its tenant, for tenants with their own
+// limit, and rejects charges for unknown tenants
+func (s *PaymentServer) tenantKey(ctx context.Context, fullMethod string, req interface{}) (context.Context, string, error) {
+	rt, err := s.tenantFor(ctx)
+	if err != nil || rt == nil || rt.tenant.RateLimit == "" {
+		return ctx, "", err
+	}
+	return ctx, rt.tenant.ID, nil
 }
 
-// Charge processes a payment request
-func (s *PaymentServer) Charge(ctx context.Context, req *pb.ChargeRequest) (*pb.ChargeResponse, error) {
-	start := time.Now()
+// tenantFor returns the tenant a request belongs to, named by the
+// x-tenant-id header or else by its caller. It returns nil when the service
+// serves a single storefront, and a PermissionDenied error for unknown tenants.
+func (s *PaymentServer) tenantFor(ctx context.Context) (*tenantRuntime, error) {
+	if s.tenantRegistry == nil {
+		return nil, nil
+	}
 
-	// Validate request
-	if req.Amount == nil {
-		return nil, status.Error(codes.InvalidArgument, "amount is required")
+	md, _ := metadata.FromIncomingContext(ctx)
+	caller := callerID(ctx)
+	t, err := s.tenantRegistry.Resolve(firstValue(md, tenantIDHeader), caller)
+	var rejection *tenant.Rejection
+	if errors.As(err, &rejection) {
+		s.logger.Warn("Rejected request for an unknown tenant", map[string]interface{}{
+			"tenant": rejection.ID,
+			"caller": caller,
+			"reason": rejection.Reason,
+		})
+		metrics.GetInstance().RecordTenantRejection(rejection.Reason)
+		return nil, rejection.ToGRPCError()
 	}
+	return s.tenants[t.ID], nil
+}
 
-	if req.CreditCard == nil {
-		return nil, status.Error(codes.InvalidArgument, "credit card info is required")
+// id returns the tenant's ID, or an empty string without a tenant
+func (rt *tenantRuntime) id() string {
+	if rt == nil {
+		return ""
+	}
+	return rt.tenant.ID
+}
+
+// routerFor returns the bank router a tenant's transfers go through, or the
+// service's for charges without a tenant
+func (s *PaymentServer) routerFor(tenantID string) *bank.Router {
+	if rt, ok := s.tenants[tenantID]; ok {
+		return rt.router
 	}
+	return s.currentRouter()
+}
+
+// loggerFor returns the logger tagging entries with a tenant, or the service's
+// for charges without one
+func (s *PaymentServer) loggerFor(tenantID string) *logging.Logger {
+	if rt, ok := s.tenants[tenantID]; ok {
+		return rt.logger
+	}
+	return s.logger
+}
+
+// recordTenantRequest counts a charge in its tenant's metrics
+func recordTenantRequest(tenantID string, success bool, amount int64) {
+	if tenantID != "" {
+		metrics.GetInstance().RecordTenantRequest(tenantID, success, amount)
+	}
+}
+
+// rateLimitRejected logs and counts a charge the rate limiter turned away
+func (s *PaymentServer) rateLimitRejected(ctx context.Context, rule, key string, d middleware.Decision) {
+	s.logger.Warn("Rate limit exceeded", map[string]interface{}{
+		"rule":        rule,
+		"key":         key,
+		"retry_after": d.RetryAfter.String(),
+	})
+	metrics.GetInstance().RecordRejection(rule)
+}
+
+// resolvedCardKey is the context key of a charge's card, resolved for the rate limiter
+type resolvedCardKey struct{}
+
+// resolvedCard is a charge's card and the account it maps to
+type resolvedCard struct {
+	req        *pb.ChargeRequest
+	resolution *mapper.Resolution
+	cardLast4  string
+	bin        string
+}
+
+// accountKey rate limits a charge by the bank account its card maps to. The
+// resolved card is kept in the context so Charge doesn't map it again.
+func (s *PaymentServer) accountKey(ctx context.Context, fullMethod string, req interface{}) (context.Context, string, error) {
+	ctx, resolved, err := s.resolveForLimit(ctx, req)
+	if err != nil || resolved == nil {
+		return ctx, "", err
+	}
+	return ctx, resolved.resolution.AccountNum, nil
+}
 
-	// Validate card number
-	if err := mapper.ValidateCardNumber(req.CreditCard.CreditCardNumber); err != nil {
-		s.logger.Warn("Invalid card number", map[string]interface{}{"error": err.Error()})
-		return nil, status.Errorf(codes.InvalidArgument, "invalid card number: %v", err)
+// cardKey rate limits a charge by its card fingerprint
+func (s *PaymentServer) cardKey(ctx context.Context, fullMethod string, req interface{}) (context.Context, string, error) {
+	ctx, resolved, err := s.resolveForLimit(ctx, req)
+	if err != nil || resolved == nil {
+		return ctx, "", err
 	}
+	return ctx, resolved.resolution.Fingerprint, nil
+}
 
-	// Convert money format to cents for Bank of Anthos
-	cents, err := converter.BoutiqueMoneyToCents(req.Amount)
+func (s *PaymentServer) resolveForLimit(ctx context.Context, req interface{}) (context.Context, *resolvedCard, error) {
+	chargeReq, ok := req.(*pb.ChargeRequest)
+	if !ok {
+		return ctx, nil, nil
+	}
+	resolved, err := s.resolveCard(chargeReq)
 	if err != nil {
-		s.logger.Error("Error converting money", err, nil)
-		return nil, status.Errorf(codes.InvalidArgument, "invalid amount: %v", err)
+		return ctx, nil, err
 	}
+	return context.WithValue(ctx, resolvedCardKey{}, resolved), resolved, nil
+}
+
+// resolveCardOnce returns the card the rate limiter resolved for req, or resolves it
+func (s *PaymentServer) resolveCardOnce(ctx context.Context, req *pb.ChargeRequest) (*resolvedCard, error) {
+	if resolved, ok := ctx.Value(resolvedCardKey{}).(*resolvedCard); ok && resolved.req == req {
+		return resolved, nil
+	}
+	return s.resolveCard(req)
+}
 
-	// Map credit card to bank account
-	fromAccount, fromRouting := s.accountMapper.CardNumberToAccount(req.CreditCard.CreditCardNumber)
-	toAccount, toRouting := s.accountMapper.GetMerchantAccount()
+// checkVelocity applies the velocity policy, if one is configured
+func (s *PaymentServer) checkVelocity(ctx context.Context, cardFingerprint, fromAccount, toAccount string, cents int64) error {
+	if s.velocity == nil {
+		return nil
+	}
 
-	// Check rate limit for this account
-	rateLimiter := middleware.GetRateLimiter()
-	if !rateLimiter.Allow(fromAccount) {
-		s.logger.Warn("Rate limit exceeded", map[string]interface{}{
+	err := s.velocity.Check(velocity.Event{
+		Card:     cardFingerprint,
+		Account:  fromAccount,
+		Merchant: toAccount,
+		Caller:   callerID(ctx),
+		Amount:   cents,
+		Time:     time.Now(),
+	})
+	var violation *velocity.Violation
+	if errors.As(err, &violation) {
+		s.logger.Warn("Velocity limit exceeded", map[string]interface{}{
+			"rule":      violation.Rule,
+			"dimension": string(violation.Dimension),
+			"limit":     violation.Limit,
 			"account":   fromAccount,
-			"remaining": rateLimiter.GetRemaining(fromAccount),
 		})
-		metrics.GetInstance().RecordRejection()
-		return nil, status.Error(codes.ResourceExhausted, "too many payment requests, please try again later")
+		metrics.GetInstance().RecordRejection(violation.Rule)
+		return violation.ToGRPCError()
 	}
+	return err
+}
 
-	// Generate unique transaction UUID for Bank API
-	transactionUUID := utils.GenerateUUID()
-
-	// Log the payment request
-	s.logger.LogPaymentRequest(ctx, transactionUUID, cents,
-		req.Amount.CurrencyCode, getLastFourDigits(req.CreditCard.CreditCardNumber))
+// checkBlocklist refuses a charge that involves a blocklisted account, card or
+// BIN. The entry's reason is logged for operators but not returned.
+func (s *PaymentServer) checkBlocklist(charge blocklist.Charge) error {
+	if s.blocklist == nil {
+		return nil
+	}
 
-	s.logger.Debug("Payment details", map[string]interface{}{
-		"transaction_id": transactionUUID,
-		"amount_cents":   cents,
-		"from_account":   fromAccount,
-		"from_routing":   fromRouting,
-		"to_account":     toAccount,
-		"to_routing":     toRouting,
+	err := s.blocklist.Check(charge)
+	var blocked *blocklist.Blocked
+	if !errors.As(err, &blocked) {
+		return err
+	}
+	s.logger.Warn("Charge refused by blocklist", map[string]interface{}{
+		"kind":    string(blocked.Entry.Kind),
+		"value":   blocked.Entry.Value,
+		"party":   blocked.Party,
+		"reason":  blocked.Entry.Reason,
+		"source":  blocked.Entry.Source,
+		"account": charge.Payer,
 	})
+	metrics.GetInstance().RecordBlocklistRejection(string(blocked.Entry.Kind))
+	return blocked.ToGRPCError()
+}
 
-	// Call the Bank of Anthos API to process the real transaction
-	if s.bankClient != nil {
-		bankReq := &bank.TransactionRequest{
-			FromAccountNum: fromAccount,
-			FromRoutingNum: fromRouting,
-			ToAccountNum:   toAccount,
-			ToRoutingNum:   toRouting,
-			Amount:         cents,
-			UUID:           transactionUUID,
+// checkDuplicate registers the charge with the duplicate guard, which must be
+// told the outcome through the returned Pending. For a duplicate answered with
+// the original charge it returns the original's transaction ID instead; a
+// rejected duplicate is returned as an AlreadyExists error.
+func (s *PaymentServer) checkDuplicate(ctx context.Context, fingerprint string, amount money.Amount) (*duplicate.Pending, string, error) {
+	if s.duplicates == nil {
+		return nil, "", nil
+	}
+
+	pending, err := s.duplicates.Begin(ctx, duplicate.Key(fingerprint, amount.String()))
+	var dup *duplicate.Duplicate
+	if !errors.As(err, &dup) {
+		if err != nil {
+			return nil, "", status.FromContextError(err).Err()
 		}
+		return pending, "", nil
+	}
+
+	s.logger.Warn("Duplicate charge detected", map[string]interface{}{
+		"original_transaction_id": dup.OriginalID,
+		"amount":                  amount.String(),
+		"age_ms":                  dup.Age.Milliseconds(),
+		"action":                  string(dup.Action),
+	})
+	metrics.GetInstance().RecordDuplicate(string(dup.Action))
+	if dup.Action == duplicate.ActionReject {
+		return nil, "", dup.ToGRPCError()
+	}
 
-		bankStart := time.Now()
-		_, err := s.bankClient.CreateTransaction(bankReq)
-		bankDuration := time.Since(bankStart)
+	// Answer with the original's headers, marked as a duplicate
+	if record, err := s.transactions.Get(dup.OriginalID); err == nil {
+		s.setChargeHeaders(ctx, record, &fx.Conversion{
+			Original:       record.Original,
+			LedgerCurrency: record.Currency,
+			LedgerAmount:   record.Amount,
+			FX:             record.FX,
+		})
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs("x-duplicate-of", dup.OriginalID)); err != nil {
+		s.logger.Debug("Could not set response metadata", map[string]interface{}{"error": err.Error()})
+	}
+	return nil, dup.OriginalID, nil
+}
 
-		s.logger.LogBankAPICall(transactionUUID, fromAccount, cents, bankDuration, err)
+// reserveSpend counts the charge against the customer's and merchant's spend
+// caps. It returns nil without spend caps.
+func (s *PaymentServer) reserveSpend(record *transaction.Record) (*spendcap.Reservation, error) {
+	if s.spendCaps == nil {
+		return nil, nil
+	}
 
-		if err != nil {
-			s.logger.LogPaymentResponse(ctx, transactionUUID, false, time.Since(start), err)
+	reservation, err := s.spendCaps.Reserve(record.FromAccount, record.ToAccount, record.Amount)
+	var breach *spendcap.Breach
+	if errors.As(err, &breach) {
+		s.logger.Warn("Spend cap exceeded", map[string]interface{}{
+			"transaction_id": record.ID,
+			"scope":          string(breach.Scope),
+			"account":        breach.Account,
+			"period":         string(breach.Period),
+			"cap":            breach.Cap,
+			"remaining":      breach.Remaining,
+			"amount":         record.Amount,
+		})
+		metrics.GetInstance().RecordSpendCapBreach(string(breach.Scope), string(breach.Period))
+		return nil, breach.ToGRPCError()
+	}
+	if err != nil {
+		s.logger.Error("Failed to count charge against spend caps", err, map[string]interface{}{"transaction_id": record.ID})
+		return nil, status.Error(codes.Internal, "failed to check spend caps")
+	}
+	return reservation, nil
+}
 
-			// Record error metrics
-			metrics.GetInstance().RecordRequest(false, time.Since(start), 0, "")
-			metrics.GetInstance().RecordError("bank_api_error")
+// releaseSpend uncounts the spend of a charge whose transfer failed
+func (s *PaymentServer) releaseSpend(reservation *spendcap.Reservation) {
+	if reservation == nil {
+		return
+	}
+	if err := s.spendCaps.Release(reservation); err != nil {
+		s.logger.Error("Failed to release spend cap reservation", err, map[string]interface{}{"account": reservation.Customer})
+	}
+}
 
-			// Handle specific bank errors
-			if bankErr, ok := err.(*bank.BankError); ok {
-				return nil, bankErr.ToGRPCError()
-			}
-			return nil, bank.HandleBankError(err)
-		}
+// assessRisk scores the charge and logs the breakdown. It returns the
+// assessment, nil without a risk engine, or the error of a declined charge.
+func (s *PaymentServer) assessRisk(req risk.Request, cardLast4 string) (*risk.Assessment, error) {
+	if s.risk == nil {
+		return nil, nil
+	}
+
+	assessment := s.risk.Assess(req)
+	s.logger.Info("Risk assessment", map[string]interface{}{
+		"account":    req.Account,
+		"card_last4": cardLast4,
+		"amount":     req.Amount,
+		"anomaly":    req.Anomaly,
+		"score":      assessment.Score,
+		"outcome":    string(assessment.Outcome),
+		"reason":     assessment.Reason,
+		"rules":      assessment.Rules,
+	})
+	metrics.GetInstance().RecordRiskDecision(string(assessment.Outcome), assessment.Reason, assessment.Score)
+
+	switch assessment.Outcome {
+	case risk.OutcomeDecline:
+		return nil, assessment.ToGRPCError()
+	case risk.OutcomeReview:
+		s.logger.Warn("Charge flagged for manual review", map[string]interface{}{
+			"account": req.Account,
+			"score":   assessment.Score,
+		})
+	}
+	return assessment, nil
+}
+
+// executeReviewed runs the bank transfer of a charge approved in review
+func (s *PaymentServer) executeReviewed(ctx context.Context, record *transaction.Record) (transaction.Status, error) {
+	// The account or card may have been frozen while the charge waited
+	if err := s.checkBlocklist(blocklist.Charge{Payer: record.FromAccount, Payee: record.ToAccount, Card: record.CardFingerprint, BIN: record.BIN}); err != nil {
+		return "", err
+	}
+	txStatus, err := s.transfer(record)
+	if err != nil {
+		return "", err
+	}
+	metrics.GetInstance().RecordReviewDecision(record.Review.Decision)
+	s.observeCharge(risk.Request{
+		Account: record.FromAccount,
+		Card:    record.CardFingerprint,
+		Amount:  record.Amount,
+		Time:    time.Now(),
+	})
+	return txStatus, nil
+}
+
+// releaseReviewed declines a charge rejected in review or past its SLA. Parked
+// charges never reached the bank, so there is nothing to reverse.
+func (s *PaymentServer) releaseReviewed(ctx context.Context, record *transaction.Record) {
+	s.loggerFor(record.Tenant).LogTransaction(record.ID, record.FromAccount, record.ToAccount, record.Amount,
+		record.Currency, "Charge declined in review, no money moved")
+	metrics.GetInstance().RecordReviewDecision(record.Review.Decision)
+}
+
+// scoreAnomaly rates how unusual the charge is for the account's profile. It
+// returns nil while the account is still building its profile.
+func (s *PaymentServer) scoreAnomaly(req risk.Request) *float64 {
+	if s.profiles == nil {
+		return nil
+	}
 
-		s.logger.LogTransaction(transactionUUID, fromAccount, toAccount, cents,
-			req.Amount.CurrencyCode, "Bank transaction successful")
-		s.logger.LogPaymentResponse(ctx, transactionUUID, true, time.Since(start), nil)
+	anomaly, err := s.profiles.Score(req.Account, req.Card, req.Amount, req.Time)
+	if err != nil {
+		s.logger.Error("Failed to score charge against account profile", err, map[string]interface{}{"account": req.Account})
+		return nil
+	}
+	if anomaly.Learning {
+		return nil
+	}
 
-		// Record metrics
-		metrics.GetInstance().RecordRequest(true, time.Since(start), cents, getLastFourDigits(req.CreditCard.CreditCardNumber))
+	s.logger.Debug("Anomaly score", map[string]interface{}{
+		"account":   req.Account,
+		"score":     anomaly.Score,
+		"amount":    anomaly.Amount,
+		"hour":      anomaly.Hour,
+		"frequency": anomaly.Frequency,
+		"card":      anomaly.Card,
+	})
+	metrics.GetInstance().RecordAnomalyScore(anomaly.Score)
+	return &anomaly.Score
+}
 
-		// Use the transaction UUID as the response ID
-		response := &pb.ChargeResponse{
-			TransactionId: transactionUUID,
+// observeCharge tells the risk engine and the account's profile about a
+// completed charge
+func (s *PaymentServer) observeCharge(req risk.Request) {
+	if s.risk != nil {
+		s.risk.Observe(req)
+	}
+	if s.profiles != nil {
+		if err := s.profiles.Observe(req.Account, req.Card, req.Amount, req.Time); err != nil {
+			s.logger.Error("Failed to update account profile", err, map[string]interface{}{"account": req.Account})
 		}
-		return response, nil
-	} else {
-		// Fallback to simulation if Bank client is not available
-		s.logger.Warn("Bank client not available, simulating payment", nil)
-		s.transactionCounter++
-		transactionID := fmt.Sprintf("SIM-%d-%d", time.Now().Unix(), s.transactionCounter)
+	}
+}
+
+// callerID identifies the client making a request, see callerKey
+func callerID(ctx context.Context) string {
+	_, id, _ := callerKey(ctx, "", nil)
+	return id
+}
+
+// convertToLedger converts the charge amount into the ledger currency
+func (s *PaymentServer) convertToLedger(ctx context.Context, m *pb.Money) (*fx.Conversion, error) {
+	amount, err := money.FromProto(m)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid amount: %v", err)
+	}
+
+	conversion, err := s.fxConverter.Convert(ctx, amount, s.roundingPolicy)
+	if err == nil {
+		return conversion, nil
+	}
+
+	s.logger.Warn("Currency conversion failed", map[string]interface{}{
+		"currency":        amount.Currency,
+		"ledger_currency": s.fxConverter.LedgerCurrency(),
+		"error":           err.Error(),
+	})
+	switch {
+	case errors.Is(err, money.ErrInvalid), errors.Is(err, money.ErrOverflow), errors.Is(err, money.ErrInexact):
+		return nil, status.Errorf(codes.InvalidArgument, "invalid amount: %v", err)
+	case errors.Is(err, fx.ErrNoRate):
+		metrics.GetInstance().RecordError("fx_no_rate")
+		return nil, status.Errorf(codes.FailedPrecondition, "charges in %s are not supported", amount.Currency)
+	case errors.Is(err, fx.ErrStaleRate):
+		metrics.GetInstance().RecordError("fx_stale_rate")
+		return nil, status.Error(codes.Unavailable, "exchange rates are out of date, please try again later")
+	default:
+		metrics.GetInstance().RecordError("fx_provider_error")
+		return nil, status.Error(codes.Unavailable, "exchange rates are unavailable, please try again later")
+	}
+}
+
+// recordTransaction stores the charge and returns its details as response
+// metadata. The charge has already gone through, so a store failure is only logged.
+func (s *PaymentServer) recordTransaction(ctx context.Context, record *transaction.Record, conversion *fx.Conversion) {
+	if err := s.transactions.Put(record); err != nil {
+		s.logger.Error("Failed to record transaction", err, map[string]interface{}{"transaction_id": record.ID})
+	}
+	s.setChargeHeaders(ctx, record, conversion)
+}
+
+// setChargeHeaders returns the charge's status and conversion details as response metadata
+func (s *PaymentServer) setChargeHeaders(ctx context.Context, record *transaction.Record, conversion *fx.Conversion) {
+	header := metadata.Pairs(
+		"x-payment-status", string(record.Status),
+		"x-original-amount", conversion.Original.String(),
+		"x-ledger-amount", strconv.FormatInt(conversion.LedgerAmount, 10),
+		"x-ledger-currency", conversion.LedgerCurrency,
+	)
+	if conversion.FX != nil {
+		header.Append("x-fx-rate", conversion.FX.Rate)
+		header.Append("x-fx-applied-rate", conversion.FX.AppliedRate)
+		header.Append("x-fx-spread-bps", strconv.FormatInt(conversion.FX.SpreadBasisPoints, 10))
+		header.Append("x-fx-rate-timestamp", conversion.FX.RateTimestamp.UTC().Format(time.RFC3339))
+	}
+	if err := grpc.SetHeader(ctx, header); err != nil {
+		s.logger.Debug("Could not set response metadata", map[string]interface{}{"error": err.Error()})
+	}
+}
 
-		s.logger.LogPaymentResponse(ctx, transactionID, true, time.Since(start), nil)
+// bankClients returns the ledgers holding the sender's and the recipient's
+// accounts, which differ for cross-bank transfers
+func bankClients(router *bank.Router, fromRouting, toRouting string) (debit, credit *bank.Client, err error) {
+	debit, err = router.ClientFor(fromRouting)
+	if err != nil {
+		return nil, nil, err
+	}
+	credit, err = router.ClientFor(toRouting)
+	if err != nil {
+		return nil, nil, err
+	}
+	return debit, credit, nil
+}
+
+// creditRecipientBank records a cross-bank transfer in the recipient's ledger as
+// a deposit from an external account. The sender has already been debited, so
+// a failure here is logged for reconciliation rather than failing the charge.
+func (s *PaymentServer) creditRecipientBank(client *bank.Client, bankReq *bank.TransactionRequest) {
+	bankStart := time.Now()
+	_, err := client.CreateDeposit(bankReq)
+	s.logger.LogBankAPICall(bankReq.UUID, bankReq.ToAccountNum, bankReq.Amount, time.Since(bankStart), err)
+
+	if err != nil {
+		s.logger.Error("Cross-bank credit failed, transfer needs reconciliation", err, map[string]interface{}{
+			"transaction_id": bankReq.UUID,
+			"to_account":     bankReq.ToAccountNum,
+			"to_routing":     bankReq.ToRoutingNum,
+			"bank_api_url":   client.BaseURL(),
+		})
+		metrics.GetInstance().RecordError("bank_credit_error")
+	}
+}
+
+// resolveCard maps the request's card token or card number to the account it
+// debits, along with the card's last four digits for logging and its BIN
+func (s *PaymentServer) resolveCard(req *pb.ChargeRequest) (*resolvedCard, error) {
+	hasCardNumber := req.CreditCard != nil && req.CreditCard.CreditCardNumber != ""
 
-		response := &pb.ChargeResponse{
-			TransactionId: transactionID,
+	switch {
+	case req.CardToken != "" && hasCardNumber:
+		return nil, status.Error(codes.InvalidArgument, "provide either credit card info or a card token, not both")
+	case req.CardToken != "":
+		if s.vault == nil {
+			return nil, status.Error(codes.FailedPrecondition, "card tokenization is not enabled")
+		}
+		info, err := s.vault.Lookup(req.CardToken)
+		if err != nil {
+			s.logger.Warn("Unknown card token", map[string]interface{}{"error": err.Error()})
+			return nil, mappingError(err)
+		}
+		if err := s.validateTokenizedCard(info); err != nil {
+			return nil, err
+		}
+		res, err := s.currentMapper().ResolveToken(req.CardToken)
+		recordMapping(res)
+		if err != nil {
+			return nil, s.cardMappingFailed(info.LastFour, err)
 		}
-		return response, nil
+		return &resolvedCard{req: req, resolution: res, cardLast4: info.LastFour, bin: info.BIN}, nil
+	case req.CreditCard == nil:
+		return nil, status.Error(codes.InvalidArgument, "credit card info is required")
+	}
+
+	// Validate card number, brand, expiry and CVV
+	if _, err := s.cardValidator.Validate(cardFromProto(req.CreditCard)); err != nil {
+		s.logger.Warn("Invalid card", map[string]interface{}{"error": err.Error()})
+		return nil, cardValidationError(err)
+	}
+
+	cardLast4 := getLastFourDigits(req.CreditCard.CreditCardNumber)
+	res, err := s.currentMapper().Resolve(req.CreditCard.CreditCardNumber)
+	recordMapping(res)
+	if err != nil {
+		return nil, s.cardMappingFailed(cardLast4, err)
+	}
+	return &resolvedCard{req: req, resolution: res, cardLast4: cardLast4, bin: getBIN(req.CreditCard.CreditCardNumber)}, nil
+}
+
+// validateTokenizedCard re-checks a token's brand and expiry, which may have
+// changed since the card was tokenized. The CVV was checked at tokenization.
+func (s *PaymentServer) validateTokenizedCard(info *vault.TokenInfo) error {
+	err := s.cardValidator.CheckBrand(card.Brand(info.Brand))
+	if err == nil {
+		err = s.cardValidator.ValidateExpiry(info.ExpirationYear, info.ExpirationMonth)
+	}
+	if err != nil {
+		s.logger.Warn("Invalid tokenized card", map[string]interface{}{"card_last4": info.LastFour, "error": err.Error()})
+		return cardValidationError(err)
+	}
+	return nil
+}
+
+// cardFromProto converts the request's card info for validation
+func cardFromProto(info *pb.CreditCardInfo) card.Card {
+	return card.Card{
+		Number:          info.CreditCardNumber,
+		CVV:             info.CreditCardCvv,
+		ExpirationYear:  info.CreditCardExpirationYear,
+		ExpirationMonth: info.CreditCardExpirationMonth,
+	}
+}
+
+// cardValidationError converts a card validation failure into an InvalidArgument
+// error whose ErrorInfo reason names the failed check
+func cardValidationError(err error) error {
+	var validationErr *card.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.ToGRPCError()
+	}
+	return status.Errorf(codes.InvalidArgument, "invalid card: %v", err)
+}
+
+// recordMapping counts the outcome of every strategy tried for a card
+func recordMapping(res *mapper.Resolution) {
+	for _, result := range res.Trace {
+		metrics.GetInstance().RecordMapping(result.Strategy, result.Outcome)
+	}
+}
+
+// cardMappingFailed logs and records a card that could not be mapped to an account
+func (s *PaymentServer) cardMappingFailed(cardLast4 string, err error) error {
+	s.logger.Warn("Card could not be mapped to an account", map[string]interface{}{
+		"card_last4": cardLast4,
+		"error":      err.Error(),
+	})
+	metrics.GetInstance().RecordError("card_mapping_error")
+	return mappingError(err)
+}
+
+// Tokenize stores a card in the vault and returns a token to charge in its place
+func (s *PaymentServer) Tokenize(ctx context.Context, req *pb.TokenizeRequest) (*pb.TokenizeResponse, error) {
+	if _, err := s.tenantFor(ctx); err != nil {
+		return nil, err
+	}
+	if s.vault == nil {
+		return nil, status.Error(codes.FailedPrecondition, "card tokenization is not enabled")
+	}
+	if req.CreditCard == nil {
+		return nil, status.Error(codes.InvalidArgument, "credit card info is required")
+	}
+	brand, err := s.cardValidator.Validate(cardFromProto(req.CreditCard))
+	if err != nil {
+		s.logger.Warn("Invalid card", map[string]interface{}{"error": err.Error()})
+		return nil, cardValidationError(err)
+	}
+
+	info, err := s.vault.Tokenize(vault.Card{
+		Number:          req.CreditCard.CreditCardNumber,
+		Brand:           string(brand),
+		ExpirationYear:  req.CreditCard.CreditCardExpirationYear,
+		ExpirationMonth: req.CreditCard.CreditCardExpirationMonth,
+	})
+	if err != nil {
+		s.logger.Error("Failed to tokenize card", err, nil)
+		return nil, status.Error(codes.Internal, "failed to tokenize card")
+	}
+
+	s.logger.Info("Card tokenized", map[string]interface{}{"card_last4": info.LastFour})
+	return &pb.TokenizeResponse{CardToken: info.Token, LastFour: info.LastFour}, nil
+}
+
+// mappingError converts a card mapping failure into a gRPC error
+func mappingError(err error) error {
+	switch {
+	case errors.Is(err, mapper.ErrCardNotLinked):
+		return status.Error(codes.NotFound, "card is not linked to a bank account")
+	case errors.Is(err, mapper.ErrCardSuspended):
+		return status.Error(codes.PermissionDenied, "card is suspended")
+	case errors.Is(err, vault.ErrTokenNotFound):
+		return status.Error(codes.NotFound, "card token not found")
+	case errors.Is(err, vault.ErrInvalidToken):
+		return status.Error(codes.InvalidArgument, "malformed card token")
+	case errors.Is(err, mapper.ErrNoVault):
+		return status.Error(codes.FailedPrecondition, "card tokenization is not enabled")
+	default:
+		return status.Error(codes.Internal, "failed to resolve card account")
 	}
 }
 
@@ -262,3 +1979,17 @@ func getLastFourDigits(cardNumber string) string {
 	}
 	return "****"
 }
+
+// getBIN returns the first six digits of a card number, ignoring spaces and dashes
+func getBIN(cardNumber string) string {
+	var digits strings.Builder
+	for _, ch := range cardNumber {
+		if ch >= '0' && ch <= '9' {
+			digits.WriteRune(ch)
+			if digits.Len() == 6 {
+				return digits.String()
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,723 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/bank"
+	"github.com/gke-hackathon/payment-integration/card"
+	"github.com/gke-hackathon/payment-integration/config"
+	"github.com/gke-hackathon/payment-integration/duplicate"
+	"github.com/gke-hackathon/payment-integration/fx"
+	"github.com/gke-hackathon/payment-integration/logging"
+	"github.com/gke-hackathon/payment-integration/mapper"
+	"github.com/gke-hackathon/payment-integration/middleware"
+	"github.com/gke-hackathon/payment-integration/money"
+	pb "github.com/gke-hackathon/payment-integration/proto"
+	"github.com/gke-hackathon/payment-integration/risk"
+	"github.com/gke-hackathon/payment-integration/spendcap"
+	"github.com/gke-hackathon/payment-integration/tenant"
+	"github.com/gke-hackathon/payment-integration/transaction"
+	"github.com/gke-hackathon/payment-integration/vault"
+	"github.com/gke-hackathon/payment-integration/velocity"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestPaymentServer(t *testing.T) *PaymentServer {
+	logger := logging.NewLogger("test")
+
+	cardVault, err := vault.New([]byte("0123456789abcdef0123456789abcdef"), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountMapper := mapper.NewAccountMapper("9999999999", "883745000")
+	accountMapper.EnableLegacyLastTen()
+	accountMapper.UseDetokenizer(vault.NewDetokenizer(cardVault, "mapper", logger))
+
+	return &PaymentServer{
+		accountMapper: accountMapper,
+		vault:         cardVault,
+		cardValidator: card.NewValidator([]card.Brand{card.BrandVisa, card.BrandMastercard}),
+		fxConverter:   testFXConverter(t, nil),
+		transactions:  transaction.NewMemoryStore(),
+		logger:        logger,
+	}
+}
+
+// testFXConverter returns a converter into USD with a 1% spread
+func testFXConverter(t *testing.T, provider fx.RateProvider) *fx.Converter {
+	converter, err := fx.NewConverter(provider, "USD", time.Hour, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return converter
+}
+
+// testCard returns a valid, unexpired Visa card
+func testCard() *pb.CreditCardInfo {
+	return &pb.CreditCardInfo{
+		CreditCardNumber:          "4532015112830366",
+		CreditCardCvv:             123,
+		CreditCardExpirationYear:  int32(time.Now().Year() + 2),
+		CreditCardExpirationMonth: 1,
+	}
+}
+
+func TestChargeWithCardToken(t *testing.T) {
+	s := newTestPaymentServer(t)
+	ctx := context.Background()
+
+	tokenized, err := s.Tokenize(ctx, &pb.TokenizeRequest{CreditCard: testCard()})
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if tokenized.LastFour != "0366" {
+		t.Errorf("Expected last four 0366, got %s", tokenized.LastFour)
+	}
+
+	amount := &pb.Money{CurrencyCode: "USD", Units: 10}
+	if _, err := s.Charge(ctx, &pb.ChargeRequest{Amount: amount, CardToken: tokenized.CardToken}); err != nil {
+		t.Errorf("Charge with token failed: %v", err)
+	}
+
+	_, err = s.Charge(ctx, &pb.ChargeRequest{Amount: amount, CardToken: "tok_unknown"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for unknown token, got %v", err)
+	}
+
+	_, err = s.Charge(ctx, &pb.ChargeRequest{
+		Amount:     amount,
+		CardToken:  tokenized.CardToken,
+		CreditCard: testCard(),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for token and card number, got %v", err)
+	}
+}
+
+func TestChargeCardValidationReasons(t *testing.T) {
+	s := newTestPaymentServer(t)
+	amount := &pb.Money{CurrencyCode: "USD", Units: 10}
+
+	expired := testCard()
+	expired.CreditCardExpirationYear = 2020
+
+	badCVV := testCard()
+	badCVV.CreditCardCvv = 1234
+
+	badChecksum := testCard()
+	badChecksum.CreditCardNumber = "4532015112830367"
+
+	amex := &pb.CreditCardInfo{
+		CreditCardNumber:          "378282246310005",
+		CreditCardCvv:             1234,
+		CreditCardExpirationYear:  int32(time.Now().Year() + 2),
+		CreditCardExpirationMonth: 1,
+	}
+
+	tests := []struct {
+		name   string
+		card   *pb.CreditCardInfo
+		reason string
+	}{
+		{"Expired", expired, card.ReasonExpired},
+		{"CVV too long", badCVV, card.ReasonInvalidCVV},
+		{"Luhn failure", badChecksum, card.ReasonLuhn},
+		{"Brand not accepted", amex, card.ReasonBrandNotAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Charge(context.Background(), &pb.ChargeRequest{Amount: amount, CreditCard: tt.card})
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("Expected InvalidArgument, got %v", err)
+			}
+			var reason string
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.ErrorInfo); ok {
+					reason = info.Reason
+				}
+			}
+			if reason != tt.reason {
+				t.Errorf("Expected reason %s, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestChargeSubCentAmount(t *testing.T) {
+	s := newTestPaymentServer(t)
+	amount := &pb.Money{CurrencyCode: "USD", Units: 10, Nanos: 5_000_000}
+
+	s.roundingPolicy = money.RoundReject
+	_, err := s.Charge(context.Background(), &pb.ChargeRequest{Amount: amount, CreditCard: testCard()})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for sub-cent amount, got %v", err)
+	}
+
+	s.roundingPolicy = money.RoundHalfEven
+	if _, err := s.Charge(context.Background(), &pb.ChargeRequest{Amount: amount, CreditCard: testCard()}); err != nil {
+		t.Errorf("Expected sub-cent amount to be rounded, got %v", err)
+	}
+}
+
+// fixedRateProvider quotes one rate for every currency pair
+type fixedRateProvider struct {
+	rate      string
+	timestamp time.Time
+}
+
+func (p fixedRateProvider) Quote(ctx context.Context, from, to string) (fx.Quote, error) {
+	rate, err := fx.ParseRate(p.rate)
+	if err != nil {
+		return fx.Quote{}, err
+	}
+	return fx.Quote{From: from, To: to, Rate: rate, Timestamp: p.timestamp, Source: "test"}, nil
+}
+
+// headerStream captures the response headers set by a handler
+type headerStream struct {
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "/hipstershop.PaymentService/Charge" }
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
+
+func TestChargeConvertsCurrency(t *testing.T) {
+	s := newTestPaymentServer(t)
+	rateTime := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	s.fxConverter = testFXConverter(t, fixedRateProvider{rate: "1.08", timestamp: rateTime})
+
+	stream := &headerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+	// EUR 10.00 at 1.08 plus a 1% spread is USD 10.908, rounded half to even
+	resp, err := s.Charge(ctx, &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "EUR", Units: 10}, CreditCard: testCard()})
+	if err != nil {
+		t.Fatalf("Charge failed: %v", err)
+	}
+
+	record, err := s.transactions.Get(resp.TransactionId)
+	if err != nil {
+		t.Fatalf("Transaction was not recorded: %v", err)
+	}
+	if record.Amount != 1091 || record.Currency != "USD" {
+		t.Errorf("Expected 1091 USD cents, got %d %s", record.Amount, record.Currency)
+	}
+	if record.Original != (money.Amount{Currency: "EUR", Units: 10}) {
+		t.Errorf("Expected original EUR 10, got %v", record.Original)
+	}
+	if record.FX == nil || record.FX.Rate != "1.08" || record.FX.AppliedRate != "1.0908" || !record.FX.RateTimestamp.Equal(rateTime) {
+		t.Errorf("Unexpected FX details %+v", record.FX)
+	}
+
+	expectedHeaders := map[string]string{
+		"x-original-amount":   "EUR 10",
+		"x-ledger-amount":     "1091",
+		"x-ledger-currency":   "USD",
+		"x-fx-rate":           "1.08",
+		"x-fx-applied-rate":   "1.0908",
+		"x-fx-spread-bps":     "100",
+		"x-fx-rate-timestamp": rateTime.Format(time.RFC3339),
+	}
+	for key, expected := range expectedHeaders {
+		if got := stream.header.Get(key); len(got) != 1 || got[0] != expected {
+			t.Errorf("Expected header %s=%s, got %v", key, expected, got)
+		}
+	}
+}
+
+func TestChargeCurrencyErrors(t *testing.T) {
+	s := newTestPaymentServer(t)
+	euros := &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "EUR", Units: 10}, CreditCard: testCard()}
+
+	// No rate provider: only the ledger currency can be charged
+	if _, err := s.Charge(context.Background(), euros); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without rates, got %v", err)
+	}
+
+	s.fxConverter = testFXConverter(t, fixedRateProvider{rate: "1.08", timestamp: time.Now().Add(-2 * time.Hour)})
+	if _, err := s.Charge(context.Background(), euros); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable for a stale rate, got %v", err)
+	}
+
+	unknown := &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "XYZ", Units: 10}, CreditCard: testCard()}
+	if _, err := s.Charge(context.Background(), unknown); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an unknown currency, got %v", err)
+	}
+}
+
+func TestChargeVelocityRuleRejection(t *testing.T) {
+	s := newTestPaymentServer(t)
+	checker, err := velocity.NewChecker(&velocity.Policy{Rules: []velocity.Rule{{
+		Name:      "card-per-minute",
+		Dimension: velocity.DimensionCard,
+		Window:    velocity.Window(time.Minute),
+		MaxCount:  1,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.velocity = checker
+
+	req := &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: 10}, CreditCard: testCard()}
+	if _, err := s.Charge(context.Background(), req); err != nil {
+		t.Fatalf("First charge failed: %v", err)
+	}
+
+	// The same card again, this time by token, is caught by its fingerprint
+	tokenized, err := s.Tokenize(context.Background(), &pb.TokenizeRequest{CreditCard: testCard()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Charge(context.Background(), &pb.ChargeRequest{Amount: req.Amount, CardToken: tokenized.CardToken})
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted, got %v", err)
+	}
+	var rule string
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Reason == velocity.ReasonVelocityLimit {
+			rule = info.Metadata["rule"]
+		}
+	}
+	if rule != "card-per-minute" {
+		t.Errorf("Expected the rejection to name card-per-minute, got %q", rule)
+	}
+}
+
+func TestChargeRateLimitInterceptor(t *testing.T) {
+	s := newTestPaymentServer(t)
+	s.rateLimiter = middleware.NewTokenBucket(middleware.BucketConfig{Rate: 1, Burst: 1})
+	defer s.rateLimiter.Stop()
+	interceptor := s.UnaryInterceptor()
+
+	info := &grpc.UnaryServerInfo{FullMethod: pb.PaymentService_Charge_FullMethodName}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Charge(ctx, req.(*pb.ChargeRequest))
+	}
+	charge := func() (*headerStream, error) {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		_, err := interceptor(ctx, &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: 10}, CreditCard: testCard()}, info, handler)
+		return stream, err
+	}
+
+	stream, err := charge()
+	if err != nil {
+		t.Fatalf("First charge failed: %v", err)
+	}
+	if got := stream.header.Get(middleware.HeaderRemaining); len(got) != 1 || got[0] != "0" {
+		t.Errorf("Expected 0 remaining, got %v", got)
+	}
+
+	_, err = charge()
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted, got %v", err)
+	}
+
+	// Mapping failures surface from the interceptor as they would from Charge
+	bad := &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: 10}}
+	if _, err := interceptor(context.Background(), bad, info, handler); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a card, got %v", err)
+	}
+}
+
+func TestChargeRiskOutcomes(t *testing.T) {
+	s := newTestPaymentServer(t)
+	engine, err := risk.NewEngine(&risk.Policy{
+		ReviewAt:    10,
+		Amount:      []risk.AmountThreshold{{AboveCents: 5000, Score: 10}},
+		BlockedBINs: []string{"453201"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.risk = engine
+	amount := &pb.Money{CurrencyCode: "USD", Units: 10}
+
+	// The BIN is blocked whether the card arrives as a number or a token
+	tokenized, err := s.Tokenize(context.Background(), &pb.TokenizeRequest{CreditCard: testCard()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range []*pb.ChargeRequest{
+		{Amount: amount, CreditCard: testCard()},
+		{Amount: amount, CardToken: tokenized.CardToken},
+	} {
+		_, err := s.Charge(context.Background(), req)
+		st := status.Convert(err)
+		if st.Code() != codes.PermissionDenied {
+			t.Fatalf("Expected PermissionDenied, got %v", err)
+		}
+		var reason string
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.ErrorInfo); ok {
+				reason = info.Reason
+			}
+		}
+		if reason != risk.ReasonBINBlocked {
+			t.Errorf("Expected reason %s, got %q", risk.ReasonBINBlocked, reason)
+		}
+	}
+
+	// Flagged charges still go through, with the assessment recorded
+	engine, err = risk.NewEngine(&risk.Policy{ReviewAt: 10, Amount: []risk.AmountThreshold{{AboveCents: 5000, Score: 10}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.risk = engine
+	resp, err := s.Charge(context.Background(), &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: 75}, CreditCard: testCard()})
+	if err != nil {
+		t.Fatalf("Expected the flagged charge to go through, got %v", err)
+	}
+	record, err := s.transactions.Get(resp.TransactionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Risk == nil || record.Risk.Outcome != risk.OutcomeReview || record.Risk.Score != 10 {
+		t.Errorf("Expected a recorded review assessment, got %+v", record.Risk)
+	}
+}
+
+func TestChargeDuplicateGuard(t *testing.T) {
+	s := newTestPaymentServer(t)
+	charge := func(units int64) (*pb.ChargeResponse, metadata.MD, error) {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		resp, err := s.Charge(ctx, &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: units}, CreditCard: testCard()})
+		return resp, stream.header, err
+	}
+
+	// A charge that fails doesn't count, so its retry goes through
+	engine, err := risk.NewEngine(&risk.Policy{BlockedBINs: []string{"453201"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.risk = engine
+	s.duplicates = duplicate.NewGuard(time.Minute, duplicate.ActionReturnFirst)
+	if _, _, err := charge(10); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("Expected the blocked charge to fail, got %v", err)
+	}
+	s.risk = nil
+
+	first, _, err := charge(10)
+	if err != nil {
+		t.Fatalf("Charge failed: %v", err)
+	}
+	second, header, err := charge(10)
+	if err != nil || second.TransactionId != first.TransactionId {
+		t.Fatalf("Expected the duplicate to return %s, got %v (%v)", first.TransactionId, second, err)
+	}
+	if got := header.Get("x-duplicate-of"); len(got) != 1 || got[0] != first.TransactionId {
+		t.Errorf("Expected x-duplicate-of %s, got %v", first.TransactionId, got)
+	}
+	if got := header.Get("x-payment-status"); len(got) != 1 || got[0] != "simulated" {
+		t.Errorf("Expected the original's x-payment-status, got %v", got)
+	}
+
+	// A different amount is a new charge
+	third, _, err := charge(11)
+	if err != nil || third.TransactionId == first.TransactionId {
+		t.Fatalf("Expected a new charge for a different amount, got %v (%v)", third, err)
+	}
+
+	s.duplicates = duplicate.NewGuard(time.Minute, duplicate.ActionReject)
+	if _, _, err := charge(10); err != nil {
+		t.Fatalf("Charge failed: %v", err)
+	}
+	if _, _, err := charge(10); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists for a rejected duplicate, got %v", err)
+	}
+}
+
+func TestTokenizeWithoutVault(t *testing.T) {
+	s := &PaymentServer{accountMapper: mapper.NewAccountMapper("", ""), logger: logging.NewLogger("test")}
+
+	_, err := s.Tokenize(context.Background(), &pb.TokenizeRequest{CreditCard: testCard()})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %v", err)
+	}
+}
+
+// fakeLedger records the transactions posted to a bank backend
+type fakeLedger struct {
+	mu           sync.Mutex
+	transactions []bank.TransactionRequest
+}
+
+func (l *fakeLedger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req bank.TransactionRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	l.mu.Lock()
+	l.transactions = append(l.transactions, req)
+	l.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+}
+
+type staticAuthenticator struct{}
+
+func (staticAuthenticator) GetAuthHeader(accountNumber string) (string, error) {
+	return "Bearer " + accountNumber, nil
+}
+
+func TestChargeRoutesAcrossBanks(t *testing.T) {
+	anthos, other := &fakeLedger{}, &fakeLedger{}
+	anthosServer, otherServer := httptest.NewServer(anthos), httptest.NewServer(other)
+	defer anthosServer.Close()
+	defer otherServer.Close()
+
+	validator := bank.NewRoutingValidator("883745000")
+	router := bank.NewRouter(validator)
+	router.AddRoute("883745000", bank.NewClient(anthosServer.URL, staticAuthenticator{}))
+	router.AddRoute("021000021", bank.NewClient(otherServer.URL, staticAuthenticator{}))
+
+	hasher, _ := mapper.NewCardHasher([]byte("0123456789abcdef0123456789abcdef"))
+	accountMapper := mapper.NewAccountMapper("9999999999", "021000021")
+	accountMapper.UseLinkStore(mapper.NewMemoryLinkStore(), hasher)
+	accountMapper.UseRoutingValidator(validator)
+	accountMapper.LinkCard("4532015112830366", "1011226111", "883745000", "")
+	accountMapper.LinkCard("5555555555554444", "1033623433", "021000021", "")
+
+	s := &PaymentServer{
+		accountMapper: accountMapper,
+		bankRouter:    router,
+		cardValidator: card.NewValidator(nil),
+		fxConverter:   testFXConverter(t, nil),
+		transactions:  transaction.NewMemoryStore(),
+		logger:        logging.NewLogger("test"),
+	}
+	amount := &pb.Money{CurrencyCode: "USD", Units: 10}
+
+	// Customer at Bank of Anthos paying the merchant at the other bank: debit and credit legs
+	if _, err := s.Charge(context.Background(), &pb.ChargeRequest{Amount: amount, CreditCard: testCard()}); err != nil {
+		t.Fatalf("Cross-bank charge failed: %v", err)
+	}
+	if len(anthos.transactions) != 1 || len(other.transactions) != 1 {
+		t.Fatalf("Expected one transaction at each bank, got %d and %d", len(anthos.transactions), len(other.transactions))
+	}
+	if tx := anthos.transactions[0]; tx.FromRoutingNum != "883745000" || tx.ToRoutingNum != "021000021" || tx.ToAccountNum != "9999999999" {
+		t.Errorf("Unexpected debit leg: %+v", tx)
+	}
+	if anthos.transactions[0].UUID != other.transactions[0].UUID {
+		t.Error("Expected both legs to share the transaction UUID")
+	}
+
+	// Customer at the merchant's bank: a single same-bank transaction
+	mastercard := testCard()
+	mastercard.CreditCardNumber = "5555555555554444"
+	if _, err := s.Charge(context.Background(), &pb.ChargeRequest{Amount: amount, CreditCard: mastercard}); err != nil {
+		t.Fatalf("Same-bank charge failed: %v", err)
+	}
+	if len(anthos.transactions) != 1 || len(other.transactions) != 2 {
+		t.Errorf("Expected only the other bank to be called, got %d and %d", len(anthos.transactions), len(other.transactions))
+	}
+}
+
+func TestChargeWithoutBankRoute(t *testing.T) {
+	router := bank.NewRouter(bank.NewRoutingValidator())
+	router.AddRoute("021000021", bank.NewClient("http://unused", staticAuthenticator{}))
+
+	accountMapper := mapper.NewAccountMapper("9999999999", "021000021")
+	hasher, _ := mapper.NewCardHasher([]byte("0123456789abcdef0123456789abcdef"))
+	accountMapper.UseLinkStore(mapper.NewMemoryLinkStore(), hasher)
+	accountMapper.LinkCard("4532015112830366", "1011226111", "011000015", "")
+
+	s := &PaymentServer{
+		accountMapper: accountMapper,
+		bankRouter:    router,
+		cardValidator: card.NewValidator(nil),
+		fxConverter:   testFXConverter(t, nil),
+		transactions:  transaction.NewMemoryStore(),
+		logger:        logging.NewLogger("test"),
+	}
+
+	_, err := s.Charge(context.Background(), &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: 1}, CreditCard: testCard()})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for unrouted bank, got %v", err)
+	}
+}
+
+func TestChargeTenants(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.yaml")
+	if err := os.WriteFile(path, []byte(`
+tenants:
+  - id: boutique-eu
+    callers: [frontend-eu]
+    merchant_account: "2222222222"
+    routing_number: "883745000"
+    rate_limit: "1/m:2"
+    spend_caps:
+      daily_cents: 1500
+  - id: boutique-us
+    merchant_account: "3333333333"
+    routing_number: "883745000"
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.Tenants.Path = path
+
+	s := newTestPaymentServer(t)
+	registry, tenants, err := newTenants(cfg, nil, s.logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.tenantRegistry, s.tenants = registry, tenants
+	s.tenantLimiter = newTenantLimiter(s.tenantRegistry, s.logger)
+	defer s.tenantLimiter.Stop()
+	if s.spendCaps, err = newSpendCaps(cfg.SpendCaps, s.tenantRegistry, s.logger); err != nil {
+		t.Fatal(err)
+	}
+	interceptor := s.UnaryInterceptor()
+
+	info := &grpc.UnaryServerInfo{FullMethod: pb.PaymentService_Charge_FullMethodName}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Charge(ctx, req.(*pb.ChargeRequest))
+	}
+	charge := func(md metadata.MD) (*transaction.Record, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		resp, err := interceptor(ctx, &pb.ChargeRequest{Amount: &pb.Money{CurrencyCode: "USD", Units: 10}, CreditCard: testCard()}, info, handler)
+		if err != nil {
+			return nil, err
+		}
+		return s.transactions.Get(resp.(*pb.ChargeResponse).TransactionId)
+	}
+	reason := func(err error) string {
+		for _, detail := range status.Convert(err).Details() {
+			if info, ok := detail.(*errdetails.ErrorInfo); ok {
+				return info.Reason
+			}
+		}
+		return ""
+	}
+
+	// The caller or the x-tenant-id header picks the tenant, which is paid
+	record, err := charge(metadata.Pairs(callerIDHeader, "frontend-eu"))
+	if err != nil || record.Tenant != "boutique-eu" || record.ToAccount != "2222222222" {
+		t.Fatalf("Expected a charge paid to boutique-eu, got %+v (%v)", record, err)
+	}
+	record, err = charge(metadata.Pairs(tenantIDHeader, "boutique-us"))
+	if err != nil || record.Tenant != "boutique-us" || record.ToAccount != "3333333333" {
+		t.Fatalf("Expected a charge paid to boutique-us, got %+v (%v)", record, err)
+	}
+
+	rejected := []struct {
+		md     metadata.MD
+		reason string
+	}{
+		{metadata.Pairs(tenantIDHeader, "boutique-apac"), tenant.ReasonUnknownTenant},
+		{metadata.Pairs(callerIDHeader, "frontend-apac"), tenant.ReasonUnknownTenant},
+		{metadata.Pairs(callerIDHeader, "frontend-eu", tenantIDHeader, "boutique-us"), tenant.ReasonTenantMismatch},
+	}
+	for _, tt := range rejected {
+		_, err := charge(tt.md)
+		if status.Code(err) != codes.PermissionDenied || reason(err) != tt.reason {
+			t.Errorf("%v: expected PermissionDenied %s, got %v", tt.md, tt.reason, err)
+		}
+	}
+	if _, err := s.Tokenize(metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantIDHeader, "boutique-apac")),
+		&pb.TokenizeRequest{CreditCard: testCard()}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected tokenizing for an unknown tenant to be rejected, got %v", err)
+	}
+
+	// boutique-eu has its own spend cap and rate limit; boutique-us has neither
+	if _, err := charge(metadata.Pairs(callerIDHeader, "frontend-eu")); reason(err) != spendcap.ReasonSpendCapExceeded {
+		t.Errorf("Expected boutique-eu's spend cap to be exceeded, got %v", err)
+	}
+	if _, err := charge(metadata.Pairs(callerIDHeader, "frontend-eu")); reason(err) != middleware.ReasonRateLimited {
+		t.Errorf("Expected boutique-eu's rate limit to be exceeded, got %v", err)
+	}
+	if _, err := charge(metadata.Pairs(tenantIDHeader, "boutique-us")); err != nil {
+		t.Errorf("Expected boutique-us to be unaffected, got %v", err)
+	}
+}
+
+func TestNewPaymentServerInvalidFiles(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Config)
+	}{
+		{"velocity policy", func(cfg *config.Config) { cfg.Velocity.PolicyPath = missing }},
+		{"risk policy", func(cfg *config.Config) { cfg.Risk.PolicyPath = missing }},
+		{"spend cap policy", func(cfg *config.Config) { cfg.SpendCaps.PolicyPath = missing }},
+		{"tenants", func(cfg *config.Config) { cfg.Tenants.Path = missing }},
+		{"bank routes", func(cfg *config.Config) { cfg.Bank.RoutesPath = missing }},
+		{"mapping strategies", func(cfg *config.Config) { cfg.Mapper.StrategiesPath = missing }},
+		{"vault master key", func(cfg *config.Config) { cfg.Vault.MasterKey = "not base64!" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Auth.PrivateKeyPath = missing
+			tt.modify(cfg)
+			if s, err := NewPaymentServer(cfg, logging.NewLogger("test")); err == nil {
+				t.Errorf("Expected an error, got %v", s)
+			}
+		})
+	}
+}
+
+func TestApplyConfigReusesBankClients(t *testing.T) {
+	var healthChecks int
+	var mu sync.Mutex
+	bankAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		healthChecks++
+		mu.Unlock()
+	}))
+	defer bankAPI.Close()
+	checks := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return healthChecks
+	}
+
+	s := newTestPaymentServer(t)
+	s.baseMapper = s.accountMapper
+	s.bankAuth = staticAuthenticator{}
+	cfg := config.Default()
+	cfg.Bank.APIURL = bankAPI.URL
+
+	apply := func() *bank.Client {
+		t.Helper()
+		if err := s.ApplyConfig(cfg); err != nil {
+			t.Fatal(err)
+		}
+		client, err := s.currentRouter().ClientFor(cfg.Merchant.RoutingNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	first := apply()
+	cfg.Merchant.Account = "2222222222"
+	if second := apply(); second != first || checks() != 1 {
+		t.Errorf("Expected the unchanged bank's client to be reused, got %d health checks", checks())
+	}
+	cfg.Bank.APIURL = bankAPI.URL + "/v2"
+	if third := apply(); third == first || third.BaseURL() != cfg.Bank.APIURL || checks() != 2 {
+		t.Errorf("Expected a new client for the changed bank, got %s after %d health checks", third.BaseURL(), checks())
+	}
+}
@@ -0,0 +1,256 @@
+package spendcap
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "payment-integration"
+
+// ReasonSpendCapExceeded is the ErrorInfo reason of a charge over a spend cap
+const ReasonSpendCapExceeded = "SPEND_CAP_EXCEEDED"
+
+const (
+	dayLayout   = "2006-01-02"
+	monthLayout = "2006-01"
+)
+
+// Breach describes the cap a charge would have exceeded
+type Breach struct {
+	Scope   Scope
+	Account string
+	Period  Period
+	// Cap and Remaining are in ledger cents
+	Cap       int64
+	Remaining int64
+	ResetsAt  time.Time
+}
+
+// Error implements the error interface
+func (b *Breach) Error() string {
+	return fmt.Sprintf("%s %s spend cap of %d cents exceeded for %s, %d remaining",
+		b.Scope, b.Period, b.Cap, b.Account, b.Remaining)
+}
+
+// ToGRPCError converts a Breach to a FailedPrecondition status. The account is
+// left out so a customer never learns the merchant's account number.
+func (b *Breach) ToGRPCError() error {
+	st := status.New(codes.FailedPrecondition, fmt.Sprintf("the payment exceeds the %s %s spend cap", b.Scope, b.Period))
+	info := &errdetails.ErrorInfo{
+		Reason: ReasonSpendCapExceeded,
+		Domain: errorDomain,
+		Metadata: map[string]string{
+			"scope":           string(b.Scope),
+			"period":          string(b.Period),
+			"cap_cents":       strconv.FormatInt(b.Cap, 10),
+			"remaining_cents": strconv.FormatInt(b.Remaining, 10),
+			"resets_at":       b.ResetsAt.UTC().Format(time.RFC3339),
+		},
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Window is an account's standing against one cap
+type Window struct {
+	// Cap is 0 when the period is uncapped
+	Cap       int64
+	Spent     int64
+	Remaining int64
+	ResetsAt  time.Time
+}
+
+// Allowance is an account's standing against its daily and monthly caps
+type Allowance struct {
+	Scope   Scope
+	Account string
+	Daily   Window
+	Monthly Window
+}
+
+// Reservation is the spend counted for a charge, so it can be released if the
+// charge fails
+type Reservation struct {
+	Customer string
+	Merchant string
+	Amount   int64
+	day      string
+	month    string
+}
+
+// Caps enforces a spend cap policy over persisted usage
+type Caps struct {
+	policy   *Policy
+	location *time.Location
+	store    Store
+	now      func() time.Time
+
+	// mu makes checking and counting a charge atomic
+	mu sync.Mutex
+}
+
+// NewCaps creates caps for a validated policy over store
+func NewCaps(policy *Policy, store Store) (*Caps, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	location, _ := time.LoadLocation(policy.Timezone)
+	return &Caps{policy: policy, location: location, store: store, now: time.Now}, nil
+}
+
+// SetClock overrides the time source, for tests
+func (c *Caps) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// Reserve counts a charge against the customer's and the merchant's caps. If
+// either cap would be exceeded nothing is counted and a *Breach is returned.
+func (c *Caps) Reserve(customer, merchant string, amount int64) (*Reservation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now().In(c.location)
+	r := &Reservation{Customer: customer, Merchant: merchant, Amount: amount,
+		day: now.Format(dayLayout), month: now.Format(monthLayout)}
+
+	var updates []*Usage
+	for _, side := range []struct {
+		scope   Scope
+		account string
+	}{{ScopeCustomer, customer}, {ScopeMerchant, merchant}} {
+		usage, err := c.current(side.scope, side.account, now)
+		if err != nil {
+			return nil, err
+		}
+		limits := c.policy.scope(side.scope).limits(side.account)
+		if breach := c.check(usage, limits, amount, now); breach != nil {
+			return nil, breach
+		}
+		usage.DaySpent += amount
+		usage.MonthSpent += amount
+		updates = append(updates, usage)
+	}
+
+	if err := c.store.Put(updates...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Release uncounts a reserved charge that did not go through. Spend from a day
+// or month that has since ended is left alone.
+func (c *Caps) Release(r *Reservation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now().In(c.location)
+	var updates []*Usage
+	for _, side := range []struct {
+		scope   Scope
+		account string
+	}{{ScopeCustomer, r.Customer}, {ScopeMerchant, r.Merchant}} {
+		usage, err := c.current(side.scope, side.account, now)
+		if err != nil {
+			return err
+		}
+		if usage.Day == r.day {
+			usage.DaySpent = max(0, usage.DaySpent-r.Amount)
+		}
+		if usage.Month == r.month {
+			usage.MonthSpent = max(0, usage.MonthSpent-r.Amount)
+		}
+		updates = append(updates, usage)
+	}
+	return c.store.Put(updates...)
+}
+
+// Allowance returns how much the account can still spend, or receive, today and
+// this month
+func (c *Caps) Allowance(scope Scope, account string) (*Allowance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now().In(c.location)
+	usage, err := c.current(scope, account, now)
+	if err != nil {
+		return nil, err
+	}
+	limits := c.policy.scope(scope).limits(account)
+	return &Allowance{
+		Scope:   scope,
+		Account: account,
+		Daily:   window(limits.Daily, usage.DaySpent, nextDay(now)),
+		Monthly: window(limits.Monthly, usage.MonthSpent, nextMonth(now)),
+	}, nil
+}
+
+// current returns the account's usage with any ended day or month reset. It is
+// called with mu held.
+func (c *Caps) current(scope Scope, account string, now time.Time) (*Usage, error) {
+	usage, err := c.store.Get(scope, account)
+	if err != nil {
+		return nil, err
+	}
+	if usage == nil {
+		usage = &Usage{Scope: scope, Account: account}
+	}
+	if day := now.Format(dayLayout); usage.Day != day {
+		usage.Day, usage.DaySpent = day, 0
+	}
+	if month := now.Format(monthLayout); usage.Month != month {
+		usage.Month, usage.MonthSpent = month, 0
+	}
+	return usage, nil
+}
+
+func (c *Caps) check(usage *Usage, limits Limits, amount int64, now time.Time) *Breach {
+	for _, w := range []struct {
+		period Period
+		cap    int64
+		spent  int64
+		resets time.Time
+	}{
+		{PeriodDaily, limits.Daily, usage.DaySpent, nextDay(now)},
+		{PeriodMonthly, limits.Monthly, usage.MonthSpent, nextMonth(now)},
+	} {
+		if w.cap > 0 && w.spent+amount > w.cap {
+			return &Breach{
+				Scope:     usage.Scope,
+				Account:   usage.Account,
+				Period:    w.period,
+				Cap:       w.cap,
+				Remaining: max(0, w.cap-w.spent),
+				ResetsAt:  w.resets,
+			}
+		}
+	}
+	return nil
+}
+
+func window(cap, spent int64, resets time.Time) Window {
+	w := Window{Cap: cap, Spent: spent, ResetsAt: resets}
+	if cap > 0 {
+		w.Remaining = max(0, cap-spent)
+	}
+	return w
+}
+
+// nextDay returns midnight at the start of the next calendar day in now's zone
+func nextDay(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+// nextMonth returns midnight at the start of the next calendar month in now's zone
+func nextMonth(now time.Time) time.Time {
+	y, m, _ := now.Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,126 @@
+// Package spendcap caps how much each customer account can spend, and each
+// merchant account can receive, per calendar day and month.
+package spendcap
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scope is the side of a charge a cap applies to
+type Scope string
+
+const (
+	// ScopeCustomer caps the mapped account a charge debits
+	ScopeCustomer Scope = "customer"
+	// ScopeMerchant caps the merchant account a charge credits
+	ScopeMerchant Scope = "merchant"
+)
+
+// ParseScope parses "customer" or "merchant"
+func ParseScope(value string) (Scope, error) {
+	switch scope := Scope(strings.ToLower(strings.TrimSpace(value))); scope {
+	case ScopeCustomer, ScopeMerchant:
+		return scope, nil
+	}
+	return "", fmt.Errorf("spend cap scope %q must be customer or merchant", value)
+}
+
+// Period is the calendar period a cap covers
+type Period string
+
+const (
+	PeriodDaily   Period = "daily"
+	PeriodMonthly Period = "monthly"
+)
+
+// Limits are the caps in ledger cents; 0 leaves a period uncapped
+type Limits struct {
+	Daily   int64 `yaml:"daily_cents"`
+	Monthly int64 `yaml:"monthly_cents"`
+}
+
+// ScopePolicy gives every account in a scope the default limits, except those
+// with their own
+type ScopePolicy struct {
+	Default  Limits            `yaml:"default"`
+	Accounts map[string]Limits `yaml:"accounts"`
+}
+
+// limits returns the account's limits
+func (p *ScopePolicy) limits(account string) Limits {
+	if limits, ok := p.Accounts[account]; ok {
+		return limits
+	}
+	return p.Default
+}
+
+// Policy configures the spend caps
+type Policy struct {
+	// Timezone is the IANA zone calendar days and months are counted in; defaults to UTC
+	Timezone  string      `yaml:"timezone"`
+	Customers ScopePolicy `yaml:"customers"`
+	Merchants ScopePolicy `yaml:"merchants"`
+}
+
+// scope returns the policy for a scope
+func (p *Policy) scope(scope Scope) *ScopePolicy {
+	if scope == ScopeMerchant {
+		return &p.Merchants
+	}
+	return &p.Customers
+}
+
+// Validate checks the timezone, limits and account numbers
+func (p *Policy) Validate() error {
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("spend cap timezone: %w", err)
+	}
+	for _, scope := range []Scope{ScopeCustomer, ScopeMerchant} {
+		sp := p.scope(scope)
+		if err := sp.Default.Validate(); err != nil {
+			return fmt.Errorf("%s default spend cap: %w", scope, err)
+		}
+		for account, limits := range sp.Accounts {
+			if account == "" || strings.Trim(account, "0123456789") != "" {
+				return fmt.Errorf("%s spend cap account %q must be digits", scope, account)
+			}
+			if err := limits.Validate(); err != nil {
+				return fmt.Errorf("%s spend cap for %s: %w", scope, account, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks that the caps are not negative and the daily cap fits in the monthly one
+func (l Limits) Validate() error {
+	if l.Daily < 0 || l.Monthly < 0 {
+		return fmt.Errorf("caps must not be negative")
+	}
+	if l.Daily > 0 && l.Monthly > 0 && l.Daily > l.Monthly {
+		return fmt.Errorf("daily cap %d is above the monthly cap %d", l.Daily, l.Monthly)
+	}
+	return nil
+}
+
+// LoadPolicy reads a YAML (or JSON) spend cap policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spend cap policy: %w", err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse spend cap policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
@@ -0,0 +1,226 @@
+package spendcap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestCaps(t *testing.T, policy *Policy, store Store) (*Caps, *time.Time) {
+	t.Helper()
+	caps, err := NewCaps(policy, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 23:00 on the last day of March in New York
+	now := time.Date(2026, 4, 1, 3, 0, 0, 0, time.UTC)
+	caps.SetClock(func() time.Time { return now })
+	return caps, &now
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "caps.yaml")
+	policy := `
+timezone: America/New_York
+customers:
+  default: {daily_cents: 50000, monthly_cents: 200000}
+  accounts:
+    "1011226111": {daily_cents: 100000}
+merchants:
+  default: {monthly_cents: 10000000}
+`
+	if err := os.WriteFile(path, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	if limits := loaded.Customers.limits("1011226111"); limits.Daily != 100000 || limits.Monthly != 0 {
+		t.Errorf("Expected the account's own limits, got %+v", limits)
+	}
+	if limits := loaded.Customers.limits("1033623433"); limits.Daily != 50000 {
+		t.Errorf("Expected the default limits, got %+v", limits)
+	}
+
+	invalid := []Policy{
+		{Timezone: "Mars/Olympus"},
+		{Customers: ScopePolicy{Default: Limits{Daily: -1}}},
+		{Merchants: ScopePolicy{Default: Limits{Daily: 200, Monthly: 100}}},
+		{Customers: ScopePolicy{Accounts: map[string]Limits{"acct-1": {Daily: 100}}}},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", p)
+		}
+	}
+}
+
+func TestReserveAndBreach(t *testing.T) {
+	caps, _ := newTestCaps(t, &Policy{
+		Customers: ScopePolicy{Default: Limits{Daily: 1000, Monthly: 1500}},
+		Merchants: ScopePolicy{Default: Limits{Daily: 5000}, Accounts: map[string]Limits{"2222222222": {Daily: 700}}},
+	}, NewMemoryStore())
+
+	tests := []struct {
+		customer, merchant string
+		amount             int64
+		scope              Scope
+		period             Period
+		remaining          int64
+	}{
+		{"1111111111", "9999999999", 600, "", "", 0},
+		{"1111111111", "9999999999", 500, ScopeCustomer, PeriodDaily, 400},
+		{"1111111111", "9999999999", 400, "", "", 0},
+		{"3333333333", "2222222222", 800, ScopeMerchant, PeriodDaily, 700},
+		{"3333333333", "2222222222", 700, "", "", 0},
+	}
+
+	for i, tt := range tests {
+		_, err := caps.Reserve(tt.customer, tt.merchant, tt.amount)
+		var breach *Breach
+		if tt.scope == "" {
+			if err != nil {
+				t.Errorf("%d: expected the charge to be allowed, got %v", i, err)
+			}
+			continue
+		}
+		if !errors.As(err, &breach) {
+			t.Fatalf("%d: expected a breach, got %v", i, err)
+		}
+		if breach.Scope != tt.scope || breach.Period != tt.period || breach.Remaining != tt.remaining {
+			t.Errorf("%d: expected %s %s with %d remaining, got %+v", i, tt.scope, tt.period, tt.remaining, breach)
+		}
+	}
+
+	// A breach on the customer side counts nothing against the merchant
+	allowance, err := caps.Allowance(ScopeMerchant, "9999999999")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowance.Daily.Spent != 1000 || allowance.Daily.Remaining != 4000 || allowance.Monthly.Cap != 0 {
+		t.Errorf("Unexpected merchant allowance %+v", allowance)
+	}
+}
+
+func TestCalendarRollover(t *testing.T) {
+	caps, now := newTestCaps(t, &Policy{
+		Timezone:  "America/New_York",
+		Customers: ScopePolicy{Default: Limits{Daily: 1000, Monthly: 1500}},
+	}, NewMemoryStore())
+
+	if _, err := caps.Reserve("1111111111", "9999999999", 1000); err != nil {
+		t.Fatal(err)
+	}
+	allowance, _ := caps.Allowance(ScopeCustomer, "1111111111")
+	if allowance.Daily.Remaining != 0 || allowance.Daily.ResetsAt.UTC() != time.Date(2026, 4, 1, 4, 0, 0, 0, time.UTC) {
+		t.Errorf("Expected the day to reset at New York midnight, got %+v", allowance.Daily)
+	}
+
+	// Past midnight in New York both the day and the month have rolled over
+	*now = now.Add(2 * time.Hour)
+	if _, err := caps.Reserve("1111111111", "9999999999", 1000); err != nil {
+		t.Errorf("Expected a new day and month, got %v", err)
+	}
+
+	// The next day only the daily cap resets
+	*now = now.Add(24 * time.Hour)
+	_, err := caps.Reserve("1111111111", "9999999999", 1000)
+	var breach *Breach
+	if !errors.As(err, &breach) || breach.Period != PeriodMonthly || breach.Remaining != 500 {
+		t.Fatalf("Expected the monthly cap to be hit, got %v", err)
+	}
+
+	st := status.Convert(breach.ToGRPCError())
+	if st.Code() != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %s", st.Code())
+	}
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	if !ok || info.Reason != ReasonSpendCapExceeded || info.Metadata["remaining_cents"] != "500" || info.Metadata["resets_at"] != "2026-05-01T04:00:00Z" {
+		t.Errorf("Unexpected error details %v", st.Details())
+	}
+}
+
+func TestReleaseAndPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spend.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &Policy{Customers: ScopePolicy{Default: Limits{Daily: 1000}}}
+	caps, _ := newTestCaps(t, policy, store)
+
+	reservation, err := caps.Reserve("1111111111", "9999999999", 800)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := caps.Release(reservation); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := caps.Reserve("1111111111", "9999999999", 600); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps, _ = newTestCaps(t, policy, reopened)
+	allowance, err := caps.Allowance(ScopeCustomer, "1111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowance.Daily.Spent != 600 || allowance.Daily.Remaining != 400 {
+		t.Errorf("Expected 600 spent after reopening, got %+v", allowance.Daily)
+	}
+}
+
+func TestReserveRollsBackFailedWrites(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spend")
+	store, err := NewFileStore(filepath.Join(dir, "spend.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &Policy{Customers: ScopePolicy{Default: Limits{Daily: 1000}}}
+	caps, _ := newTestCaps(t, policy, store)
+	if _, err := caps.Reserve("1111111111", "9999999999", 300); err != nil {
+		t.Fatal(err)
+	}
+
+	// With a file in place of the directory every write fails
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := caps.Reserve("1111111111", "9999999999", 500); err == nil {
+		t.Fatal("Expected Reserve to fail")
+	}
+
+	allowance, err := caps.Allowance(ScopeCustomer, "1111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowance.Daily.Spent != 300 || allowance.Daily.Remaining != 700 {
+		t.Errorf("Expected the failed charge not to count, got %+v", allowance.Daily)
+	}
+	if usage, _ := store.Get(ScopeMerchant, "9999999999"); usage == nil || usage.DaySpent != 300 {
+		t.Errorf("Expected the merchant's spend to be restored, got %+v", usage)
+	}
+
+	// An account without earlier spend is left without any
+	if _, err := caps.Reserve("2222222222", "9999999999", 100); err == nil {
+		t.Fatal("Expected Reserve to fail")
+	}
+	if usage, _ := store.Get(ScopeCustomer, "2222222222"); usage != nil {
+		t.Errorf("Expected no spend for the new account, got %+v", usage)
+	}
+}
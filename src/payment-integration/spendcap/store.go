@@ -0,0 +1,130 @@
+package spendcap
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+// Usage is what an account has spent, or received, in the current day and month
+type Usage struct {
+	Scope   Scope  `json:"scope"`
+	Account string `json:"account"`
+	// Day is the calendar day DaySpent covers, as 2006-01-02
+	Day      string `json:"day"`
+	DaySpent int64  `json:"day_spent"`
+	// Month is the calendar month MonthSpent covers, as 2006-01
+	Month      string `json:"month"`
+	MonthSpent int64  `json:"month_spent"`
+}
+
+// Store persists usage. Get returns nil for accounts without any.
+type Store interface {
+	Get(scope Scope, account string) (*Usage, error)
+	Put(usage ...*Usage) error
+}
+
+func usageKey(scope Scope, account string) string {
+	return string(scope) + ":" + account
+}
+
+// MemoryStore keeps usage in memory
+type MemoryStore struct {
+	mu    sync.RWMutex
+	usage map[string]Usage
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{usage: make(map[string]Usage)}
+}
+
+// Get returns a copy of the account's usage, or nil
+func (s *MemoryStore) Get(scope Scope, account string) (*Usage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage, ok := s.usage[usageKey(scope, account)]
+	if !ok {
+		return nil, nil
+	}
+	return &usage, nil
+}
+
+// Put inserts or replaces usage
+func (s *MemoryStore) Put(usage ...*Usage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range usage {
+		s.usage[usageKey(u.Scope, u.Account)] = *u
+	}
+	return nil
+}
+
+// delete removes an account's usage
+func (s *MemoryStore) delete(scope Scope, account string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.usage, usageKey(scope, account))
+}
+
+// list returns all usage ordered by scope and account
+func (s *MemoryStore) list() []Usage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := make([]Usage, 0, len(s.usage))
+	for _, u := range s.usage {
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usageKey(usage[i].Scope, usage[i].Account) < usageKey(usage[j].Scope, usage[j].Account)
+	})
+	return usage
+}
+
+// FileStore is a MemoryStore that writes through to a JSON file
+type FileStore struct {
+	*MemoryStore
+	path    string
+	writeMu sync.Mutex
+}
+
+// NewFileStore loads usage from path, starting empty if the file does not exist
+func NewFileStore(path string) (*FileStore, error) {
+	var usage []*Usage
+	if _, err := storage.ReadJSON(path, &usage); err != nil {
+		return nil, fmt.Errorf("failed to load spend usage: %w", err)
+	}
+
+	store := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	store.MemoryStore.Put(usage...)
+	return store, nil
+}
+
+// Put inserts or replaces usage and persists the store. If the store can't be
+// written, the previous usage is restored so memory matches the file.
+func (s *FileStore) Put(usage ...*Usage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	previous := make([]*Usage, len(usage))
+	for i, u := range usage {
+		previous[i], _ = s.MemoryStore.Get(u.Scope, u.Account)
+	}
+	s.MemoryStore.Put(usage...)
+	if err := storage.WriteJSON(s.path, s.MemoryStore.list()); err != nil {
+		for i, u := range usage {
+			if previous[i] == nil {
+				s.MemoryStore.delete(u.Scope, u.Account)
+			} else {
+				s.MemoryStore.Put(previous[i])
+			}
+		}
+		return fmt.Errorf("failed to persist spend usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Package tenant models the storefronts sharing the payment service. Each
+// tenant is paid into its own merchant account, through its own bank
+// credentials, under its own rate and spend limits.
+package tenant
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gke-hackathon/payment-integration/bank"
+	"github.com/gke-hackathon/payment-integration/middleware"
+	"github.com/gke-hackathon/payment-integration/spendcap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+const errorDomain = "payment-integration"
+
+// Tenant is one storefront
+type Tenant struct {
+	// ID names the tenant in the x-tenant-id header, metrics and logs
+	ID string `yaml:"id"`
+	// Callers are the caller identities, x-caller-id values or peer hosts,
+	// that belong to the tenant and select it without an x-tenant-id header
+	Callers []string `yaml:"callers"`
+
+	// MerchantAccount and RoutingNumber receive the tenant's charges
+	MerchantAccount string `yaml:"merchant_account"`
+	RoutingNumber   string `yaml:"routing_number"`
+	Bank            Bank   `yaml:"bank"`
+
+	// RateLimit caps the tenant's charges as "RATE/UNIT:BURST", e.g. "5/s:20";
+	// empty leaves only the service's limits
+	RateLimit string `yaml:"rate_limit"`
+	// SpendCaps caps what the tenant's merchant account receives; 0 leaves a
+	// period uncapped
+	SpendCaps spendcap.Limits `yaml:"spend_caps"`
+}
+
+// Bank is the bank backend a tenant's transfers go through
+type Bank struct {
+	// APIURL defaults to the service's bank API URL
+	APIURL string `yaml:"api_url"`
+	// Auth holds the tenant's credentials. An empty mode reuses the service's
+	// default authenticator.
+	Auth bank.RouteAuth `yaml:"auth"`
+}
+
+// Validate checks the tenant's ID, accounts and limits
+func (t *Tenant) Validate() error {
+	if t.ID == "" || strings.Trim(strings.ToLower(t.ID), "abcdefghijklmnopqrstuvwxyz0123456789-_") != "" {
+		return fmt.Errorf("tenant id %q must be letters, digits, dashes or underscores", t.ID)
+	}
+	if len(t.MerchantAccount) != 10 || !isDigits(t.MerchantAccount) {
+		return fmt.Errorf("tenant %s merchant account %q must be 10 digits", t.ID, t.MerchantAccount)
+	}
+	if len(t.RoutingNumber) != 9 || !isDigits(t.RoutingNumber) {
+		return fmt.Errorf("tenant %s routing number %q must be 9 digits", t.ID, t.RoutingNumber)
+	}
+	switch t.Bank.Auth.Mode {
+	case "", "forge", "login":
+	default:
+		return fmt.Errorf("tenant %s bank auth mode %q must be forge or login", t.ID, t.Bank.Auth.Mode)
+	}
+	if t.RateLimit != "" {
+		if _, err := middleware.ParseBucketConfig(t.RateLimit); err != nil {
+			return fmt.Errorf("tenant %s rate limit: %w", t.ID, err)
+		}
+	}
+	if err := t.SpendCaps.Validate(); err != nil {
+		return fmt.Errorf("tenant %s spend caps: %w", t.ID, err)
+	}
+	for _, caller := range t.Callers {
+		if caller == "" {
+			return fmt.Errorf("tenant %s has an empty caller", t.ID)
+		}
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// file is the layout of the tenants file
+type file struct {
+	Tenants []Tenant `yaml:"tenants"`
+}
+
+// Registry looks tenants up by ID and by caller. It is not changed once built.
+type Registry struct {
+	tenants  map[string]*Tenant
+	byCaller map[string]*Tenant
+}
+
+// NewRegistry validates tenants and indexes them. IDs, merchant accounts and
+// callers must each belong to one tenant.
+func NewRegistry(tenants []Tenant) (*Registry, error) {
+	r := &Registry{tenants: make(map[string]*Tenant), byCaller: make(map[string]*Tenant)}
+	accounts := make(map[string]string)
+	for i := range tenants {
+		t := &tenants[i]
+		if err := t.Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := r.tenants[t.ID]; ok {
+			return nil, fmt.Errorf("tenant %s is listed twice", t.ID)
+		}
+		if other, ok := accounts[t.MerchantAccount]; ok {
+			return nil, fmt.Errorf("tenants %s and %s share merchant account %s", other, t.ID, t.MerchantAccount)
+		}
+		for _, caller := range t.Callers {
+			if other, ok := r.byCaller[caller]; ok {
+				return nil, fmt.Errorf("caller %s belongs to tenants %s and %s", caller, other.ID, t.ID)
+			}
+			r.byCaller[caller] = t
+		}
+		r.tenants[t.ID] = t
+		accounts[t.MerchantAccount] = t.ID
+	}
+	return r, nil
+}
+
+// Load reads a YAML (or JSON) tenants file of the form {"tenants": [...]}
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants file: %w", err)
+	}
+	return NewRegistry(f.Tenants)
+}
+
+// Get returns the tenant with id
+func (r *Registry) Get(id string) (*Tenant, bool) {
+	t, ok := r.tenants[id]
+	return t, ok
+}
+
+// List returns the tenants ordered by ID
+func (r *Registry) List() []*Tenant {
+	tenants := make([]*Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants
+}
+
+// Resolve picks the tenant of a request from the tenant it names, if any, or
+// else its caller. A caller that belongs to a tenant can't name another one.
+func (r *Registry) Resolve(id, caller string) (*Tenant, error) {
+	owner := r.byCaller[caller]
+	if id == "" {
+		if owner == nil {
+			return nil, &Rejection{Caller: caller, Reason: ReasonUnknownTenant}
+		}
+		return owner, nil
+	}
+
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, &Rejection{ID: id, Caller: caller, Reason: ReasonUnknownTenant}
+	}
+	if owner != nil && owner != t {
+		return nil, &Rejection{ID: id, Caller: caller, Reason: ReasonTenantMismatch}
+	}
+	return t, nil
+}
+
+// ErrorInfo reasons of a rejected tenant
+const (
+	ReasonUnknownTenant  = "UNKNOWN_TENANT"
+	ReasonTenantMismatch = "TENANT_MISMATCH"
+)
+
+// Rejection is returned for a request whose tenant is unknown, or that names a
+// tenant its caller doesn't belong to
+type Rejection struct {
+	// ID is the tenant the request named, if any
+	ID     string
+	Caller string
+	Reason string
+}
+
+// Error implements the error interface
+func (r *Rejection) Error() string {
+	if r.Reason == ReasonTenantMismatch {
+		return fmt.Sprintf("caller %s does not belong to tenant %s", r.Caller, r.ID)
+	}
+	if r.ID != "" {
+		return fmt.Sprintf("unknown tenant %s", r.ID)
+	}
+	return fmt.Sprintf("caller %s belongs to no tenant", r.Caller)
+}
+
+// ToGRPCError converts a Rejection to a PermissionDenied status naming the tenant
+func (r *Rejection) ToGRPCError() error {
+	st := status.New(codes.PermissionDenied, "the request does not belong to a known tenant")
+	info := &errdetails.ErrorInfo{
+		Reason:   r.Reason,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"tenant": r.ID},
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		return detailed.Err()
+	}
+	return st.Err()
+}
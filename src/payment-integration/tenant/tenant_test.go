@@ -0,0 +1,129 @@
+package tenant
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gke-hackathon/payment-integration/bank"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.yaml")
+	if err := os.WriteFile(path, []byte(`
+tenants:
+  - id: boutique-us
+    merchant_account: "3333333333"
+    routing_number: "883745000"
+  - id: boutique-eu
+    callers: [frontend-eu, 10.0.0.7]
+    merchant_account: "2222222222"
+    routing_number: "883745000"
+    bank:
+      api_url: http://ledgerwriter.bank-eu:8080
+      auth:
+        mode: login
+        userservice_url: http://userservice.bank-eu:8080
+        credentials_path: /var/secrets/eu/credentials.json
+    rate_limit: "5/s:20"
+    spend_caps:
+      daily_cents: 100000
+      monthly_cents: 2000000
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenants := r.List()
+	if len(tenants) != 2 || tenants[0].ID != "boutique-eu" || tenants[1].ID != "boutique-us" {
+		t.Fatalf("Expected both tenants ordered by ID, got %v", tenants)
+	}
+	eu := tenants[0]
+	if eu.Bank.Auth.Mode != "login" || eu.Bank.Auth.CredentialsPath != "/var/secrets/eu/credentials.json" || eu.SpendCaps.Daily != 100000 {
+		t.Errorf("Unexpected tenant %+v", eu)
+	}
+	if got, ok := r.Get("boutique-us"); !ok || got.MerchantAccount != "3333333333" {
+		t.Errorf("Expected boutique-us, got %v", got)
+	}
+}
+
+func TestNewRegistryValidation(t *testing.T) {
+	valid := func(id, account string) Tenant {
+		return Tenant{ID: id, MerchantAccount: account, RoutingNumber: "883745000"}
+	}
+	tests := []struct {
+		name    string
+		tenants []Tenant
+		want    string
+	}{
+		{"bad id", []Tenant{valid("boutique eu", "2222222222")}, "tenant id"},
+		{"short account", []Tenant{valid("eu", "222")}, "merchant account"},
+		{"duplicate id", []Tenant{valid("eu", "2222222222"), valid("eu", "3333333333")}, "listed twice"},
+		{"shared account", []Tenant{valid("eu", "2222222222"), valid("us", "2222222222")}, "share merchant account"},
+		{"shared caller", []Tenant{
+			{ID: "eu", MerchantAccount: "2222222222", RoutingNumber: "883745000", Callers: []string{"frontend"}},
+			{ID: "us", MerchantAccount: "3333333333", RoutingNumber: "883745000", Callers: []string{"frontend"}},
+		}, "caller frontend"},
+		{"bad rate limit", []Tenant{{ID: "eu", MerchantAccount: "2222222222", RoutingNumber: "883745000", RateLimit: "5/d"}}, "rate limit"},
+		{"bad auth mode", []Tenant{{ID: "eu", MerchantAccount: "2222222222", RoutingNumber: "883745000", Bank: Bank{Auth: bank.RouteAuth{Mode: "oauth"}}}}, "auth mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry(tt.tenants)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r, err := NewRegistry([]Tenant{
+		{ID: "eu", MerchantAccount: "2222222222", RoutingNumber: "883745000", Callers: []string{"frontend-eu"}},
+		{ID: "us", MerchantAccount: "3333333333", RoutingNumber: "883745000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id, caller string
+		want       string
+		reason     string
+	}{
+		{"", "frontend-eu", "eu", ""},
+		{"eu", "frontend-eu", "eu", ""},
+		{"us", "10.0.0.9", "us", ""},
+		{"", "10.0.0.9", "", ReasonUnknownTenant},
+		{"apac", "10.0.0.9", "", ReasonUnknownTenant},
+		{"us", "frontend-eu", "", ReasonTenantMismatch},
+	}
+	for _, tt := range tests {
+		got, err := r.Resolve(tt.id, tt.caller)
+		if tt.reason == "" {
+			if err != nil || got.ID != tt.want {
+				t.Errorf("Resolve(%q, %q): expected %s, got %v (%v)", tt.id, tt.caller, tt.want, got, err)
+			}
+			continue
+		}
+
+		var rejection *Rejection
+		if !errors.As(err, &rejection) || rejection.Reason != tt.reason {
+			t.Errorf("Resolve(%q, %q): expected %s, got %v", tt.id, tt.caller, tt.reason, err)
+			continue
+		}
+		st := status.Convert(rejection.ToGRPCError())
+		if st.Code() != codes.PermissionDenied || len(st.Details()) != 1 || st.Details()[0].(*errdetails.ErrorInfo).Reason != tt.reason {
+			t.Errorf("Unexpected status %v", st)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package transaction keeps a record of every charge the service has processed.
+package transaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/fx"
+	"github.com/gke-hackathon/payment-integration/money"
+	"github.com/gke-hackathon/payment-integration/risk"
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+// ErrNotFound is returned for unknown transaction IDs
+var ErrNotFound = errors.New("transaction not found")
+
+// Status is the outcome of a charge
+type Status string
+
+const (
+	StatusCompleted Status = "completed"
+	StatusSimulated Status = "simulated"
+	// StatusPendingReview charges are parked until a reviewer decides them
+	StatusPendingReview Status = "pending_review"
+	// StatusDeclined charges were rejected in review; no money moved
+	StatusDeclined Status = "declined"
+)
+
+// Record is a processed charge
+type Record struct {
+	ID          string `json:"id"`
+	Status      Status `json:"status"`
+	FromAccount string `json:"from_account"`
+	FromRouting string `json:"from_routing"`
+	ToAccount   string `json:"to_account"`
+	ToRouting   string `json:"to_routing"`
+	// Tenant is the storefront the charge was made for, when tenants are configured
+	Tenant    string `json:"tenant,omitempty"`
+	CardLast4 string `json:"card_last_four"`
+	// CardFingerprint identifies the card without revealing its number
+	CardFingerprint string `json:"card_fingerprint,omitempty"`
+	// BIN is the card's first six digits, so BIN blocks apply to charges
+	// approved after review
+	BIN string `json:"bin,omitempty"`
+
+	// Amount is what was posted to the ledger, in Currency's minor units
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	// Original is the amount as charged, before any currency conversion
+	Original money.Amount `json:"original"`
+	// FX holds the conversion rate when Original was in another currency
+	FX *fx.Details `json:"fx,omitempty"`
+	// Risk is the risk engine's assessment, when one is configured
+	Risk *risk.Assessment `json:"risk,omitempty"`
+	// Review tracks the manual review of a flagged charge
+	Review *Review `json:"review,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Review is the manual review of a flagged charge
+type Review struct {
+	// Deadline is when the charge is declined if nobody has reviewed it
+	Deadline time.Time `json:"deadline"`
+	// Decision is "approved", "rejected" or "expired" once decided
+	Decision  string    `json:"decision,omitempty"`
+	Reviewer  string    `json:"reviewer,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	DecidedAt time.Time `json:"decided_at,omitempty"`
+}
+
+// clone copies the record, including its mutable review
+func (r *Record) clone() *Record {
+	recordCopy := *r
+	if r.Review != nil {
+		review := *r.Review
+		recordCopy.Review = &review
+	}
+	return &recordCopy
+}
+
+// Store persists transaction records
+type Store interface {
+	Put(record *Record) error
+	Get(id string) (*Record, error)
+	List() ([]*Record, error)
+}
+
+// MemoryStore keeps records in memory
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]*Record
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*Record)}
+}
+
+// Put inserts or replaces a record
+func (s *MemoryStore) Put(record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.ID] = record.clone()
+	return nil
+}
+
+// Get returns a copy of the record for id or ErrNotFound
+func (s *MemoryStore) Get(id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return record.clone(), nil
+}
+
+// List returns all records ordered by creation time
+func (s *MemoryStore) List() ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record.clone())
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].ID < records[j].ID
+		}
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return records, nil
+}
+
+// FileStore is a MemoryStore that appends each record it is given to a JSON
+// lines file, so a charge writes one line rather than every record. Opening
+// the store replays the file, later lines replacing earlier ones for the same
+// ID, and compacts it once superseded lines outnumber the records.
+type FileStore struct {
+	*MemoryStore
+	path    string
+	writeMu sync.Mutex
+	file    *os.File
+	size    int64
+}
+
+// NewFileStore loads records from path, starting empty if the file does not
+// exist. A JSON array written by earlier versions is converted to a log.
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	compact, err := store.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transactions: %w", err)
+	}
+	if compact {
+		if err := store.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transactions: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open transactions: %w", err)
+	}
+	store.file = file
+	store.size = info.Size()
+	return store, nil
+}
+
+// load replays the file into memory and reports whether it should be compacted
+func (s *FileStore) load() (bool, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var records []*Record
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return false, err
+		}
+		for _, record := range records {
+			s.MemoryStore.Put(record)
+		}
+		return true, nil
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	count := 0
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			// A crash can leave the last line half written
+			if i == len(lines)-1 {
+				return true, nil
+			}
+			return false, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		s.MemoryStore.Put(&record)
+		count++
+	}
+	return count > 2*len(s.MemoryStore.records), nil
+}
+
+// compact rewrites the file with one line per record
+func (s *FileStore) compact() error {
+	records, _ := s.MemoryStore.List()
+	var buf bytes.Buffer
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode transaction %s: %w", record.ID, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := storage.WriteFile(s.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to compact transactions: %w", err)
+	}
+	return nil
+}
+
+// Put inserts or replaces a record and appends it to the file. Memory is only
+// updated once the line is written, so a failed write leaves no trace.
+func (s *FileStore) Put(record *Record) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction %s: %w", record.ID, err)
+	}
+	line = append(line, '\n')
+	if _, err = s.file.Write(line); err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		// Cut off a partly written line so the next one starts cleanly
+		s.file.Truncate(s.size)
+		return fmt.Errorf("failed to persist transaction %s: %w", record.ID, err)
+	}
+	s.size += int64(len(line))
+
+	s.MemoryStore.Put(record)
+	return nil
+}
+
+// Close closes the file. The store must not be written afterwards.
+func (s *FileStore) Close() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,142 @@
+package transaction
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/fx"
+	"github.com/gke-hackathon/payment-integration/money"
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+func TestFileStorePersistsRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.json")
+	rateTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &Record{
+		ID:        "tx-1",
+		Status:    StatusCompleted,
+		Amount:    1091,
+		Currency:  "USD",
+		Original:  money.Amount{Currency: "EUR", Units: 10},
+		FX:        &fx.Details{Rate: "1.08", AppliedRate: "1.0908", SpreadBasisPoints: 100, RateTimestamp: rateTime},
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := store.Put(record); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.Get("tx-1")
+	if err != nil {
+		t.Fatalf("Expected record after reopening, got %v", err)
+	}
+	if got.Amount != 1091 || got.Original != record.Original {
+		t.Errorf("Expected %+v, got %+v", record, got)
+	}
+	if got.FX == nil || got.FX.AppliedRate != "1.0908" || !got.FX.RateTimestamp.Equal(rateTime) {
+		t.Errorf("Expected FX details to survive, got %+v", got.FX)
+	}
+
+	if _, err := reopened.Get("tx-2"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestFileStoreAppendsAndCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &Record{ID: "tx-1", Status: StatusPendingReview, Amount: 500, CreatedAt: time.Now().UTC()}
+	for _, status := range []Status{StatusPendingReview, StatusDeclined, StatusCompleted} {
+		record.Status = status
+		if err := store.Put(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := countLines(t, path); lines != 3 {
+		t.Errorf("Expected one appended line per Put, got %d", lines)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got, err := reopened.Get("tx-1"); err != nil || got.Status != StatusCompleted {
+		t.Errorf("Expected the last version of the record, got %+v (%v)", got, err)
+	}
+	if lines := countLines(t, path); lines != 1 {
+		t.Errorf("Expected superseded lines to be compacted, got %d lines", lines)
+	}
+}
+
+func TestFileStoreConvertsJSONArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.json")
+	if err := storage.WriteJSON(path, []*Record{{ID: "tx-1", Status: StatusCompleted}, {ID: "tx-2", Status: StatusSimulated}}); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if records, _ := store.List(); len(records) != 2 {
+		t.Errorf("Expected both records, got %d", len(records))
+	}
+	if lines := countLines(t, path); lines != 2 {
+		t.Errorf("Expected the array to be rewritten as one line per record, got %d lines", lines)
+	}
+}
+
+func TestFileStoreFailedPutLeavesNoTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(&Record{ID: "tx-1", Status: StatusPendingReview}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every write fails once the file is closed
+	store.file.Close()
+	if err := store.Put(&Record{ID: "tx-1", Status: StatusCompleted}); err == nil {
+		t.Fatal("Expected Put to fail")
+	}
+	if err := store.Put(&Record{ID: "tx-2", Status: StatusPendingReview}); err == nil {
+		t.Fatal("Expected Put to fail")
+	}
+
+	if got, err := store.Get("tx-1"); err != nil || got.Status != StatusPendingReview {
+		t.Errorf("Expected the previous record to be kept, got %+v (%v)", got, err)
+	}
+	if _, err := store.Get("tx-2"); err != ErrNotFound {
+		t.Errorf("Expected the failed record not to be stored, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+)
+
+// dataKey is an AES-256 key used to encrypt card numbers
+type dataKey struct {
+	ID        string
+	Key       []byte
+	CreatedAt time.Time
+	aead      cipher.AEAD
+}
+
+// wrappedKey is a data key encrypted under the master key, as persisted on disk
+type wrappedKey struct {
+	ID         string    `json:"id"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// keyring holds every data key ever issued; only the newest encrypts new records
+type keyring struct {
+	master   cipher.AEAD
+	keys     map[string]*dataKey
+	activeID string
+}
+
+func newKeyring(masterKey []byte) (*keyring, error) {
+	if len(masterKey) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(masterKey))
+	}
+	master, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &keyring{master: master, keys: make(map[string]*dataKey)}, nil
+}
+
+// rotate generates a new data key and makes it active
+func (k *keyring) rotate(now time.Time) (*dataKey, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	id := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, fmt.Errorf("failed to generate key id: %w", err)
+	}
+
+	dk, err := newDataKey(hex.EncodeToString(id), key, now)
+	if err != nil {
+		return nil, err
+	}
+	k.keys[dk.ID] = dk
+	k.activeID = dk.ID
+	return dk, nil
+}
+
+// active returns the data key used for new records
+func (k *keyring) active() *dataKey {
+	return k.keys[k.activeID]
+}
+
+// wrap encrypts every data key under the master key for persistence
+func (k *keyring) wrap() ([]wrappedKey, error) {
+	wrapped := make([]wrappedKey, 0, len(k.keys))
+	for _, dk := range k.keys {
+		nonce, ciphertext, err := seal(k.master, dk.Key, []byte(dk.ID))
+		if err != nil {
+			return nil, err
+		}
+		wrapped = append(wrapped, wrappedKey{ID: dk.ID, Nonce: nonce, Ciphertext: ciphertext, CreatedAt: dk.CreatedAt})
+	}
+	return wrapped, nil
+}
+
+// unwrap restores persisted data keys
+func (k *keyring) unwrap(wrapped []wrappedKey, activeID string) error {
+	for _, wk := range wrapped {
+		key, err := k.master.Open(nil, wk.Nonce, wk.Ciphertext, []byte(wk.ID))
+		if err != nil {
+			return fmt.Errorf("failed to unwrap data key %s: %w", wk.ID, err)
+		}
+		dk, err := newDataKey(wk.ID, key, wk.CreatedAt)
+		if err != nil {
+			return err
+		}
+		k.keys[wk.ID] = dk
+	}
+	if _, ok := k.keys[activeID]; activeID != "" && !ok {
+		return fmt.Errorf("active data key %s is missing", activeID)
+	}
+	k.activeID = activeID
+	return nil
+}
+
+func newDataKey(id string, key []byte, createdAt time.Time) (*dataKey, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &dataKey{ID: id, Key: key, CreatedAt: createdAt, aead: aead}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a fresh random nonce
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) (nonce, ciphertext []byte, err error) {
+	nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, additionalData), nil
+}
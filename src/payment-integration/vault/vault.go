@@ -0,0 +1,352 @@
+// Package vault stores card numbers encrypted at rest and hands out opaque
+// tokens in their place, so raw PANs only exist at the edge that tokenizes them.
+package vault
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/logging"
+	"github.com/gke-hackathon/payment-integration/storage"
+)
+
+// TokenPrefix marks a string as a vault token rather than a card number
+const TokenPrefix = "tok_"
+
+var (
+	// ErrTokenNotFound is returned when a token is not in the vault
+	ErrTokenNotFound = errors.New("card token not found")
+	// ErrInvalidToken is returned when a string is not a well-formed token
+	ErrInvalidToken = errors.New("malformed card token")
+)
+
+// Card is the card data accepted for tokenization. The CVV is never stored.
+type Card struct {
+	Number          string
+	Brand           string
+	ExpirationYear  int32
+	ExpirationMonth int32
+}
+
+// TokenInfo describes a token without revealing the card number
+type TokenInfo struct {
+	Token    string `json:"token"`
+	LastFour string `json:"last_four"`
+	// BIN is the card number's first six digits, for risk rules
+	BIN             string    `json:"bin,omitempty"`
+	Brand           string    `json:"brand"`
+	ExpirationYear  int32     `json:"expiration_year"`
+	ExpirationMonth int32     `json:"expiration_month"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// record is a token's persisted form; the PAN is sealed under KeyID
+type record struct {
+	TokenInfo
+	Fingerprint string `json:"fingerprint"`
+	KeyID       string `json:"key_id"`
+	Nonce       []byte `json:"nonce"`
+	Ciphertext  []byte `json:"ciphertext"`
+}
+
+// vaultFile is the on-disk layout: wrapped data keys plus sealed records
+type vaultFile struct {
+	ActiveKey string       `json:"active_key"`
+	Keys      []wrappedKey `json:"keys"`
+	Records   []*record    `json:"records"`
+}
+
+// Vault encrypts card numbers with AES-GCM under a data key that rotates on a
+// fixed interval. Data keys are wrapped with the master key before being persisted.
+type Vault struct {
+	mu            sync.RWMutex
+	keys          *keyring
+	indexKey      []byte
+	records       map[string]*record
+	byFingerprint map[string]string
+	rotation      time.Duration
+	path          string
+	now           func() time.Time
+}
+
+// New opens the vault at path, creating it if the file does not exist. An empty
+// path keeps the vault in memory. A rotation of zero disables automatic rotation.
+func New(masterKey []byte, path string, rotation time.Duration) (*Vault, error) {
+	keys, err := newKeyring(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fingerprints use a key derived from the master key so equal PANs map to one token
+	mac := hmac.New(sha256.New, masterKey)
+	mac.Write([]byte("payment-integration vault fingerprint"))
+
+	v := &Vault{
+		keys:          keys,
+		indexKey:      mac.Sum(nil),
+		records:       make(map[string]*record),
+		byFingerprint: make(map[string]string),
+		rotation:      rotation,
+		path:          path,
+		now:           time.Now,
+	}
+
+	if path != "" {
+		var file vaultFile
+		found, err := storage.ReadJSON(path, &file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load vault: %w", err)
+		}
+		if found {
+			if err := keys.unwrap(file.Keys, file.ActiveKey); err != nil {
+				return nil, err
+			}
+			for _, rec := range file.Records {
+				v.records[rec.Token] = rec
+				v.byFingerprint[rec.Fingerprint] = rec.Token
+			}
+		}
+	}
+
+	if keys.active() == nil {
+		if _, err := keys.rotate(v.now().UTC()); err != nil {
+			return nil, err
+		}
+		if err := v.flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// Tokenize stores a card and returns its token. Tokenizing the same card number
+// again returns the same token, re-encrypted under the active data key.
+func (v *Vault) Tokenize(card Card) (*TokenInfo, error) {
+	number := cleanNumber(card.Number)
+	if len(number) < 4 {
+		return nil, fmt.Errorf("card number is too short")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now().UTC()
+	if err := v.rotateIfDue(now); err != nil {
+		return nil, err
+	}
+
+	fingerprint := v.fingerprint(number)
+	rec := &record{Fingerprint: fingerprint}
+	if token, ok := v.byFingerprint[fingerprint]; ok {
+		existing := *v.records[token]
+		rec = &existing
+	} else {
+		token, err := newToken()
+		if err != nil {
+			return nil, err
+		}
+		rec.Token = token
+		rec.CreatedAt = now
+	}
+
+	key := v.keys.active()
+	nonce, ciphertext, err := seal(key.aead, []byte(number), []byte(rec.Token))
+	if err != nil {
+		return nil, err
+	}
+	rec.KeyID = key.ID
+	rec.Nonce = nonce
+	rec.Ciphertext = ciphertext
+	rec.LastFour = number[len(number)-4:]
+	rec.BIN = cardBIN(number)
+	rec.Brand = card.Brand
+	rec.ExpirationYear = card.ExpirationYear
+	rec.ExpirationMonth = card.ExpirationMonth
+
+	previous, existed := v.records[rec.Token]
+	v.records[rec.Token] = rec
+	v.byFingerprint[fingerprint] = rec.Token
+	if err := v.flush(); err != nil {
+		if existed {
+			v.records[rec.Token] = previous
+		} else {
+			delete(v.records, rec.Token)
+			delete(v.byFingerprint, fingerprint)
+		}
+		return nil, err
+	}
+
+	info := rec.TokenInfo
+	return &info, nil
+}
+
+// Lookup returns a token's metadata without decrypting the card number
+func (v *Vault) Lookup(token string) (*TokenInfo, error) {
+	if !IsToken(token) {
+		return nil, ErrInvalidToken
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	rec, ok := v.records[token]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	info := rec.TokenInfo
+	return &info, nil
+}
+
+// Rotate generates a new data key for future tokenizations and returns its id.
+// Existing records stay readable under the key that sealed them.
+func (v *Vault) Rotate() (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, err := v.keys.rotate(v.now().UTC())
+	if err != nil {
+		return "", err
+	}
+	if err := v.flush(); err != nil {
+		return "", err
+	}
+	return key.ID, nil
+}
+
+// ActiveKeyID returns the id of the data key sealing new records
+func (v *Vault) ActiveKeyID() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.keys.activeID
+}
+
+// detokenize decrypts a token's card number. It is reachable only through a Detokenizer.
+func (v *Vault) detokenize(token string) (string, *TokenInfo, error) {
+	if !IsToken(token) {
+		return "", nil, ErrInvalidToken
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	rec, ok := v.records[token]
+	if !ok {
+		return "", nil, ErrTokenNotFound
+	}
+	key, ok := v.keys.keys[rec.KeyID]
+	if !ok {
+		return "", nil, fmt.Errorf("data key %s for token is missing", rec.KeyID)
+	}
+
+	number, err := key.aead.Open(nil, rec.Nonce, rec.Ciphertext, []byte(rec.Token))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decrypt card: %w", err)
+	}
+	info := rec.TokenInfo
+	return string(number), &info, nil
+}
+
+// rotateIfDue rotates the data key once it is older than the rotation interval
+func (v *Vault) rotateIfDue(now time.Time) error {
+	if v.rotation <= 0 || now.Sub(v.keys.active().CreatedAt) < v.rotation {
+		return nil
+	}
+	if _, err := v.keys.rotate(now); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (v *Vault) fingerprint(number string) string {
+	mac := hmac.New(sha256.New, v.indexKey)
+	mac.Write([]byte(number))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// flush persists keys and records; the caller must hold the write lock
+func (v *Vault) flush() error {
+	if v.path == "" {
+		return nil
+	}
+
+	keys, err := v.keys.wrap()
+	if err != nil {
+		return err
+	}
+	file := vaultFile{ActiveKey: v.keys.activeID, Keys: keys, Records: make([]*record, 0, len(v.records))}
+	for _, rec := range v.records {
+		file.Records = append(file.Records, rec)
+	}
+	if err := storage.WriteJSON(v.path, file); err != nil {
+		return fmt.Errorf("failed to persist vault: %w", err)
+	}
+	return nil
+}
+
+// Detokenizer is the only way to read a card number back out of the vault.
+// Each instance is bound to a named caller and audit-logs every detokenization.
+type Detokenizer struct {
+	vault  *Vault
+	caller string
+	logger *logging.Logger
+}
+
+// NewDetokenizer grants caller the ability to decrypt tokens
+func NewDetokenizer(v *Vault, caller string, logger *logging.Logger) *Detokenizer {
+	return &Detokenizer{vault: v, caller: caller, logger: logger}
+}
+
+// Detokenize returns the card number for token, recording the access and its purpose
+func (d *Detokenizer) Detokenize(token, purpose string) (string, error) {
+	number, info, err := d.vault.detokenize(token)
+	if err != nil {
+		d.logger.LogAudit(d.caller, "card.detokenize", token, map[string]interface{}{
+			"purpose": purpose,
+			"error":   err.Error(),
+		})
+		return "", err
+	}
+
+	d.logger.LogAudit(d.caller, "card.detokenize", token, map[string]interface{}{
+		"purpose":    purpose,
+		"card_last4": info.LastFour,
+	})
+	return number, nil
+}
+
+// cardBIN returns the first six digits of a card number
+func cardBIN(number string) string {
+	if len(number) < 6 {
+		return ""
+	}
+	return number[:6]
+}
+
+// IsToken reports whether s has the shape of a vault token
+func IsToken(s string) bool {
+	return strings.HasPrefix(s, TokenPrefix) && len(s) > len(TokenPrefix)
+}
+
+// newToken returns a random token that carries no card data
+func newToken() (string, error) {
+	b := make([]byte, 18)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return TokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// cleanNumber removes spaces and dashes from a card number
+func cleanNumber(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
@@ -0,0 +1,110 @@
+package vault
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gke-hackathon/payment-integration/logging"
+)
+
+var testMasterKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestTokenizeAndDetokenize(t *testing.T) {
+	v, err := New(testMasterKey, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := v.Tokenize(Card{Number: "4532-0151-1283-0366", ExpirationYear: 2030, ExpirationMonth: 4})
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if !IsToken(info.Token) || strings.Contains(info.Token, "0366") {
+		t.Errorf("Expected opaque token, got %s", info.Token)
+	}
+	if info.LastFour != "0366" || info.ExpirationYear != 2030 {
+		t.Errorf("Unexpected token info: %+v", info)
+	}
+
+	number, err := NewDetokenizer(v, "test", logging.NewLogger("test")).Detokenize(info.Token, "test")
+	if err != nil {
+		t.Fatalf("Detokenize failed: %v", err)
+	}
+	if number != "4532015112830366" {
+		t.Errorf("Expected 4532015112830366, got %s", number)
+	}
+
+	again, _ := v.Tokenize(Card{Number: "4532015112830366"})
+	if again.Token != info.Token {
+		t.Errorf("Expected the same token for the same card, got %s and %s", info.Token, again.Token)
+	}
+}
+
+func TestLookupErrors(t *testing.T) {
+	v, _ := New(testMasterKey, "", 0)
+
+	if _, err := v.Lookup("4532015112830366"); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+	if _, err := v.Lookup("tok_missing"); err != ErrTokenNotFound {
+		t.Errorf("Expected ErrTokenNotFound, got %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	v, _ := New(testMasterKey, "", time.Hour)
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	first, _ := v.Tokenize(Card{Number: "4532015112830366"})
+	firstKey := v.ActiveKeyID()
+
+	now = now.Add(2 * time.Hour)
+	second, _ := v.Tokenize(Card{Number: "5555555555554444"})
+	if v.ActiveKeyID() == firstKey {
+		t.Fatal("Expected data key to rotate after the interval")
+	}
+
+	detokenizer := NewDetokenizer(v, "test", logging.NewLogger("test"))
+	for token, want := range map[string]string{first.Token: "4532015112830366", second.Token: "5555555555554444"} {
+		if got, err := detokenizer.Detokenize(token, "test"); err != nil || got != want {
+			t.Errorf("Expected %s, got %s (%v)", want, got, err)
+		}
+	}
+}
+
+func TestVaultPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+
+	v, err := New(testMasterKey, path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := v.Tokenize(Card{Number: "4532015112830366"})
+	v.Rotate()
+
+	raw, _ := os.ReadFile(path)
+	if bytes.Contains(raw, []byte("4532015112830366")) {
+		t.Fatal("Vault file contains the raw card number")
+	}
+
+	reopened, err := New(testMasterKey, path, 0)
+	if err != nil {
+		t.Fatalf("Failed to reopen vault: %v", err)
+	}
+	if reopened.ActiveKeyID() != v.ActiveKeyID() {
+		t.Errorf("Expected active key %s, got %s", v.ActiveKeyID(), reopened.ActiveKeyID())
+	}
+	number, err := NewDetokenizer(reopened, "test", logging.NewLogger("test")).Detokenize(info.Token, "test")
+	if err != nil || number != "4532015112830366" {
+		t.Errorf("Expected card number after reopen, got %s (%v)", number, err)
+	}
+
+	if _, err := New([]byte("fedcba9876543210fedcba9876543210"), path, 0); err == nil {
+		t.Error("Expected error opening vault with the wrong master key")
+	}
+}
@@ -0,0 +1,205 @@
+package velocity
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "payment-integration"
+
+// ReasonVelocityLimit is the ErrorInfo reason of a velocity rejection
+const ReasonVelocityLimit = "VELOCITY_LIMIT_EXCEEDED"
+
+// slotsPerWindow is the number of buckets a window is divided into. Windows
+// slide one bucket at a time, e.g. every minute for an hour window.
+const slotsPerWindow = 60
+
+// Event is one charge as seen by the velocity rules
+type Event struct {
+	Card     string
+	Account  string
+	Merchant string
+	Caller   string
+	// Amount is in ledger cents
+	Amount int64
+	Time   time.Time
+}
+
+// key returns the event's value for a dimension; global rules share one key
+func (e Event) key(d Dimension) string {
+	switch d {
+	case DimensionCard:
+		return e.Card
+	case DimensionAccount:
+		return e.Account
+	case DimensionMerchant:
+		return e.Merchant
+	case DimensionCaller:
+		return e.Caller
+	default:
+		return ""
+	}
+}
+
+// Violation names the rule a charge broke
+type Violation struct {
+	Rule      string
+	Dimension Dimension
+	Window    Window
+	// Limit is "count" or "amount", whichever was exceeded
+	Limit string
+}
+
+// Error implements the error interface
+func (v *Violation) Error() string {
+	return fmt.Sprintf("velocity rule %s exceeded: %s per %s over %s", v.Rule, v.Limit, v.Dimension, v.Window)
+}
+
+// ToGRPCError converts a Violation to a ResourceExhausted status naming the rule
+func (v *Violation) ToGRPCError() error {
+	st := status.New(codes.ResourceExhausted, "too many payment requests, please try again later")
+	info := &errdetails.ErrorInfo{
+		Reason: ReasonVelocityLimit,
+		Domain: errorDomain,
+		Metadata: map[string]string{
+			"rule":      v.Rule,
+			"dimension": string(v.Dimension),
+			"window":    v.Window.String(),
+			"limit":     v.Limit,
+		},
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Checker applies a policy to a stream of charges
+type Checker struct {
+	rules []Rule
+
+	mu       sync.Mutex
+	counters []map[string]*counter // per rule, keyed by dimension value
+	lastGC   time.Time
+}
+
+// NewChecker creates a checker for a validated policy
+func NewChecker(policy *Policy) (*Checker, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	c := &Checker{rules: policy.Rules, counters: make([]map[string]*counter, len(policy.Rules))}
+	for i := range c.counters {
+		c.counters[i] = make(map[string]*counter)
+	}
+	return c, nil
+}
+
+// Rules returns the checker's rules
+func (c *Checker) Rules() []Rule {
+	return c.rules
+}
+
+// Check tests the charge against every rule and, only if all of them allow it,
+// records it. The first rule broken is returned as a *Violation. Rules whose
+// dimension is empty in the event (e.g. no caller) are skipped.
+func (c *Checker) Check(e Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.collectGarbage(e.Time)
+
+	touched := make([]*counter, len(c.rules))
+	for i, rule := range c.rules {
+		key := e.key(rule.Dimension)
+		if key == "" && rule.Dimension != DimensionGlobal {
+			continue
+		}
+
+		ctr, ok := c.counters[i][key]
+		if !ok {
+			ctr = newCounter(time.Duration(rule.Window))
+			c.counters[i][key] = ctr
+		}
+		count, amount := ctr.total(e.Time)
+
+		if rule.MaxCount > 0 && count+1 > rule.MaxCount {
+			return &Violation{Rule: rule.Name, Dimension: rule.Dimension, Window: rule.Window, Limit: "count"}
+		}
+		if rule.MaxAmount > 0 && amount+e.Amount > rule.MaxAmount {
+			return &Violation{Rule: rule.Name, Dimension: rule.Dimension, Window: rule.Window, Limit: "amount"}
+		}
+		touched[i] = ctr
+	}
+
+	for _, ctr := range touched {
+		if ctr != nil {
+			ctr.add(e.Time, e.Amount)
+		}
+	}
+	return nil
+}
+
+// collectGarbage drops counters with nothing left in their window, at most once
+// a minute. It is called with mu held.
+func (c *Checker) collectGarbage(now time.Time) {
+	if now.Sub(c.lastGC) < time.Minute {
+		return
+	}
+	c.lastGC = now
+	for _, counters := range c.counters {
+		for key, ctr := range counters {
+			if count, _ := ctr.total(now); count == 0 {
+				delete(counters, key)
+			}
+		}
+	}
+}
+
+// counter is a sliding window of charge counts and amounts, split into slots
+type counter struct {
+	slotWidth time.Duration
+	slots     [slotsPerWindow]slot
+}
+
+type slot struct {
+	index  int64
+	count  int64
+	amount int64
+}
+
+func newCounter(window time.Duration) *counter {
+	width := window / slotsPerWindow
+	if width <= 0 {
+		width = 1
+	}
+	return &counter{slotWidth: width}
+}
+
+// total sums the slots inside the window ending at now
+func (c *counter) total(now time.Time) (count, amount int64) {
+	current := now.UnixNano() / int64(c.slotWidth)
+	for _, s := range c.slots {
+		if s.index > current-slotsPerWindow && s.index <= current {
+			count += s.count
+			amount += s.amount
+		}
+	}
+	return count, amount
+}
+
+// add records a charge in the slot for now
+func (c *counter) add(now time.Time, amount int64) {
+	index := now.UnixNano() / int64(c.slotWidth)
+	s := &c.slots[index%slotsPerWindow]
+	if s.index != index {
+		*s = slot{index: index}
+	}
+	s.count++
+	s.amount += amount
+}
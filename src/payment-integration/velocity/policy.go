@@ -0,0 +1,125 @@
+// Package velocity enforces declarative limits on how many charges, and how much
+// money, flow through a card, account, merchant or caller over time windows.
+package velocity
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dimension is what a rule counts charges by
+type Dimension string
+
+const (
+	DimensionCard     Dimension = "card"
+	DimensionAccount  Dimension = "account"
+	DimensionMerchant Dimension = "merchant"
+	DimensionCaller   Dimension = "caller"
+	DimensionGlobal   Dimension = "global"
+)
+
+// Rule limits the charges in one dimension over a sliding window. A rule may
+// cap the number of charges, their total amount, or both.
+type Rule struct {
+	Name      string    `yaml:"name"`
+	Dimension Dimension `yaml:"dimension"`
+	Window    Window    `yaml:"window"`
+	// MaxCount is the most charges allowed in the window, 0 for no count limit
+	MaxCount int64 `yaml:"max_count"`
+	// MaxAmount is the most ledger cents allowed in the window, 0 for no amount limit
+	MaxAmount int64 `yaml:"max_amount_cents"`
+}
+
+// Window is a rule's time window. In configuration it is "minute", "hour", "day"
+// or a Go duration such as "15m".
+type Window time.Duration
+
+// UnmarshalYAML parses a window name or duration
+func (w *Window) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	window, err := ParseWindow(value)
+	if err != nil {
+		return err
+	}
+	*w = window
+	return nil
+}
+
+// ParseWindow parses "minute", "hour", "day" or a Go duration
+func ParseWindow(value string) (Window, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "minute":
+		return Window(time.Minute), nil
+	case "hour":
+		return Window(time.Hour), nil
+	case "day":
+		return Window(24 * time.Hour), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("window %q must be minute, hour, day or a positive duration", value)
+	}
+	return Window(d), nil
+}
+
+// String renders the window as a duration
+func (w Window) String() string {
+	return time.Duration(w).String()
+}
+
+// Policy is the set of rules every charge is checked against
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Validate checks that every rule is complete and names are unique
+func (p *Policy) Validate() error {
+	names := make(map[string]bool)
+	for i, rule := range p.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("velocity rule %d needs a name", i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("velocity rule name %q is used twice", rule.Name)
+		}
+		names[rule.Name] = true
+
+		switch rule.Dimension {
+		case DimensionCard, DimensionAccount, DimensionMerchant, DimensionCaller, DimensionGlobal:
+		default:
+			return fmt.Errorf("velocity rule %s has unknown dimension %q", rule.Name, rule.Dimension)
+		}
+		if rule.Window <= 0 {
+			return fmt.Errorf("velocity rule %s needs a window", rule.Name)
+		}
+		if rule.MaxCount < 0 || rule.MaxAmount < 0 || (rule.MaxCount == 0 && rule.MaxAmount == 0) {
+			return fmt.Errorf("velocity rule %s needs a positive max_count or max_amount_cents", rule.Name)
+		}
+	}
+	return nil
+}
+
+// LoadPolicy reads a YAML (or JSON) velocity policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read velocity policy: %w", err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse velocity policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
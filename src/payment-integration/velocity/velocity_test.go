@@ -0,0 +1,145 @@
+package velocity
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func mustChecker(t *testing.T, rules ...Rule) *Checker {
+	t.Helper()
+	c, err := NewChecker(&Policy{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func violatedRule(err error) string {
+	var v *Violation
+	if errors.As(err, &v) {
+		return v.Rule
+	}
+	return ""
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "velocity.yaml")
+	policy := `
+rules:
+  - name: card-burst
+    dimension: card
+    window: minute
+    max_count: 3
+  - name: account-daily-spend
+    dimension: account
+    window: day
+    max_amount_cents: 50000
+  - name: global-ceiling
+    dimension: global
+    window: 10s
+    max_count: 100
+`
+	if err := os.WriteFile(path, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	if len(loaded.Rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %d", len(loaded.Rules))
+	}
+	if loaded.Rules[1].Window != Window(24*time.Hour) || loaded.Rules[1].MaxAmount != 50000 {
+		t.Errorf("Unexpected rule %+v", loaded.Rules[1])
+	}
+	if loaded.Rules[2].Window != Window(10*time.Second) {
+		t.Errorf("Expected a 10s window, got %v", loaded.Rules[2].Window)
+	}
+}
+
+func TestPolicyValidation(t *testing.T) {
+	minute := Window(time.Minute)
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{"missing name", []Rule{{Dimension: DimensionCard, Window: minute, MaxCount: 1}}},
+		{"duplicate name", []Rule{
+			{Name: "a", Dimension: DimensionCard, Window: minute, MaxCount: 1},
+			{Name: "a", Dimension: DimensionAccount, Window: minute, MaxCount: 1},
+		}},
+		{"unknown dimension", []Rule{{Name: "a", Dimension: "ip", Window: minute, MaxCount: 1}}},
+		{"no window", []Rule{{Name: "a", Dimension: DimensionCard, MaxCount: 1}}},
+		{"no limit", []Rule{{Name: "a", Dimension: DimensionCard, Window: minute}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewChecker(&Policy{Rules: tt.rules}); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestCountAndAmountRules(t *testing.T) {
+	c := mustChecker(t,
+		Rule{Name: "card-count", Dimension: DimensionCard, Window: Window(time.Minute), MaxCount: 2},
+		Rule{Name: "account-amount", Dimension: DimensionAccount, Window: Window(time.Hour), MaxAmount: 1000},
+	)
+	now := time.Now()
+
+	if err := c.Check(Event{Card: "c1", Account: "a1", Amount: 400, Time: now}); err != nil {
+		t.Fatalf("Unexpected rejection: %v", err)
+	}
+	if err := c.Check(Event{Card: "c1", Account: "a1", Amount: 400, Time: now}); err != nil {
+		t.Fatalf("Unexpected rejection: %v", err)
+	}
+	if rule := violatedRule(c.Check(Event{Card: "c1", Account: "a2", Amount: 1, Time: now})); rule != "card-count" {
+		t.Errorf("Expected card-count to fire, got %q", rule)
+	}
+
+	// A different card on the same account hits the amount rule
+	if rule := violatedRule(c.Check(Event{Card: "c2", Account: "a1", Amount: 201, Time: now})); rule != "account-amount" {
+		t.Errorf("Expected account-amount to fire, got %q", rule)
+	}
+	// Rejected charges are not counted, so the remaining allowance is still 200
+	if err := c.Check(Event{Card: "c2", Account: "a1", Amount: 200, Time: now}); err != nil {
+		t.Errorf("Expected the remaining allowance to be usable, got %v", err)
+	}
+}
+
+func TestWindowSlides(t *testing.T) {
+	c := mustChecker(t, Rule{Name: "per-minute", Dimension: DimensionAccount, Window: Window(time.Minute), MaxCount: 1})
+	now := time.Unix(1_700_000_000, 0)
+
+	if err := c.Check(Event{Account: "a1", Time: now}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Check(Event{Account: "a1", Time: now.Add(30 * time.Second)}); err == nil {
+		t.Error("Expected rejection within the window")
+	}
+	if err := c.Check(Event{Account: "a1", Time: now.Add(61 * time.Second)}); err != nil {
+		t.Errorf("Expected the window to have slid past the first charge, got %v", err)
+	}
+}
+
+func TestGlobalAndMissingDimensions(t *testing.T) {
+	c := mustChecker(t,
+		Rule{Name: "caller", Dimension: DimensionCaller, Window: Window(time.Minute), MaxCount: 1},
+		Rule{Name: "global", Dimension: DimensionGlobal, Window: Window(time.Minute), MaxCount: 3},
+	)
+	now := time.Now()
+
+	// Events without a caller skip the caller rule but still count globally
+	for i := 0; i < 3; i++ {
+		if err := c.Check(Event{Account: "a", Time: now}); err != nil {
+			t.Fatalf("Unexpected rejection %d: %v", i+1, err)
+		}
+	}
+	if rule := violatedRule(c.Check(Event{Account: "b", Caller: "checkout", Time: now})); rule != "global" {
+		t.Errorf("Expected the global ceiling to fire, got %q", rule)
+	}
+}